@@ -1,21 +1,23 @@
 package launchr
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
-	"reflect"
 	"strings"
 
 	"github.com/launchrctl/launchr/internal/launchr"
 	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
 	_ "github.com/launchrctl/launchr/plugins" // include default plugins
 )
 
 type appImpl struct {
 	// Cli related.
-	cmd      *Command
-	earlyCmd launchr.CmdEarlyParsed
+	cmd       *Command
+	earlyCmd  launchr.CmdEarlyParsed
+	errFormat errorFormat
 
 	// FS related.
 	mFS     []ManagedFS
@@ -24,12 +26,51 @@ type appImpl struct {
 
 	// Services.
 	streams    Streams
-	services   map[ServiceInfo]Service
+	services   *launchr.ServiceManager
 	pluginMngr PluginManager
 }
 
-func newApp() *appImpl {
-	return &appImpl{}
+// errorFormat is a format of the error printed when the app fails.
+type errorFormat string
+
+// Error formats.
+const (
+	errorFormatText errorFormat = "text"
+	errorFormatJSON errorFormat = "json"
+)
+
+// String implements [fmt.Stringer] interface.
+func (e *errorFormat) String() string {
+	return string(*e)
+}
+
+// Set implements [github.com/spf13/pflag.Value] interface.
+func (e *errorFormat) Set(v string) error {
+	ef := errorFormat(v)
+	switch ef {
+	case errorFormatText, errorFormatJSON:
+		*e = ef
+		return nil
+	default:
+		return errors.New(`must be one of "text" or "json"`)
+	}
+}
+
+// Type implements [github.com/spf13/pflag.Value] interface.
+func (e *errorFormat) Type() string {
+	return "ErrorFormat"
+}
+
+// errorJSON is an error printed with json error format.
+type errorJSON struct {
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+	// Validation has the errors of the action input validation.
+	Validation jsonschema.ErrSchemaValidationArray `json:"validation,omitempty"`
+}
+
+func newApp(sm *launchr.ServiceManager) *appImpl {
+	return &appImpl{services: sm}
 }
 
 func (app *appImpl) Name() string         { return name }
@@ -40,42 +81,12 @@ func (app *appImpl) SetStreams(s Streams) { app.streams = s }
 func (app *appImpl) RegisterFS(fs ManagedFS)      { app.mFS = append(app.mFS, fs) }
 func (app *appImpl) GetRegisteredFS() []ManagedFS { return app.mFS }
 
-func (app *appImpl) RootCmd() *Command                      { return app.cmd }
-func (app *appImpl) CmdEarlyParsed() launchr.CmdEarlyParsed { return app.earlyCmd }
-
-func (app *appImpl) AddService(s Service) {
-	info := s.ServiceInfo()
-	launchr.InitServiceInfo(&info, s)
-	if _, ok := app.services[info]; ok {
-		panic(fmt.Errorf("service %s already exists, review your code", info))
-	}
-	app.services[info] = s
-}
-
-func (app *appImpl) GetService(v any) {
-	// Check v is a pointer and implements [Service] to set a value later.
-	t := reflect.TypeOf(v)
-	isPtr := t != nil && t.Kind() == reflect.Pointer
-	var stype reflect.Type
-	if isPtr {
-		stype = t.Elem()
-	}
+func (app *appImpl) RootCmd() *Command                       { return app.cmd }
+func (app *appImpl) CmdEarlyParsed() launchr.CmdEarlyParsed  { return app.earlyCmd }
+func (app *appImpl) ServiceManager() *launchr.ServiceManager { return app.services }
 
-	// v must be [Service] but can't equal it because all elements implement it
-	// and the first value will always be returned.
-	intService := reflect.TypeOf((*Service)(nil)).Elem()
-	if !isPtr || !stype.Implements(intService) || stype == intService {
-		panic(fmt.Errorf("argument must be a pointer to a type (interface) implementing Service, %q given", t))
-	}
-	for _, srv := range app.services {
-		st := reflect.TypeOf(srv)
-		if st.AssignableTo(stype) {
-			reflect.ValueOf(v).Elem().Set(reflect.ValueOf(srv))
-			return
-		}
-	}
-	panic(fmt.Sprintf("service %q does not exist", stype))
-}
+func (app *appImpl) AddService(s Service) { app.services.Add(s) }
+func (app *appImpl) GetService(v any)     { app.services.Get(v) }
 
 // init initializes application and plugins.
 func (app *appImpl) init() error {
@@ -107,13 +118,13 @@ func (app *appImpl) init() error {
 	app.RegisterFS(action.NewDiscoveryFS(os.DirFS(actionsPath), app.GetWD()))
 
 	// Prepare dependencies.
-	app.services = make(map[ServiceInfo]Service)
 	app.pluginMngr = launchr.NewPluginManagerWithRegistered()
 	// @todo consider home dir for global config.
 	config := launchr.ConfigFromFS(os.DirFS(app.cfgDir))
 	actionMngr := action.NewManager(
 		action.WithDefaultRuntime,
 		action.WithContainerRuntimeConfig(config, name+"_"),
+		action.WithImageBuildResolverPlugins(app.pluginMngr),
 	)
 
 	// Register services for other modules.
@@ -127,6 +138,8 @@ func (app *appImpl) init() error {
 			return err
 		}
 	}
+	// The flag is added after the hooks to be validated on the command execution and not on early parsing by plugins.
+	app.cmd.PersistentFlags().Var(&app.errFormat, "error-format", "error output format, may be text or json (default text)")
 
 	return nil
 }
@@ -134,7 +147,7 @@ func (app *appImpl) init() error {
 func (app *appImpl) exec() error {
 	if app.earlyCmd.IsVersion {
 		app.cmd.SetVersionTemplate(Version().Full())
-		return app.cmd.Execute()
+		return app.cmd.ExecuteContext(app.context())
 	}
 
 	// Add application commands from plugins.
@@ -144,14 +157,20 @@ func (app *appImpl) exec() error {
 		}
 	}
 
-	return app.cmd.Execute()
+	return app.cmd.ExecuteContext(app.context())
+}
+
+// context returns a context of the commands with the services of the app.
+func (app *appImpl) context() context.Context {
+	return launchr.ContextWithServiceManager(context.Background(), app.services)
 }
 
 // Execute is an entrypoint to the launchr app.
 func (app *appImpl) Execute() int {
+	defer app.closeLog()
 	var err error
 	if err = app.init(); err != nil {
-		Term().Error().Println(err)
+		app.services.Term().Error().Println(err)
 		return 125
 	}
 	if err = app.exec(); err != nil {
@@ -164,20 +183,37 @@ func (app *appImpl) Execute() int {
 		default:
 			status = 1
 		}
-		msg := err.Error()
-		if msg != "" {
-			Term().Error().Println(err)
-		}
-
+		app.printError(err, status)
 		return status
 	}
 
 	return 0
 }
 
+// closeLog writes the queued logs to the log drains before the exit.
+func (app *appImpl) closeLog() {
+	if err := app.services.Log().Close(); err != nil {
+		app.services.Term().Warning().Println(err)
+	}
+}
+
+// printError prints the error of the app in the requested format.
+func (app *appImpl) printError(err error, status int) {
+	msg := err.Error()
+	if app.errFormat != errorFormatJSON {
+		if msg != "" {
+			app.services.Term().Error().Println(err)
+		}
+		return
+	}
+	res := errorJSON{Error: msg, ExitCode: status}
+	errors.As(err, &res.Validation)
+	_ = json.NewEncoder(app.streams.Err()).Encode(res)
+}
+
 // Run executes the application.
 func Run() int {
-	return newApp().Execute()
+	return newApp(launchr.NewServiceManager()).Execute()
 }
 
 // RunAndExit runs the application and exits with a result code.
@@ -50,7 +50,7 @@ func (app *appImpl) gen() error {
 	flags.StringVar(&config.BuildDir, "build-dir", config.BuildDir, "Build directory where the files will be generated")
 	flags.BoolVar(&isRelease, "release", isRelease, "Generate core plugins and main.go")
 
-	return app.cmd.Execute()
+	return app.cmd.ExecuteContext(app.context())
 }
 
 // Generate runs generation of included plugins.
@@ -58,11 +58,11 @@ func (app *appImpl) Generate() int {
 	// Do not discover actions on generate.
 	var err error
 	if err = app.init(); err != nil {
-		Term().Error().Println(err)
+		app.services.Term().Error().Println(err)
 		return 125
 	}
 	if err = app.gen(); err != nil {
-		Term().Error().Println(err)
+		app.services.Term().Error().Println(err)
 		return 125
 	}
 	return 0
@@ -71,7 +71,7 @@ func (app *appImpl) Generate() int {
 // Gen generates application specific build files and returns os exit code.
 func Gen() int {
 	launchr.IsGen = true
-	return newApp().Generate()
+	return newApp(launchr.NewServiceManager()).Generate()
 }
 
 // GenAndExit runs the generation and exits with a result code.
@@ -1,10 +1,15 @@
 package launchr
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+// lockPollInterval is an interval of attempts to lock a file locked by another process.
+const lockPollInterval = 100 * time.Millisecond
+
 // @todo refactor to use one implementation here and in keyring.
 
 // LockedFile is file with a lock for other processes.
@@ -26,14 +31,7 @@ func (f *LockedFile) Filename() string {
 
 // Open opens a file and locks it for other..
 func (f *LockedFile) Open(flag int, perm os.FileMode) (err error) {
-	isCreate := flag&os.O_CREATE == os.O_CREATE
-	if isCreate {
-		err = EnsurePath(filepath.Dir(f.fname))
-		if err != nil {
-			return err
-		}
-	}
-	f.file, err = os.OpenFile(f.fname, flag, perm) //nolint:gosec
+	err = f.open(flag, perm)
 	if err != nil {
 		return err
 	}
@@ -46,6 +44,45 @@ func (f *LockedFile) Open(flag int, perm os.FileMode) (err error) {
 	return nil
 }
 
+// OpenContext opens a file and locks it for other processes like [LockedFile.Open],
+// but stops waiting for the lock when ctx is done and returns the error of ctx.
+func (f *LockedFile) OpenContext(ctx context.Context, flag int, perm os.FileMode) error {
+	err := f.open(flag, perm)
+	if err != nil {
+		return err
+	}
+	t := time.NewTicker(lockPollInterval)
+	defer t.Stop()
+	for {
+		err = f.lock(false)
+		if err == nil {
+			return nil
+		}
+		if !isLockBusy(err) {
+			_ = f.Close()
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (f *LockedFile) open(flag int, perm os.FileMode) (err error) {
+	isCreate := flag&os.O_CREATE == os.O_CREATE
+	if isCreate {
+		err = EnsurePath(filepath.Dir(f.fname))
+		if err != nil {
+			return err
+		}
+	}
+	f.file, err = os.OpenFile(f.fname, flag, perm) //nolint:gosec
+	return err
+}
+
 // Read implements [io.ReadWriteCloser] interface.
 func (f *LockedFile) Read(p []byte) (n int, err error) { return f.file.Read(p) }
 
@@ -3,6 +3,7 @@
 package launchr
 
 import (
+	"errors"
 	"syscall"
 )
 
@@ -34,3 +35,8 @@ func (f *LockedFile) unlock() {
 	}
 	f.locked = false
 }
+
+// isLockBusy returns true if a non-blocking lock failed because the file is locked.
+func isLockBusy(err error) bool {
+	return errors.Is(err, syscall.EWOULDBLOCK)
+}
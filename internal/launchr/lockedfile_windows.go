@@ -3,6 +3,8 @@
 package launchr
 
 import (
+	"errors"
+
 	"golang.org/x/sys/windows"
 )
 
@@ -20,6 +22,7 @@ func (f *LockedFile) lock(waitToAcquire bool) (err error) {
 	if err != nil {
 		return err
 	}
+	f.locked = true
 	return nil
 }
 
@@ -31,7 +34,12 @@ func (f *LockedFile) unlock() {
 	ol := new(windows.Overlapped)
 	err := windows.UnlockFileEx(windows.Handle(f.file.Fd()), 0, allBytes, allBytes, ol)
 	if err != nil {
-		Log().Warn("unlock is called on a not locked file: %s", err)
+		Log().Warn("unlock is called on a not locked file", "error", err)
 	}
 	f.locked = false
 }
+
+// isLockBusy returns true if a non-blocking lock failed because the file is locked.
+func isLockBusy(err error) bool {
+	return errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
@@ -23,6 +23,7 @@ type Slog = slog.Logger
 type Logger struct {
 	*Slog
 	LogOptions
+	drains []*LogDrain
 }
 
 // A LogLevel is the importance or severity of a log event.
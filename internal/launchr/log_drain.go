@@ -0,0 +1,279 @@
+package launchr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Types of log drains.
+const (
+	LogDrainFile   = "file"   // LogDrainFile writes logs to a file with rotation.
+	LogDrainSyslog = "syslog" // LogDrainSyslog sends logs to syslog.
+	LogDrainHTTP   = "http"   // LogDrainHTTP posts logs to an HTTP endpoint as JSON lines.
+	LogDrainLoki   = "loki"   // LogDrainLoki pushes logs to Grafana Loki.
+)
+
+const (
+	// defaultLogDrainBuffer is a default number of records queued for a slow drain.
+	defaultLogDrainBuffer = 1024
+	// maxLogDrainBatch is a maximum number of records written to a drain at once.
+	maxLogDrainBatch = 100
+	// logDrainCloseTimeout is a time to wait for a drain to write the queued records on close.
+	logDrainCloseTimeout = 5 * time.Second
+)
+
+// LogDrainOptions configures an additional destination of the app logs.
+type LogDrainOptions struct {
+	// Type is one of LogDrain constants.
+	Type string `yaml:"type"`
+	// Level is a minimal level of the records, may be debug, info, warn or error. Default is info.
+	Level string `yaml:"level"`
+	// Format is a format of the records, may be json or plain. Default is json.
+	Format string `yaml:"format"`
+	// Buffer is a number of records queued when the drain is slow, the records over it are dropped.
+	Buffer int `yaml:"buffer"`
+
+	// Path is a path of the log file.
+	Path string `yaml:"path"`
+	// MaxSize is a size of the log file in megabytes to rotate it. Default is 10.
+	MaxSize int `yaml:"max_size"`
+	// MaxFiles is a number of rotated files to keep. Default is 5.
+	MaxFiles int `yaml:"max_files"`
+
+	// Address is an address of a syslog server, e.g. "udp://localhost:514". The local syslog is used if empty.
+	Address string `yaml:"address"`
+	// Tag is a syslog tag. Default is the app name.
+	Tag string `yaml:"tag"`
+
+	// URL is an endpoint of HTTP and Loki drains.
+	URL string `yaml:"url"`
+	// Headers are additional headers of HTTP requests, e.g. for authorization.
+	Headers map[string]string `yaml:"headers"`
+	// Labels are labels of Loki streams. The label "app" with the app name is added by default.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// logDrainRecord is a formatted record queued for a drain.
+type logDrainRecord struct {
+	time time.Time
+	line []byte
+}
+
+// logDrainSink writes a batch of records to a destination.
+type logDrainSink interface {
+	write(records []logDrainRecord) error
+	io.Closer
+}
+
+// LogDrain is an additional destination of the app logs, e.g. a file or a remote service.
+// The records are queued and written in the background, so a slow drain doesn't block the app.
+// The records are dropped when the queue is full.
+type LogDrain struct {
+	name    string
+	handler slog.Handler
+	sink    logDrainSink
+	queue   chan logDrainRecord
+	done    chan struct{}
+	dropped atomic.Int64
+	failed  atomic.Int64
+	mx      sync.RWMutex // mx guards the queue from writes after close.
+	closed  bool
+}
+
+// NewLogDrain creates a drain and starts writing to it.
+func NewLogDrain(opts LogDrainOptions) (*LogDrain, error) {
+	lvl, err := parseLogDrainLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	var sink logDrainSink
+	switch opts.Type {
+	case LogDrainFile:
+		sink, err = newLogDrainFile(opts)
+	case LogDrainSyslog:
+		sink, err = newLogDrainSyslog(opts)
+	case LogDrainHTTP, LogDrainLoki:
+		sink, err = newLogDrainHTTP(opts)
+	default:
+		err = fmt.Errorf("unknown log drain type %q", opts.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	size := opts.Buffer
+	if size <= 0 {
+		size = defaultLogDrainBuffer
+	}
+	d := &LogDrain{
+		name:  opts.Type,
+		sink:  sink,
+		queue: make(chan logDrainRecord, size),
+		done:  make(chan struct{}),
+	}
+	hopts := &slog.HandlerOptions{Level: lvl}
+	switch opts.Format {
+	case "", "json":
+		d.handler = slog.NewJSONHandler(d, hopts)
+	case "plain":
+		d.handler = slog.NewTextHandler(d, hopts)
+	default:
+		_ = sink.Close()
+		return nil, fmt.Errorf("unknown log drain format %q, must be json or plain", opts.Format)
+	}
+	go d.run()
+	return d, nil
+}
+
+func parseLogDrainLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log drain level %q, must be debug, info, warn or error", s)
+	}
+}
+
+// Write implements [io.Writer] interface, it queues a record without blocking.
+func (d *LogDrain) Write(p []byte) (int, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+	if d.closed {
+		d.dropped.Add(1)
+		return len(p), nil
+	}
+	select {
+	case d.queue <- logDrainRecord{time: time.Now(), line: append([]byte(nil), p...)}:
+	default:
+		d.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped because the drain was slow.
+func (d *LogDrain) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+func (d *LogDrain) run() {
+	defer close(d.done)
+	for rec := range d.queue {
+		batch := []logDrainRecord{rec}
+	collect:
+		for len(batch) < maxLogDrainBatch {
+			select {
+			case rec, ok := <-d.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, rec)
+			default:
+				break collect
+			}
+		}
+		if err := d.sink.write(batch); err != nil {
+			// The error can't be logged, the drain may receive it.
+			d.failed.Add(int64(len(batch)))
+		}
+	}
+}
+
+// Close writes the queued records and closes the drain.
+// It waits at most for a few seconds if the drain is slow.
+func (d *LogDrain) Close() error {
+	d.mx.Lock()
+	if d.closed {
+		d.mx.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.mx.Unlock()
+	select {
+	case <-d.done:
+	case <-time.After(logDrainCloseTimeout):
+	}
+	err := d.sink.Close()
+	lost := d.dropped.Load() + d.failed.Load()
+	if err == nil && lost > 0 {
+		err = fmt.Errorf("log drain %q lost %d records", d.name, lost)
+	}
+	return err
+}
+
+// WithDrains returns a logger writing to the logger l and to the drains.
+// The drains have their own levels, they don't depend on the level of l.
+func (l *Logger) WithDrains(drains ...*LogDrain) *Logger {
+	if len(drains) == 0 {
+		return l
+	}
+	handlers := []slog.Handler{l.Handler()}
+	for _, d := range drains {
+		handlers = append(handlers, d.handler)
+	}
+	return &Logger{
+		Slog:       slog.New(multiHandler(handlers)),
+		LogOptions: l.LogOptions,
+		drains:     slices.Concat(l.drains, drains),
+	}
+}
+
+// Close closes the drains of the logger.
+func (l *Logger) Close() error {
+	errs := make([]error, 0, len(l.drains))
+	for _, d := range l.drains {
+		errs = append(errs, d.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// multiHandler passes records to several handlers.
+type multiHandler []slog.Handler
+
+func (h multiHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	for _, hh := range h {
+		if hh.Enabled(ctx, lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	errs := make([]error, 0, len(h))
+	for _, hh := range h {
+		if hh.Enabled(ctx, r.Level) {
+			errs = append(errs, hh.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	res := make(multiHandler, len(h))
+	for i, hh := range h {
+		res[i] = hh.WithAttrs(attrs)
+	}
+	return res
+}
+
+func (h multiHandler) WithGroup(name string) slog.Handler {
+	res := make(multiHandler, len(h))
+	for i, hh := range h {
+		res[i] = hh.WithGroup(name)
+	}
+	return res
+}
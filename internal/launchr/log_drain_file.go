@@ -0,0 +1,100 @@
+package launchr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Defaults of the file log drain rotation.
+const (
+	defaultLogDrainMaxSize  = 10 // defaultLogDrainMaxSize is a size of a log file in megabytes.
+	defaultLogDrainMaxFiles = 5  // defaultLogDrainMaxFiles is a number of rotated log files.
+)
+
+// logDrainFile writes records to a file and rotates it when it reaches the size limit.
+// Rotated files get a number suffix, e.g. "launchr.log.1" is the most recent one.
+type logDrainFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func newLogDrainFile(opts LogDrainOptions) (*logDrainFile, error) {
+	if opts.Path == "" {
+		return nil, errors.New("path of the file log drain is not set")
+	}
+	path, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err = EnsurePath(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	d := &logDrainFile{
+		path:     path,
+		maxSize:  int64(opts.MaxSize) * 1024 * 1024,
+		maxFiles: opts.MaxFiles,
+	}
+	if d.maxSize <= 0 {
+		d.maxSize = defaultLogDrainMaxSize * 1024 * 1024
+	}
+	if d.maxFiles <= 0 {
+		d.maxFiles = defaultLogDrainMaxFiles
+	}
+	if err = d.open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *logDrainFile) open() error {
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	d.f, d.size = f, st.Size()
+	return nil
+}
+
+func (d *logDrainFile) rotate() error {
+	if err := d.f.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", d.path, d.maxFiles))
+	for i := d.maxFiles - 1; i > 0; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", d.path, i), fmt.Sprintf("%s.%d", d.path, i+1))
+	}
+	if err := os.Rename(d.path, d.path+".1"); err != nil {
+		return err
+	}
+	return d.open()
+}
+
+func (d *logDrainFile) write(records []logDrainRecord) error {
+	for _, rec := range records {
+		if d.size > 0 && d.size+int64(len(rec.line)) > d.maxSize {
+			if err := d.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := d.f.Write(rec.line)
+		d.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *logDrainFile) Close() error {
+	return d.f.Close()
+}
@@ -0,0 +1,98 @@
+package launchr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// logDrainHTTPTimeout is a timeout of a request to an HTTP log drain.
+const logDrainHTTPTimeout = 10 * time.Second
+
+// logDrainHTTP posts records to an HTTP endpoint.
+// A generic endpoint receives a batch as JSON lines, Loki receives it in its push format.
+type logDrainHTTP struct {
+	url     string
+	loki    bool
+	headers map[string]string
+	labels  map[string]string
+	client  *http.Client
+}
+
+func newLogDrainHTTP(opts LogDrainOptions) (*logDrainHTTP, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("url of the %s log drain is not set", opts.Type)
+	}
+	d := &logDrainHTTP{
+		url:     opts.URL,
+		loki:    opts.Type == LogDrainLoki,
+		headers: opts.Headers,
+		client:  &http.Client{Timeout: logDrainHTTPTimeout},
+	}
+	if d.loki {
+		d.labels = map[string]string{"app": Version().Name}
+		maps.Copy(d.labels, opts.Labels)
+	}
+	return d, nil
+}
+
+// lokiPush is a request body of Loki push API.
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (d *logDrainHTTP) body(records []logDrainRecord) ([]byte, string, error) {
+	if !d.loki {
+		var buf bytes.Buffer
+		for _, rec := range records {
+			buf.Write(rec.line)
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+	stream := lokiStream{Stream: d.labels, Values: make([][2]string, 0, len(records))}
+	for _, rec := range records {
+		ts := strconv.FormatInt(rec.time.UnixNano(), 10)
+		stream.Values = append(stream.Values, [2]string{ts, string(bytes.TrimRight(rec.line, "\n"))})
+	}
+	b, err := json.Marshal(lokiPush{Streams: []lokiStream{stream}})
+	return b, "application/json", err
+}
+
+func (d *logDrainHTTP) write(records []logDrainRecord) error {
+	body, ctype, err := d.body(records)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ctype)
+	for k, v := range d.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+func (d *logDrainHTTP) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
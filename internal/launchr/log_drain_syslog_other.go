@@ -0,0 +1,9 @@
+//go:build !unix
+
+package launchr
+
+import "errors"
+
+func newLogDrainSyslog(_ LogDrainOptions) (logDrainSink, error) {
+	return nil, errors.New("syslog log drain is not supported on the platform")
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package launchr
+
+import (
+	"log/syslog"
+	"net/url"
+)
+
+// logDrainSyslog sends records to a local or a remote syslog.
+type logDrainSyslog struct {
+	w *syslog.Writer
+}
+
+func newLogDrainSyslog(opts LogDrainOptions) (*logDrainSyslog, error) {
+	tag := opts.Tag
+	if tag == "" {
+		tag = Version().Name
+	}
+	var network, addr string
+	if opts.Address != "" {
+		u, err := url.Parse(opts.Address)
+		if err != nil {
+			return nil, err
+		}
+		network, addr = u.Scheme, u.Host
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &logDrainSyslog{w: w}, nil
+}
+
+func (d *logDrainSyslog) write(records []logDrainRecord) error {
+	for _, rec := range records {
+		if _, err := d.w.Write(rec.line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *logDrainSyslog) Close() error {
+	return d.w.Close()
+}
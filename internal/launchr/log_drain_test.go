@@ -0,0 +1,120 @@
+package launchr
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LogDrainFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	d, err := NewLogDrain(LogDrainOptions{Type: LogDrainFile, Path: path, Level: "warn", MaxFiles: 2})
+	require.NoError(t, err)
+	// Rotate after every record.
+	d.sink.(*logDrainFile).maxSize = 10
+
+	base := NewTextHandlerLogger(io.Discard)
+	l := base.WithDrains(d)
+	l.Info("skipped")
+	for _, msg := range []string{"first", "second", "third"} {
+		l.Warn(msg)
+	}
+	require.NoError(t, l.Close())
+
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		require.NoError(t, err)
+		return string(b)
+	}
+	assert.Contains(t, read(path), `"msg":"third"`)
+	assert.Contains(t, read(path+".1"), `"msg":"second"`)
+	assert.Contains(t, read(path+".2"), `"msg":"first"`)
+	assert.NoFileExists(t, path+".3")
+}
+
+func Test_LogDrainHTTP(t *testing.T) {
+	t.Parallel()
+	var mx sync.Mutex
+	var auth []string
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mx.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		bodies = append(bodies, b)
+		mx.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d, err := NewLogDrain(LogDrainOptions{Type: LogDrainLoki, URL: srv.URL, Labels: map[string]string{"env": "ci"}, Headers: map[string]string{"Authorization": "Bearer token"}})
+	require.NoError(t, err)
+	l := NewTextHandlerLogger(io.Discard).WithDrains(d)
+	l.Info("hello", "key", "value")
+	require.NoError(t, l.Close())
+
+	require.Len(t, bodies, 1)
+	assert.Equal(t, []string{"Bearer token"}, auth)
+	var push lokiPush
+	require.NoError(t, json.Unmarshal(bodies[0], &push))
+	require.Len(t, push.Streams, 1)
+	assert.Equal(t, "ci", push.Streams[0].Stream["env"])
+	assert.Contains(t, push.Streams[0].Stream, "app")
+	require.Len(t, push.Streams[0].Values, 1)
+	assert.Contains(t, push.Streams[0].Values[0][1], `"key":"value"`)
+}
+
+// blockingSink is a drain sink blocked until it's released.
+type blockingSink struct {
+	release chan struct{}
+	written int
+}
+
+func (s *blockingSink) write(records []logDrainRecord) error {
+	<-s.release
+	s.written += len(records)
+	return nil
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func Test_LogDrainBackpressure(t *testing.T) {
+	t.Parallel()
+	sink := &blockingSink{release: make(chan struct{})}
+	d := &LogDrain{name: "test", sink: sink, queue: make(chan logDrainRecord, 2), done: make(chan struct{})}
+	go d.run()
+	// Writes never block, the records over the queue size are dropped.
+	for i := 0; i < 10; i++ {
+		n, err := d.Write([]byte("record\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 7, n)
+	}
+	assert.GreaterOrEqual(t, d.Dropped(), int64(7))
+	close(sink.release)
+	err := d.Close()
+	assert.ErrorContains(t, err, "lost")
+	assert.Equal(t, int64(10), int64(sink.written)+d.Dropped())
+}
+
+func Test_LogDrainInvalid(t *testing.T) {
+	t.Parallel()
+	for _, opts := range []LogDrainOptions{
+		{Type: "unknown"},
+		{Type: LogDrainFile},
+		{Type: LogDrainHTTP},
+		{Type: LogDrainLoki, URL: "http://localhost", Level: "trace"},
+		{Type: LogDrainFile, Path: filepath.Join(t.TempDir(), "f.log"), Format: "xml"},
+	} {
+		_, err := NewLogDrain(opts)
+		assert.Error(t, err, opts)
+	}
+}
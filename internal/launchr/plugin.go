@@ -0,0 +1,123 @@
+package launchr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PluginAPIVersion is the current version of the plugin API.
+	// It must be incremented on incompatible changes of the plugin interfaces.
+	PluginAPIVersion = 1
+	// PluginAPIMinVersion is the oldest plugin API version supported by the app.
+	PluginAPIMinVersion = 1
+)
+
+// PluginCapability is a flag of a plugin API feature that a plugin requires.
+type PluginCapability uint64
+
+// Plugin API capabilities.
+const (
+	PluginCapabilityAppInit         PluginCapability = 1 << iota // PluginCapabilityAppInit - app initialisation hook.
+	PluginCapabilityCobra                                        // PluginCapabilityCobra - cobra commands hook.
+	PluginCapabilityGenerate                                     // PluginCapabilityGenerate - code generation hook.
+	PluginCapabilityActionDiscovery                              // PluginCapabilityActionDiscovery - action discovery hook.
+	PluginCapabilityActionsAlter                                 // PluginCapabilityActionsAlter - altering of discovered actions.
+	PluginCapabilityValueProcessors                              // PluginCapabilityValueProcessors - action value processors.
+)
+
+// PluginCapabilitiesSupported is a set of capabilities supported by the current plugin API.
+const PluginCapabilitiesSupported = PluginCapabilityAppInit |
+	PluginCapabilityCobra |
+	PluginCapabilityGenerate |
+	PluginCapabilityActionDiscovery |
+	PluginCapabilityActionsAlter |
+	PluginCapabilityValueProcessors
+
+var pluginCapabilityNames = map[PluginCapability]string{
+	PluginCapabilityAppInit:         "app_init",
+	PluginCapabilityCobra:           "cobra",
+	PluginCapabilityGenerate:        "generate",
+	PluginCapabilityActionDiscovery: "action_discovery",
+	PluginCapabilityActionsAlter:    "actions_alter",
+	PluginCapabilityValueProcessors: "value_processors",
+}
+
+// Has returns true if all capabilities c are set.
+func (p PluginCapability) Has(c PluginCapability) bool {
+	return p&c == c
+}
+
+func (p PluginCapability) String() string {
+	if p == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(pluginCapabilityNames))
+	for i := 0; i < 64; i++ {
+		c := PluginCapability(1) << i
+		if !p.Has(c) {
+			continue
+		}
+		if name, ok := pluginCapabilityNames[c]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("unknown(%d)", i))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// CheckPluginCompatibility checks if a plugin can be used with the current plugin API.
+func CheckPluginCompatibility(pi PluginInfo) error {
+	switch {
+	case pi.APIVersion == 0:
+		// Version is not declared, consider compatible.
+	case pi.APIVersion > PluginAPIVersion:
+		return fmt.Errorf("plugin targets plugin API v%d, but the app supports up to v%d, update the app", pi.APIVersion, PluginAPIVersion)
+	case pi.APIVersion < PluginAPIMinVersion:
+		return fmt.Errorf("plugin targets plugin API v%d, but the app requires at least v%d, update the plugin", pi.APIVersion, PluginAPIMinVersion)
+	}
+	if missing := pi.Capabilities &^ PluginCapabilitiesSupported; missing != 0 {
+		return fmt.Errorf("plugin requires unsupported capabilities: %s", missing)
+	}
+	return nil
+}
+
+// HealthStatus is a status of a health check.
+type HealthStatus int
+
+// Health check statuses.
+const (
+	HealthPass HealthStatus = iota // HealthPass - the check passed.
+	HealthWarn                     // HealthWarn - the check passed with issues.
+	HealthFail                     // HealthFail - the check failed.
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthPass:
+		return "pass"
+	case HealthWarn:
+		return "warn"
+	case HealthFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthCheck is a result of a health check.
+type HealthCheck struct {
+	Name        string       // Name is a short name of the check.
+	Status      HealthStatus // Status is a result status of the check.
+	Message     string       // Message describes the result.
+	Remediation string       // Remediation suggests how to fix a failed check.
+}
+
+// HealthCheckPlugin is an interface to implement a plugin reporting its health.
+type HealthCheckPlugin interface {
+	Plugin
+	// HealthCheck runs plugin checks, e.g. availability of external services.
+	HealthCheck(ctx context.Context) []HealthCheck
+}
@@ -0,0 +1,43 @@
+package launchr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckPluginCompatibility(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name   string
+		info   PluginInfo
+		expErr bool
+	}
+	tts := []testCase{
+		{"undeclared version", PluginInfo{}, false},
+		{"current version", PluginInfo{APIVersion: PluginAPIVersion}, false},
+		{"newer version", PluginInfo{APIVersion: PluginAPIVersion + 1}, true},
+		{"older version", PluginInfo{APIVersion: PluginAPIMinVersion - 1}, PluginAPIMinVersion > 1},
+		{"supported capabilities", PluginInfo{APIVersion: PluginAPIVersion, Capabilities: PluginCapabilityCobra | PluginCapabilityAppInit}, false},
+		{"unsupported capabilities", PluginInfo{Capabilities: PluginCapabilityCobra | 1<<63}, true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckPluginCompatibility(tt.info)
+			if tt.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_PluginCapabilityString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "none", PluginCapability(0).String())
+	assert.Equal(t, "app_init,cobra", (PluginCapabilityAppInit | PluginCapabilityCobra).String())
+	assert.Equal(t, "unknown(63)", PluginCapability(1<<63).String())
+}
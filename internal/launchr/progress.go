@@ -0,0 +1,112 @@
+package launchr
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+// progressInterval is a minimal interval between progress updates.
+const progressInterval = 2 * time.Second
+
+// Progress reports the progress of a transfer of bytes, e.g. a copy of files.
+// The progress is printed periodically with the throughput and the estimated time left.
+type Progress struct {
+	mx       sync.Mutex
+	p        TextPrinter
+	title    string
+	total    int64
+	n        int64
+	start    time.Time
+	last     time.Time
+	now      func() time.Time
+	interval time.Duration
+}
+
+// ProgressStats is a summary of a finished transfer.
+type ProgressStats struct {
+	// Bytes is a number of transferred bytes.
+	Bytes int64
+	// Duration is a duration of the transfer.
+	Duration time.Duration
+}
+
+// String implements [fmt.Stringer] interface.
+func (s ProgressStats) String() string {
+	return fmt.Sprintf("%s in %s (%s/s)", units.HumanSize(float64(s.Bytes)), s.Duration.Round(time.Millisecond), units.HumanSize(throughput(s.Bytes, s.Duration)))
+}
+
+// Progress creates a progress of a transfer with a title and total number of bytes.
+// If total is unknown, it must be 0, then the percentage and the time left are not shown.
+func (t *Terminal) Progress(title string, total int64) *Progress {
+	return newProgress(t.Info(), title, total, time.Now)
+}
+
+func newProgress(p TextPrinter, title string, total int64, now func() time.Time) *Progress {
+	start := now()
+	return &Progress{
+		p:        p,
+		title:    title,
+		total:    total,
+		start:    start,
+		last:     start,
+		now:      now,
+		interval: progressInterval,
+	}
+}
+
+// Reader wraps r to count the read bytes.
+func (p *Progress) Reader(r io.Reader) io.Reader {
+	return io.TeeReader(r, p)
+}
+
+// Write implements [io.Writer] interface to count the transferred bytes.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.n += int64(len(b))
+	if now := p.now(); now.Sub(p.last) >= p.interval {
+		p.last = now
+		p.p.Printfln("%s: %s", p.title, p.status(now))
+	}
+	return len(b), nil
+}
+
+// Done finishes the progress and returns the transfer summary.
+func (p *Progress) Done() ProgressStats {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return ProgressStats{Bytes: p.n, Duration: p.now().Sub(p.start)}
+}
+
+func (p *Progress) status(now time.Time) string {
+	elapsed := now.Sub(p.start)
+	speed := throughput(p.n, elapsed)
+	if p.total <= 0 {
+		return fmt.Sprintf("%s, %s/s", units.HumanSize(float64(p.n)), units.HumanSize(speed))
+	}
+	// The size of archives is a bit bigger than the size of the files.
+	total := max(p.total, p.n)
+	eta := time.Duration(0)
+	if speed > 0 {
+		eta = time.Duration(float64(total-p.n) / speed * float64(time.Second))
+	}
+	return fmt.Sprintf(
+		"%s / %s (%d%%), %s/s, %s left",
+		units.HumanSize(float64(p.n)),
+		units.HumanSize(float64(total)),
+		p.n*100/total,
+		units.HumanSize(speed),
+		eta.Round(time.Second),
+	)
+}
+
+func throughput(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
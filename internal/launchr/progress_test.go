@@ -0,0 +1,62 @@
+package launchr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPrinter struct {
+	lines []string
+}
+
+func (p *testPrinter) SetOutput(io.Writer) {}
+func (p *testPrinter) Print(a ...any)      { p.lines = append(p.lines, fmt.Sprint(a...)) }
+func (p *testPrinter) Println(a ...any)    { p.lines = append(p.lines, fmt.Sprint(a...)) }
+func (p *testPrinter) Printf(f string, a ...any) {
+	p.lines = append(p.lines, fmt.Sprintf(f, a...))
+}
+func (p *testPrinter) Printfln(f string, a ...any) {
+	p.lines = append(p.lines, fmt.Sprintf(f, a...))
+}
+
+func Test_Progress(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+
+	tp := &testPrinter{}
+	p := newProgress(tp, "Copying", 4000, clock)
+	_, err := p.Write(make([]byte, 500))
+	require.NoError(t, err)
+	// Updates are throttled.
+	assert.Empty(t, tp.lines)
+	now = now.Add(2 * time.Second)
+	_, err = io.Copy(io.Discard, p.Reader(strings.NewReader(strings.Repeat("a", 500))))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Copying: 1kB / 4kB (25%), 500B/s, 6s left"}, tp.lines)
+	stats := p.Done()
+	assert.Equal(t, ProgressStats{Bytes: 1000, Duration: 2 * time.Second}, stats)
+	assert.Equal(t, "1kB in 2s (500B/s)", stats.String())
+
+	// The total size is unknown.
+	tp = &testPrinter{}
+	p = newProgress(tp, "Copying", 0, clock)
+	now = now.Add(4 * time.Second)
+	_, err = p.Write(make([]byte, 2000))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Copying: 2kB, 500B/s"}, tp.lines)
+
+	// The archive is bigger than the files.
+	tp = &testPrinter{}
+	p = newProgress(tp, "Copying", 100, clock)
+	now = now.Add(2 * time.Second)
+	_, err = p.Write(make([]byte, 200))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Copying: 200B / 200B (100%), 100B/s, 0s left"}, tp.lines)
+}
@@ -0,0 +1,132 @@
+package launchr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// ServiceManager holds the services, the logger and the terminal of an app instance.
+// Several app instances may coexist in one process with isolated managers, e.g. in tests or servers.
+// The manager is passed to the commands and the actions in the context, see [ContextWithServiceManager].
+type ServiceManager struct {
+	mx       sync.RWMutex
+	services map[ServiceInfo]Service
+	log      *Logger   // log is nil if the default logger of the process is used.
+	term     *Terminal // term is nil if the default terminal of the process is used.
+}
+
+// defaultServiceManager is used when a context doesn't have a manager.
+var defaultServiceManager = NewServiceManager()
+
+// NewServiceManager creates a manager using the default [Log] and [Term] of the process.
+func NewServiceManager() *ServiceManager {
+	return &ServiceManager{services: make(map[ServiceInfo]Service)}
+}
+
+// NewIsolatedServiceManager creates a manager with its own logger and terminal not printing anything.
+func NewIsolatedServiceManager() *ServiceManager {
+	return &ServiceManager{
+		services: make(map[ServiceInfo]Service),
+		log:      NewTextHandlerLogger(io.Discard),
+		term:     NewTerminal(),
+	}
+}
+
+// Add registers a service, the service of the same type must not be registered already.
+func (sm *ServiceManager) Add(s Service) {
+	info := s.ServiceInfo()
+	InitServiceInfo(&info, s)
+	sm.mx.Lock()
+	defer sm.mx.Unlock()
+	if _, ok := sm.services[info]; ok {
+		panic(fmt.Errorf("service %s already exists, review your code", info))
+	}
+	sm.services[info] = s
+}
+
+// Get retrieves a service of type [v] and assigns it to [v].
+// It panics if the service doesn't exist.
+func (sm *ServiceManager) Get(v any) {
+	// Check v is a pointer and implements [Service] to set a value later.
+	t := reflect.TypeOf(v)
+	isPtr := t != nil && t.Kind() == reflect.Pointer
+	var stype reflect.Type
+	if isPtr {
+		stype = t.Elem()
+	}
+
+	// v must be [Service] but can't equal it because all elements implement it
+	// and the first value will always be returned.
+	intService := reflect.TypeOf((*Service)(nil)).Elem()
+	if !isPtr || !stype.Implements(intService) || stype == intService {
+		panic(fmt.Errorf("argument must be a pointer to a type (interface) implementing Service, %q given", t))
+	}
+	sm.mx.RLock()
+	defer sm.mx.RUnlock()
+	for _, srv := range sm.services {
+		st := reflect.TypeOf(srv)
+		if st.AssignableTo(stype) {
+			reflect.ValueOf(v).Elem().Set(reflect.ValueOf(srv))
+			return
+		}
+	}
+	panic(fmt.Sprintf("service %q does not exist", stype))
+}
+
+// Log returns the logger of the manager.
+func (sm *ServiceManager) Log() *Logger {
+	sm.mx.RLock()
+	defer sm.mx.RUnlock()
+	if sm.log == nil {
+		return Log()
+	}
+	return sm.log
+}
+
+// SetLogger sets the logger of the manager, the default logger is set if the manager isn't isolated.
+func (sm *ServiceManager) SetLogger(l *Logger) {
+	sm.mx.Lock()
+	defer sm.mx.Unlock()
+	if sm.log == nil {
+		SetLogger(l)
+		return
+	}
+	sm.log = l
+}
+
+// Term returns the terminal of the manager.
+func (sm *ServiceManager) Term() *Terminal {
+	if sm.term == nil {
+		return Term()
+	}
+	return sm.term
+}
+
+type serviceManagerKey struct{}
+
+// ContextWithServiceManager returns a copy of ctx with the service manager.
+func ContextWithServiceManager(ctx context.Context, sm *ServiceManager) context.Context {
+	return context.WithValue(ctx, serviceManagerKey{}, sm)
+}
+
+// ServiceManagerFromContext returns the service manager of ctx.
+// If it's not set, a manager without services using the default [Log] and [Term] is returned.
+func ServiceManagerFromContext(ctx context.Context) *ServiceManager {
+	if sm, ok := ctx.Value(serviceManagerKey{}).(*ServiceManager); ok {
+		return sm
+	}
+	return defaultServiceManager
+}
+
+// LogFromContext returns the logger of the service manager of ctx.
+func LogFromContext(ctx context.Context) *Logger {
+	return ServiceManagerFromContext(ctx).Log()
+}
+
+// TermFromContext returns the terminal of the service manager of ctx.
+func TermFromContext(ctx context.Context) *Terminal {
+	return ServiceManagerFromContext(ctx).Term()
+}
@@ -0,0 +1,68 @@
+package launchr
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testService interface {
+	Service
+	Name() string
+}
+
+type testServiceImpl struct{ name string }
+
+func (s *testServiceImpl) ServiceInfo() ServiceInfo { return ServiceInfo{} }
+func (s *testServiceImpl) Name() string             { return s.name }
+
+func Test_ServiceManager(t *testing.T) {
+	t.Parallel()
+	sm1 := NewIsolatedServiceManager()
+	sm2 := NewIsolatedServiceManager()
+	sm1.Add(&testServiceImpl{name: "first"})
+	sm2.Add(&testServiceImpl{name: "second"})
+	assert.Panics(t, func() { sm1.Add(&testServiceImpl{}) })
+
+	var s testService
+	sm1.Get(&s)
+	assert.Equal(t, "first", s.Name())
+	sm2.Get(&s)
+	assert.Equal(t, "second", s.Name())
+	assert.Panics(t, func() { NewServiceManager().Get(&s) })
+	assert.Panics(t, func() { sm1.Get(s) })
+
+	// The output of isolated managers is separated.
+	var out1, out2 bytes.Buffer
+	sm1.Term().SetOutput(&out1)
+	sm1.Term().EnableOutput()
+	sm2.Term().SetOutput(&out2)
+	sm1.Term().Printfln("hello %s", "first")
+	sm2.Term().Println("hello second")
+	assert.Equal(t, "hello first\n", out1.String())
+	assert.Empty(t, out2.String())
+	assert.False(t, Term().IsEnabled())
+
+	var log1 bytes.Buffer
+	sm1.SetLogger(NewTextHandlerLogger(&log1))
+	sm1.Log().SetLevel(LogLevelInfo)
+	sm1.Log().Info("started")
+	assert.Contains(t, log1.String(), "started")
+	assert.NotSame(t, sm1.Log(), sm2.Log())
+	assert.NotSame(t, sm1.Log(), Log())
+
+	// Not isolated managers use the default logger and terminal.
+	sm := NewServiceManager()
+	assert.Same(t, Log(), sm.Log())
+	assert.Same(t, Term(), sm.Term())
+
+	ctx := ContextWithServiceManager(context.Background(), sm1)
+	require.Same(t, sm1, ServiceManagerFromContext(ctx))
+	assert.Same(t, sm1.Log(), LogFromContext(ctx))
+	assert.Same(t, sm1.Term(), TermFromContext(ctx))
+	assert.Same(t, Log(), LogFromContext(context.Background()))
+	assert.Same(t, Term(), TermFromContext(context.Background()))
+}
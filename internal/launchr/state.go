@@ -0,0 +1,149 @@
+package launchr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stateVersionKey is a key of the format version in the state files.
+const stateVersionKey = "version"
+
+// ErrStateVersion is returned when a state file is written in a newer format than the app supports.
+var ErrStateVersion = errors.New("state format is newer than supported, upgrade the app")
+
+// StateMigration upgrades a state document to the next version of the format.
+type StateMigration func(doc *yaml.Node) error
+
+// StateFormat is a versioned format of yaml state files in the config directory.
+// The version is written in the field "version" of the document,
+// the files written before the format was versioned have version 1.
+type StateFormat struct {
+	// Version is the current version of the format.
+	Version int
+	// Migrations upgrade a document from version i+1 to version i+2,
+	// there must be a migration for every version older than the current one.
+	Migrations []StateMigration
+}
+
+// Decode reads the state document content into v migrating it to the current version.
+// The migrated document is written in the current version on the next save.
+func (f StateFormat) Decode(content []byte, v any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		// The document is empty.
+		return nil
+	}
+	root := doc.Content[0]
+	version, err := stateDocVersion(root)
+	if err != nil {
+		return err
+	}
+	if version > f.Version {
+		return fmt.Errorf("%w: version %d, supported %d", ErrStateVersion, version, f.Version)
+	}
+	for ; version < f.Version; version++ {
+		if version-1 >= len(f.Migrations) {
+			return fmt.Errorf("no migration of the state from version %d", version)
+		}
+		if err = f.Migrations[version-1](root); err != nil {
+			return fmt.Errorf("failed to migrate the state from version %d: %w", version, err)
+		}
+	}
+	return root.Decode(v)
+}
+
+// Encode returns the state document of v in the current version.
+func (f StateFormat) Encode(v any) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(v); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, errors.New("state must be a mapping")
+	}
+	setStateDocVersion(&doc, f.Version)
+	return yaml.Marshal(&doc)
+}
+
+// stateDocVersion returns the version of the state document n.
+func stateDocVersion(n *yaml.Node) (int, error) {
+	if n.Kind != yaml.MappingNode {
+		return 0, errors.New("state must be a mapping")
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value != stateVersionKey {
+			continue
+		}
+		var version int
+		if err := n.Content[i+1].Decode(&version); err != nil || version < 1 {
+			return 0, fmt.Errorf("state version %q is not valid", n.Content[i+1].Value)
+		}
+		return version, nil
+	}
+	return 1, nil
+}
+
+// setStateDocVersion sets the version of the state document n, the version is the first field.
+func setStateDocVersion(n *yaml.Node, version int) {
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(version)}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == stateVersionKey {
+			n.Content[i+1] = val
+			return
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: stateVersionKey}
+	n.Content = append([]*yaml.Node{key, val}, n.Content...)
+}
+
+// WriteFileAtomic writes content to file fname so the file is never left partially written,
+// e.g. when the app is interrupted. The content is written to a temporary file replacing fname.
+func WriteFileAtomic(fname string, content []byte, perm os.FileMode) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(fname), "."+filepath.Base(fname)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(content); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fname)
+}
+
+// StateEntry is a file or a directory of the state kept in the config directory.
+type StateEntry struct {
+	// Path is a slash-separated path relative to the config directory.
+	Path string
+	// Description describes the kept state.
+	Description string
+	// Format is a format of the state files, it's nil for caches which are recreated when needed.
+	Format *StateFormat
+}
+
+// StatePlugin is an interface to implement a plugin keeping state in the config directory.
+type StatePlugin interface {
+	Plugin
+	// State returns the state entries of the plugin.
+	State() []StateEntry
+}
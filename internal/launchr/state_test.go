@@ -0,0 +1,83 @@
+package launchr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testState struct {
+	Name  string `yaml:"name"`
+	Count int    `yaml:"count"`
+}
+
+func Test_StateFormat(t *testing.T) {
+	t.Parallel()
+	// Version 2 renames the field "title" to "name".
+	f := StateFormat{
+		Version: 2,
+		Migrations: []StateMigration{func(doc *yaml.Node) error {
+			for i := 0; i < len(doc.Content); i += 2 {
+				if doc.Content[i].Value == "title" {
+					doc.Content[i].Value = "name"
+				}
+			}
+			return nil
+		}},
+	}
+	content, err := f.Encode(&testState{Name: "foo", Count: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "version: 2\nname: foo\ncount: 2\n", string(content))
+
+	type testCase struct {
+		name    string
+		content string
+		exp     testState
+		expErr  error
+	}
+	tts := []testCase{
+		{"current version", "version: 2\nname: foo\ncount: 2\n", testState{Name: "foo", Count: 2}, nil},
+		{"not versioned", "title: foo\ncount: 2\n", testState{Name: "foo", Count: 2}, nil},
+		{"empty", "", testState{}, nil},
+		{"newer version", "version: 3\nname: foo\n", testState{}, ErrStateVersion},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var s testState
+			err := f.Decode([]byte(tt.content), &s)
+			if tt.expErr != nil {
+				assert.ErrorIs(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.exp, s)
+		})
+	}
+
+	var s testState
+	assert.EqualError(t, f.Decode([]byte("version: 0\n"), &s), `state version "0" is not valid`)
+	assert.EqualError(t, StateFormat{Version: 2}.Decode([]byte("name: foo\n"), &s), "no migration of the state from version 1")
+}
+
+func Test_WriteFileAtomic(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "state.yaml")
+	require.NoError(t, WriteFileAtomic(fname, []byte("first"), 0600))
+	require.NoError(t, WriteFileAtomic(fname, []byte("second"), 0600))
+	content, err := os.ReadFile(fname) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+	// No temporary files are left.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, WriteFileAtomic(filepath.Join(dir, "missing", "state.yaml"), nil, 0600))
+}
@@ -57,6 +57,12 @@ func (o *Out) Write(p []byte) (int, error) {
 	return o.out.Write(p)
 }
 
+// File returns the underlying file of the stream, e.g. [os.Stdout], or nil if the stream is not a file.
+func (o *Out) File() *os.File {
+	f, _ := o.out.(*os.File)
+	return f
+}
+
 // SetRawTerminal sets raw mode on the input terminal.
 func (o *Out) SetRawTerminal() (err error) {
 	if os.Getenv("NORAW") != "" || !o.commonStream.isTerminal {
@@ -102,6 +108,12 @@ func (i *In) Close() error {
 	return i.in.Close()
 }
 
+// File returns the underlying file of the stream, e.g. [os.Stdin], or nil if the stream is not a file.
+func (i *In) File() *os.File {
+	f, _ := i.in.(*os.File)
+	return f
+}
+
 // SetRawTerminal sets raw mode on the input terminal.
 func (i *In) SetRawTerminal() (err error) {
 	if os.Getenv("NORAW") != "" || !i.commonStream.isTerminal {
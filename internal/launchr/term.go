@@ -3,6 +3,7 @@ package launchr
 import (
 	"io"
 	"log"
+	"os"
 	"reflect"
 
 	"github.com/pterm/pterm"
@@ -19,7 +20,24 @@ var DefaultTextPrinter = message.NewPrinter(language.English)
 
 func init() {
 	// Initialize the default printer.
-	defaultTerm = &Terminal{
+	defaultTerm = newTerminal(true)
+}
+
+// NewTerminal creates a [Terminal] printing to stdout with the output disabled.
+// Unlike the default [Term], it doesn't change the global output of pterm and std log.
+func NewTerminal() *Terminal {
+	t := newTerminal(false)
+	t.SetOutput(os.Stdout)
+	for _, p := range t.p {
+		if pp, ok := p.(*ptermPrinter); ok {
+			pp.w = t
+		}
+	}
+	return t
+}
+
+func newTerminal(global bool) *Terminal {
+	t := &Terminal{
 		p: []TextPrinter{
 			printerBasic:   newPTermBasicPrinter(pterm.DefaultBasicText),
 			printerInfo:    newPTermPrefixPrinter(pterm.Info),
@@ -28,9 +46,11 @@ func init() {
 			printerError:   newPTermPrefixPrinter(pterm.Error),
 		},
 		enabled: true,
+		global:  global,
 	}
 	// Do not output anything when not in the app, e.g. in tests.
-	defaultTerm.DisableOutput()
+	t.DisableOutput()
+	return t
 }
 
 // Predefined keys of terminal printers.
@@ -64,17 +84,46 @@ type TextPrinter interface {
 }
 
 // Constructors to copy default pterm printers.
-func newPTermBasicPrinter(p pterm.BasicTextPrinter) TextPrinter { return &ptermPrinter{&p} }
-func newPTermPrefixPrinter(p pterm.PrefixPrinter) TextPrinter   { return &ptermPrinter{&p} }
+func newPTermBasicPrinter(p pterm.BasicTextPrinter) TextPrinter { return &ptermPrinter{pterm: &p} }
+func newPTermPrefixPrinter(p pterm.PrefixPrinter) TextPrinter   { return &ptermPrinter{pterm: &p} }
 
 type ptermPrinter struct {
 	pterm pterm.TextPrinter
+	// w is set to print to the writer bypassing the global output switch of pterm.
+	w io.Writer
+}
+
+func (p *ptermPrinter) Print(a ...any) {
+	if p.w != nil {
+		_, _ = io.WriteString(p.w, p.pterm.Sprint(a...))
+		return
+	}
+	p.pterm.Print(a...)
 }
 
-func (p *ptermPrinter) Print(a ...any)                   { p.pterm.Print(a...) }
-func (p *ptermPrinter) Println(a ...any)                 { p.pterm.Println(a...) }
-func (p *ptermPrinter) Printf(format string, a ...any)   { p.pterm.Printf(format, a...) }
-func (p *ptermPrinter) Printfln(format string, a ...any) { p.pterm.Printfln(format, a...) }
+func (p *ptermPrinter) Println(a ...any) {
+	if p.w != nil {
+		_, _ = io.WriteString(p.w, p.pterm.Sprintln(a...))
+		return
+	}
+	p.pterm.Println(a...)
+}
+
+func (p *ptermPrinter) Printf(format string, a ...any) {
+	if p.w != nil {
+		_, _ = io.WriteString(p.w, p.pterm.Sprintf(format, a...))
+		return
+	}
+	p.pterm.Printf(format, a...)
+}
+
+func (p *ptermPrinter) Printfln(format string, a ...any) {
+	if p.w != nil {
+		_, _ = io.WriteString(p.w, p.pterm.Sprintfln(format, a...))
+		return
+	}
+	p.pterm.Printfln(format, a...)
+}
 func (p *ptermPrinter) SetOutput(w io.Writer) {
 	// Call p.pterm.WithWriter(w)
 	// All pterm structs have this method, but not in the interface.
@@ -84,7 +133,9 @@ func (p *ptermPrinter) SetOutput(w io.Writer) {
 	if !method.IsValid() {
 		panic("WithWriter is not implemented for this pterm.TextPrinter")
 	}
-	method.Call([]reflect.Value{reflect.ValueOf(w)})
+	// The method returns a copy of the printer with the writer.
+	res := method.Call([]reflect.Value{reflect.ValueOf(w)})
+	p.pterm = res[0].Interface().(pterm.TextPrinter)
 }
 
 // Terminal prints formatted text to the console.
@@ -93,6 +144,7 @@ type Terminal struct {
 	p []TextPrinter // p contains styled printers.
 
 	enabled bool // enabled disables output to the console if set to false.
+	global  bool // global is set for the default terminal controlling the output of pterm and std log.
 }
 
 // Term returns default [Terminal] to print application messages to the console.
@@ -102,21 +154,32 @@ func Term() *Terminal {
 
 // EnableOutput enables the output.
 func (t *Terminal) EnableOutput() {
-	pterm.EnableOutput()
+	if t.global {
+		pterm.EnableOutput()
+	}
 	t.enabled = true
 }
 
 // DisableOutput disables the output.
 func (t *Terminal) DisableOutput() {
-	pterm.DisableOutput()
+	if t.global {
+		pterm.DisableOutput()
+	}
 	t.enabled = false
 }
 
+// IsEnabled returns true if the output is enabled.
+func (t *Terminal) IsEnabled() bool {
+	return t.enabled
+}
+
 // SetOutput sets an output to target writer.
 func (t *Terminal) SetOutput(w io.Writer) {
 	t.w = w
 	// If some library uses std log, redirect as well.
-	log.SetOutput(w)
+	if t.global {
+		log.SetOutput(w)
+	}
 	// Ensure underlying printers use self.
 	// Used to simplify update of writers in the printers.
 	for i := 0; i < len(t.p); i++ {
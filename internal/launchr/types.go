@@ -51,6 +51,8 @@ type AppInternal interface {
 	App
 	RootCmd() *Command
 	CmdEarlyParsed() CmdEarlyParsed
+	// ServiceManager returns the services, the logger and the terminal of the app.
+	ServiceManager() *ServiceManager
 }
 
 // AppVersion stores application version.
@@ -68,7 +70,13 @@ type AppVersion struct {
 // PluginInfo provides information about the plugin and is used as a unique data to identify a plugin.
 type PluginInfo struct {
 	// Weight defines the order of plugins calling. @todo rework to a real dependency resolving.
-	Weight   int
+	Weight int
+	// APIVersion is a version of the plugin API the plugin targets, see [PluginAPIVersion].
+	// Zero value means the version is not declared and the plugin is considered compatible.
+	APIVersion int
+	// Capabilities is a set of plugin API features the plugin requires.
+	Capabilities PluginCapability
+
 	pkgPath  string
 	typeName string
 }
@@ -172,8 +180,18 @@ type PluginManager interface {
 }
 
 // NewPluginManagerWithRegistered creates [PluginManager] with registered plugins.
+// Plugins incompatible with the current plugin API are skipped with a warning.
 func NewPluginManagerWithRegistered() PluginManager {
-	return pluginManagerMap(registeredPlugins)
+	m := make(pluginManagerMap, len(registeredPlugins))
+	for pi, p := range registeredPlugins {
+		if err := CheckPluginCompatibility(pi); err != nil {
+			Log().Warn("plugin was skipped due to incompatibility", "plugin", pi.String(), "error", err)
+			Term().Warning().Printfln("Plugin %q was skipped, it needs rebuilding: %v", pi, err)
+			continue
+		}
+		m[pi] = p
+	}
+	return m
 }
 
 type pluginManagerMap PluginsMap
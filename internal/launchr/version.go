@@ -7,6 +7,7 @@ import (
 	"runtime/debug"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	_ "unsafe" // Use unsafe to have linked variables from the main package.
 )
@@ -18,15 +19,17 @@ var (
 	//go:linkname version github.com/launchrctl/launchr.version
 	version string
 	//go:linkname builtWith github.com/launchrctl/launchr.builtWith
-	builtWith  string
-	appVersion *AppVersion
+	builtWith   string
+	appVersion  *AppVersion
+	versionOnce sync.Once
 )
 
 // Version provides app version info.
 func Version() *AppVersion {
-	if appVersion == nil {
+	// Actions may check the version concurrently.
+	versionOnce.Do(func() {
 		appVersion = NewVersion(name, version, builtWith, registeredPlugins)
-	}
+	})
 	return appVersion
 }
 
@@ -194,6 +194,11 @@ func (a *Action) RuntimeDef() *DefRuntime {
 	return a.def.Runtime
 }
 
+// ImageBuildResolverInfo implements [ImageBuildResolverDescriber].
+func (a *Action) ImageBuildResolverInfo() ImageBuildResolverInfo {
+	return ImageBuildResolverInfo{Name: "action " + a.ID, Priority: ImageBuildResolverPriorityAction}
+}
+
 // ImageBuildInfo implements [ImageBuildResolver].
 func (a *Action) ImageBuildInfo(image string) *types.BuildDefinition {
 	return a.RuntimeDef().Container.Build.ImageBuildInfo(image, a.Dir())
@@ -201,6 +206,27 @@ func (a *Action) ImageBuildInfo(image string) *types.BuildDefinition {
 
 // SetInput saves arguments and options for later processing in run, templates, etc.
 func (a *Action) SetInput(input *Input) (err error) {
+	if err = a.setInput(input); err != nil {
+		return err
+	}
+	return a.EnsureLoaded()
+}
+
+// LoadStages processes the input like [Action.SetInput] and returns the action file after each processing stage.
+// The stages processed before an error are returned with the error, so it's visible which stage failed.
+func (a *Action) LoadStages(input *Input) ([]LoadStage, error) {
+	sl, ok := a.loader.(StagedLoader)
+	if !ok {
+		return nil, fmt.Errorf("action %q doesn't support processing stages", a.ID)
+	}
+	if err := a.setInput(input); err != nil {
+		return nil, err
+	}
+	return sl.LoadStages(LoadContext{Action: a})
+}
+
+// setInput processes and validates the input and resets the loaded definition.
+func (a *Action) setInput(input *Input) (err error) {
 	def := a.ActionDef()
 
 	// Process arguments.
@@ -223,7 +249,7 @@ func (a *Action) SetInput(input *Input) (err error) {
 	a.input = input
 	// Reset to load the action file again with new replacements.
 	a.Reset()
-	return a.EnsureLoaded()
+	return nil
 }
 
 func (a *Action) processInputParams(def ParametersList, inp InputParams, changed InputParams) error {
@@ -278,6 +304,11 @@ func (a *Action) Execute(ctx context.Context) error {
 		panic("runtime is not set, call SetRuntime first")
 	}
 	defer a.runtime.Close()
+	if def, err := a.Raw(); err == nil && def.Action != nil {
+		if err = def.Action.CheckRequirements(); err != nil {
+			return err
+		}
+	}
 	if err := a.runtime.Init(ctx, a); err != nil {
 		return err
 	}
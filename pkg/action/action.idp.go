@@ -1,8 +1,11 @@
 package action
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // IDProvider provides an ID for an action.
@@ -43,3 +46,212 @@ type StringID string
 func (idp StringID) GetID(_ *Action) string {
 	return string(idp)
 }
+
+// Names of built-in [IDProvider] to select in the configuration.
+const (
+	IDProviderPath      = "path"       // IDProviderPath is [DefaultIDProvider].
+	IDProviderGitRemote = "git_remote" // IDProviderGitRemote is [GitRemoteIDProvider].
+)
+
+// IDDescriber is an [IDProvider] describing how an action id is derived.
+type IDDescriber interface {
+	IDProvider
+	// DescribeID returns a human-readable origin of the action id.
+	DescribeID(a *Action) string
+}
+
+// DescribeID returns a human-readable origin of the action id given by idp.
+func DescribeID(idp IDProvider, a *Action) string {
+	if d, ok := idp.(IDDescriber); ok {
+		return d.DescribeID(a)
+	}
+	return fmt.Sprintf("provider %T", idp)
+}
+
+// NewIDProvider creates a built-in [IDProvider] by name wrapped in [ExplicitIDProvider].
+// The path provider is used if the name is empty.
+func NewIDProvider(name string) (IDProvider, error) {
+	var idp IDProvider
+	switch name {
+	case "", IDProviderPath:
+		idp = DefaultIDProvider{}
+	case IDProviderGitRemote:
+		idp = &GitRemoteIDProvider{}
+	default:
+		return nil, fmt.Errorf("unknown action id provider %q, use %q or %q", name, IDProviderPath, IDProviderGitRemote)
+	}
+	return ExplicitIDProvider{Parent: idp}, nil
+}
+
+// DescribeID implements [IDDescriber] interface.
+func (idp DefaultIDProvider) DescribeID(a *Action) string {
+	return "path " + filepath.Dir(a.fpath)
+}
+
+// ExplicitIDProvider is an [IDProvider] returning the id declared in the action definition.
+// The parent provider is used if the id is not declared.
+type ExplicitIDProvider struct {
+	Parent IDProvider
+}
+
+// GetID implements [IDProvider] interface.
+func (idp ExplicitIDProvider) GetID(a *Action) string {
+	if id := explicitID(a); id != "" {
+		return id
+	}
+	return idp.Parent.GetID(a)
+}
+
+// DescribeID implements [IDDescriber] interface.
+func (idp ExplicitIDProvider) DescribeID(a *Action) string {
+	if explicitID(a) != "" {
+		return "explicit id"
+	}
+	return DescribeID(idp.Parent, a)
+}
+
+// explicitID returns the id declared in the action definition.
+// If the definition can't be loaded, the error is reported when the action is added.
+func explicitID(a *Action) string {
+	if a.loader == nil {
+		return ""
+	}
+	def, err := a.Raw()
+	if err != nil || def.Action == nil {
+		return ""
+	}
+	return def.Action.ID
+}
+
+// GitRemoteIDProvider is an [IDProvider] prefixing the path based id with the name of the git repository
+// containing the action, e.g. "tools.platform:build" for a repository "git@github.com:org/tools.git".
+// The repository name is taken from the "origin" remote or the first remote if there is no "origin".
+// The path based id is used if the action is not in a git repository with a remote.
+type GitRemoteIDProvider struct {
+	mx      sync.Mutex
+	remotes map[string]string // remotes caches remote urls by the git directory.
+}
+
+// GetID implements [IDProvider] interface.
+func (idp *GitRemoteIDProvider) GetID(a *Action) string {
+	id := DefaultIDProvider{}.GetID(a)
+	name := gitRepoName(idp.remote(a))
+	if id == "" || name == "" {
+		return id
+	}
+	return name + "." + id
+}
+
+// DescribeID implements [IDDescriber] interface.
+func (idp *GitRemoteIDProvider) DescribeID(a *Action) string {
+	desc := DefaultIDProvider{}.DescribeID(a)
+	if remote := idp.remote(a); remote != "" {
+		desc = "git remote " + remote + ", " + desc
+	}
+	return desc
+}
+
+// remote returns a remote url of the git repository containing the action.
+func (idp *GitRemoteIDProvider) remote(a *Action) string {
+	gitDir := findGitDir(a.Dir())
+	if gitDir == "" {
+		return ""
+	}
+	idp.mx.Lock()
+	defer idp.mx.Unlock()
+	if remote, ok := idp.remotes[gitDir]; ok {
+		return remote
+	}
+	if idp.remotes == nil {
+		idp.remotes = make(map[string]string)
+	}
+	remote := readGitRemote(gitDir)
+	idp.remotes[gitDir] = remote
+	return remote
+}
+
+// findGitDir returns a git directory of the repository containing dir.
+// Worktrees with a ".git" file are resolved to the common git directory.
+func findGitDir(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(dir, ".git")
+		if info, errStat := os.Stat(p); errStat == nil {
+			if info.IsDir() {
+				return p
+			}
+			return resolveGitFile(p)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// resolveGitFile reads a ".git" file of a worktree or a submodule pointing to the git directory.
+func resolveGitFile(p string) string {
+	content, err := os.ReadFile(p) //nolint:gosec
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(p), gitDir)
+	}
+	// Worktrees share the config of the main repository.
+	if common, errCommon := os.ReadFile(filepath.Join(gitDir, "commondir")); errCommon == nil { //nolint:gosec
+		c := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(c) {
+			c = filepath.Join(gitDir, c)
+		}
+		return filepath.Clean(c)
+	}
+	return gitDir
+}
+
+// readGitRemote reads a url of the "origin" remote or the first remote from the git config.
+func readGitRemote(gitDir string) string {
+	content, err := os.ReadFile(filepath.Join(gitDir, "config")) //nolint:gosec
+	if err != nil {
+		return ""
+	}
+	var first, section string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = strings.ReplaceAll(strings.Trim(line, "[]"), " ", "")
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "url" || !strings.HasPrefix(section, "remote\"") {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		if section == `remote"origin"` {
+			return val
+		}
+		if first == "" {
+			first = val
+		}
+	}
+	return first
+}
+
+// gitRepoName returns a repository name from a remote url,
+// e.g. "tools" for "git@github.com:org/tools.git" or "https://github.com/org/tools".
+func gitRepoName(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimRight(remote, "/"), ".git")
+	if i := strings.LastIndexAny(remote, "/:"); i != -1 {
+		remote = remote[i+1:]
+	}
+	return remote
+}
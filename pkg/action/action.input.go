@@ -179,6 +179,11 @@ func (input *Input) Streams() launchr.Streams {
 	return input.io
 }
 
+// SetStreams sets input io.
+func (input *Input) SetStreams(io launchr.Streams) {
+	input.io = io
+}
+
 func argsNamedToPos(args InputParams, argsDef ParametersList) []string {
 	if args == nil {
 		return nil
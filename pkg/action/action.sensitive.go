@@ -0,0 +1,100 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sensitiveMinLen is a minimal length of a sensitive value replaced inside of strings.
+// Short values may be a part of normal text, they are masked only as whole strings.
+const sensitiveMinLen = 4
+
+// SensitiveMask replaces values of sensitive parameters, e.g. in logs and summaries.
+// A nil mask doesn't change values.
+type SensitiveMask struct {
+	values []string
+}
+
+// NewSensitiveMask creates a mask of the values, empty values are skipped.
+func NewSensitiveMask(values ...string) *SensitiveMask {
+	m := &SensitiveMask{}
+	for _, v := range values {
+		if v != "" {
+			m.values = append(m.values, v)
+		}
+	}
+	// Replace the longest values first if one value contains another.
+	sort.SliceStable(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+	return m
+}
+
+// Values returns the masked values.
+func (m *SensitiveMask) Values() []string {
+	if m == nil {
+		return nil
+	}
+	return m.values
+}
+
+// Mask replaces the sensitive values in s.
+func (m *SensitiveMask) Mask(s string) string {
+	if m == nil {
+		return s
+	}
+	for _, v := range m.values {
+		if s == v {
+			return maskedValue
+		}
+		if len(v) >= sensitiveMinLen {
+			s = strings.ReplaceAll(s, v, maskedValue)
+		}
+	}
+	return s
+}
+
+// MaskSlice returns a copy of l with the sensitive values replaced.
+func (m *SensitiveMask) MaskSlice(l []string) []string {
+	if m == nil || l == nil {
+		return l
+	}
+	res := make([]string, len(l))
+	for i, s := range l {
+		res[i] = m.Mask(s)
+	}
+	return res
+}
+
+// SensitiveMask returns a mask of the input values of the parameters declared as sensitive.
+func (a *Action) SensitiveMask() *SensitiveMask {
+	if a.input == nil {
+		return nil
+	}
+	def := a.ActionDef()
+	var values []string
+	values = sensitiveValues(values, def.Arguments, a.input.Args())
+	values = sensitiveValues(values, def.Options, a.input.Opts())
+	return NewSensitiveMask(values...)
+}
+
+func sensitiveValues(values []string, params ParametersList, input InputParams) []string {
+	for _, p := range params {
+		if !p.Sensitive {
+			continue
+		}
+		switch v := input[p.Name].(type) {
+		case nil:
+		case []string:
+			values = append(values, v...)
+		case []any:
+			for _, item := range v {
+				values = append(values, fmt.Sprint(item))
+			}
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	return values
+}
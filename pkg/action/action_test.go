@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -329,6 +330,12 @@ func Test_ActionInputValidate(t *testing.T) {
 			}
 			err := a.ValidateInput(input)
 			assert.Equal(t, err == nil, input.IsValidated())
+			if errs, ok := err.(jsonschema.ErrSchemaValidationArray); ok {
+				// Structured details are checked separately, compare the paths and the messages.
+				for i := range errs {
+					errs[i] = newError(errs[i].Path, errs[i].Msg)
+				}
+			}
 			if tt.expErr == errAny {
 				assert.True(t, assert.Error(t, err))
 			} else if assert.IsType(t, tt.expErr, err) {
@@ -339,3 +346,59 @@ func Test_ActionInputValidate(t *testing.T) {
 		})
 	}
 }
+
+func Test_ActionInputValidateJSON(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name string
+		yaml string
+		args InputParams
+		opts InputParams
+		exp  string
+	}{
+		{"type and required", validMultipleArgsAndOpts, InputParams{"arg_int": "str", "arg_str": "str", "arg_bool": true}, InputParams{"opt_str": 1}, `[
+			{"instance":"/arguments","schema":"/properties/arguments/required","keyword":"required","param":"arg_str2","properties":["arg_str2"],"message":"missing property 'arg_str2'"},
+			{"instance":"/arguments/arg_int","schema":"/properties/arguments/properties/arg_int/type","keyword":"type","param":"arg_int","expected":["integer"],"message":"got string, want integer"},
+			{"instance":"/options","schema":"/properties/options/required","keyword":"required","param":"opt_str_required","properties":["opt_str_required"],"message":"missing property 'opt_str_required'"},
+			{"instance":"/options/opt_str","schema":"/properties/options/properties/opt_str/type","keyword":"type","param":"opt_str","expected":["string"],"message":"got number, want string"}
+		]`},
+		{"enum", validArgStringEnum, InputParams{"arg_enum": "invalid"}, nil, `[
+			{"instance":"/arguments/arg_enum","schema":"/properties/arguments/properties/arg_enum/enum","keyword":"enum","param":"arg_enum","expected":["enum1","enum2"],"message":"value must be one of 'enum1', 'enum2'"}
+		]`},
+	}
+	for _, tt := range tt {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := NewFromYAML(tt.name, []byte(tt.yaml))
+			err := a.ValidateInput(NewInput(a, tt.args, tt.opts, nil))
+			require.IsType(t, jsonschema.ErrSchemaValidationArray{}, err)
+			b, errJSON := json.Marshal(err)
+			require.NoError(t, errJSON)
+			assert.JSONEq(t, tt.exp, string(b))
+		})
+	}
+}
+
+func Test_ActionSensitiveMask(t *testing.T) {
+	t.Parallel()
+	m := NewSensitiveMask("abc", "", "token-1", "token-12")
+	assert.Equal(t, []string{"token-12", "token-1", "abc"}, m.Values())
+	assert.Equal(t, "***", m.Mask("abc"))
+	// Short values are masked only as whole strings.
+	assert.Equal(t, "abcd", m.Mask("abcd"))
+	assert.Equal(t, "--token=***", m.Mask("--token=token-12"))
+	assert.Equal(t, []string{"ls", "***", "-t=***"}, m.MaskSlice([]string{"ls", "abc", "-t=token-1"}))
+
+	var nilMask *SensitiveMask
+	assert.Equal(t, "abc", nilMask.Mask("abc"))
+	assert.Equal(t, []string{"abc"}, nilMask.MaskSlice([]string{"abc"}))
+	assert.Nil(t, nilMask.Values())
+
+	a := NewFromYAML("sensitive", []byte(validSensitiveParams))
+	assert.Nil(t, a.SensitiveMask())
+	input := NewInput(a, InputParams{"user": "admin", "password": "p4ssw0rd"}, InputParams{"tokens": []any{"tok1", "tok2"}}, nil)
+	require.NoError(t, a.SetInput(input))
+	mask := a.SensitiveMask()
+	assert.ElementsMatch(t, []string{"p4ssw0rd", "tok1", "tok2"}, mask.Values())
+	assert.Equal(t, "admin:***", mask.Mask("admin:p4ssw0rd"))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"io/fs"
 	"math/rand"
+	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"testing/fstest"
 
@@ -176,6 +178,61 @@ func Test_Discover_IDProvider(t *testing.T) {
 	}
 }
 
+func Test_Discover_ExplicitAndGitIDProvider(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	actionDir := filepath.Join(root, "platform", "actions", "build")
+	require.NoError(t, os.MkdirAll(actionDir, 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0750))
+	gitConfig := "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = https://github.com/org/upstream.git\n" +
+		"[remote \"origin\"]\n\turl = git@github.com:org/tools.git\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "config"), []byte(gitConfig), 0600))
+
+	newAction := func(idp IDProvider, y string) *Action {
+		return New(idp, &YamlLoader{Bytes: []byte(y)}, root, filepath.Join("platform", "actions", "build", "action.yaml"))
+	}
+	const implicit = "runtime: plugin\naction:\n  title: Build\n"
+	const explicit = "runtime: plugin\naction:\n  id: build\n  title: Build\n"
+
+	idp, err := NewIDProvider("")
+	require.NoError(t, err)
+	a := newAction(idp, implicit)
+	assert.Equal(t, "platform:build", a.ID)
+	assert.Equal(t, "path "+filepath.Join("platform", "actions", "build"), DescribeID(idp, a))
+	a = newAction(idp, explicit)
+	assert.Equal(t, "build", a.ID)
+	assert.Equal(t, "explicit id", DescribeID(idp, a))
+
+	idp, err = NewIDProvider(IDProviderGitRemote)
+	require.NoError(t, err)
+	a = newAction(idp, implicit)
+	assert.Equal(t, "tools.platform:build", a.ID)
+	assert.Equal(t, "git remote git@github.com:org/tools.git, path "+filepath.Join("platform", "actions", "build"), DescribeID(idp, a))
+	assert.Equal(t, "build", newAction(idp, explicit).ID)
+
+	// A worktree points to the main repository.
+	wt := t.TempDir()
+	wtActionDir := filepath.Join(wt, "actions", "test")
+	require.NoError(t, os.MkdirAll(wtActionDir, 0750))
+	wtGitDir := filepath.Join(root, ".git", "worktrees", "wt")
+	require.NoError(t, os.MkdirAll(wtGitDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "commondir"), []byte("../..\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+wtGitDir+"\n"), 0600))
+	assert.Equal(t, filepath.Join(root, ".git"), findGitDir(wtActionDir))
+
+	_, err = NewIDProvider("uuid")
+	assert.Error(t, err)
+
+	for remote, exp := range map[string]string{
+		"git@github.com:org/tools.git":  "tools",
+		"https://github.com/org/tools/": "tools",
+		"ssh://host/repo":               "repo",
+		"":                              "",
+	} {
+		assert.Equal(t, exp, gitRepoName(remote))
+	}
+}
+
 func _generateActionPath(d int, pathType genPathType) string {
 	elems := make([]string, 0, d+3)
 	for i := 0; i < d; i++ {
@@ -213,3 +270,56 @@ func _mergeFsMaps(maps ...fstest.MapFS) fstest.MapFS {
 	}
 	return m
 }
+
+func Test_DiscoverConfigActions(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name   string
+		fs     fsmy
+		expIDs []string
+	}
+
+	tts := []testCase{
+		{"no config", fsmy{}, []string{}},
+		{"valid actions", fsmy{"config.yaml": validCfgActionsYaml}, []string{"hello", "my.ns:deep"}},
+		{"invalid actions", fsmy{"config.yaml": invalidCfgActionsYaml}, []string{}},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := launchr.ConfigFromFS(tt.fs.MapFS())
+			actions := DiscoverConfigActions(cfg, ".")
+			ids := make([]string, 0, len(actions))
+			for _, a := range actions {
+				ids = append(ids, a.ID)
+				_, err := a.Raw()
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expIDs, ids)
+		})
+	}
+}
+
+const validCfgActionsYaml = `
+actions:
+  hello:
+    action:
+      title: Hello
+    runtime:
+      type: container
+      image: alpine:latest
+      command: [echo, hello]
+  my.ns:deep:
+    action:
+      title: Deep
+    runtime: plugin
+`
+
+const invalidCfgActionsYaml = `
+actions:
+  - action:
+      title: Hello
+    runtime: plugin
+`
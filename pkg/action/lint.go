@@ -0,0 +1,132 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LintSeverity is a severity of a lint issue.
+type LintSeverity string
+
+// Lint severities.
+const (
+	LintWarning LintSeverity = "warning"
+	LintError   LintSeverity = "error"
+)
+
+// Lint rules.
+const (
+	LintRuleImageLatest   = "image-latest"
+	LintRuleBuildContext  = "build-context"
+	LintRulePrivileged    = "privileged"
+	LintRuleEnvSecret     = "env-secret"
+	LintRuleUndefinedVar  = "undefined-var"
+	LintRuleInvalidAction = "invalid-action"
+)
+
+// LintIssue is a potential problem found in an action definition.
+type LintIssue struct {
+	Action   string       `yaml:"action"`
+	Rule     string       `yaml:"rule"`
+	Severity LintSeverity `yaml:"-"`
+	Message  string       `yaml:"message"`
+}
+
+var (
+	rgxSecretEnv   = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|credential)`)
+	privilegedCaps = []string{"ALL", "SYS_ADMIN", "CAP_SYS_ADMIN"}
+)
+
+// IsSecretName checks if a name of a variable or a configuration key looks like a secret.
+func IsSecretName(name string) bool {
+	return rgxSecretEnv.MatchString(name)
+}
+
+// predefinedTplVars are template variables available in all action definitions.
+var predefinedTplVars = []string{"current_uid", "current_gid", "current_working_dir", "actions_base_dir", "action_dir"}
+
+// Lint inspects the container definition of action a for common mistakes.
+func Lint(a *Action) []LintIssue {
+	var res []LintIssue
+	add := func(rule string, sev LintSeverity, msg string, args ...any) {
+		res = append(res, LintIssue{Action: a.ID, Rule: rule, Severity: sev, Message: fmt.Sprintf(msg, args...)})
+	}
+	def, err := a.Raw()
+	if err != nil {
+		add(LintRuleInvalidAction, LintError, "%v", err)
+		return res
+	}
+	if content, errC := a.DefinitionEncoded(); errC == nil {
+		for _, v := range undefinedTplVars(content, def.Action) {
+			add(LintRuleUndefinedVar, LintError, "template variable %q is not defined", v)
+		}
+	}
+	if def.Runtime == nil || def.Runtime.Container == nil {
+		return res
+	}
+	ctr := def.Runtime.Container
+
+	if ctr.Build == nil && ctr.Image != DevcontainerImage && isLatestImage(ctr.Image) {
+		add(LintRuleImageLatest, LintWarning, "image %q is not pinned to a version, runs may not be reproducible", ctr.Image)
+	}
+	if ctr.Build != nil {
+		bctx := ctr.Build.ImageBuildInfo("", a.Dir()).Context
+		if stat, errStat := os.Stat(bctx); errStat != nil || !stat.IsDir() {
+			add(LintRuleBuildContext, LintError, "build context %q doesn't exist", ctr.Build.Context)
+		}
+	}
+	if ctr.DockerSocket {
+		add(LintRulePrivileged, LintWarning, "docker socket gives the container root access to the host")
+	}
+	if ctr.Security != nil {
+		for _, c := range ctr.Security.CapAdd {
+			if slices.Contains(privilegedCaps, strings.ToUpper(c)) {
+				add(LintRulePrivileged, LintError, "capability %q gives the container privileged access to the host", c)
+			}
+		}
+	}
+	for _, env := range ctr.Env {
+		k, v, _ := strings.Cut(env, "=")
+		// Values taken from the host environment or templates are fine.
+		if v == "" || strings.Contains(v, "$") || strings.Contains(v, "{{") {
+			continue
+		}
+		if IsSecretName(k) {
+			add(LintRuleEnvSecret, LintError, "environment variable %q looks like a secret, pass it from the host environment instead", k)
+		}
+	}
+	return res
+}
+
+// isLatestImage returns true if the image has no tag or digest or is tagged "latest".
+func isLatestImage(image string) bool {
+	if image == "" || strings.Contains(image, "@") {
+		return false
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	_, tag, ok := strings.Cut(name, ":")
+	return !ok || tag == "latest"
+}
+
+// undefinedTplVars returns template variables used in the definition but not declared as parameters.
+func undefinedTplVars(content []byte, def *DefAction) []string {
+	defined := slices.Clone(predefinedTplVars)
+	if def != nil {
+		for _, params := range []ParametersList{def.Arguments, def.Options} {
+			for _, p := range params {
+				defined = append(defined, p.Name, replDashes.Replace(p.Name))
+			}
+		}
+	}
+	var res []string
+	for _, m := range rgxTplVar.FindAllSubmatch(content, -1) {
+		k := string(m[1])
+		if !slices.Contains(defined, k) && !slices.Contains(res, k) {
+			res = append(res, k)
+		}
+	}
+	return res
+}
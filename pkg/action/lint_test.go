@@ -0,0 +1,62 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lintActionYaml = `
+action:
+  title: Lint
+  arguments:
+    - name: arg-name
+runtime:
+  type: container
+  image: alpine
+  build:
+    context: ./not-exists
+  command:
+    - echo {{ .arg_name }} {{ .action_dir }} {{ .missing }}
+  env:
+    API_TOKEN: abc
+    DB_PASSWORD: ${DB_PASSWORD}
+    PLAIN: value
+  security:
+    cap_add: [NET_ADMIN, SYS_ADMIN]
+`
+
+func Test_Lint(t *testing.T) {
+	t.Parallel()
+	a := NewFromYAML("lint", []byte(lintActionYaml))
+	issues := Lint(a)
+	rules := make([]string, 0, len(issues))
+	for _, i := range issues {
+		assert.Equal(t, "lint", i.Action)
+		rules = append(rules, i.Rule)
+	}
+	// The image is built, the tag is not checked.
+	assert.Equal(t, []string{LintRuleUndefinedVar, LintRuleBuildContext, LintRulePrivileged, LintRuleEnvSecret}, rules)
+
+	invalid := NewFromYAML("invalid", []byte("action: [invalid"))
+	issues = Lint(invalid)
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, LintRuleInvalidAction, issues[0].Rule)
+	}
+}
+
+func Test_IsLatestImage(t *testing.T) {
+	t.Parallel()
+	tts := map[string]bool{
+		"alpine":                      true,
+		"alpine:latest":               true,
+		"localhost:5000/alpine":       true,
+		"alpine:3.20":                 false,
+		"localhost:5000/alpine:3.20":  false,
+		"alpine@sha256:0123456789abc": false,
+		"":                            false,
+	}
+	for image, exp := range tts {
+		assert.Equal(t, exp, isLatestImage(image), image)
+	}
+}
@@ -31,6 +31,47 @@ type LoadProcessor interface {
 	Process(LoadContext, []byte) ([]byte, error)
 }
 
+// LoadStage is an action file after a processing stage.
+type LoadStage struct {
+	Name    string // Name is a name of the stage, e.g. "env expansion".
+	Content []byte // Content is the processed action file.
+}
+
+// StagedLoader is a [Loader] providing the action file after each processing stage to debug the processing.
+type StagedLoader interface {
+	// LoadStages returns the action file content and the results of the processors.
+	// The stages processed before an error are returned with the error.
+	LoadStages(LoadContext) ([]LoadStage, error)
+}
+
+// StagedLoadProcessor is a [LoadProcessor] consisting of several processors.
+type StagedLoadProcessor interface {
+	LoadProcessor
+	// ProcessStages processes the input and returns the result of each processor.
+	ProcessStages(LoadContext, []byte) ([]LoadStage, error)
+}
+
+// loadProcessorName returns a name of a processing stage of the processor.
+func loadProcessorName(p LoadProcessor) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// processStages processes b with p and returns the results of all stages.
+func processStages(p LoadProcessor, ctx LoadContext, b []byte) ([]LoadStage, error) {
+	if sp, ok := p.(StagedLoadProcessor); ok {
+		return sp.ProcessStages(ctx, b)
+	}
+	name := loadProcessorName(p)
+	res, err := p.Process(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return []LoadStage{{Name: name, Content: res}}, nil
+}
+
 type pipeProcessor struct {
 	p []LoadProcessor
 }
@@ -51,8 +92,26 @@ func (p *pipeProcessor) Process(ctx LoadContext, b []byte) ([]byte, error) {
 	return b, nil
 }
 
+// ProcessStages implements [StagedLoadProcessor] interface.
+func (p *pipeProcessor) ProcessStages(ctx LoadContext, b []byte) ([]LoadStage, error) {
+	var res []LoadStage
+	for _, proc := range p.p {
+		stages, err := processStages(proc, ctx, b)
+		res = append(res, stages...)
+		if err != nil {
+			return res, err
+		}
+		if len(stages) > 0 {
+			b = stages[len(stages)-1].Content
+		}
+	}
+	return res, nil
+}
+
 type envProcessor struct{}
 
+func (p envProcessor) String() string { return "env expansion" }
+
 func (p envProcessor) Process(_ LoadContext, b []byte) ([]byte, error) {
 	s := os.Expand(string(b), getenv)
 	return []byte(s), nil
@@ -73,6 +132,8 @@ func getenv(key string) string {
 
 type inputProcessor struct{}
 
+func (p inputProcessor) String() string { return "template execution" }
+
 var rgxTplVar = regexp.MustCompile(`{{.*?\.(\S+).*?}}`)
 
 type errMissingVar struct {
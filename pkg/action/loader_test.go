@@ -1,6 +1,8 @@
 package action
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"strings"
 	"testing"
@@ -115,3 +117,56 @@ func Test_PipeProcessor(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "VAL1,arg1,optVal1", string(res))
 }
+
+func Test_LoadStages(t *testing.T) {
+	t.Setenv("TEST_STAGE_ENV", "envVal")
+	y := `
+action:
+  title: Test
+  arguments:
+    - name: arg1
+  options:
+    - name: optStr
+      default: optDef
+runtime:
+  type: container
+  image: alpine
+  command:
+    - echo
+    - "{{ .arg1 }}"
+    - "{{ .optStr }}"
+  env:
+    ENV1: ${TEST_STAGE_ENV} # {{ .arg1 }}
+`
+	newAction := func(y string) *Action {
+		loader := YamlDiscoveryStrategy{TargetRgx: rgxYamlFile}.Loader(
+			func() (fs.File, error) { return nil, errors.New("not used") },
+			envProcessor{}, inputProcessor{},
+		)
+		loader.(*YamlFileLoader).Bytes = []byte(y)
+		return New(StringID("test"), loader, "", "action.yaml")
+	}
+	a := newAction(y)
+	_, err := a.Raw()
+	require.NoError(t, err)
+	stages, err := a.LoadStages(NewInput(a, InputParams{"arg1": "argVal"}, nil, nil))
+	require.NoError(t, err)
+	names := make([]string, len(stages))
+	for i, s := range stages {
+		names[i] = s.Name
+	}
+	assert.Equal(t, []string{"source", "comments removal", "env expansion", "template execution"}, names)
+	assert.Equal(t, y, string(stages[0].Content))
+	assert.Contains(t, string(stages[1].Content), "ENV1: ${TEST_STAGE_ENV} \n")
+	assert.Contains(t, string(stages[2].Content), "ENV1: envVal \n")
+	assert.Contains(t, string(stages[2].Content), `- "{{ .optStr }}"`)
+	assert.Contains(t, string(stages[3].Content), "- \"argVal\"\n    - \"optDef\"")
+
+	// The stages before an error are returned.
+	a = newAction(strings.Replace(y, ".optStr", ".optUnd", 1))
+	_, err = a.Raw()
+	require.NoError(t, err)
+	stages, err = a.LoadStages(NewInput(a, InputParams{"arg1": "argVal"}, nil, nil))
+	assert.ErrorContains(t, err, "template execution: the following variables were used but never defined")
+	assert.Len(t, stages, 3)
+}
@@ -0,0 +1,79 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// ErrRunBudget is returned when a run is stopped because it exceeded its duration budget.
+// It unwraps to a [launchr.ExitError] with [RunTimeoutExitCode].
+type ErrRunBudget struct {
+	ActionID string
+	Budget   time.Duration
+}
+
+// Error implements error interface.
+func (err ErrRunBudget) Error() string {
+	return fmt.Sprintf("action %q is stopped, the run exceeded the duration budget %s", err.ActionID, err.Budget)
+}
+
+// Unwrap returns the exit error of the stopped run.
+func (err ErrRunBudget) Unwrap() error {
+	return launchr.NewExitError(RunTimeoutExitCode, err.Error())
+}
+
+// RunBudget compares the duration of a finished run with its budget.
+type RunBudget struct {
+	// Budget is the expected maximum duration of the run.
+	Budget time.Duration
+	// Duration is the actual duration of the run.
+	Duration time.Duration
+}
+
+// Exceeded checks if the run took longer than the budget.
+func (b *RunBudget) Exceeded() bool {
+	return b.Duration > b.Budget
+}
+
+// String implements [fmt.Stringer] interface.
+func (b *RunBudget) String() string {
+	s := fmt.Sprintf("budget %s", b.Budget)
+	if b.Exceeded() {
+		s += fmt.Sprintf(", exceeded by %s", (b.Duration - b.Budget).Round(time.Millisecond))
+	}
+	return s
+}
+
+// watchRunBudget watches the duration of the run id of action a against the budget of the action.
+// When the run exceeds it, a warning is logged, so it's delivered to the log drains,
+// and the run is stopped if the action requests it. The returned function stops watching
+// and returns the budget of the finished run, it's nil if the action has no budget.
+// The terminal is shared with the output of the run, so the warning is printed there after the run.
+func watchRunBudget(ctx context.Context, a *Action, id string) (context.Context, func() *RunBudget) {
+	def, err := a.Raw()
+	if err != nil || def.Runtime.RunBudget() <= 0 {
+		return ctx, func() *RunBudget { return nil }
+	}
+	budget, kill := def.Runtime.RunBudget(), def.Runtime.BudgetKill
+	ctx, cancel := context.WithCancelCause(ctx)
+	start := time.Now()
+	sm := launchr.ServiceManagerFromContext(ctx)
+	timer := time.AfterFunc(budget, func() {
+		sm.Log().Warn("action run exceeded the duration budget", "action_id", a.ID, "run_id", id, "budget", budget, "stop", kill)
+		if kill {
+			cancel(ErrRunBudget{ActionID: a.ID, Budget: budget})
+		}
+	})
+	return ctx, func() *RunBudget {
+		timer.Stop()
+		cancel(nil)
+		b := &RunBudget{Budget: budget, Duration: time.Since(start)}
+		if b.Exceeded() {
+			sm.Term().Warning().Printfln("Action %q exceeded the duration budget %s, the run took %s.", a.ID, budget, b.Duration.Round(time.Millisecond))
+		}
+		return b
+	}
+}
@@ -0,0 +1,123 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// dependencyResolver resolves the actions declared in [DefAction.DependsOn] in the order of execution.
+type dependencyResolver struct {
+	m     Manager
+	order []*Action
+	state map[string]bool // state is false while the dependencies of the action are resolved and true after.
+	path  []string
+}
+
+// resolveDependencies returns the dependencies of action a in the order of execution.
+// Every action is returned once even if several actions depend on it.
+// The input of the dependencies is set, a must have the input already.
+func resolveDependencies(m Manager, a *Action) ([]*Action, error) {
+	r := &dependencyResolver{m: m, state: make(map[string]bool)}
+	if err := r.visit(a); err != nil {
+		return nil, err
+	}
+	// The last action is a itself.
+	return r.order[:len(r.order)-1], nil
+}
+
+func (r *dependencyResolver) visit(a *Action) error {
+	done, seen := r.state[a.ID]
+	if done {
+		return nil
+	}
+	r.path = append(r.path, a.ID)
+	if seen {
+		return fmt.Errorf("dependency cycle of actions: %s", strings.Join(r.path, " -> "))
+	}
+	r.state[a.ID] = false
+	for _, dep := range a.ActionDef().DependsOn {
+		id := r.m.GetIDFromAlias(dep.Action)
+		if r.state[id] {
+			continue
+		}
+		da, ok := r.m.Get(id)
+		if !ok {
+			return fmt.Errorf("dependency %q of action %q is not found", dep.Action, a.ID)
+		}
+		if _, inProgress := r.state[id]; !inProgress {
+			if err := setDependencyInput(da, dep, a.Input()); err != nil {
+				return fmt.Errorf("invalid input of dependency %q of action %q: %w", da.ID, a.ID, err)
+			}
+		}
+		if err := r.visit(da); err != nil {
+			return err
+		}
+	}
+	r.path = r.path[:len(r.path)-1]
+	r.state[a.ID] = true
+	r.order = append(r.order, a)
+	return nil
+}
+
+// setDependencyInput sets the input of dependency action a declared in dep.
+// The parameters not set in dep take the values of the parameters with the same name of the dependent action input.
+func setDependencyInput(a *Action, dep DefDependency, parent *Input) error {
+	def := a.ActionDef()
+	args := make(InputParams, len(def.Arguments))
+	for _, p := range def.Arguments {
+		if v, ok := dependencyValue(p.Name, dep.Args, parent); ok {
+			args[p.Name] = v
+		}
+	}
+	opts := make(InputParams, len(def.Options))
+	for _, p := range def.Options {
+		if v, ok := dependencyValue(p.Name, dep.Options, parent); ok {
+			opts[p.Name] = v
+		}
+	}
+	input := NewInput(a, args, opts, parent.Streams())
+	if rt, ok := a.Runtime().(RuntimeFlags); ok {
+		if err := rt.UseFlags(InputParams{}); err != nil {
+			return err
+		}
+		if err := rt.ValidateInput(a, input); err != nil {
+			return err
+		}
+	}
+	return a.SetInput(input)
+}
+
+func dependencyValue(name string, values map[string]any, parent *Input) (any, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	if parent == nil {
+		return nil, false
+	}
+	if v, ok := parent.Args()[name]; ok {
+		return v, true
+	}
+	v, ok := parent.Opts()[name]
+	return v, ok
+}
+
+// runDependencies runs the dependencies of action a one by one, a failed dependency stops the run.
+func (m *actionManagerMap) runDependencies(ctx context.Context, a *Action) error {
+	if len(a.ActionDef().DependsOn) == 0 {
+		return nil
+	}
+	deps, err := resolveDependencies(m, a)
+	if err != nil {
+		return err
+	}
+	for _, da := range deps {
+		ri := m.registerRun(da, "")
+		err = da.Execute(ctx)
+		m.updateRunUsage(ri.ID, da)
+		if err != nil {
+			return fmt.Errorf("dependency %q of action %q failed: %w", da.ID, a.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type durationEstimateKey struct{}
+
+// durationEstimate is an expected duration of a run of an action.
+type durationEstimate struct {
+	actionID string
+	duration time.Duration
+}
+
+// WithDurationEstimate returns a context with the expected duration d of the run of action id,
+// e.g. a median duration of the previous runs. The progress of the run relative to the estimate
+// is shown in the heartbeat of container actions.
+func WithDurationEstimate(ctx context.Context, id string, d time.Duration) context.Context {
+	return context.WithValue(ctx, durationEstimateKey{}, durationEstimate{actionID: id, duration: d})
+}
+
+// durationEstimateFromContext returns the expected duration of the run of action id or 0 if it's unknown.
+// The estimate isn't applied to the actions run by the action, e.g. dependencies and meta steps.
+func durationEstimateFromContext(ctx context.Context, id string) time.Duration {
+	if e, ok := ctx.Value(durationEstimateKey{}).(durationEstimate); ok && e.actionID == id {
+		return e.duration
+	}
+	return 0
+}
+
+// FormatEstimate returns a short rounded duration, e.g. "45s" or "4m".
+func FormatEstimate(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	s := strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -27,6 +27,10 @@ type Manager interface {
 	Decorate(a *Action, withFn ...DecorateWithFn) *Action
 	// GetIDFromAlias returns a real action ID by its alias. If not, returns alias.
 	GetIDFromAlias(alias string) string
+	// Subscribe registers a callback called when actions are added or deleted.
+	// The callback is called synchronously and must not block.
+	// The returned function removes the subscription.
+	Subscribe(fn func(ManagerEvent)) (unsubscribe func())
 
 	// GetActionIDProvider returns global application action id provider.
 	GetActionIDProvider() IDProvider
@@ -42,7 +46,11 @@ type Manager interface {
 	// DefaultRuntime provides the default action runtime.
 	DefaultRuntime() Runtime
 	// Run executes an action in foreground.
+	// The actions declared in [DefAction.DependsOn] are executed before the action.
+	// The run is cancelled with [ErrRunTimeout] when the timeout of the runtime definition elapses.
 	Run(ctx context.Context, a *Action) (RunInfo, error)
+	// RunAll executes a group of actions concurrently and returns a summary of the runs.
+	RunAll(ctx context.Context, actions []*Action, opts RunAllOptions) (RunSummary, error)
 	// RunBackground executes an action in background.
 	RunBackground(ctx context.Context, a *Action, runID string) (RunInfo, chan error)
 	// RunInfoByAction returns all running actions by action id.
@@ -66,6 +74,21 @@ type ManagerUnsafe interface {
 	GetUnsafe(id string) (*Action, bool)
 }
 
+// ManagerEventType is a type of change of the actions in [Manager].
+type ManagerEventType int
+
+// Action catalog change types.
+const (
+	ManagerEventAdded   ManagerEventType = iota // ManagerEventAdded - an action was added.
+	ManagerEventDeleted                         // ManagerEventDeleted - an action was deleted.
+)
+
+// ManagerEvent describes a change of the actions in [Manager].
+type ManagerEvent struct {
+	Type     ManagerEventType
+	ActionID string
+}
+
 // DecorateWithFn is a type alias for functions accepted in a [Manager.Decorate] interface method.
 type DecorateWithFn = func(m Manager, a *Action)
 
@@ -77,7 +100,12 @@ type actionManagerMap struct {
 	mxRun         sync.Mutex
 	dwFns         []DecorateWithFn
 	processors    map[string]ValueProcessor
+	mxProc        sync.RWMutex
 	idProvider    IDProvider
+
+	subscribers map[int]func(ManagerEvent)
+	subLastID   int
+	mxSub       sync.Mutex
 }
 
 // NewManager constructs a new action manager.
@@ -88,6 +116,7 @@ func NewManager(withFns ...DecorateWithFn) Manager {
 		runStore:      make(map[string]RunInfo),
 		dwFns:         withFns,
 		processors:    make(map[string]ValueProcessor),
+		subscribers:   make(map[int]func(ManagerEvent)),
 	}
 }
 
@@ -96,6 +125,14 @@ func (m *actionManagerMap) ServiceInfo() launchr.ServiceInfo {
 }
 
 func (m *actionManagerMap) Add(a *Action) error {
+	if err := m.add(a); err != nil {
+		return err
+	}
+	m.notify(ManagerEvent{Type: ManagerEventAdded, ActionID: a.ID})
+	return nil
+}
+
+func (m *actionManagerMap) add(a *Action) error {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 
@@ -104,13 +141,12 @@ func (m *actionManagerMap) Add(a *Action) error {
 	if err != nil {
 		return err
 	}
-	// Collect action aliases.
+	// Check action aliases are not taken.
 	for _, alias := range def.Action.Aliases {
 		id, ok := m.actionAliases[alias]
 		if ok {
 			return fmt.Errorf("alias %q is already defined by %q", alias, id)
 		}
-		m.actionAliases[alias] = a.ID
 	}
 	// Set action related processors.
 	err = a.SetProcessors(m.GetValueProcessors())
@@ -118,6 +154,10 @@ func (m *actionManagerMap) Add(a *Action) error {
 		// Skip action because the definition is not correct.
 		return err
 	}
+	// Register aliases only when the action is valid to not leave dangling aliases.
+	for _, alias := range def.Action.Aliases {
+		m.actionAliases[alias] = a.ID
+	}
 	m.actionStore[a.ID] = a
 	return nil
 }
@@ -129,6 +169,8 @@ func (m *actionManagerMap) AllUnsafe() map[string]*Action {
 }
 
 func (m *actionManagerMap) GetIDFromAlias(alias string) string {
+	m.mx.Lock()
+	defer m.mx.Unlock()
 	if id, ok := m.actionAliases[alias]; ok {
 		return id
 	}
@@ -136,18 +178,51 @@ func (m *actionManagerMap) GetIDFromAlias(alias string) string {
 }
 
 func (m *actionManagerMap) Delete(id string) {
+	if m.delete(id) {
+		m.notify(ManagerEvent{Type: ManagerEventDeleted, ActionID: id})
+	}
+}
+
+func (m *actionManagerMap) delete(id string) bool {
 	m.mx.Lock()
 	defer m.mx.Unlock()
 	_, ok := m.actionStore[id]
 	if !ok {
-		return
+		return false
 	}
 	delete(m.actionStore, id)
-	for _, idAlias := range m.actionAliases {
+	for alias, idAlias := range m.actionAliases {
 		if idAlias == id {
-			delete(m.actionAliases, id)
+			delete(m.actionAliases, alias)
 		}
 	}
+	return true
+}
+
+func (m *actionManagerMap) Subscribe(fn func(ManagerEvent)) (unsubscribe func()) {
+	m.mxSub.Lock()
+	defer m.mxSub.Unlock()
+	m.subLastID++
+	id := m.subLastID
+	m.subscribers[id] = fn
+	return func() {
+		m.mxSub.Lock()
+		defer m.mxSub.Unlock()
+		delete(m.subscribers, id)
+	}
+}
+
+// notify calls subscribers outside the storage lock, so they may access the manager.
+func (m *actionManagerMap) notify(e ManagerEvent) {
+	m.mxSub.Lock()
+	subs := make([]func(ManagerEvent), 0, len(m.subscribers))
+	for _, fn := range m.subscribers {
+		subs = append(subs, fn)
+	}
+	m.mxSub.Unlock()
+	for _, fn := range subs {
+		fn(e)
+	}
 }
 
 func (m *actionManagerMap) All() map[string]*Action {
@@ -172,6 +247,8 @@ func (m *actionManagerMap) GetUnsafe(id string) (*Action, bool) {
 }
 
 func (m *actionManagerMap) AddValueProcessor(name string, vp ValueProcessor) {
+	m.mxProc.Lock()
+	defer m.mxProc.Unlock()
 	if _, ok := m.processors[name]; ok {
 		panic(fmt.Sprintf("processor `%q` with the same name already exists", name))
 	}
@@ -179,7 +256,9 @@ func (m *actionManagerMap) AddValueProcessor(name string, vp ValueProcessor) {
 }
 
 func (m *actionManagerMap) GetValueProcessors() map[string]ValueProcessor {
-	return m.processors
+	m.mxProc.RLock()
+	defer m.mxProc.RUnlock()
+	return maps.Clone(m.processors)
 }
 
 func (m *actionManagerMap) Decorate(a *Action, withFns ...DecorateWithFn) *Action {
@@ -197,15 +276,19 @@ func (m *actionManagerMap) Decorate(a *Action, withFns ...DecorateWithFn) *Actio
 }
 
 func (m *actionManagerMap) GetActionIDProvider() IDProvider {
+	m.mx.Lock()
+	defer m.mx.Unlock()
 	if m.idProvider == nil {
-		m.SetActionIDProvider(nil)
+		m.idProvider = ExplicitIDProvider{Parent: DefaultIDProvider{}}
 	}
 	return m.idProvider
 }
 
 func (m *actionManagerMap) SetActionIDProvider(p IDProvider) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
 	if p == nil {
-		p = DefaultIDProvider{}
+		p = ExplicitIDProvider{Parent: DefaultIDProvider{}}
 	}
 	m.idProvider = p
 }
@@ -219,15 +302,83 @@ type RunInfo struct {
 	ID     string
 	Action *Action
 	Status string
+	// Labels are user metadata of the run, e.g. a ticket or a deployment,
+	// they are set if the runtime implements [RuntimeRunLabeler].
+	Labels map[string]string
+	// Usage is resource usage of the run, it's set when the run is finished
+	// and the runtime implements [RuntimeUsageReporter].
+	Usage *RunUsage
+	// Service is a state of a service action, it's set if the runtime implements [RuntimeServiceReporter].
+	Service *ServiceStatus
+	// Report is progress, outputs and warnings reported by the action, it's set when the run is finished
+	// and the runtime implements [RuntimeReporter].
+	Report *RunReport
+	// Budget compares the duration of the run with the budget of the action, it's set when the run is finished
+	// and the action has a budget.
+	Budget *RunBudget
 	// @todo add more info for status like error message or exit code. Or have it in output.
 }
 
+// RunUsage stores resource usage of an action run.
+type RunUsage struct {
+	// CPUTime is a total CPU time consumed by the run.
+	CPUTime time.Duration
+	// MaxMemory is a maximum observed memory usage in bytes.
+	MaxMemory uint64
+	// NetworkRx is a number of bytes received.
+	NetworkRx uint64
+	// NetworkTx is a number of bytes sent.
+	NetworkTx uint64
+	// CopiedIn is a number of bytes copied to the runtime environment, e.g. the working directory.
+	CopiedIn uint64
+	// CopiedOut is a number of bytes copied back from the runtime environment.
+	CopiedOut uint64
+	// CopyTime is a total duration of the copy operations.
+	CopyTime time.Duration
+}
+
+// RunReport stores structured information an action reported about its run.
+type RunReport struct {
+	// Progress is the last reported progress in percent.
+	Progress int
+	// Outputs are key-value results of the run.
+	Outputs map[string]string
+	// Warnings are warning messages of the run.
+	Warnings []string
+}
+
+// Service states of an action run.
+const (
+	ServiceStateRunning    = "running"    // ServiceStateRunning is a running service.
+	ServiceStateRestarting = "restarting" // ServiceStateRestarting is a service waiting for a restart after a failure.
+	ServiceStateStopped    = "stopped"    // ServiceStateStopped is a service exited normally or stopped by a user.
+	ServiceStateFailed     = "failed"     // ServiceStateFailed is a service failed without restarts left.
+)
+
+// ServiceStatus stores a state of a service action run.
+type ServiceStatus struct {
+	// State is one of ServiceState constants.
+	State string
+	// Restarts is a number of restarts after failures.
+	Restarts int
+	// MaxRestarts is a maximum number of restarts.
+	MaxRestarts int
+	// LastExitCode is an exit code of the last failure.
+	LastExitCode int
+	// NextRestart is a time of the next restart when the service is restarting.
+	NextRestart time.Time
+}
+
 func (m *actionManagerMap) registerRun(a *Action, id string) RunInfo {
 	// @todo rethink the implementation
 	m.mxRun.Lock()
 	defer m.mxRun.Unlock()
 	if id == "" {
 		id = strconv.FormatInt(time.Now().Unix(), 10) + "-" + a.ID
+		// The same action may run several times at once, e.g. with [Manager.RunAll].
+		for i, base := 2, id; m.runStore[id].ID != ""; i++ {
+			id = base + "-" + strconv.Itoa(i)
+		}
 	}
 	// @todo validate the action is actually running and the method was not just incorrectly requested
 	ri := RunInfo{
@@ -235,6 +386,9 @@ func (m *actionManagerMap) registerRun(a *Action, id string) RunInfo {
 		Action: a,
 		Status: "created",
 	}
+	if r, ok := a.Runtime().(RuntimeRunLabeler); ok {
+		ri.Labels = maps.Clone(r.RunLabels())
+	}
 	m.runStore[id] = ri
 	return ri
 }
@@ -248,9 +402,55 @@ func (m *actionManagerMap) updateRunStatus(id string, st string) {
 	}
 }
 
+func (m *actionManagerMap) updateRunBudget(id string, b *RunBudget) {
+	m.mxRun.Lock()
+	defer m.mxRun.Unlock()
+	if ri, ok := m.runStore[id]; ok && b != nil {
+		ri.Budget = b
+		m.runStore[id] = ri
+	}
+}
+
+func (m *actionManagerMap) updateRunUsage(id string, a *Action) RunInfo {
+	m.mxRun.Lock()
+	defer m.mxRun.Unlock()
+	ri := m.runStore[id]
+	if r, ok := a.Runtime().(RuntimeUsageReporter); ok {
+		ri.Usage = r.Usage()
+		m.runStore[id] = ri
+	}
+	if r, ok := a.Runtime().(RuntimeReporter); ok {
+		ri.Report = r.Report()
+		m.runStore[id] = ri
+	}
+	return withServiceStatus(ri)
+}
+
+// withServiceStatus sets the current service state of the run, the state changes while the run is in progress.
+func withServiceStatus(ri RunInfo) RunInfo {
+	if ri.Action == nil {
+		return ri
+	}
+	if r, ok := ri.Action.Runtime().(RuntimeServiceReporter); ok {
+		ri.Service = r.ServiceStatus()
+	}
+	return ri
+}
+
 func (m *actionManagerMap) Run(ctx context.Context, a *Action) (RunInfo, error) {
 	// @todo add the same status change info
-	return m.registerRun(a, ""), a.Execute(ctx)
+	ri := m.registerRun(a, "")
+	ctx, cancel, _ := withActionTimeout(ctx, a)
+	defer cancel()
+	ctx, stopBudget := watchRunBudget(ctx, a, ri.ID)
+	if err := m.runDependencies(ctx, a); err != nil {
+		m.updateRunBudget(ri.ID, stopBudget())
+		m.updateRunStatus(ri.ID, "error")
+		return ri, runTimeoutError(ctx, err)
+	}
+	err := a.Execute(ctx)
+	m.updateRunBudget(ri.ID, stopBudget())
+	return m.updateRunUsage(ri.ID, a), runTimeoutError(ctx, err)
 }
 
 func (m *actionManagerMap) RunBackground(ctx context.Context, a *Action, runID string) (RunInfo, chan error) {
@@ -258,8 +458,17 @@ func (m *actionManagerMap) RunBackground(ctx context.Context, a *Action, runID s
 	ri := m.registerRun(a, runID)
 	chErr := make(chan error)
 	go func() {
+		ctx, cancel, _ := withActionTimeout(ctx, a)
+		defer cancel()
+		ctx, stopBudget := watchRunBudget(ctx, a, ri.ID)
 		m.updateRunStatus(ri.ID, "running")
-		err := a.Execute(ctx)
+		err := m.runDependencies(ctx, a)
+		if err == nil {
+			err = a.Execute(ctx)
+			m.updateRunUsage(ri.ID, a)
+		}
+		m.updateRunBudget(ri.ID, stopBudget())
+		err = runTimeoutError(ctx, err)
 		chErr <- err
 		close(chErr)
 		if err != nil {
@@ -278,7 +487,7 @@ func (m *actionManagerMap) RunInfoByAction(aid string) []RunInfo {
 	run := make([]RunInfo, 0, len(m.runStore)/2)
 	for _, v := range m.runStore {
 		if v.Action.ID == aid {
-			run = append(run, v)
+			run = append(run, withServiceStatus(v))
 		}
 	}
 	return run
@@ -288,25 +497,64 @@ func (m *actionManagerMap) RunInfoByID(id string) (RunInfo, bool) {
 	m.mxRun.Lock()
 	defer m.mxRun.Unlock()
 	ri, ok := m.runStore[id]
-	return ri, ok
+	return withServiceStatus(ri), ok
 }
 
 // WithDefaultRuntime adds a default [Runtime] for an action.
 func WithDefaultRuntime(m Manager, a *Action) {
-	if a.Runtime() == nil {
-		a.SetRuntime(m.DefaultRuntime())
+	if a.Runtime() != nil {
+		return
 	}
+	if def, err := a.Raw(); err == nil && def.Runtime != nil {
+		switch def.Runtime.Type {
+		case runtimeTypeMeta:
+			// Meta actions run other actions of the manager.
+			a.SetRuntime(NewMetaRuntime(m))
+			return
+		case runtimeTypeShell:
+			a.SetRuntime(NewShellRuntime())
+			return
+		}
+	}
+	a.SetRuntime(m.DefaultRuntime())
 }
 
 // WithContainerRuntimeConfig configures a [ContainerRuntime].
 func WithContainerRuntimeConfig(cfg launchr.Config, prefix string) DecorateWithFn {
 	r := LaunchrConfigImageBuildResolver{cfg}
 	ccr := NewImageBuildCacheResolver(cfg)
+	rec := NewRunRecorder(cfg)
+	// The configuration is parsed once on the first use.
+	getRuntimeConfig := sync.OnceValue(func() ConfigRuntime {
+		return LaunchrConfigRuntime(cfg)
+	})
 	return func(_ Manager, a *Action) {
 		if env, ok := a.Runtime().(ContainerRuntime); ok {
+			rcfg := getRuntimeConfig()
 			env.AddImageBuildResolver(r)
 			env.SetImageBuildCacheResolver(ccr)
-			env.SetContainerNameProvider(ContainerNameProvider{Prefix: prefix, RandomSuffix: true})
+			env.SetContainerNameProvider(NewContainerNameProvider(prefix, rcfg.ContainerName))
+			env.SetRuntimeConfig(rcfg)
+			env.SetRunRecorder(rec)
+		}
+	}
+}
+
+// WithImageBuildResolverPlugins adds image build resolvers of [ImageBuildResolverPlugin] to a [ContainerRuntime].
+// The plugins are requested once on the first use.
+func WithImageBuildResolverPlugins(pm launchr.PluginManager) DecorateWithFn {
+	getResolvers := sync.OnceValue(func() []ImageBuildResolver {
+		var res []ImageBuildResolver
+		for _, p := range launchr.GetPluginByType[ImageBuildResolverPlugin](pm) {
+			res = append(res, p.V.ImageBuildResolvers()...)
+		}
+		return res
+	})
+	return func(_ Manager, a *Action) {
+		if env, ok := a.Runtime().(ContainerRuntime); ok {
+			for _, r := range getResolvers() {
+				env.AddImageBuildResolver(r)
+			}
 		}
 	}
 }
@@ -0,0 +1,312 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// RunAllOptions stores options of running a group of actions with [Manager.RunAll].
+type RunAllOptions struct {
+	// MaxParallel is a maximum number of actions running at once, 0 runs all actions at once.
+	MaxParallel int
+	// MaxParallelImages is a maximum number of images prepared at once before the run,
+	// 0 prepares all images at once.
+	MaxParallelImages int
+	// ContinueOnError runs all actions regardless of failures.
+	// Otherwise, the first failure cancels the running actions and the rest are skipped.
+	ContinueOnError bool
+}
+
+// Statuses of an action in [RunSummary].
+const (
+	RunResultSuccess = "success" // RunResultSuccess is a successful run.
+	RunResultFailure = "failure" // RunResultFailure is a failed run.
+	RunResultSkipped = "skipped" // RunResultSkipped is an action not started after a failure.
+)
+
+// RunResult is a result of an action run in a group.
+type RunResult struct {
+	// Run is an info of the run, it's empty for skipped actions.
+	Run      RunInfo
+	ActionID string
+	// Status is one of RunResult constants.
+	Status   string
+	Err      error
+	Duration time.Duration
+}
+
+// ExitCode returns the exit code of the run.
+func (r RunResult) ExitCode() int {
+	if r.Err == nil {
+		return 0
+	}
+	var exitErr launchr.ExitError
+	if errors.As(r.Err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// RunSummary is a combined report of a group of actions run with [Manager.RunAll].
+type RunSummary struct {
+	// Results are in the order of the actions.
+	Results  []RunResult
+	Duration time.Duration
+}
+
+// Count returns the number of the actions with the status.
+func (s RunSummary) Count(status string) int {
+	n := 0
+	for _, r := range s.Results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode returns the highest exit code of the failed actions.
+func (s RunSummary) ExitCode() int {
+	code := 0
+	for _, r := range s.Results {
+		code = max(code, r.ExitCode())
+	}
+	return code
+}
+
+// String implements [fmt.Stringer] interface.
+func (s RunSummary) String() string {
+	return fmt.Sprintf(
+		"%d actions in %s: %d succeeded, %d failed, %d skipped",
+		len(s.Results), s.Duration.Round(time.Millisecond),
+		s.Count(RunResultSuccess), s.Count(RunResultFailure), s.Count(RunResultSkipped),
+	)
+}
+
+// Write prints the summary and the results of the actions to w.
+func (s RunSummary) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, s.String())
+	for _, r := range s.Results {
+		line := fmt.Sprintf("  %s\t%s\t%s", r.ActionID, r.Status, r.Duration.Round(time.Millisecond))
+		if r.Run.Budget != nil {
+			line += fmt.Sprintf(" (%s)", r.Run.Budget)
+		}
+		if r.Err != nil {
+			var mask *SensitiveMask
+			if r.Run.Action != nil {
+				mask = r.Run.Action.SensitiveMask()
+			}
+			line += "\t" + mask.Mask(r.Err.Error())
+		}
+		_, _ = fmt.Fprintln(tw, line)
+	}
+	return tw.Flush()
+}
+
+// prefixWriter writes complete lines prefixed with the prefix.
+// Writers sharing the mutex don't mix their lines.
+type prefixWriter struct {
+	w      io.Writer
+	mx     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	lines := w.buf[:i+1]
+	w.buf = slices.Clone(w.buf[i+1:])
+	if err := w.writeLines(lines); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the last incomplete line.
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	lines := append(w.buf, '\n')
+	w.buf = nil
+	return w.writeLines(lines)
+}
+
+func (w *prefixWriter) writeLines(lines []byte) error {
+	var b bytes.Buffer
+	for _, line := range bytes.SplitAfter(lines, []byte{'\n'}) {
+		if len(line) > 0 {
+			b.WriteString(w.prefix)
+			b.Write(line)
+		}
+	}
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	_, err := w.w.Write(b.Bytes())
+	return err
+}
+
+// concurrentStreams are streams of an action running concurrently with other actions.
+// The actions can't share the input and the terminal, so the input is empty and the output
+// isn't a terminal. The output lines are prefixed with the action id.
+type concurrentStreams struct {
+	in  *launchr.In
+	out *launchr.Out
+	err io.Writer
+
+	flush []*prefixWriter
+}
+
+func newConcurrentStreams(streams launchr.Streams, id string, mx *sync.Mutex) *concurrentStreams {
+	prefix := "[" + id + "] "
+	out := &prefixWriter{w: streams.Out(), mx: mx, prefix: prefix}
+	errw := &prefixWriter{w: streams.Err(), mx: mx, prefix: prefix}
+	return &concurrentStreams{
+		in:    launchr.NewIn(io.NopCloser(strings.NewReader(""))),
+		out:   launchr.NewOut(out),
+		err:   errw,
+		flush: []*prefixWriter{out, errw},
+	}
+}
+
+func (s *concurrentStreams) In() *launchr.In   { return s.in }
+func (s *concurrentStreams) Out() *launchr.Out { return s.out }
+func (s *concurrentStreams) Err() io.Writer    { return s.err }
+
+// Flush writes the incomplete lines of the output.
+func (s *concurrentStreams) Flush() {
+	for _, w := range s.flush {
+		_ = w.Flush()
+	}
+}
+
+// RunAll executes the actions concurrently with [Manager.Run] and returns a summary of the runs.
+// The returned error is an [launchr.ExitError] with the highest exit code of the failed actions.
+// When several actions run at once, they get the streams without the input and the terminal,
+// their output is prefixed with the action id.
+// The images of the actions are prepared concurrently before the run, see [RuntimeImagePreparer].
+func (m *actionManagerMap) RunAll(ctx context.Context, actions []*Action, opts RunAllOptions) (RunSummary, error) {
+	limit := opts.MaxParallel
+	if limit <= 0 || limit > len(actions) {
+		limit = len(actions)
+	}
+	var outMx sync.Mutex
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	prepareImages(ctx, actions, opts.MaxParallelImages, &outMx)
+	summary := RunSummary{Results: make([]RunResult, len(actions))}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, a := range actions {
+		summary.Results[i] = RunResult{ActionID: a.ID, Status: RunResultSkipped}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		// Other action may fail while waiting for the slot.
+		if ctx.Err() != nil {
+			<-sem
+			continue
+		}
+		wg.Add(1)
+		go func(i int, a *Action) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if input := a.Input(); limit > 1 && input != nil && input.Streams() != nil {
+				streams := newConcurrentStreams(input.Streams(), a.ID, &outMx)
+				defer streams.Flush()
+				input.SetStreams(streams)
+			}
+			started := time.Now()
+			ri, err := m.Run(ctx, a)
+			res := RunResult{Run: ri, ActionID: a.ID, Status: RunResultSuccess, Err: err, Duration: time.Since(started)}
+			if err != nil {
+				res.Status = RunResultFailure
+				if !opts.ContinueOnError {
+					cancel()
+				}
+			}
+			summary.Results[i] = res
+		}(i, a)
+	}
+	wg.Wait()
+	summary.Duration = time.Since(start)
+
+	failed := summary.Count(RunResultFailure)
+	if failed == 0 {
+		if summary.Count(RunResultSkipped) > 0 {
+			// The parent context is canceled before all actions are started.
+			return summary, ctx.Err()
+		}
+		return summary, nil
+	}
+	return summary, launchr.NewExitError(summary.ExitCode(), fmt.Sprintf("%d of %d actions failed", failed, len(actions)))
+}
+
+// prepareImages prepares the images of the actions concurrently, at most limit images at once.
+// Nothing is prepared in advance if only one action has an image.
+// A failure is only logged, the run of the action prepares the image again and reports the error.
+func prepareImages(ctx context.Context, actions []*Action, limit int, outMx *sync.Mutex) {
+	var prepare []*Action
+	for _, a := range actions {
+		if _, ok := a.Runtime().(RuntimeImagePreparer); ok && !slices.Contains(prepare, a) {
+			prepare = append(prepare, a)
+		}
+	}
+	if len(prepare) < 2 {
+		return
+	}
+	if limit <= 0 || limit > len(prepare) {
+		limit = len(prepare)
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, a := range prepare {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(a *Action) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if input := a.Input(); limit > 1 && input != nil && input.Streams() != nil {
+				orig := input.Streams()
+				streams := newConcurrentStreams(orig, a.ID, outMx)
+				input.SetStreams(streams)
+				defer func() {
+					streams.Flush()
+					input.SetStreams(orig)
+				}()
+			}
+			if err := a.Runtime().(RuntimeImagePreparer).PrepareImage(ctx, a); err != nil {
+				launchr.LogFromContext(ctx).Warn("failed to prepare the image before the run", "action_id", a.ID, "error", err)
+			}
+		}(a)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// RunTimeoutExitCode is the exit code of a run stopped by the timeout, the same as of the timeout utility.
+const RunTimeoutExitCode = 124
+
+// ErrRunTimeout is returned when a run is stopped because its timeout elapsed.
+// It unwraps to a [launchr.ExitError] with [RunTimeoutExitCode].
+type ErrRunTimeout struct {
+	ActionID string
+	Timeout  time.Duration
+}
+
+// Error implements error interface.
+func (err ErrRunTimeout) Error() string {
+	return fmt.Sprintf("action %q is stopped, the run exceeded the timeout %s", err.ActionID, err.Timeout)
+}
+
+// Unwrap returns the exit error of the timeout.
+func (err ErrRunTimeout) Unwrap() error {
+	return launchr.NewExitError(RunTimeoutExitCode, err.Error())
+}
+
+type runTimeoutKey struct{}
+
+// WithRunTimeout returns a context overriding the timeout of the action run with [Manager.Run],
+// e.g. from a command line flag. Zero timeout disables the timeout of the action definition.
+// The override isn't applied to the actions run by the action, e.g. dependencies and meta steps.
+func WithRunTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, runTimeoutKey{}, timeout)
+}
+
+// withActionTimeout returns a context cancelled when the timeout of the action elapses.
+// The timeout is taken from the context override or from the runtime definition.
+func withActionTimeout(ctx context.Context, a *Action) (context.Context, context.CancelFunc, time.Duration) {
+	timeout, ok := ctx.Value(runTimeoutKey{}).(time.Duration)
+	if ok {
+		ctx = context.WithValue(ctx, runTimeoutKey{}, nil)
+	} else if def, err := a.Raw(); err == nil && def.Runtime != nil {
+		timeout = def.Runtime.RunTimeout()
+	}
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, 0
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrRunTimeout{ActionID: a.ID, Timeout: timeout})
+	return ctx, cancel, timeout
+}
+
+// runTimeoutError replaces the error of the run with [ErrRunTimeout] if the timeout of ctx elapsed
+// or with [ErrRunBudget] if the run was stopped after exceeding the budget.
+func runTimeoutError(ctx context.Context, err error) error {
+	var errTimeout ErrRunTimeout
+	var errBudget ErrRunBudget
+	cause := context.Cause(ctx)
+	switch {
+	case errors.As(cause, &errTimeout):
+		return errTimeout
+	case errors.As(cause, &errBudget):
+		return errBudget
+	}
+	return err
+}
@@ -0,0 +1,585 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+func Test_ManagerSubscribe(t *testing.T) {
+	t.Parallel()
+	am := NewManager()
+	var events []ManagerEvent
+	unsubscribe := am.Subscribe(func(e ManagerEvent) {
+		// Manager must be accessible in the callback.
+		_, _ = am.Get(e.ActionID)
+		events = append(events, e)
+	})
+
+	a := NewFromYAML("my_actions", []byte(validEmptyVersionYaml))
+	require.NoError(t, am.Add(a))
+	am.Delete(a.ID)
+	// Deleting a missing action doesn't produce an event.
+	am.Delete(a.ID)
+	// Invalid action is not added.
+	require.Error(t, am.Add(NewFromYAML("invalid", []byte(invalidEmptyCmdYaml))))
+
+	unsubscribe()
+	require.NoError(t, am.Add(a))
+
+	assert.Equal(t, []ManagerEvent{
+		{Type: ManagerEventAdded, ActionID: a.ID},
+		{Type: ManagerEventDeleted, ActionID: a.ID},
+	}, events)
+}
+
+func Test_ManagerConcurrentRun(t *testing.T) {
+	t.Parallel()
+	am := NewManager(WithDefaultRuntime)
+	addTestValueProcessors(am)
+	a := NewFromYAML("my_actions", []byte(actionProcessWithDefault))
+	a.SetRuntime(NewFnRuntime(func(_ context.Context, a *Action) error {
+		_ = a.Input().Arg("arg1")
+		return nil
+	}))
+	require.NoError(t, am.Add(a))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, ok := am.Get(am.GetIDFromAlias("my_actions"))
+			if !assert.True(t, ok) {
+				return
+			}
+			input := NewInput(a, InputParams{"arg1": fmt.Sprintf("A%d", i)}, nil, nil)
+			if !assert.NoError(t, a.SetInput(input)) {
+				return
+			}
+			assert.Equal(t, fmt.Sprintf("B%d", i), a.Input().Arg("arg1"))
+			if i%2 == 0 {
+				_, err := am.Run(context.Background(), a)
+				assert.NoError(t, err)
+			} else {
+				ri, chErr := am.RunBackground(context.Background(), a, "")
+				assert.NoError(t, <-chErr)
+				_, _ = am.RunInfoByID(ri.ID)
+			}
+			_ = am.RunInfoByAction(a.ID)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func Test_ManagerConcurrentAddDelete(t *testing.T) {
+	t.Parallel()
+	am := NewManager()
+	unsubscribe := am.Subscribe(func(ManagerEvent) {})
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("my_actions_%d", i)
+			a := NewFromYAML(id, []byte(validFullYaml))
+			// Aliases are the same for all actions, only one may be added at a time.
+			if err := am.Add(a); err == nil {
+				assert.Equal(t, id, am.GetIDFromAlias("alias1"))
+				am.Delete(id)
+			}
+			_ = am.GetIDFromAlias("alias2")
+			_ = am.All()
+			_ = am.GetValueProcessors()
+			_ = am.GetActionIDProvider()
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, "alias1", am.GetIDFromAlias("alias1"))
+}
+
+type testLabeledRuntime struct {
+	Runtime
+	labels map[string]string
+}
+
+func (r testLabeledRuntime) RunLabels() map[string]string { return r.labels }
+
+func Test_ManagerRunLabels(t *testing.T) {
+	t.Parallel()
+	am := NewManager()
+	labels := map[string]string{"ticket": "PLT-123"}
+	a := NewFromYAML("my_actions", []byte(validEmptyVersionYaml))
+	a.SetRuntime(testLabeledRuntime{NewFnRuntime(func(_ context.Context, _ *Action) error { return nil }), labels})
+	require.NoError(t, a.SetInput(NewInput(a, nil, nil, nil)))
+
+	ri, err := am.Run(context.Background(), a)
+	require.NoError(t, err)
+	assert.Equal(t, labels, ri.Labels)
+	ri, chErr := am.RunBackground(context.Background(), a, "")
+	require.NoError(t, <-chErr)
+	assert.Equal(t, labels, ri.Labels)
+	// Labels are copied to the run info.
+	labels["ticket"] = "PLT-456"
+	ri, ok := am.RunInfoByID(ri.ID)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"ticket": "PLT-123"}, ri.Labels)
+}
+
+func Test_ManagerDependencies(t *testing.T) {
+	t.Parallel()
+	const buildYaml = `
+runtime: plugin
+action:
+  title: Build
+  arguments:
+    - name: name
+  options:
+    - name: mode
+      default: debug
+`
+	const testYaml = `
+runtime: plugin
+action:
+  title: Test
+  depends_on:
+    - build
+  arguments:
+    - name: name
+`
+	const deployYaml = `
+runtime: plugin
+action:
+  title: Deploy
+  depends_on:
+    - action: test
+    - action: build
+      options:
+        mode: release
+  arguments:
+    - name: name
+`
+	const cycleYaml = `
+runtime: plugin
+action:
+  title: Cycle
+  depends_on:
+    - %s
+`
+	var runs []string
+	var failed string
+	fn := NewFnRuntime(func(_ context.Context, a *Action) error {
+		runs = append(runs, fmt.Sprintf("%s %v %v", a.ID, a.Input().Args(), a.Input().Opts()))
+		if a.ID == failed {
+			return launchr.NewExitError(2, "failed")
+		}
+		return nil
+	})
+	am := NewManager()
+	for id, y := range map[string]string{
+		"build":   buildYaml,
+		"test":    testYaml,
+		"deploy":  deployYaml,
+		"cycle1":  fmt.Sprintf(cycleYaml, "cycle2"),
+		"cycle2":  fmt.Sprintf(cycleYaml, "cycle1"),
+		"missing": fmt.Sprintf(cycleYaml, "unknown"),
+	} {
+		a := NewFromYAML(id, []byte(y))
+		a.SetRuntime(fn)
+		require.NoError(t, am.Add(a))
+	}
+	run := func(id string, args InputParams) error {
+		a, ok := am.Get(id)
+		require.True(t, ok)
+		require.NoError(t, a.SetInput(NewInput(a, args, nil, launchr.NoopStreams())))
+		runs = nil
+		_, err := am.Run(context.Background(), a)
+		return err
+	}
+
+	// The dependencies run once in the order, the input is passed through or defaulted.
+	require.NoError(t, run("deploy", InputParams{"name": "app"}))
+	assert.Equal(t, []string{
+		"build map[name:app] map[mode:debug]",
+		"test map[name:app] map[]",
+		"deploy map[name:app] map[]",
+	}, runs)
+
+	// A failed dependency stops the run.
+	failed = "build"
+	err := run("test", InputParams{"name": "app"})
+	assert.EqualError(t, err, `dependency "build" of action "test" failed: failed`)
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.ExitCode())
+	assert.Equal(t, []string{"build map[name:app] map[mode:debug]"}, runs)
+
+	err = run("cycle1", nil)
+	assert.EqualError(t, err, "dependency cycle of actions: cycle1 -> cycle2 -> cycle1")
+	assert.Empty(t, runs)
+	err = run("missing", nil)
+	assert.EqualError(t, err, `dependency "unknown" of action "missing" is not found`)
+}
+
+func Test_MetaRuntime(t *testing.T) {
+	t.Parallel()
+	const stepYaml = `
+runtime: plugin
+action:
+  title: Step
+  arguments:
+    - name: name
+`
+	const metaYaml = `
+runtime:
+  type: meta
+  continue_on_error: %t
+  steps:
+    - fail
+    - action: step
+      args:
+        name: app-step
+action:
+  title: Meta
+  arguments:
+    - name: name
+`
+	const cycleYaml = `
+runtime:
+  type: meta
+  steps:
+    - %s
+action:
+  title: Cycle
+`
+	var runs []string
+	fn := NewFnRuntime(func(_ context.Context, a *Action) error {
+		runs = append(runs, fmt.Sprintf("%s %v", a.ID, a.Input().Args()))
+		if a.ID == "fail" {
+			return launchr.NewExitError(3, "failed")
+		}
+		return nil
+	})
+	am := NewManager(WithDefaultRuntime)
+	for id, y := range map[string]string{
+		"step":     stepYaml,
+		"fail":     stepYaml,
+		"stop":     fmt.Sprintf(metaYaml, false),
+		"continue": fmt.Sprintf(metaYaml, true),
+		"cycle1":   fmt.Sprintf(cycleYaml, "cycle2"),
+		"cycle2":   fmt.Sprintf(cycleYaml, "cycle1"),
+	} {
+		a := NewFromYAML(id, []byte(y))
+		if id == "step" || id == "fail" {
+			a.SetRuntime(fn)
+		}
+		require.NoError(t, am.Add(a))
+	}
+	run := func(id string, args InputParams) error {
+		a, ok := am.Get(id)
+		require.True(t, ok)
+		require.NoError(t, a.SetInput(NewInput(a, args, nil, launchr.NoopStreams())))
+		runs = nil
+		_, err := am.Run(context.Background(), a)
+		return err
+	}
+
+	// The run is stopped on a failed step.
+	err := run("stop", InputParams{"name": "app"})
+	assert.EqualError(t, err, `step 1 "fail" of action "stop" failed: failed`)
+	assert.Equal(t, []string{"fail map[name:app]"}, runs)
+
+	// The steps continue, the exit codes are aggregated.
+	err = run("continue", InputParams{"name": "app"})
+	assert.EqualError(t, err, `1 of 2 steps of action "continue" failed`)
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+	assert.Equal(t, []string{"fail map[name:app]", "step map[name:app-step]"}, runs)
+
+	err = run("cycle1", nil)
+	assert.EqualError(t, err, `step 1 "cycle2" of action "cycle1" failed: step 1 "cycle1" of action "cycle2" failed: meta action cycle: cycle1 -> cycle2 -> cycle1`)
+}
+
+func Test_ManagerRunAll(t *testing.T) {
+	t.Parallel()
+	const stepYaml = `
+runtime: plugin
+action:
+  title: Step
+`
+	const metaYaml = `
+runtime:
+  type: meta
+  strategy: parallel
+  steps: [ fail, step ]
+action:
+  title: Meta
+`
+	var running, peak atomic.Int32
+	fn := NewFnRuntime(func(_ context.Context, a *Action) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(10 * time.Millisecond)
+		if a.ID == "fail" {
+			return launchr.NewExitError(3, "failed")
+		}
+		return nil
+	})
+	am := NewManager(WithDefaultRuntime)
+	for id, y := range map[string]string{"step": stepYaml, "fail": stepYaml, "meta": metaYaml} {
+		a := NewFromYAML(id, []byte(y))
+		if id != "meta" {
+			a.SetRuntime(fn)
+		}
+		require.NoError(t, am.Add(a))
+	}
+	actions := func(ids ...string) []*Action {
+		res := make([]*Action, len(ids))
+		for i, id := range ids {
+			a, ok := am.Get(id)
+			require.True(t, ok)
+			require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+			res[i] = a
+		}
+		return res
+	}
+	statuses := func(s RunSummary) []string {
+		res := make([]string, len(s.Results))
+		for i, r := range s.Results {
+			res[i] = r.Status
+		}
+		return res
+	}
+
+	// Parallelism is limited, every run has its own id.
+	summary, err := am.RunAll(context.Background(), actions("step", "step", "step", "step"), RunAllOptions{MaxParallel: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), peak.Load())
+	assert.Equal(t, 4, summary.Count(RunResultSuccess))
+	ids := make(map[string]bool)
+	for _, r := range summary.Results {
+		ids[r.Run.ID] = true
+	}
+	assert.Len(t, ids, 4)
+
+	// The first failure skips the rest.
+	summary, err = am.RunAll(context.Background(), actions("fail", "step", "step"), RunAllOptions{MaxParallel: 1})
+	assert.EqualError(t, err, "1 of 3 actions failed")
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+	assert.Equal(t, []string{RunResultFailure, RunResultSkipped, RunResultSkipped}, statuses(summary))
+	assert.Contains(t, summary.String(), "3 actions in")
+	assert.Contains(t, summary.String(), "0 succeeded, 1 failed, 2 skipped")
+
+	// All actions run regardless of failures.
+	summary, err = am.RunAll(context.Background(), actions("fail", "step", "step"), RunAllOptions{MaxParallel: 1, ContinueOnError: true})
+	assert.EqualError(t, err, "1 of 3 actions failed")
+	assert.Equal(t, []string{RunResultFailure, RunResultSuccess, RunResultSuccess}, statuses(summary))
+
+	// Meta action with the parallel strategy.
+	_, err = am.Run(context.Background(), actions("meta")[0])
+	assert.EqualError(t, err, `step 1 "fail" of action "meta" failed: failed`)
+}
+
+func Test_ManagerRunAllStreams(t *testing.T) {
+	t.Parallel()
+	fn := NewFnRuntime(func(_ context.Context, a *Action) error {
+		streams := a.Input().Streams()
+		if streams.In().IsTerminal() {
+			return errors.New("input is a terminal")
+		}
+		_, _ = fmt.Fprint(streams.Out(), "line 1\nline")
+		_, _ = fmt.Fprint(streams.Out(), " 2\nlast")
+		_, _ = fmt.Fprintln(streams.Err(), "error")
+		return nil
+	})
+	var out, stderr bytes.Buffer
+	streams := activityStreams{Streams: launchr.NoopStreams(), out: launchr.NewOut(&out), err: &stderr}
+	actions := make([]*Action, 2)
+	for i := range actions {
+		a := NewFromYAML(fmt.Sprintf("step%d", i), []byte("runtime: plugin\naction:\n  title: Step\n"))
+		a.SetRuntime(fn)
+		require.NoError(t, a.SetInput(NewInput(a, nil, nil, streams)))
+		actions[i] = a
+	}
+	am := NewManager(WithDefaultRuntime)
+	_, err := am.RunAll(context.Background(), actions, RunAllOptions{})
+	require.NoError(t, err)
+	for _, id := range []string{"step0", "step1"} {
+		for _, line := range []string{"line 1", "line 2", "last"} {
+			assert.Contains(t, out.String(), "["+id+"] "+line+"\n")
+		}
+		assert.Contains(t, stderr.String(), "["+id+"] error\n")
+	}
+}
+
+// imageRuntime is a runtime preparing images of the actions.
+type imageRuntime struct {
+	FnRuntime
+	prepare func(ctx context.Context, a *Action) error
+}
+
+func (r imageRuntime) PrepareImage(ctx context.Context, a *Action) error {
+	return r.prepare(ctx, a)
+}
+
+func Test_ManagerRunAllImages(t *testing.T) {
+	t.Parallel()
+	var mx sync.Mutex
+	var events []string
+	var preparing, peak atomic.Int32
+	r := imageRuntime{
+		FnRuntime: func(_ context.Context, a *Action) error {
+			mx.Lock()
+			defer mx.Unlock()
+			events = append(events, "run "+a.ID)
+			return nil
+		},
+		prepare: func(_ context.Context, a *Action) error {
+			n := preparing.Add(1)
+			defer preparing.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+			mx.Lock()
+			defer mx.Unlock()
+			events = append(events, "prepare "+a.ID)
+			if a.ID == "step0" {
+				return errors.New("image error")
+			}
+			return nil
+		},
+	}
+	actions := make([]*Action, 3)
+	for i := range actions {
+		a := NewFromYAML(fmt.Sprintf("step%d", i), []byte("runtime: plugin\naction:\n  title: Step\n"))
+		a.SetRuntime(r)
+		require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+		actions[i] = a
+	}
+	am := NewManager(WithDefaultRuntime)
+	// The images are prepared concurrently before the actions run one by one.
+	// A failed preparation is left to the run of the action.
+	summary, err := am.RunAll(context.Background(), actions, RunAllOptions{MaxParallel: 1, MaxParallelImages: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Count(RunResultSuccess))
+	assert.Equal(t, int32(2), peak.Load())
+	require.Len(t, events, 6)
+	assert.ElementsMatch(t, []string{"prepare step0", "prepare step1", "prepare step2"}, events[:3])
+	assert.Equal(t, []string{"run step0", "run step1", "run step2"}, events[3:])
+}
+
+func Test_ManagerRunTimeout(t *testing.T) {
+	t.Parallel()
+	am := NewManager()
+	a := NewFromYAML("slow", []byte("runtime:\n  type: plugin\n  timeout: 20ms\naction:\n  title: Slow\n"))
+	a.SetRuntime(NewFnRuntime(func(ctx context.Context, _ *Action) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}))
+	require.NoError(t, am.Add(a))
+
+	run := func(ctx context.Context) error {
+		a, ok := am.Get("slow")
+		require.True(t, ok)
+		require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+		_, err := am.Run(ctx, a)
+		return err
+	}
+
+	// The timeout of the definition.
+	start := time.Now()
+	err := run(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, ErrRunTimeout{ActionID: "slow", Timeout: 20 * time.Millisecond}, err)
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, RunTimeoutExitCode, exitErr.ExitCode())
+
+	// The timeout is overridden.
+	err = run(WithRunTimeout(context.Background(), 10*time.Millisecond))
+	assert.Equal(t, ErrRunTimeout{ActionID: "slow", Timeout: 10 * time.Millisecond}, err)
+
+	// Cancellation isn't reported as the timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, run(ctx), context.Canceled)
+}
+
+func Test_ManagerRunBudget(t *testing.T) {
+	t.Parallel()
+	sleep := NewFnRuntime(func(ctx context.Context, _ *Action) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	})
+	run := func(def string) (RunInfo, error) {
+		a := NewFromYAML("nightly", []byte(def))
+		a.SetRuntime(sleep)
+		require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+		return NewManager().Run(context.Background(), a)
+	}
+
+	// The run within the budget.
+	ri, err := run("runtime:\n  type: plugin\n  budget: 1m\naction:\n  title: Nightly\n")
+	require.NoError(t, err)
+	require.NotNil(t, ri.Budget)
+	assert.Equal(t, time.Minute, ri.Budget.Budget)
+	assert.False(t, ri.Budget.Exceeded())
+
+	// The run exceeding the budget finishes.
+	ri, err = run("runtime:\n  type: plugin\n  budget: 10ms\naction:\n  title: Nightly\n")
+	require.NoError(t, err)
+	require.NotNil(t, ri.Budget)
+	assert.True(t, ri.Budget.Exceeded())
+	assert.Contains(t, ri.Budget.String(), "budget 10ms, exceeded by ")
+
+	// The run exceeding the budget is stopped.
+	_, err = run("runtime:\n  type: plugin\n  budget: 10ms\n  budget_kill: true\naction:\n  title: Nightly\n")
+	assert.Equal(t, ErrRunBudget{ActionID: "nightly", Budget: 10 * time.Millisecond}, err)
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, RunTimeoutExitCode, exitErr.ExitCode())
+
+	// No budget is set.
+	ri, err = run("runtime: plugin\naction:\n  title: Nightly\n")
+	require.NoError(t, err)
+	assert.Nil(t, ri.Budget)
+}
+
+func Test_DurationEstimate(t *testing.T) {
+	t.Parallel()
+	ctx := WithDurationEstimate(context.Background(), "build", 4*time.Minute)
+	assert.Equal(t, 4*time.Minute, durationEstimateFromContext(ctx, "build"))
+	// The estimate isn't applied to other actions, e.g. dependencies.
+	assert.Zero(t, durationEstimateFromContext(ctx, "dep"))
+	assert.Zero(t, durationEstimateFromContext(context.Background(), "build"))
+
+	assert.Equal(t, "45s", FormatEstimate(44600*time.Millisecond))
+	assert.Equal(t, "4m", FormatEstimate(4*time.Minute+20*time.Second))
+	assert.Equal(t, "1h", FormatEstimate(time.Hour+10*time.Second))
+	assert.Equal(t, "1h30m", FormatEstimate(90*time.Minute))
+}
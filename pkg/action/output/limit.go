@@ -0,0 +1,69 @@
+package output
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LimitedBuffer captures output keeping only its beginning and its end,
+// so a run producing a huge output doesn't exhaust memory or disk.
+// It's safe for concurrent use.
+type LimitedBuffer struct {
+	mx      sync.Mutex
+	head    []byte
+	tail    []byte
+	headMax int
+	tailMax int
+	dropped int64
+}
+
+// NewLimitedBuffer creates a buffer keeping at most head bytes of the beginning
+// and tail bytes of the end of the output. Zero or negative head and tail
+// disable the limit, the whole output is kept.
+func NewLimitedBuffer(head, tail int) *LimitedBuffer {
+	return &LimitedBuffer{headMax: max(head, 0), tailMax: max(tail, 0)}
+}
+
+// Write implements [io.Writer] interface.
+func (b *LimitedBuffer) Write(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	n := len(p)
+	if b.headMax == 0 && b.tailMax == 0 {
+		b.head = append(b.head, p...)
+		return n, nil
+	}
+	if free := b.headMax - len(b.head); free > 0 {
+		free = min(free, len(p))
+		b.head = append(b.head, p[:free]...)
+		p = p[free:]
+	}
+	b.tail = append(b.tail, p...)
+	if over := len(b.tail) - b.tailMax; over > 0 {
+		b.dropped += int64(over)
+		b.tail = append(b.tail[:0], b.tail[over:]...)
+	}
+	return n, nil
+}
+
+// Truncated returns the number of bytes dropped from the middle of the output.
+func (b *LimitedBuffer) Truncated() int64 {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.dropped
+}
+
+// String returns the kept output. A note about truncation separates
+// the beginning and the end if a part of the output was dropped.
+func (b *LimitedBuffer) String() string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.dropped == 0 {
+		return string(b.head) + string(b.tail)
+	}
+	note := fmt.Sprintf("[... %d bytes of output truncated ...]\n", b.dropped)
+	if len(b.head) > 0 {
+		note = "\n" + note
+	}
+	return string(b.head) + note + string(b.tail)
+}
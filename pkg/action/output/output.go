@@ -0,0 +1,200 @@
+// Package output provides machine-readable output of action runs.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// Format is an output format of action runs.
+type Format string
+
+// Output formats.
+const (
+	FormatText Format = "text" // FormatText is a default output of actions as is.
+	FormatJSON Format = "json" // FormatJSON is newline-delimited JSON events, see [Emitter].
+)
+
+// String implements [fmt.Stringer] interface.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+// Set implements [github.com/spf13/pflag.Value] interface.
+func (f *Format) Set(v string) error {
+	ff := Format(v)
+	switch ff {
+	case FormatText, FormatJSON:
+		*f = ff
+		return nil
+	default:
+		return errors.New(`must be one of "text" or "json"`)
+	}
+}
+
+// Type implements [github.com/spf13/pflag.Value] interface.
+func (f *Format) Type() string {
+	return "OutputFormat"
+}
+
+// Types of events.
+const (
+	EventStart = "start" // EventStart is emitted before the action run.
+	EventLog   = "log"   // EventLog is a line of the action output.
+	EventExit  = "exit"  // EventExit is emitted after the action run.
+)
+
+// Streams of log events.
+const (
+	StreamStdout = "stdout" // StreamStdout is the standard output of the action.
+	StreamStderr = "stderr" // StreamStderr is the error output of the action.
+)
+
+type startEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	ActionID string    `json:"action_id"`
+}
+
+type logEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	ActionID string    `json:"action_id"`
+	Stream   string    `json:"stream"`
+	Line     string    `json:"line"`
+}
+
+type exitEvent struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	ActionID string    `json:"action_id"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	Duration int64     `json:"duration_ms"`
+}
+
+// Emitter writes events of an action run to w as newline-delimited JSON.
+// It's safe to use by several goroutines, e.g. for stdout and stderr of a container.
+type Emitter struct {
+	mx       sync.Mutex
+	w        io.Writer
+	actionID string
+	started  time.Time
+	streams  []*lineWriter
+	now      func() time.Time
+}
+
+// NewEmitter creates an [Emitter] of the action events.
+func NewEmitter(w io.Writer, actionID string) *Emitter {
+	return &Emitter{w: w, actionID: actionID, now: time.Now}
+}
+
+// Start emits the start event.
+func (e *Emitter) Start() error {
+	e.started = e.now()
+	return e.emit(startEvent{Type: EventStart, Time: e.started, ActionID: e.actionID})
+}
+
+// Exit emits the rest of the output and the exit event with the result of the run.
+func (e *Emitter) Exit(runErr error) error {
+	for _, lw := range e.streams {
+		lw.flush()
+	}
+	ev := exitEvent{Type: EventExit, Time: e.now(), ActionID: e.actionID}
+	ev.Duration = ev.Time.Sub(e.started).Milliseconds()
+	if runErr != nil {
+		ev.Error = runErr.Error()
+		ev.ExitCode = 1
+		var exitErr launchr.ExitError
+		if errors.As(runErr, &exitErr) {
+			ev.ExitCode = exitErr.ExitCode()
+		}
+	}
+	return e.emit(ev)
+}
+
+// Streams returns streams emitting every line of the output as a log event.
+// The input stream is kept. The output isn't a terminal, so containers run without a TTY.
+func (e *Emitter) Streams(s launchr.Streams) launchr.Streams {
+	out := &lineWriter{e: e, stream: StreamStdout}
+	errw := &lineWriter{e: e, stream: StreamStderr}
+	e.streams = []*lineWriter{out, errw}
+	return &streams{in: s.In(), out: launchr.NewOut(out), err: errw}
+}
+
+func (e *Emitter) emit(ev any) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
+
+// lineWriter emits the written data line by line.
+type lineWriter struct {
+	e      *Emitter
+	stream string
+	mx     sync.Mutex
+	buf    []byte
+}
+
+// Write implements [io.Writer] interface.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimSuffix(w.buf[:i], []byte{'\r'}))
+		w.buf = w.buf[i+1:]
+		if err := w.emitLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush emits the last line without a line break.
+func (w *lineWriter) flush() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	if len(w.buf) == 0 {
+		return
+	}
+	_ = w.emitLine(string(w.buf))
+	w.buf = nil
+}
+
+func (w *lineWriter) emitLine(line string) error {
+	return w.e.emit(logEvent{Type: EventLog, Time: w.e.now(), ActionID: w.e.actionID, Stream: w.stream, Line: line})
+}
+
+type streams struct {
+	in  *launchr.In
+	out *launchr.Out
+	err io.Writer
+}
+
+// StreamsFormat returns the output format of streams, [FormatJSON] for streams of an [Emitter].
+func StreamsFormat(s launchr.Streams) Format {
+	if _, ok := s.(*streams); ok {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+func (s *streams) In() *launchr.In   { return s.in }
+func (s *streams) Out() *launchr.Out { return s.out }
+func (s *streams) Err() io.Writer    { return s.err }
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+func Test_Emitter(t *testing.T) {
+	t.Parallel()
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	e := NewEmitter(&buf, "platform:build")
+	calls := 0
+	e.now = func() time.Time {
+		calls++
+		return started.Add(time.Duration(calls-1) * time.Second)
+	}
+	require.NoError(t, e.Start())
+	s := e.Streams(launchr.NoopStreams())
+	assert.False(t, s.Out().IsTerminal())
+	_, _ = fmt.Fprint(s.Out(), "line 1\r\nline")
+	_, _ = fmt.Fprint(s.Err(), "warning\n\n")
+	_, _ = fmt.Fprint(s.Out(), " 2\npartial")
+	require.NoError(t, e.Exit(fmt.Errorf("run failed: %w", launchr.NewExitError(3, "exit code 3"))))
+
+	exp := `{"type":"start","time":"2024-01-02T03:04:05Z","action_id":"platform:build"}
+{"type":"log","time":"2024-01-02T03:04:06Z","action_id":"platform:build","stream":"stdout","line":"line 1"}
+{"type":"log","time":"2024-01-02T03:04:07Z","action_id":"platform:build","stream":"stderr","line":"warning"}
+{"type":"log","time":"2024-01-02T03:04:08Z","action_id":"platform:build","stream":"stderr","line":""}
+{"type":"log","time":"2024-01-02T03:04:09Z","action_id":"platform:build","stream":"stdout","line":"line 2"}
+{"type":"log","time":"2024-01-02T03:04:10Z","action_id":"platform:build","stream":"stdout","line":"partial"}
+{"type":"exit","time":"2024-01-02T03:04:11Z","action_id":"platform:build","exit_code":3,"error":"run failed: exit code 3","duration_ms":6000}
+`
+	assert.Equal(t, exp, buf.String())
+
+	// Other errors exit with code 1.
+	buf.Reset()
+	e = NewEmitter(&buf, "platform:build")
+	require.NoError(t, e.Start())
+	require.NoError(t, e.Exit(errors.New("failed")))
+	assert.Contains(t, buf.String(), `"exit_code":1,"error":"failed"`)
+}
+
+func Test_Format(t *testing.T) {
+	t.Parallel()
+	f := FormatText
+	require.NoError(t, f.Set("json"))
+	assert.Equal(t, FormatJSON, f)
+	assert.EqualError(t, f.Set("yaml"), `must be one of "text" or "json"`)
+	assert.Equal(t, "json", f.String())
+}
+
+func Test_StreamsFormat(t *testing.T) {
+	t.Parallel()
+	e := NewEmitter(&bytes.Buffer{}, "test")
+	assert.Equal(t, FormatJSON, StreamsFormat(e.Streams(launchr.NoopStreams())))
+	assert.Equal(t, FormatText, StreamsFormat(launchr.NoopStreams()))
+}
+
+func Test_LimitedBuffer(t *testing.T) {
+	t.Parallel()
+	b := NewLimitedBuffer(3, 4)
+	_, _ = b.Write([]byte("ab"))
+	assert.Equal(t, "ab", b.String())
+	_, _ = b.Write([]byte("cdefg"))
+	assert.Equal(t, "abcdefg", b.String())
+	_, _ = b.Write([]byte("hijk"))
+	assert.Equal(t, int64(4), b.Truncated())
+	assert.Equal(t, "abc\n[... 4 bytes of output truncated ...]\nhijk", b.String())
+
+	// Only the tail is kept.
+	b = NewLimitedBuffer(0, 4)
+	_, _ = b.Write([]byte("abc"))
+	_, _ = b.Write([]byte("def"))
+	assert.Equal(t, "[... 2 bytes of output truncated ...]\ncdef", b.String())
+
+	// No limit.
+	b = NewLimitedBuffer(0, 0)
+	_, _ = b.Write([]byte("abcdef"))
+	assert.Equal(t, "abcdef", b.String())
+}
@@ -0,0 +1,194 @@
+package action
+
+import (
+	"strings"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action/output"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+// ConfigRuntimeKey is a field name in [launchr.Config] file for runtime configuration.
+const ConfigRuntimeKey = "runtime"
+
+// ConfigImagesOverridesKey is a field name in [launchr.Config] file for overrides of image defaults.
+const ConfigImagesOverridesKey = "images_overrides"
+
+// defaultArtifactsDir is a default directory of the collected artifacts in the working directory.
+const defaultArtifactsDir = ".artifacts"
+
+// defaultHeartbeatInterval is a default period of silence before a heartbeat is printed.
+const defaultHeartbeatInterval = time.Minute
+
+// Default sizes of the captured output kept by [ConfigOutputLimit].
+const (
+	defaultOutputLimitHead = 16 * 1024
+	defaultOutputLimitTail = 48 * 1024
+)
+
+// ConfigRuntime is a container to parse runtime configuration in [launchr.Config].
+type ConfigRuntime struct {
+	// HeartbeatInterval is a period of action output silence after which
+	// a status line is printed. Zero or negative value disables the heartbeat.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// HideEstimates hides the expected duration of actions estimated from the history of runs.
+	HideEstimates bool `yaml:"hide_estimates"`
+	// RestrictWrites enforces the restricted writes mode for all container actions.
+	// See the runtime flag "restrict-writes".
+	RestrictWrites bool `yaml:"restrict_writes"`
+	// ShowCommand prints the resolved command of container actions before the run.
+	// See the runtime flag "show-command".
+	ShowCommand bool `yaml:"show_command"`
+	// Timeouts limit operations of the container driver so an unresponsive
+	// container engine fails the action instead of hanging.
+	Timeouts driver.Timeouts `yaml:"timeouts"`
+	// Docker configures the connection to the docker daemon.
+	Docker driver.DockerOptions `yaml:"docker"`
+	// Security is a baseline security profile applied to all container actions.
+	Security ConfigSecurity `yaml:"security"`
+	// ContainerName configures names of created containers.
+	ContainerName ConfigContainerName `yaml:"container_name"`
+	// RecordSensitive keeps values of sensitive parameters in run records, they are masked by default.
+	RecordSensitive bool `yaml:"record_sensitive"`
+	// OutputLimit limits the captured output of runs, e.g. in run records, workflow states and batch results.
+	OutputLimit ConfigOutputLimit `yaml:"output_limit"`
+	// ArtifactsDir is a host directory of the collected artifacts of container actions,
+	// a relative path is relative to the working directory, ".artifacts" is used if empty.
+	ArtifactsDir string `yaml:"artifacts_dir"`
+	// ImagesOverrides is read from the top level field [ConfigImagesOverridesKey].
+	ImagesOverrides ConfigImagesOverrides `yaml:"-"`
+}
+
+// ConfigImagesOverrides overrides defaults of images in all actions, the key is an image name
+// with or without a tag, e.g. "corp/base" or "corp/base:1.2".
+type ConfigImagesOverrides map[string]ConfigImageOverride
+
+// ConfigImageOverride overrides defaults of an image.
+type ConfigImageOverride struct {
+	// Entrypoint replaces the entrypoint of the image, the runtime flag "entrypoint" takes precedence.
+	Entrypoint []string `yaml:"entrypoint"`
+	// User is a user running the container instead of the current user.
+	User string `yaml:"user"`
+}
+
+// Find returns the override of the image. An override of the image with the tag
+// takes precedence over the override of all tags of the image.
+func (o ConfigImagesOverrides) Find(image string) (ConfigImageOverride, bool) {
+	if ov, ok := o[image]; ok {
+		return ov, true
+	}
+	ov, ok := o[imageRepository(image)]
+	return ov, ok
+}
+
+// imageRepository returns the image name without the tag and the digest.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// ConfigContainerName configures generation of container names.
+type ConfigContainerName struct {
+	// Template is a container name with placeholders:
+	//   - {prefix} - the app name prefix
+	//   - {action} - the action id
+	//   - {user} - the current user name
+	//   - {hash} - a short random hex hash
+	//   - {random} - a random human-friendly name
+	Template string `yaml:"template"`
+	// Deterministic derives {hash} and {random} from the run id set in [EnvVarRunID]
+	// or from the working directory, so the names are predictable, e.g. in CI.
+	Deterministic bool `yaml:"deterministic"`
+	// StalePolicy defines what to do with a stopped container left with the same name, e.g. after a crash.
+	// By default, a numeric suffix is added to the name.
+	StalePolicy ContainerStalePolicy `yaml:"stale_policy"`
+}
+
+// ContainerStalePolicy defines handling of stale containers with the generated name.
+type ContainerStalePolicy string
+
+// Stale container policies.
+const (
+	ContainerStaleRemove ContainerStalePolicy = "remove" // ContainerStaleRemove removes the stale container and uses the name.
+	ContainerStaleFail   ContainerStalePolicy = "fail"   // ContainerStaleFail fails the run and asks to remove the container.
+	ContainerStaleReuse  ContainerStalePolicy = "reuse"  // ContainerStaleReuse starts the stale container again.
+)
+
+// ConfigSecurity is a security profile of container actions.
+// An action may relax the profile with [DefContainerSecurity].
+type ConfigSecurity struct {
+	// DropCapabilities drops all kernel capabilities of a container.
+	DropCapabilities bool `yaml:"drop_capabilities"`
+	// ReadonlyRootfs mounts the container root filesystem read-only, "/tmp" stays writable.
+	ReadonlyRootfs bool `yaml:"readonly_rootfs"`
+	// NoNewPrivileges prevents processes from gaining new privileges, e.g. with setuid binaries.
+	NoNewPrivileges bool `yaml:"no_new_privileges"`
+	// NonRoot requires a container to run as a non-root user.
+	NonRoot bool `yaml:"non_root"`
+	// AllowDockerSocket allows actions to mount the docker socket while the profile is set.
+	AllowDockerSocket bool `yaml:"allow_docker_socket"`
+}
+
+// ConfigOutputLimit limits the captured output of runs. The beginning and the end of the output
+// are kept, the middle part is replaced with a note about truncation.
+// Zero head and tail keep the whole output.
+type ConfigOutputLimit struct {
+	// Head is a size in bytes of the kept beginning of the output.
+	Head int `yaml:"head"`
+	// Tail is a size in bytes of the kept end of the output.
+	Tail int `yaml:"tail"`
+}
+
+// NewBuffer creates a buffer capturing output within the limit.
+func (l ConfigOutputLimit) NewBuffer() *output.LimitedBuffer {
+	return output.NewLimitedBuffer(l.Head, l.Tail)
+}
+
+// IsSet checks if any restriction of the profile is enabled.
+func (s ConfigSecurity) IsSet() bool {
+	return s.DropCapabilities || s.ReadonlyRootfs || s.NoNewPrivileges || s.NonRoot
+}
+
+// DefaultConfigRuntime returns runtime configuration used when nothing is set in config.
+func DefaultConfigRuntime() ConfigRuntime {
+	return ConfigRuntime{
+		HeartbeatInterval: defaultHeartbeatInterval,
+		OutputLimit: ConfigOutputLimit{
+			Head: defaultOutputLimitHead,
+			Tail: defaultOutputLimitTail,
+		},
+	}
+}
+
+// LaunchrConfigRuntime reads runtime configuration from [launchr.Config].
+// Default values are used for the fields not defined in the config.
+func LaunchrConfigRuntime(cfg launchr.Config) ConfigRuntime {
+	rcfg := DefaultConfigRuntime()
+	if cfg == nil {
+		return rcfg
+	}
+	err := cfg.Get(ConfigRuntimeKey, &rcfg)
+	if err != nil {
+		launchr.Term().Warning().Printfln("configuration file field %q is malformed", ConfigRuntimeKey)
+		return DefaultConfigRuntime()
+	}
+	if err = cfg.Get(ConfigImagesOverridesKey, &rcfg.ImagesOverrides); err != nil {
+		launchr.Term().Warning().Printfln("configuration file field %q is malformed", ConfigImagesOverridesKey)
+		rcfg.ImagesOverrides = nil
+	}
+	switch rcfg.ContainerName.StalePolicy {
+	case "", ContainerStaleRemove, ContainerStaleFail, ContainerStaleReuse:
+	default:
+		launchr.Term().Warning().Printfln("configuration file field %q has unknown value %q", ConfigRuntimeKey+".container_name.stale_policy", rcfg.ContainerName.StalePolicy)
+		rcfg.ContainerName.StalePolicy = ""
+	}
+	if rcfg.OutputLimit.Head < 0 || rcfg.OutputLimit.Tail < 0 {
+		launchr.Term().Warning().Printfln("configuration file field %q has negative size", ConfigRuntimeKey+".output_limit")
+		rcfg.OutputLimit = DefaultConfigRuntime().OutputLimit
+	}
+	return rcfg
+}
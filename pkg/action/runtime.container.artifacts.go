@@ -0,0 +1,146 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ArtifactsManifestName is a name of the manifest file in the directory of the collected artifacts.
+const ArtifactsManifestName = "manifest.yaml"
+
+// ArtifactsManifest lists the artifacts collected after a container run.
+type ArtifactsManifest struct {
+	ActionID  string    `yaml:"action_id"`
+	RunID     string    `yaml:"run_id"`
+	Collected time.Time `yaml:"collected"`
+	// Files are the collected files of all artifacts.
+	Files []ArtifactFile `yaml:"files"`
+	// Missing are the artifacts not found in the container.
+	Missing []string `yaml:"missing,omitempty"`
+}
+
+// ArtifactFile is a collected file of an artifact.
+type ArtifactFile struct {
+	// Artifact is the path of the artifact declared in the action.
+	Artifact string `yaml:"artifact"`
+	// Path is a slash-separated path of the file relative to the directory of the run artifacts.
+	Path   string `yaml:"path"`
+	Size   int64  `yaml:"size"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// artifactsDir returns a host directory of the artifacts of the run id of action a.
+func (c *runtimeContainer) artifactsDir(a *Action, runID string) string {
+	dir := c.rtcfg.ArtifactsDir
+	if dir == "" {
+		dir = defaultArtifactsDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(a.WorkDir(), dir)
+	}
+	return filepath.Join(dir, runID)
+}
+
+// artifactPaths returns the path of the artifact p in the container and the relative path
+// of its copy on the host. Relative artifacts are kept relative to the working directory,
+// absolute ones keep the full path.
+func artifactPaths(p string) (string, string) {
+	if path.IsAbs(p) {
+		p = path.Clean(p)
+		return p, strings.TrimPrefix(p, "/")
+	}
+	p = path.Clean(p)
+	return path.Join(containerHostMount, p), p
+}
+
+// collectArtifacts copies the artifacts of action a from the container to the host and writes the manifest
+// listing the collected files with checksums. A missing artifact doesn't fail the collection,
+// a failed run may not produce all of them. It returns the directory of the collected artifacts.
+func (c *runtimeContainer) collectArtifacts(ctx context.Context, a *Action, cid, runID string) (string, error) {
+	dir := c.artifactsDir(a, runID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+	m := ArtifactsManifest{ActionID: a.ID, RunID: runID, Collected: time.Now()}
+	for _, p := range a.RuntimeDef().Container.Artifacts {
+		src, rel := artifactPaths(p)
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if rel == "." {
+			// The whole working directory is copied to the root of the run artifacts.
+			dst = dir
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+			return "", err
+		}
+		err := c.copyFromContainer(ctx, cid, fmt.Sprintf("Collecting artifact %q", p), src, filepath.Dir(dst), filepath.Base(dst))
+		if err != nil {
+			c.log().Debug("failed to collect the artifact", "artifact", p, "error", err)
+			c.term().Warning().Printfln("Artifact %q is not collected: %v", p, err)
+			m.Missing = append(m.Missing, p)
+			continue
+		}
+		files, err := artifactFiles(dir, dst, p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the artifact %q: %w", p, err)
+		}
+		m.Files = append(m.Files, files...)
+	}
+	content, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(filepath.Join(dir, ArtifactsManifestName), content, 0600); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// artifactFiles returns the files of the artifact p copied to dst with their sizes and checksums.
+func artifactFiles(dir, dst, p string) ([]ArtifactFile, error) {
+	var files []ArtifactFile
+	err := filepath.WalkDir(dst, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || fpath == filepath.Join(dir, ArtifactsManifestName) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fpath)
+		if err != nil {
+			return err
+		}
+		f := ArtifactFile{Artifact: p, Path: filepath.ToSlash(rel)}
+		f.Size, f.SHA256, err = fileSum(fpath)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// fileSum returns the size and the sha256 checksum of the file.
+func fileSum(fpath string) (int64, string, error) {
+	f, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
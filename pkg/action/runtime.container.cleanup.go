@@ -0,0 +1,38 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// RemoveOrphanedVolumes removes the volumes of container runs of the app not used by any container,
+// e.g. volumes of the working directory left after a crash with the flag "use-volume-wd".
+// Cache volumes are kept. It returns the names of the removed volumes.
+func RemoveOrphanedVolumes(ctx context.Context, d driver.ContainerRunner) ([]string, error) {
+	vd, ok := d.(driver.ContainerRunnerVolumes)
+	if !ok {
+		return nil, errors.New("the container engine doesn't support managing volumes")
+	}
+	vols, err := vd.VolumeList(ctx, types.VolumeListOptions{
+		Labels:   []string{LabelApp + "=" + launchr.Version().Name, LabelRunID},
+		Dangling: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var removed []string
+	var errs []error
+	for _, v := range vols {
+		if err = vd.VolumeRemove(ctx, v.Name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove volume %q of run %q: %w", v.Name, v.Labels[LabelRunID], err))
+			continue
+		}
+		removed = append(removed, v.Name)
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,261 @@
+package action
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// DevcontainerImage is an image name to use the dev container definition of the working directory.
+const DevcontainerImage = "devcontainer"
+
+// DevcontainerPath is a path of the dev container definition relative to the working directory.
+const DevcontainerPath = ".devcontainer/devcontainer.json"
+
+const (
+	devcontainerFeaturePath = "/tmp/launchr-features"
+	devcontainerFeatureFile = "devcontainer-feature.json"
+)
+
+var rgxFeatureOptEnv = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// devcontainerDef is a subset of devcontainer.json used to build an image.
+// See https://containers.dev/implementors/json_reference/.
+type devcontainerDef struct {
+	Image      string             `json:"image"`
+	Dockerfile string             `json:"dockerFile"` // Deprecated: use build.dockerfile
+	Build      *devcontainerBuild `json:"build"`
+	Features   map[string]any     `json:"features"`
+}
+
+type devcontainerBuild struct {
+	Dockerfile string             `json:"dockerfile"`
+	Context    string             `json:"context"`
+	Args       map[string]*string `json:"args"`
+}
+
+type devcontainerFeatureDef struct {
+	Options map[string]struct {
+		Default any `json:"default"`
+	} `json:"options"`
+}
+
+// devcontainerFeature is a local feature to install in the image.
+type devcontainerFeature struct {
+	dir  string
+	opts map[string]any
+}
+
+// devcontainerImageBuildResolver provides build definitions of the images described in devcontainer.json.
+// When the dev container has features, the image is built in 2 steps: the base image from
+// the "image" or "build" fields and the final image installing the features on top of it.
+type devcontainerImageBuildResolver struct {
+	path     string
+	image    string
+	base     string
+	build    *types.BuildDefinition
+	features []devcontainerFeature
+	ctxDir   string
+}
+
+// newDevcontainerImageBuildResolver reads devcontainer.json in the working directory wd.
+func newDevcontainerImageBuildResolver(wd string) (*devcontainerImageBuildResolver, error) {
+	path := launchr.MustAbs(filepath.Join(wd, DevcontainerPath))
+	content, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dev container definition: %w", err)
+	}
+	var def devcontainerDef
+	if err = json.Unmarshal(jsoncToJSON(content), &def); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	r := &devcontainerImageBuildResolver{path: path}
+	tag := fmt.Sprintf("%x", sha256.Sum256([]byte(path)))[:12]
+
+	if def.Build == nil && def.Dockerfile != "" {
+		def.Build = &devcontainerBuild{Dockerfile: def.Dockerfile}
+	}
+	switch {
+	case def.Build != nil && def.Build.Dockerfile != "":
+		bctx := filepath.Join(dir, def.Build.Context)
+		buildfile, errRel := filepath.Rel(bctx, filepath.Join(dir, def.Build.Dockerfile))
+		if errRel != nil || strings.HasPrefix(buildfile, "..") {
+			return nil, fmt.Errorf("dockerfile %q must be inside of the build context %q", def.Build.Dockerfile, bctx)
+		}
+		r.build = &types.BuildDefinition{Context: bctx, Buildfile: buildfile, Args: def.Build.Args}
+		r.base = "launchr-devcontainer:" + tag
+	case def.Image != "":
+		r.base = def.Image
+	default:
+		return nil, fmt.Errorf("dev container definition %q must have an image or a dockerfile", path)
+	}
+
+	r.features, err = devcontainerFeatures(dir, def.Features)
+	if err != nil {
+		return nil, err
+	}
+	r.image = r.base
+	if len(r.features) > 0 {
+		if r.build != nil {
+			r.base = "launchr-devcontainer-base:" + tag
+		}
+		r.image = "launchr-devcontainer:" + tag
+		r.ctxDir = filepath.Join(os.TempDir(), "launchr-devcontainer-"+tag)
+	}
+	return r, nil
+}
+
+// devcontainerFeatures returns local features sorted by id.
+// Features published in registries are not supported, an error is returned for them.
+func devcontainerFeatures(dir string, features map[string]any) ([]devcontainerFeature, error) {
+	ids := make([]string, 0, len(features))
+	for id := range features {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	res := make([]devcontainerFeature, 0, len(ids))
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "./") && !strings.HasPrefix(id, "../") {
+			return nil, fmt.Errorf("dev container feature %q is not supported, only local features referenced with a relative path like \"./features/name\" are supported", id)
+		}
+		f := devcontainerFeature{dir: filepath.Join(dir, id), opts: make(map[string]any)}
+		content, err := os.ReadFile(filepath.Join(f.dir, devcontainerFeatureFile)) //nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dev container feature %q: %w", id, err)
+		}
+		var def devcontainerFeatureDef
+		if err = json.Unmarshal(jsoncToJSON(content), &def); err != nil {
+			return nil, fmt.Errorf("failed to parse dev container feature %q: %w", id, err)
+		}
+		for k, o := range def.Options {
+			f.opts[k] = o.Default
+		}
+		switch v := features[id].(type) {
+		case map[string]any:
+			for k, o := range v {
+				f.opts[k] = o
+			}
+		case string:
+			// A string value is a shorthand for the version option.
+			f.opts["version"] = v
+		}
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+// ImageBuildResolverInfo implements [ImageBuildResolverDescriber].
+func (r *devcontainerImageBuildResolver) ImageBuildResolverInfo() ImageBuildResolverInfo {
+	return ImageBuildResolverInfo{Name: "devcontainer " + r.path, Priority: ImageBuildResolverPriorityAction}
+}
+
+// ImageBuildInfo implements [ImageBuildResolver].
+func (r *devcontainerImageBuildResolver) ImageBuildInfo(image string) *types.BuildDefinition {
+	switch {
+	case image == r.image && len(r.features) > 0:
+		return &types.BuildDefinition{Context: r.ctxDir, Buildfile: "Dockerfile", Tags: []string{image}}
+	case image == r.base:
+		return r.build.ImageBuildInfo(image, "")
+	default:
+		return nil
+	}
+}
+
+// prepare creates the build context installing the features.
+func (r *devcontainerImageBuildResolver) prepare() error {
+	if len(r.features) == 0 {
+		return nil
+	}
+	if err := os.RemoveAll(r.ctxDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.ctxDir, 0750); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# Generated from %s\nFROM %s\nUSER root\n", r.path, r.base)
+	for i, f := range r.features {
+		name := fmt.Sprintf("%d-%s", i, filepath.Base(f.dir))
+		if err := os.CopyFS(filepath.Join(r.ctxDir, name), os.DirFS(f.dir)); err != nil {
+			return fmt.Errorf("failed to copy dev container feature %q: %w", f.dir, err)
+		}
+		dst := devcontainerFeaturePath + "/" + name
+		fmt.Fprintf(buf, "COPY %s %s\n", name, dst)
+		fmt.Fprintf(buf, "RUN cd %s && chmod +x install.sh && %s./install.sh\n", dst, featureOptsEnv(f.opts))
+	}
+	return os.WriteFile(filepath.Join(r.ctxDir, "Dockerfile"), buf.Bytes(), 0600)
+}
+
+// featureOptsEnv returns feature options as environment variables of the install script.
+func featureOptsEnv(opts map[string]any) string {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var s strings.Builder
+	for _, k := range keys {
+		if opts[k] == nil {
+			continue
+		}
+		env := strings.ToUpper(rgxFeatureOptEnv.ReplaceAllString(k, "_"))
+		val := strings.ReplaceAll(fmt.Sprint(opts[k]), "'", `'\''`)
+		fmt.Fprintf(&s, "%s='%s' ", env, val)
+	}
+	return s.String()
+}
+
+// jsoncToJSON removes comments and trailing commas of JSON with comments used in devcontainer.json.
+func jsoncToJSON(b []byte) []byte {
+	res := make([]byte, 0, len(b))
+	inStr := false
+	for i := 0; i < len(b); i++ {
+		ch := b[i]
+		switch {
+		case inStr:
+			if ch == '\\' && i+1 < len(b) {
+				res = append(res, ch, b[i+1])
+				i++
+				continue
+			}
+			if ch == '"' {
+				inStr = false
+			}
+		case ch == '"':
+			inStr = true
+		case ch == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				res = append(res, '\n')
+			}
+			continue
+		case ch == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end < 0 {
+				return res
+			}
+			i += end + 3
+			continue
+		case ch == '}' || ch == ']':
+			// Remove a trailing comma.
+			trimmed := bytes.TrimRight(res, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				res = append(trimmed[:len(trimmed)-1], res[len(trimmed):]...)
+			}
+		}
+		res = append(res, ch)
+	}
+	return res
+}
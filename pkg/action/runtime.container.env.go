@@ -0,0 +1,80 @@
+package action
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseEnvFlag parses KEY=VALUE environment variables given in a runtime flag.
+// Flag values are split by comma, so the parts without "=" are joined back to the previous value.
+func parseEnvFlag(list []string) ([]string, error) {
+	res := make([]string, 0, len(list))
+	for _, v := range list {
+		if strings.Contains(v, "=") {
+			res = append(res, v)
+			continue
+		}
+		if len(res) == 0 {
+			return nil, fmt.Errorf("invalid environment variable %q, expected KEY=VALUE", v)
+		}
+		res[len(res)-1] += "," + v
+	}
+	return res, nil
+}
+
+// readEnvFile reads environment variables from a file in KEY=VALUE format.
+// Empty lines and lines starting with "#" are skipped.
+// A variable without a value takes the value from the current environment.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer f.Close()
+	var res []string
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, _, hasVal := strings.Cut(line, "=")
+		if k == "" || strings.ContainsAny(k, " \t") {
+			return nil, fmt.Errorf("invalid environment variable in %s:%d", path, lineno)
+		}
+		if !hasVal {
+			v, ok := os.LookupEnv(k)
+			if !ok {
+				continue
+			}
+			line = k + "=" + v
+		}
+		res = append(res, line)
+	}
+	return res, scanner.Err()
+}
+
+// mergeEnv returns environment variables of base overridden by the variables of override.
+func mergeEnv(base []string, override []string) []string {
+	if len(override) == 0 {
+		return base
+	}
+	res := make([]string, 0, len(base)+len(override))
+	idx := make(map[string]int, len(base)+len(override))
+	for _, list := range [][]string{base, override} {
+		for _, e := range list {
+			k, _, _ := strings.Cut(e, "=")
+			if i, ok := idx[k]; ok {
+				res[i] = e
+				continue
+			}
+			idx[k] = len(res)
+			res = append(res, e)
+		}
+	}
+	return res
+}
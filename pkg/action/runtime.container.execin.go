@@ -0,0 +1,161 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// findExecContainer finds a running container by a name or a label selector "KEY=VALUE".
+func (c *runtimeContainer) findExecContainer(ctx context.Context, selector string) (string, error) {
+	opts := types.ContainerListOptions{Running: true}
+	byLabel := strings.Contains(selector, "=")
+	if byLabel {
+		opts.Labels = []string{selector}
+	} else {
+		opts.SearchName = selector
+	}
+	var found []types.ContainerListResult
+	for _, ctr := range c.driver.ContainerList(ctx, opts) {
+		// The name filter matches a part of the name, find the exact match.
+		if byLabel || containerHasName(ctr, selector) {
+			found = append(found, ctr)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("running container %q is not found", selector)
+	case 1:
+		return found[0].ID, nil
+	default:
+		names := make([]string, 0, len(found))
+		for _, ctr := range found {
+			names = append(names, containerName(ctr))
+		}
+		return "", fmt.Errorf("several running containers match %q: %s", selector, strings.Join(names, ", "))
+	}
+}
+
+func containerHasName(ctr types.ContainerListResult, name string) bool {
+	for _, n := range ctr.Names {
+		if strings.TrimPrefix(n, "/") == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containerName(ctr types.ContainerListResult) string {
+	if len(ctr.Names) == 0 {
+		return ctr.ID
+	}
+	return strings.TrimPrefix(ctr.Names[0], "/")
+}
+
+// executeIn runs the action command in an already running container.
+// The container keeps its own file system, the working directory is not mounted.
+func (c *runtimeContainer) executeIn(ctx context.Context, a *Action) error {
+	d, ok := c.driver.(driver.ContainerRunnerExec)
+	if !ok {
+		return fmt.Errorf("container environment %q doesn't support executing in running containers", c.dtype)
+	}
+	if c.useVolWD {
+		return fmt.Errorf("flag --%s can't be used with --%s", containerFlagExecIn, containerFlagUseVolumeWD)
+	}
+	if c.isWritesRestricted() {
+		return errors.New("writes can't be restricted when executing in a running container")
+	}
+	streams := a.Input().Streams()
+	runDef := a.RuntimeDef()
+	cmd := runDef.Container.Command
+	if c.exec {
+		cmd = a.Input().ArgsPositional()
+	}
+	if c.entrypointSet {
+		cmd = append([]string{c.entrypoint}, cmd...)
+	}
+	if len(cmd) == 0 {
+		return errors.New("command to execute in the container is empty")
+	}
+
+	if c.isCommandShown() {
+		// The entrypoint is a part of the command when executing in a running container.
+		_, _ = fmt.Fprintf(streams.Err(), "Command: %s\n", formatCommand(a.SensitiveMask().MaskSlice(cmd)))
+	}
+
+	log := c.log("run_env", c.dtype, "action_id", a.ID, "exec_in", c.execIn, "command", a.SensitiveMask().MaskSlice(cmd))
+	log.Debug("looking for a running container to execute the action")
+	cid, err := c.findExecContainer(ctx, c.execIn)
+	if err != nil {
+		return err
+	}
+
+	opts := types.ContainerExecOptions{
+		User:         runDef.Container.User,
+		Tty:          isTtyRequested(streams),
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          mergeEnv(runDef.Container.Env, c.env),
+		Cmd:          cmd,
+	}
+	if ttyErr := streams.In().CheckTty(opts.AttachStdin, opts.Tty); ttyErr != nil {
+		return ttyErr
+	}
+	exitCode, err := c.execCommand(ctx, d, cid, opts, streams)
+	if err != nil {
+		if _, ok = err.(driver.EscapeError); ok {
+			return nil
+		}
+		return err
+	}
+	if exitCode != 0 {
+		return launchr.NewExitError(exitCode, fmt.Sprintf("action %q finished with exit code %d", a.ID, exitCode))
+	}
+	return nil
+}
+
+// execCommand executes a command in the running container and returns its exit code.
+func (c *runtimeContainer) execCommand(ctx context.Context, d driver.ContainerRunnerExec, cid string, opts types.ContainerExecOptions, streams launchr.Streams) (int, error) {
+	execID, err := d.ContainerExecCreate(ctx, cid, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute in the container: %w", err)
+	}
+
+	log := c.log("container_id", cid, "exec_id", execID)
+	log.Debug("attaching exec streams")
+	cio, err := d.ContainerExecAttach(ctx, execID, types.ContainerExecAttachOptions{Tty: opts.Tty})
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach to the container: %w", err)
+	}
+	defer func() {
+		_ = cio.Close()
+	}()
+	if opts.Tty {
+		if err = driver.MonitorTtySize(ctx, c.driver, streams, execID, true); err != nil {
+			log.Error("error monitoring tty size", "error", err)
+		}
+	}
+	err = driver.ContainerIOStream(ctx, streams, cio, &types.ContainerCreateOptions{
+		AttachStdin:  opts.AttachStdin,
+		AttachStdout: opts.AttachStdout,
+		AttachStderr: opts.AttachStderr,
+		Tty:          opts.Tty,
+	})
+	if err != nil {
+		log.Debug("error hijack", "error", err)
+		return 0, err
+	}
+
+	insp, err := d.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, err
+	}
+	log.Info("action finished with the exit code", "exit_code", insp.ExitCode)
+	return insp.ExitCode, nil
+}
@@ -2,17 +2,31 @@ package action
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"os"
 	osuser "os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/go-units"
 
 	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action/output"
 	"github.com/launchrctl/launchr/pkg/driver"
 	"github.com/launchrctl/launchr/pkg/jsonschema"
 	"github.com/launchrctl/launchr/pkg/types"
@@ -20,15 +34,54 @@ import (
 
 const (
 	// Container mount paths.
-	containerHostMount   = "/host"
-	containerActionMount = "/action"
+	containerHostMount    = "/host"
+	containerActionMount  = "/action"
+	containerDockerSocket = "/var/run/docker.sock"
 
 	// Environment specific flags.
 	containerFlagUseVolumeWD = "use-volume-wd"
+	containerFlagChownWD     = "chown-volume-wd"
 	containerFlagRemoveImage = "remove-image"
 	containerFlagNoCache     = "no-cache"
 	containerFlagEntrypoint  = "entrypoint"
 	containerFlagExec        = "exec"
+	containerFlagExplainImg  = "explain-image"
+	containerFlagRestrictWr  = "restrict-writes"
+	containerFlagEnv         = "env"
+	containerFlagEnvFile     = "env-file"
+	containerFlagExecIn      = "exec-in"
+	containerFlagLabel       = "label"
+	containerFlagMountFlags  = "mount-flags"
+	containerFlagShowCmd     = "show-command"
+)
+
+// EnvVarRunID is an environment variable with a run id used for deterministic container names, e.g. in CI.
+const EnvVarRunID = "LAUNCHR_RUN_ID"
+
+// mountFlagsNone disables flags of the working and action directory mounts.
+const mountFlagsNone = "none"
+
+// containerStopTimeout limits stopping the container of a cancelled run.
+const containerStopTimeout = 30 * time.Second
+
+// relabelWarnFiles is a number of files in the working directory to warn about slow SELinux relabeling.
+const relabelWarnFiles = 10000
+
+// allowedMountFlags are the flags supported by --mount-flags.
+var allowedMountFlags = []string{"z", "Z", "ro", "rw", "cached", "delegated", "consistent"}
+
+// containerNameRetries is a number of attempts to find a free container name.
+const containerNameRetries = 10
+
+// Labels set on containers and images created by launchr to identify them in external tools.
+const (
+	LabelApp        = "launchr.app"          // LabelApp - name of the app.
+	LabelVersion    = "launchr.version"      // LabelVersion - version of the app.
+	LabelActionID   = "launchr.action_id"    // LabelActionID - id of the running action.
+	LabelRunID      = "launchr.run_id"       // LabelRunID - unique id of the action run.
+	LabelWorkDirSum = "launchr.workdir_hash" // LabelWorkDirSum - sha256 hash of the working directory path.
+	LabelInputSum   = "launchr.input_hash"   // LabelInputSum - sha256 hash of the input defining the container.
+	LabelSession    = "launchr.session"      // LabelSession - working directory of the session owning the volume.
 )
 
 type runtimeContainer struct {
@@ -40,31 +93,105 @@ type runtimeContainer struct {
 	imgres   ChainImageBuildResolver
 	imgccres *ImageBuildCacheResolver
 	nameprv  ContainerNameProvider
+	rtcfg    ConfigRuntime
+	imgfl    *imageEnsureFlight
+	recorder *RunRecorder
 
 	// Runtime flags
 	useVolWD      bool
+	chownWD       bool
 	removeImg     bool
 	noCache       bool
 	entrypoint    string
 	entrypointSet bool
 	exec          bool
+	explainImg    bool
+	restrictWr    bool
+	showCmd       bool
+	env           []string
+	execIn        string
+	labels        map[string]string
+	mountFlags    string
+
+	// State of the last execution
+	sm     *launchr.ServiceManager
+	usage  *containerUsage
+	report *containerReport
+	api    driver.APIFeatures
+	// session keeps the copied working directory between the runs, it's found for every run.
+	session *Session
+	// service is set by the run and read by other goroutines, e.g. polling the run info.
+	service atomic.Pointer[containerService]
 }
 
 // ContainerNameProvider provides an ability to generate a random container name
 type ContainerNameProvider struct {
 	Prefix       string
 	RandomSuffix bool
+	// Template is a name template with placeholders, see [ConfigContainerName].
+	// If empty, the name is the prefix, the action id and a random suffix if enabled.
+	Template string
+	// RunID makes the generated names deterministic, the random values are derived from it.
+	RunID string
+}
+
+// NewContainerNameProvider creates a [ContainerNameProvider] from the runtime configuration.
+func NewContainerNameProvider(prefix string, cfg ConfigContainerName) ContainerNameProvider {
+	p := ContainerNameProvider{Prefix: prefix, RandomSuffix: true, Template: cfg.Template}
+	if cfg.Deterministic {
+		p.RunID = os.Getenv(EnvVarRunID)
+		if p.RunID == "" {
+			// Keep the names stable for the working directory.
+			p.RunID = launchr.MustAbs(".")
+		}
+	}
+	return p
 }
 
+var (
+	rplContainerName    = strings.NewReplacer("-", "_", ":", "_", ".", "_")
+	rgxInvalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+)
+
 // Get generates a new container name
 func (p ContainerNameProvider) Get(name string) string {
-	var rpl = strings.NewReplacer("-", "_", ":", "_", ".", "_")
-	suffix := ""
-	if p.RandomSuffix {
-		suffix = "_" + driver.GetRandomName(0)
+	if p.Template == "" {
+		suffix := ""
+		if p.RandomSuffix {
+			suffix = "_" + p.random(name)
+		}
+		return p.Prefix + rplContainerName.Replace(name) + suffix
 	}
+	usr := ""
+	if u, err := osuser.Current(); err == nil {
+		usr = u.Username
+	}
+	res := strings.NewReplacer(
+		"{prefix}", p.Prefix,
+		"{action}", rplContainerName.Replace(name),
+		"{user}", usr,
+		"{hash}", p.hash(name),
+		"{random}", p.random(name),
+	).Replace(p.Template)
+	return rgxInvalidNameChars.ReplaceAllString(res, "_")
+}
 
-	return p.Prefix + rpl.Replace(name) + suffix
+// hash returns a short hex hash, it's derived from the run id in the deterministic mode.
+func (p ContainerNameProvider) hash(name string) string {
+	if p.RunID != "" {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(p.RunID+"\x00"+name)))[:8]
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// random returns a human-friendly random name, in the deterministic mode, the hash is used instead.
+func (p ContainerNameProvider) random(name string) string {
+	if p.RunID != "" {
+		return p.hash(name)
+	}
+	return driver.GetRandomName(0)
 }
 
 // NewContainerRuntimeDocker creates a new action Docker runtime.
@@ -77,6 +204,8 @@ func NewContainerRuntime(t driver.Type) ContainerRuntime {
 	return &runtimeContainer{
 		dtype:   t,
 		nameprv: ContainerNameProvider{Prefix: "launchr_", RandomSuffix: true},
+		rtcfg:   DefaultConfigRuntime(),
+		imgfl:   imageEnsureGroup,
 	}
 }
 
@@ -93,6 +222,13 @@ func (c *runtimeContainer) FlagsDefinition() ParametersList {
 			Type:        jsonschema.Boolean,
 			Default:     false,
 		},
+		&DefParameter{
+			Name:        containerFlagChownWD,
+			Title:       "Change owner of volume WD",
+			Description: "Change owner of the working directory copied with --use-volume-wd to the container user.",
+			Type:        jsonschema.Boolean,
+			Default:     false,
+		},
 		&DefParameter{
 			Name:        containerFlagRemoveImage,
 			Title:       "Remove Image",
@@ -121,6 +257,64 @@ func (c *runtimeContainer) FlagsDefinition() ParametersList {
 			Type:        jsonschema.Boolean,
 			Default:     false,
 		},
+		&DefParameter{
+			Name:        containerFlagEnv,
+			Title:       "Environment variables",
+			Description: "Set environment variables KEY=VALUE overriding the action environment, may be specified multiple times",
+			Type:        jsonschema.Array,
+			Items:       &DefArrayItems{Type: jsonschema.String},
+			Default:     []any{},
+		},
+		&DefParameter{
+			Name:        containerFlagEnvFile,
+			Title:       "Environment file",
+			Description: "Read environment variables from a file, --env flags take precedence",
+			Type:        jsonschema.String,
+			Default:     "",
+		},
+		&DefParameter{
+			Name:        containerFlagExecIn,
+			Title:       "Execute in container",
+			Description: "Execute the command in a running container found by a name or a label KEY=VALUE instead of creating a new one",
+			Type:        jsonschema.String,
+			Default:     "",
+		},
+		&DefParameter{
+			Name:        containerFlagLabel,
+			Title:       "Run labels",
+			Description: "Add metadata KEY=VALUE to the run and the created container, may be specified multiple times",
+			Type:        jsonschema.Array,
+			Items:       &DefArrayItems{Type: jsonschema.String},
+			Default:     []any{},
+		},
+		&DefParameter{
+			Name:        containerFlagMountFlags,
+			Title:       "Mount flags",
+			Description: "Set comma-separated flags of the working and action directory mounts overriding the SELinux flags, e.g. \"Z\", use \"none\" to mount without flags",
+			Type:        jsonschema.String,
+			Default:     "",
+		},
+		&DefParameter{
+			Name:        containerFlagRestrictWr,
+			Title:       "Restrict writes",
+			Description: "Mount everything except the working directory read-only and report attempted writes outside of it",
+			Type:        jsonschema.Boolean,
+			Default:     false,
+		},
+		&DefParameter{
+			Name:        containerFlagExplainImg,
+			Title:       "Explain image",
+			Description: "Print which image build resolver supplied the build definition of the image",
+			Type:        jsonschema.Boolean,
+			Default:     false,
+		},
+		&DefParameter{
+			Name:        containerFlagShowCmd,
+			Title:       "Show command",
+			Description: "Print the resolved entrypoint and command to stderr before the container starts",
+			Type:        jsonschema.Boolean,
+			Default:     false,
+		},
 	}
 }
 
@@ -129,6 +323,10 @@ func (c *runtimeContainer) UseFlags(flags InputParams) error {
 		c.useVolWD = v.(bool)
 	}
 
+	if ch, ok := flags[containerFlagChownWD]; ok {
+		c.chownWD = ch.(bool)
+	}
+
 	if r, ok := flags[containerFlagRemoveImage]; ok {
 		c.removeImg = r.(bool)
 	}
@@ -146,6 +344,56 @@ func (c *runtimeContainer) UseFlags(flags InputParams) error {
 		c.exec = ex.(bool)
 	}
 
+	if ei, ok := flags[containerFlagExplainImg]; ok {
+		c.explainImg = ei.(bool)
+	}
+
+	if rw, ok := flags[containerFlagRestrictWr]; ok {
+		c.restrictWr = rw.(bool)
+	}
+
+	if sc, ok := flags[containerFlagShowCmd]; ok {
+		c.showCmd = sc.(bool)
+	}
+
+	if ei, ok := flags[containerFlagExecIn]; ok {
+		c.execIn = ei.(string)
+	}
+
+	c.env = nil
+	if ef, ok := flags[containerFlagEnvFile]; ok && ef.(string) != "" {
+		env, err := readEnvFile(ef.(string))
+		if err != nil {
+			return err
+		}
+		c.env = mergeEnv(c.env, env)
+	}
+
+	if e, ok := flags[containerFlagEnv]; ok {
+		env, err := parseEnvFlag(CastSliceAnyToTyped[string](CastSliceTypedToAny(e)))
+		if err != nil {
+			return err
+		}
+		c.env = mergeEnv(c.env, env)
+	}
+
+	c.mountFlags = ""
+	if mf, ok := flags[containerFlagMountFlags]; ok {
+		c.mountFlags = mf.(string)
+		if err := validateMountFlags(c.mountFlags); err != nil {
+			return err
+		}
+	}
+
+	c.labels = nil
+	if l, ok := flags[containerFlagLabel]; ok {
+		labels, err := parseLabelFlag(CastSliceAnyToTyped[string](CastSliceTypedToAny(l)))
+		if err != nil {
+			return err
+		}
+		c.labels = labels
+	}
+
 	return nil
 }
 func (c *runtimeContainer) ValidateInput(_ *Action, input *Input) error {
@@ -156,27 +404,116 @@ func (c *runtimeContainer) ValidateInput(_ *Action, input *Input) error {
 	return nil
 }
 func (c *runtimeContainer) AddImageBuildResolver(r ImageBuildResolver) {
-	c.imgres = append(c.imgres, r)
+	c.imgres = c.imgres.Add(r)
 }
 func (c *runtimeContainer) SetImageBuildCacheResolver(s *ImageBuildCacheResolver) { c.imgccres = s }
 func (c *runtimeContainer) SetContainerNameProvider(p ContainerNameProvider)      { c.nameprv = p }
+func (c *runtimeContainer) SetRuntimeConfig(cfg ConfigRuntime)                    { c.rtcfg = cfg }
+func (c *runtimeContainer) SetRunRecorder(r *RunRecorder)                         { c.recorder = r }
 
-func (c *runtimeContainer) Init(_ context.Context, _ *Action) (err error) {
+func (c *runtimeContainer) Init(ctx context.Context, _ *Action) (err error) {
 	c.logWith = nil
+	c.sm = launchr.ServiceManagerFromContext(ctx)
 	if c.driver == nil {
-		c.driver, err = driver.New(c.dtype)
+		c.driver, err = driver.NewWithOptions(c.dtype, driver.Options{Docker: c.rtcfg.Docker})
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	if d, ok := c.driver.(driver.ContainerRunnerTimeouts); ok {
+		d.SetTimeouts(c.rtcfg.Timeouts)
+	}
+	return c.checkAPIVersion(ctx)
+}
+
+// checkAPIVersion detects the features of the daemon API.
+// Missing features required by the run fail early, other features are downgraded during the run.
+func (c *runtimeContainer) checkAPIVersion(ctx context.Context) error {
+	c.api = driver.APIFeatures{}
+	d, ok := c.driver.(driver.ContainerRunnerAPIVersion)
+	if !ok || c.execIn != "" {
+		return nil
+	}
+	version, err := d.APIVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the container engine: %w", err)
+	}
+	c.api = driver.NewAPIFeatures(version)
+	c.log().Debug("container engine API version", "version", version)
+	if c.useVolWD && c.chownWD {
+		if err = c.api.Require(driver.APIFeatureCopyOwner); err != nil {
+			return fmt.Errorf("flag --%s can't be used: %w", containerFlagChownWD, err)
+		}
+	}
+	for _, feat := range []driver.APIFeature{driver.APIFeatureAutoRemove, driver.APIFeatureWaitCondition} {
+		if !c.api.Supports(feat) {
+			c.log().Warn("container engine API doesn't support the feature, the behavior is downgraded", "version", version, "feature", feat.Name)
+		}
+	}
+	return nil
+}
+
+// RunLabels implements [RuntimeRunLabeler] interface.
+func (c *runtimeContainer) RunLabels() map[string]string {
+	return c.labels
+}
+
+// runUsage returns the usage collector of the current run.
+func (c *runtimeContainer) runUsage() *containerUsage {
+	if c.usage == nil {
+		c.usage = &containerUsage{}
+	}
+	return c.usage
+}
+
+// Usage implements [RuntimeUsageReporter] interface.
+func (c *runtimeContainer) Usage() *RunUsage {
+	if c.usage == nil {
+		return nil
+	}
+	return c.usage.Usage()
+}
+
+// Report implements [RuntimeReporter] interface.
+func (c *runtimeContainer) Report() *RunReport {
+	if c.report == nil {
+		return nil
+	}
+	return c.report.Report()
 }
 
 func (c *runtimeContainer) log(attrs ...any) *launchr.Slog {
 	if attrs != nil {
 		c.logWith = append(c.logWith, attrs...)
 	}
-	return launchr.Log().With(c.logWith...)
+	return c.services().Log().With(c.logWith...)
+}
+
+// services returns the services of the app running the action, they are taken from the context on init.
+func (c *runtimeContainer) services() *launchr.ServiceManager {
+	if c.sm == nil {
+		return launchr.ServiceManagerFromContext(context.Background())
+	}
+	return c.sm
+}
+
+// term returns the terminal of the app running the action.
+func (c *runtimeContainer) term() *launchr.Terminal {
+	return c.services().Term()
+}
+
+func (c *runtimeContainer) Execute(ctx context.Context, a *Action) error {
+	runDef := a.RuntimeDef()
+	if runDef.Container == nil || !runDef.Container.Service || c.execIn != "" {
+		return c.executeOnce(ctx, a)
+	}
+	// Service containers are restarted when they exit unexpectedly.
+	return c.superviseService(ctx, a.ID, runDef.Container.Restart, func(ctx context.Context) error {
+		return c.executeOnce(ctx, a)
+	})
 }
 
-func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
+func (c *runtimeContainer) executeOnce(ctx context.Context, a *Action) (err error) {
 	ctx, cancelFn := context.WithCancel(ctx)
 	defer cancelFn()
 	streams := a.Input().Streams()
@@ -184,12 +521,23 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 	if runDef.Container == nil {
 		return errors.New("action container configuration is not set, use different runtime")
 	}
-	log := c.log("run_env", c.dtype, "action_id", a.ID, "image", runDef.Container.Image, "command", runDef.Container.Command)
+	if c.execIn != "" {
+		return c.executeIn(ctx, a)
+	}
+	waitHealthy := runDef.Container.WaitFor == WaitForHealthy
+	if waitHealthy && c.useVolWD {
+		return fmt.Errorf("flag --%s can't be used with actions waiting for a healthy container", containerFlagUseVolumeWD)
+	}
+	c.session, err = c.findSession(ctx, a)
+	if err != nil {
+		return err
+	}
+	log := c.log("run_env", c.dtype, "action_id", a.ID, "image", runDef.Container.Image, "command", a.SensitiveMask().MaskSlice(runDef.Container.Command))
 	log.Debug("starting execution of the action")
-	name := c.nameprv.Get(a.ID)
-	existing := c.driver.ContainerList(ctx, types.ContainerListOptions{SearchName: name})
-	if len(existing) > 0 {
-		return fmt.Errorf("the action %q can't start, the container name is in use, please, try again", a.ID)
+	inputSum := c.containerInputSum(a, isTtyRequested(streams))
+	name, reuseID, err := c.containerName(ctx, a, inputSum)
+	if err != nil {
+		return err
 	}
 
 	var autoRemove = true
@@ -197,6 +545,13 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 		// Do not remove the volume until we copy the data back.
 		autoRemove = false
 	}
+	// Old daemons don't remove containers automatically, the container is removed after the run.
+	// The artifacts are collected from the stopped container, so it's removed after the run as well.
+	removeAfterRun := false
+	if autoRemove && (!c.api.Supports(driver.APIFeatureAutoRemove) || len(runDef.Container.Artifacts) > 0) {
+		autoRemove = false
+		removeAfterRun = true
+	}
 
 	// Add entrypoint command option.
 	var entrypoint []string
@@ -214,32 +569,101 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 		AttachStdin:   true,
 		AttachStdout:  true,
 		AttachStderr:  true,
-		Tty:           streams.In().IsTerminal(),
-		Env:           runDef.Container.Env,
+		Tty:           isTtyRequested(streams),
+		Env:           mergeEnv(runDef.Container.Env, c.env),
 		User:          getCurrentUser(),
 		Entrypoint:    entrypoint,
+		// The container name is unique for every run.
+		Labels: mergeLabels(c.labels, containerLabels(a, name), map[string]string{LabelInputSum: inputSum}),
 	}
-	log.Debug("creating a container for an action")
-	cid, err := c.containerCreate(ctx, a, runConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create a container: %w", err)
+	// Keep the host user as the owner of the files created in the mounted directories.
+	hostUser := runConfig.User
+	umap := c.userMapping(ctx, runDef.Container, hostUser)
+	runConfig.User = umap.User
+	runConfig.UsernsMode = umap.UsernsMode
+	// Let the action report progress, outputs and warnings in its output.
+	// The output isn't inspected for interactive sessions to keep the terminal.
+	c.report = nil
+	if !runConfig.Tty {
+		c.report = newContainerReport(c.term(), a)
+		runConfig.Env = mergeEnv(runConfig.Env, []string{EnvVarReport + "=" + reportPrefix})
 	}
-	if cid == "" {
-		return errors.New("error on creating a container")
+	// Keep a record of the run for troubleshooting.
+	var recOut *output.LimitedBuffer
+	if c.recorder != nil {
+		mask := a.SensitiveMask()
+		if c.rtcfg.RecordSensitive {
+			mask = nil
+		}
+		rec := newRunRecord(a, name, runConfig.Env, c.labels, time.Now(), mask)
+		recOut = c.rtcfg.OutputLimit.NewBuffer()
+		defer func() {
+			rec.finish(err, recOut.String())
+			if errRec := c.recorder.Save(rec); errRec != nil {
+				log.Debug("failed to save the run record", "error", errRec)
+			}
+		}()
+	}
+
+	var cid string
+	if reuseID != "" {
+		// The stale container keeps its configuration and isn't removed automatically.
+		cid = reuseID
+		runConfig.AutoRemove = false
+	} else {
+		log.Debug("creating a container for an action")
+		cid, err = c.containerCreate(ctx, a, runConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create a container: %w", err)
+		}
+		if cid == "" {
+			return errors.New("error on creating a container")
+		}
 	}
 
 	log = c.log("container_id", cid)
 	log.Debug("successfully created a container for an action")
+	// A healthy container keeps running and writing after the run.
+	if umap.Chown && !waitHealthy {
+		defer func() {
+			log.Debug("changing the owner of the files created by the container root")
+			if errChown := c.chownMounts(context.WithoutCancel(ctx), a, name, hostUser, umap.ChownUsernsMode); errChown != nil {
+				log.Warn("failed to change the owner of the files created by the container root", "error", errChown)
+				c.term().Warning().Printfln("Files created by root in the working directory may be owned by root: %v", errChown)
+			}
+		}()
+	}
+	// A healthy container keeps running after the run.
+	if removeAfterRun && reuseID == "" && !waitHealthy {
+		defer func() {
+			if errRm := c.driver.ContainerRemove(ctx, cid, types.ContainerRemoveOptions{}); errRm != nil {
+				log.Error("error on cleaning the running environment", "error", errRm)
+			}
+		}()
+	}
+	if c.useVolWD {
+		// The container isn't removed automatically to copy back the result.
+		// It's removed with the volumes after the run, also when the run fails.
+		defer c.containerRemoveWithVolumes(ctx, cid)
+	}
 	// Copy working dirs to the container.
 	if c.useVolWD {
 		// @todo test somehow.
-		launchr.Term().Info().Printfln(`Flag "--%s" is set. Copying the working directory inside the container.`, containerFlagUseVolumeWD)
-		err = c.copyDirToContainer(ctx, cid, a.WorkDir(), containerHostMount)
-		if err != nil {
-			return fmt.Errorf("failed to copy host directory to the container: %w", err)
+		var owner *idtools.Identity
+		if c.chownWD {
+			owner = c.volumeOwner(runDef.Container.User, runConfig.User)
+		}
+		if c.session != nil {
+			c.term().Info().Printfln(`Flag "--%s" is set. Using the working directory of the session volume %q.`, containerFlagUseVolumeWD, c.session.Volume)
+		} else {
+			c.term().Info().Printfln(`Flag "--%s" is set. Copying the working directory inside the container.`, containerFlagUseVolumeWD)
+			err = c.copyDirToContainer(ctx, cid, "Copying the working directory", a.WorkDir(), containerHostMount, owner)
+			if err != nil {
+				return fmt.Errorf("failed to copy host directory to the container: %w", err)
+			}
 		}
 		// @todo copy action if the original files are in memory
-		err = c.copyDirToContainer(ctx, cid, a.Dir(), containerActionMount)
+		err = c.copyDirToContainer(ctx, cid, "Copying the action directory", a.Dir(), containerActionMount, owner)
 		if err != nil {
 			return fmt.Errorf("failed to copy action directory to the container: %w", err)
 		}
@@ -257,9 +681,40 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 		defer driver.StopCatchSignals(sigc)
 	}
 
+	// Print a heartbeat if the container is silent for a long time.
+	// It is not shown for interactive sessions where silence is expected
+	// and for machine-readable output.
+	attachStreams := streams
+	if !runConfig.Tty && c.rtcfg.HeartbeatInterval > 0 && c.term().IsEnabled() && output.StreamsFormat(streams) != output.FormatJSON {
+		log.Debug("watching container output activity")
+		hb := newContainerHeartbeat(c.rtcfg.HeartbeatInterval)
+		hb.estimate = durationEstimateFromContext(ctx, a.ID)
+		attachStreams = hb.Streams(streams)
+		go hb.Watch(ctx, c, a, name)
+	}
+	// Collect messages about writes to the read-only file system.
+	// The output isn't inspected for interactive sessions to keep the terminal.
+	var wguard *containerWriteGuard
+	if c.isWritesRestricted() && !runConfig.Tty {
+		wguard = &containerWriteGuard{}
+		attachStreams = wguard.Streams(attachStreams)
+	}
+	if recOut != nil && !runConfig.Tty {
+		attachStreams = captureStreams(attachStreams, recOut)
+	}
+	if c.report != nil {
+		attachStreams = c.report.Streams(attachStreams)
+	}
+
 	// Attach streams to the terminal.
+	// The steps are attached separately, the container itself has no output.
+	steps := c.hasSteps(runDef.Container)
+	attachConfig := runConfig
+	if steps {
+		attachConfig = &types.ContainerCreateOptions{AttachStdout: true, AttachStderr: true}
+	}
 	log.Debug("attaching container streams")
-	cio, errCh, err := c.attachContainer(ctx, streams, cid, runConfig)
+	cio, errCh, err := c.attachContainer(ctx, attachStreams, cid, attachConfig)
 	if err != nil {
 		return fmt.Errorf("failed to attach to the container: %w", err)
 	}
@@ -267,7 +722,13 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 		_ = cio.Close()
 	}()
 	log.Debug("watching run status of container")
-	statusCh := c.containerWait(ctx, cid, runConfig)
+	var healthyCh <-chan error
+	var statusCh <-chan int
+	if waitHealthy {
+		healthyCh = c.containerWaitHealthy(ctx, cid)
+	} else {
+		statusCh = c.containerWait(ctx, cid, runConfig, runDef.Container.WaitFor)
+	}
 
 	// Start the container
 	log.Debug("starting container")
@@ -281,46 +742,115 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 		return err
 	}
 
+	// Stop the container if the run is cancelled, e.g. when the timeout elapses.
+	// The engine kills the container if it doesn't stop in time.
+	stopOnCancel := context.AfterFunc(ctx, func() {
+		log.Debug("stopping the container on cancelled run")
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), containerStopTimeout)
+		defer cancel()
+		if errStop := c.driver.ContainerStop(stopCtx, cid); errStop != nil {
+			log.Error("failed to stop the container of the cancelled run", "error", errStop)
+		}
+	})
+	defer stopOnCancel()
+
+	// Collect resource usage while the container is running.
+	if d, ok := c.driver.(driver.ContainerRunnerStats); ok {
+		u := c.runUsage()
+		go func() {
+			if errStats := u.Watch(ctx, d, cid); errStats != nil {
+				log.Debug("failed to collect container resource usage", "error", errStats)
+			}
+		}()
+	}
+
 	// Resize TTY on window resize.
-	if runConfig.Tty {
+	if runConfig.Tty && !steps {
 		log.Debug("watching TTY resize")
 		if err = driver.MonitorTtySize(ctx, c.driver, streams, cid, false); err != nil {
 			log.Error("error monitoring tty size", "error", err)
 		}
 	}
 
-	log.Debug("waiting execution of the container")
-	if errCh != nil {
-		if err = <-errCh; err != nil {
-			if _, ok := err.(driver.EscapeError); ok {
-				// The user entered the detach escape sequence.
-				return nil
-			}
-
-			log.Debug("error hijack", "error", err)
+	if waitHealthy {
+		log.Debug("waiting for the container to be healthy")
+		if err = <-healthyCh; err != nil {
 			return err
 		}
+		c.term().Info().Printfln("Container %q is healthy and keeps running in the background.", name)
+		return nil
 	}
 
-	status := <-statusCh
+	var status int
+	if steps {
+		log.Debug("executing the steps in the container")
+		status, err = c.executeSteps(ctx, a, cid, runConfig, attachStreams)
+		log.Debug("stopping the container of the steps")
+		if errStop := c.driver.ContainerStop(context.WithoutCancel(ctx), cid); errStop != nil {
+			log.Error("failed to stop the container of the steps", "error", errStop)
+		}
+		if errCh != nil {
+			<-errCh
+		}
+		<-statusCh
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Debug("waiting execution of the container")
+		if errCh != nil {
+			if err = <-errCh; err != nil {
+				if _, ok := err.(driver.EscapeError); ok {
+					// The user entered the detach escape sequence.
+					return nil
+				}
+
+				log.Debug("error hijack", "error", err)
+				return err
+			}
+		}
+		status = <-statusCh
+	}
 	// @todo maybe we should note that SIG was sent to the container. Code 130 is sent on Ctlr+C.
 	log.Info("action finished with the exit code", "exit_code", status)
+	if u := c.Usage(); u != nil {
+		log.Info(
+			"action resource usage",
+			"cpu_time", u.CPUTime, "max_memory", u.MaxMemory, "network_rx", u.NetworkRx, "network_tx", u.NetworkTx,
+			"copied_in", u.CopiedIn, "copied_out", u.CopiedOut, "copy_time", u.CopyTime,
+		)
+	}
 	if status != 0 {
 		err = launchr.NewExitError(status, fmt.Sprintf("action %q finished with exit code %d", a.ID, status))
 	}
+	if c.report != nil {
+		c.report.Summary()
+	}
+	if wguard != nil {
+		wguard.Report(c.term(), a)
+	} else if status != 0 && c.isWritesRestricted() {
+		c.term().Warning().Printfln("Writes outside of the working directory are restricted, the action may have failed because of that.")
+	}
+
+	if len(runDef.Container.Artifacts) > 0 {
+		log.Debug("collecting artifacts of the run")
+		dir, errArt := c.collectArtifacts(ctx, a, cid, name)
+		if errArt != nil {
+			log.Error("failed to collect artifacts", "error", errArt)
+			if err == nil {
+				err = fmt.Errorf("failed to collect artifacts: %w", errArt)
+			}
+		} else {
+			c.term().Info().Printfln("Artifacts are collected to %s", dir)
+		}
+	}
 
 	// Copy back the result from the volume.
-	// @todo it's a bad implementation considering consequential runs, need to find a better way to sync with remote.
-	if c.useVolWD {
+	// The session volume is copied back when the session stops.
+	if c.useVolWD && c.session == nil {
 		path := a.WorkDir()
-		launchr.Term().Info().Printfln(`Flag "--%s" is set. Copying back the result of the action run.`, containerFlagUseVolumeWD)
-		err = c.copyFromContainer(ctx, cid, containerHostMount, filepath.Dir(path), filepath.Base(path))
-		defer func() {
-			err = c.driver.ContainerRemove(ctx, cid, types.ContainerRemoveOptions{})
-			if err != nil {
-				log.Error("error on cleaning the running environment", "error", err)
-			}
-		}()
+		c.term().Info().Printfln(`Flag "--%s" is set. Copying back the result of the action run.`, containerFlagUseVolumeWD)
+		err = c.copyFromContainer(ctx, cid, "Copying back the working directory", containerHostMount, filepath.Dir(path), filepath.Base(path))
 		if err != nil {
 			return err
 		}
@@ -342,6 +872,25 @@ func (c *runtimeContainer) Execute(ctx context.Context, a *Action) (err error) {
 	return err
 }
 
+// containerRemoveWithVolumes removes the container and its anonymous volumes.
+// The container is removed even if the run is cancelled.
+func (c *runtimeContainer) containerRemoveWithVolumes(ctx context.Context, cid string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), containerStopTimeout)
+	defer cancel()
+	opts := types.ContainerRemoveOptions{RemoveVolumes: true, Force: true}
+	if err := c.driver.ContainerRemove(ctx, cid, opts); err != nil {
+		c.log("container_id", cid).Error("error on cleaning the running environment", "error", err)
+	}
+}
+
+// isTtyRequested returns true if a TTY must be allocated for a container.
+// A TTY is allocated only when both the input and the output are terminals.
+// When the output is piped, escape sequences of a TTY garble it. When only the input
+// is a terminal, it's attached in a normal mode, so interactive prompts still work.
+func isTtyRequested(streams launchr.Streams) bool {
+	return streams.In().IsTerminal() && streams.Out().IsTerminal()
+}
+
 func getCurrentUser() string {
 	curuser := ""
 	// If running in a container native environment, run container as a current user.
@@ -369,7 +918,21 @@ func (c *runtimeContainer) imageRemove(ctx context.Context, a *Action) error {
 	return err
 }
 
-func (c *runtimeContainer) isRebuildRequired(bi *types.BuildDefinition) (bool, error) {
+func printImageBuildTrace(term *launchr.Terminal, image string, trace []ImageBuildResolveStep) {
+	term.Info().Printfln("Image %q build resolution:", image)
+	for i, step := range trace {
+		mark := "-"
+		if step.Found {
+			mark = "+"
+		}
+		term.Printfln("  %d. %s %s: %s", i+1, mark, step.Resolver, step.Reason)
+	}
+	if !slices.ContainsFunc(trace, func(s ImageBuildResolveStep) bool { return s.Found }) {
+		term.Printfln("  No build definition found, the image will be pulled from the registry")
+	}
+}
+
+func (c *runtimeContainer) isRebuildRequired(bi *types.BuildDefinition, baseSums map[string]string) (bool, error) {
 	// @todo test image cache resolution somehow.
 	if c.imgccres == nil || bi == nil {
 		return false, nil
@@ -384,6 +947,8 @@ func (c *runtimeContainer) isRebuildRequired(bi *types.BuildDefinition) (bool, e
 	if err != nil {
 		return false, err
 	}
+	// Include sums of base images to rebuild the image when a base image is rebuilt.
+	dirSum = imageSumWithBases(dirSum, baseSums)
 
 	doRebuild := false
 	for _, tag := range bi.Tags {
@@ -401,24 +966,128 @@ func (c *runtimeContainer) isRebuildRequired(bi *types.BuildDefinition) (bool, e
 	return doRebuild, nil
 }
 
+// PrepareImage implements [RuntimeImagePreparer] interface.
+// The dev container images and the runs in existing containers are prepared on execution.
+func (c *runtimeContainer) PrepareImage(ctx context.Context, a *Action) error {
+	runDef := a.RuntimeDef()
+	if runDef.Container == nil || runDef.Container.Image == DevcontainerImage || c.execIn != "" {
+		return nil
+	}
+	created := c.driver == nil
+	if err := c.Init(ctx, a); err != nil {
+		return err
+	}
+	if created {
+		// The run creates its own connection.
+		defer func() {
+			_ = c.driver.Close()
+			c.driver = nil
+		}()
+	}
+	return c.imageEnsure(ctx, a)
+}
+
 func (c *runtimeContainer) imageEnsure(ctx context.Context, a *Action) error {
-	streams := a.Input().Streams()
+	// Replace the dev container image with the image described in devcontainer.json.
+	var dc *devcontainerImageBuildResolver
+	if runDef := a.RuntimeDef(); runDef.Container.Image == DevcontainerImage {
+		var err error
+		dc, err = newDevcontainerImageBuildResolver(a.WorkDir())
+		if err != nil {
+			return err
+		}
+		runDef.Container.Image = dc.image
+	}
+	if c.imgfl == nil {
+		return c.doImageEnsure(ctx, a, dc)
+	}
 	image := a.RuntimeDef().Container.Image
+	key := string(c.dtype) + ":" + image
+	log := c.log()
+	// Prepare the image only once if it's requested by concurrent runs.
+	shared, err := c.imgfl.Do(ctx, key, func(progress io.Reader) {
+		c.term().Printfln("Image %q is being prepared by another run, waiting...", image)
+		// Show the progress of the other run.
+		if errShow := driver.DockerDisplayJSONMessages(progress, a.Input().Streams()); errShow != nil {
+			log.Debug("stopped showing image progress of a concurrent run", "error", errShow)
+		}
+	}, func(progress io.Writer) error {
+		unlock, errLock := lockImageEnsure(ctx, key)
+		if errLock != nil && ctx.Err() != nil {
+			return errLock
+		} else if errLock != nil {
+			log.Warn("failed to lock image preparation between processes", "error", errLock)
+		} else {
+			defer unlock()
+		}
+		return c.doImageEnsure(withImageProgress(ctx, progress), a, dc)
+	})
+	if shared {
+		log.Debug("image was prepared by a concurrent run", "error", err)
+	}
+	return err
+}
+
+func (c *runtimeContainer) doImageEnsure(ctx context.Context, a *Action, dc *devcontainerImageBuildResolver) error {
+	image := a.RuntimeDef().Container.Image
+	baseRes := c.imgres
+	if dc != nil {
+		if err := dc.prepare(); err != nil {
+			return fmt.Errorf("failed to prepare dev container build: %w", err)
+		}
+		baseRes = append(ChainImageBuildResolver{dc}, baseRes...)
+	}
 	// Prepend action to have the top priority in image build resolution.
-	r := ChainImageBuildResolver{append(ChainImageBuildResolver{a}, c.imgres...)}
+	r := append(ChainImageBuildResolver{a}, baseRes...)
 
-	buildInfo := r.ImageBuildInfo(image)
-	forceRebuild, err := c.isRebuildRequired(buildInfo)
+	var buildInfo *types.BuildDefinition
+	if c.explainImg {
+		var trace []ImageBuildResolveStep
+		buildInfo, trace = r.ImageBuildInfoTrace(image)
+		printImageBuildTrace(c.term(), image, trace)
+	} else {
+		buildInfo = r.ImageBuildInfo(image)
+	}
+	return c.ensureImage(ctx, a.Input().Streams(), baseRes, image, buildInfo, nil)
+}
+
+// ensureImage pulls or builds the image. Base images of the build having
+// a build definition in the resolvers baseRes are ensured first.
+func (c *runtimeContainer) ensureImage(ctx context.Context, streams launchr.Streams, baseRes ChainImageBuildResolver, image string, buildInfo *types.BuildDefinition, visited []string) error {
+	visited = append(visited, image)
+	baseSums := make(map[string]string)
+	for _, base := range buildBaseImages(buildInfo) {
+		if slices.Contains(visited, base) {
+			return fmt.Errorf("image %q has a circular dependency on base image %q", image, base)
+		}
+		baseBuild := baseRes.ImageBuildInfo(base)
+		if baseBuild == nil {
+			continue
+		}
+		if err := c.ensureImage(ctx, streams, baseRes, base, baseBuild, visited); err != nil {
+			return err
+		}
+		if c.imgccres != nil {
+			baseSums[base] = c.imgccres.GetSum(base)
+		}
+	}
+
+	forceRebuild, err := c.isRebuildRequired(buildInfo, baseSums)
 	if err != nil {
 		return err
 	}
 
-	status, err := c.driver.ImageEnsure(ctx, types.ImageOptions{
+	imgOpts := types.ImageOptions{
 		Name:         image,
 		Build:        buildInfo,
 		NoCache:      c.noCache,
 		ForceRebuild: forceRebuild,
-	})
+	}
+	if buildInfo != nil {
+		// Images may be shared between actions, only the app is labeled.
+		imgOpts.Labels = appLabels()
+	}
+	status, err := c.driver.ImageEnsure(ctx, imgOpts)
 	if err != nil {
 		return err
 	}
@@ -434,12 +1103,12 @@ func (c *runtimeContainer) imageEnsure(ctx context.Context, a *Action) error {
 		defer func() {
 			_ = status.Progress.Close()
 		}()
-		launchr.Term().Printfln("Image %q doesn't exist locally, pulling from the registry...", image)
+		c.term().Printfln("Image %q doesn't exist locally, pulling from the registry...", image)
 		log.Info("image doesn't exist locally, pulling from the registry")
 		// Output docker status only in Debug.
-		err = driver.DockerDisplayJSONMessages(status.Progress, streams)
+		err = driver.DockerDisplayJSONMessages(teeImageProgress(ctx, status.Progress), streams)
 		if err != nil {
-			launchr.Term().Error().Println("Error occurred while pulling the image %q", image)
+			c.term().Error().Println("Error occurred while pulling the image %q", image)
 			log.Error("error while pulling the image", "error", err)
 		}
 	case types.ImageBuild:
@@ -449,12 +1118,12 @@ func (c *runtimeContainer) imageEnsure(ctx context.Context, a *Action) error {
 		defer func() {
 			_ = status.Progress.Close()
 		}()
-		launchr.Term().Printfln("Image %q doesn't exist locally, building...", image)
-		log.Info("image doesn't exist locally, building the image")
+		c.term().Printfln("Image %q doesn't exist locally, building with context of %s...", image, units.HumanSize(float64(status.ContextSize)))
+		log.Info("image doesn't exist locally, building the image", "context_size", status.ContextSize)
 		// Output docker status only in Debug.
-		err = driver.DockerDisplayJSONMessages(status.Progress, streams)
+		err = driver.DockerDisplayJSONMessages(teeImageProgress(ctx, status.Progress), streams)
 		if err != nil {
-			launchr.Term().Error().Println("Error occurred while building the image %q", image)
+			c.term().Error().Println("Error occurred while building the image %q", image)
 			log.Error("error while building the image", "error", err)
 		}
 	}
@@ -474,6 +1143,7 @@ func (c *runtimeContainer) containerCreate(ctx context.Context, a *Action, opts
 	if c.exec {
 		runDef.Container.Command = a.Input().ArgsPositional()
 	}
+	steps := c.hasSteps(runDef.Container)
 
 	createOpts := types.ContainerCreateOptions{
 		ContainerName: opts.ContainerName,
@@ -491,33 +1161,74 @@ func (c *runtimeContainer) containerCreate(ctx context.Context, a *Action, opts
 		Tty:           opts.Tty,
 		Env:           opts.Env,
 		User:          opts.User,
+		UsernsMode:    opts.UsernsMode,
 		Entrypoint:    opts.Entrypoint,
+		Labels:        opts.Labels,
+	}
+	if steps {
+		// The container is kept running while the steps are executed in it.
+		// The init process stops it on signals, so the steps are stopped as well.
+		createOpts.Entrypoint = containerStepsKeepAlive[:1]
+		createOpts.Cmd = containerStepsKeepAlive[1:]
+		createOpts.Init = true
+		createOpts.Tty = false
+		createOpts.OpenStdin = false
+		createOpts.StdinOnce = false
+		createOpts.AttachStdin = false
+	}
+
+	if ov, ok := c.rtcfg.ImagesOverrides.Find(createOpts.Image); ok {
+		c.services().Log().Debug("overriding defaults of the image", "image", createOpts.Image, "entrypoint", ov.Entrypoint, "user", ov.User)
+		if len(ov.Entrypoint) > 0 && !c.entrypointSet && !steps {
+			createOpts.Entrypoint = ov.Entrypoint
+		}
+		if ov.User != "" && !runDef.Container.RunAsRoot {
+			createOpts.User = ov.User
+		}
+	}
+
+	if c.isCommandShown() && !steps {
+		printResolvedCommand(a.Input().Streams().Err(), a.SensitiveMask(), createOpts.Entrypoint, createOpts.Cmd)
+	}
+
+	restrictWr := c.isWritesRestricted()
+	if restrictWr {
+		// Only the working directory and temporary directories are writable.
+		createOpts.ReadonlyRootfs = true
+		createOpts.Tmpfs = map[string]string{"/tmp": ""}
+	}
+	if err := applySecurityProfile(c.rtcfg.Security, runDef.Container, &createOpts); err != nil {
+		return "", err
+	}
+	if err := applyMemoryMounts(runDef.Container, &createOpts); err != nil {
+		return "", err
 	}
 
 	if c.useVolWD {
 		// Use anonymous volumes to be removed after finish.
+		// The volumes are labeled as the container to find them if they leak, e.g. after a crash.
 		createOpts.Volumes = map[string]struct{}{
 			containerHostMount:   {},
 			containerActionMount: {},
 		}
+		createOpts.VolumeLabels = createOpts.Labels
+		if c.session != nil {
+			// The working directory is kept in the session volume.
+			delete(createOpts.Volumes, containerHostMount)
+			createOpts.Binds = []string{c.session.Volume + ":" + containerHostMount}
+		}
 	} else {
-		flags := ""
-		// Check SELinux settings to allow reading the FS inside a container.
-		if c.isSELinuxEnabled(ctx) {
-			// Use the lowercase z flag to allow concurrent actions access to the FS.
-			flags += ":z"
-			launchr.Term().Warning().Printfln(
-				"SELinux is detected. The volumes will be mounted with the %q flags, which will relabel your files.\n"+
-					"This process may take time or potentially break existing permissions.",
-				flags,
-			)
-			c.log().Warn("using selinux flags", "flags", flags)
+		flags := c.mountBindFlags(ctx, a, runDef.Container)
+		actionFlags := flags
+		if restrictWr {
+			actionFlags = append([]string{"ro"}, flags...)
 		}
 		createOpts.Binds = []string{
-			launchr.MustAbs(a.WorkDir()) + ":" + containerHostMount + flags,
-			launchr.MustAbs(a.Dir()) + ":" + containerActionMount + flags,
+			launchr.MustAbs(a.WorkDir()) + ":" + containerHostMount + bindFlags(flags),
+			launchr.MustAbs(a.Dir()) + ":" + containerActionMount + bindFlags(actionFlags),
 		}
 	}
+	createOpts.Binds = append(createOpts.Binds, containerExtraBinds(a.ID, runDef.Container)...)
 	cid, err := c.driver.ContainerCreate(ctx, createOpts)
 	if err != nil {
 		return "", err
@@ -526,13 +1237,308 @@ func (c *runtimeContainer) containerCreate(ctx context.Context, a *Action, opts
 	return cid, nil
 }
 
+// containerName returns a name for a new container of action a or an id of a stale container to reuse.
+// If the name is in use, e.g. in the deterministic mode, a numeric suffix is added.
+func (c *runtimeContainer) containerName(ctx context.Context, a *Action, inputSum string) (string, string, error) {
+	base := c.nameprv.Get(a.ID)
+	existing := c.driver.ContainerList(ctx, types.ContainerListOptions{SearchName: base})
+	if stale := findContainerByName(existing, base); stale != nil && isContainerStale(stale) {
+		policy := c.rtcfg.ContainerName.StalePolicy
+		if policy == ContainerStaleReuse && (stale.Labels[LabelActionID] != a.ID || stale.Labels[LabelInputSum] != inputSum) {
+			// The stale container would run with its previous configuration.
+			c.term().Warning().Printfln("The stale container %q was created with a different input, it can't be reused.", base)
+			policy = ContainerStaleRemove
+		}
+		switch policy {
+		case ContainerStaleRemove:
+			c.term().Warning().Printfln("Removing the stale container %q left from a previous run.", base)
+			if err := c.driver.ContainerRemove(ctx, stale.ID, types.ContainerRemoveOptions{}); err != nil {
+				return "", "", fmt.Errorf("failed to remove the stale container %q: %w", base, err)
+			}
+			return base, "", nil
+		case ContainerStaleReuse:
+			c.term().Info().Printfln("Reusing the stale container %q left from a previous run.", base)
+			return base, stale.ID, nil
+		case ContainerStaleFail:
+			return "", "", fmt.Errorf("the action %q can't start, the container %q is left from a previous run, remove it with \"docker rm %s\"", a.ID, base, base)
+		}
+	}
+	name := base
+	for i := 2; i <= containerNameRetries+1; i++ {
+		if findContainerByName(existing, name) == nil {
+			return name, "", nil
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+	return "", "", fmt.Errorf("the action %q can't start, the container names %s[_N] are in use", a.ID, base)
+}
+
+// containerInputSum returns a hash of the input defining the container of the run:
+// the image, the command, the environment, the entrypoint and the TTY mode.
+func (c *runtimeContainer) containerInputSum(a *Action, tty bool) string {
+	def := a.RuntimeDef().Container
+	var cmd any = def.Command
+	if c.exec {
+		cmd = a.Input().ArgsPositional()
+	} else if len(def.Steps) > 0 {
+		cmd = def.Steps
+	}
+	h := sha256.New()
+	_ = json.NewEncoder(h).Encode([]any{def.Image, cmd, mergeEnv(def.Env, c.env), c.entrypointSet, c.entrypoint, tty})
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// findContainerByName returns a container from the list with the exact name.
+func findContainerByName(list []types.ContainerListResult, name string) *types.ContainerListResult {
+	for i, ctr := range list {
+		for _, n := range ctr.Names {
+			if strings.TrimPrefix(n, "/") == name {
+				return &list[i]
+			}
+		}
+	}
+	return nil
+}
+
+// isContainerStale checks if the container is not running and may be left after a crash.
+func isContainerStale(ctr *types.ContainerListResult) bool {
+	switch ctr.State {
+	case "created", "exited", "dead":
+		return true
+	default:
+		return false
+	}
+}
+
+// containerExtraBinds returns binds of cache volumes and the docker socket declared in the definition.
+// The volumes are created by the container engine on the first run and kept afterward.
+func containerExtraBinds(actionID string, def *DefRuntimeContainer) []string {
+	var binds []string
+	for _, cache := range def.Cache {
+		binds = append(binds, containerCacheVolume(actionID, cache)+":"+cache.Path)
+	}
+	if def.DockerSocket {
+		// The path is resolved on the host of the container engine.
+		binds = append(binds, containerDockerSocket+":"+containerDockerSocket)
+	}
+	return binds
+}
+
+// containerCacheVolume returns a volume name of the cache.
+// Shared and private caches have different prefixes. The action id is readable in the name,
+// a hash of the id distinguishes ids mapped to the same name, e.g. "a-b" and "a.b".
+func containerCacheVolume(actionID string, cache DefContainerCache) string {
+	if cache.Shared {
+		return "launchr_cache_shared_" + cache.Name
+	}
+	var rpl = strings.NewReplacer("-", "_", ":", "_", ".", "_")
+	sum := sha256.Sum256([]byte(actionID))
+	return fmt.Sprintf("launchr_cache_action_%s_%x_%s", rpl.Replace(actionID), sum[:4], cache.Name)
+}
+
+// appLabels returns labels identifying resources created by the app.
+func appLabels() map[string]string {
+	ver := launchr.Version()
+	return map[string]string{
+		LabelApp:     ver.Name,
+		LabelVersion: ver.Version,
+	}
+}
+
+// containerLabels returns labels of a container running action a.
+func containerLabels(a *Action, runID string) map[string]string {
+	labels := appLabels()
+	labels[LabelActionID] = a.ID
+	labels[LabelRunID] = runID
+	labels[LabelWorkDirSum] = workDirSum(a.WorkDir())
+	return labels
+}
+
+// mergeLabels merges labels, the latter maps take precedence.
+func mergeLabels(labels ...map[string]string) map[string]string {
+	res := make(map[string]string)
+	for _, l := range labels {
+		maps.Copy(res, l)
+	}
+	return res
+}
+
+// parseLabelFlag parses run labels in KEY=VALUE format.
+// The values split by commas are joined back. The keys with the app prefix are reserved.
+func parseLabelFlag(list []string) (map[string]string, error) {
+	res := make(map[string]string, len(list))
+	last := ""
+	for _, v := range list {
+		k, val, ok := strings.Cut(v, "=")
+		if !ok {
+			if last == "" {
+				return nil, fmt.Errorf("invalid label %q, expected KEY=VALUE", v)
+			}
+			res[last] += "," + v
+			continue
+		}
+		if k == "" {
+			return nil, fmt.Errorf("invalid label %q, expected KEY=VALUE", v)
+		}
+		if strings.HasPrefix(k, "launchr.") {
+			return nil, fmt.Errorf("label %q is reserved, the prefix \"launchr.\" is used by the app", k)
+		}
+		res[k] = val
+		last = k
+	}
+	return res, nil
+}
+
+// applyMemoryMounts adds tmpfs mounts and the shared memory size declared in the definition.
+func applyMemoryMounts(def *DefRuntimeContainer, opts *types.ContainerCreateOptions) error {
+	shm, err := parseMemorySize(def.ShmSize)
+	if err != nil {
+		return err
+	}
+	opts.ShmSize = shm
+	for _, t := range def.Tmpfs {
+		size, err := parseMemorySize(t.Size)
+		if err != nil {
+			return err
+		}
+		if opts.Tmpfs == nil {
+			opts.Tmpfs = make(map[string]string)
+		}
+		opts.Tmpfs[t.Path] = ""
+		if size > 0 {
+			opts.Tmpfs[t.Path] = "size=" + strconv.FormatInt(size, 10)
+		}
+	}
+	return nil
+}
+
+// applySecurityProfile applies the security profile to the container options.
+// The action definition may relax the profile with documented exceptions.
+// The docker socket is mounted with the profile only if the profile allows it.
+func applySecurityProfile(profile ConfigSecurity, def *DefRuntimeContainer, opts *types.ContainerCreateOptions) error {
+	relax := def.Security
+	if relax == nil {
+		relax = &DefContainerSecurity{}
+	}
+	if def.DockerSocket && profile.IsSet() && !profile.AllowDockerSocket {
+		return errors.New(
+			"the security profile doesn't allow mounting the docker socket, it gives root access to the host, " +
+				"set \"allow_docker_socket\" in the security profile of the config to allow it",
+		)
+	}
+	if profile.DropCapabilities {
+		opts.CapDrop = []string{"ALL"}
+		opts.CapAdd = relax.CapAdd
+	}
+	if profile.ReadonlyRootfs && !relax.WritableRootfs {
+		opts.ReadonlyRootfs = true
+		if opts.Tmpfs == nil {
+			opts.Tmpfs = map[string]string{"/tmp": ""}
+		}
+	}
+	if profile.NoNewPrivileges && !relax.AllowPrivilegeEscalation {
+		opts.SecurityOpt = append(opts.SecurityOpt, "no-new-privileges")
+	}
+	if profile.NonRoot && !relax.AllowRoot && isRootUser(opts.User) {
+		return fmt.Errorf(
+			"the security profile requires a non-root user, the container user is %q, "+
+				"set \"allow_root\" in the action runtime security to allow it", opts.User,
+		)
+	}
+	return nil
+}
+
+// isRootUser returns true if the container user "user[:group]" is root.
+// An empty user is considered root because it's the default user of most images.
+func isRootUser(user string) bool {
+	u, _, _ := strings.Cut(user, ":")
+	return u == "" || u == "0" || u == "root"
+}
+
+// isWritesRestricted returns true if writes outside the working directory are restricted.
+func (c *runtimeContainer) isWritesRestricted() bool {
+	return c.restrictWr || c.rtcfg.RestrictWrites
+}
+
+// isCommandShown returns true if the resolved command is printed before the run.
+func (c *runtimeContainer) isCommandShown() bool {
+	return c.showCmd || c.rtcfg.ShowCommand
+}
+
+// printResolvedCommand prints the entrypoint and the command of a container to w with sensitive values masked.
+// An empty entrypoint is the default of the image.
+func printResolvedCommand(w io.Writer, mask *SensitiveMask, entrypoint, cmd []string) {
+	ep := "(image default)"
+	if len(entrypoint) > 0 {
+		ep = formatCommand(mask.MaskSlice(entrypoint))
+	}
+	_, _ = fmt.Fprintf(w, "Entrypoint: %s\nCommand:    %s\n", ep, formatCommand(mask.MaskSlice(cmd)))
+}
+
+// formatCommand joins the command arguments, arguments with spaces or quotes are quoted.
+func formatCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// bindFlags returns a suffix of a bind mount definition with the flags.
+func bindFlags(flags []string) string {
+	if len(flags) == 0 {
+		return ""
+	}
+	return ":" + strings.Join(flags, ",")
+}
+
+// volumeOwner returns an owner of the files copied to a container volume.
+// The first defined user is used, only numeric "uid[:gid]" is supported.
+func (c *runtimeContainer) volumeOwner(users ...string) *idtools.Identity {
+	for _, u := range users {
+		if u == "" {
+			continue
+		}
+		owner, err := parseUIDGID(u)
+		if err != nil {
+			c.term().Warning().Printfln("Can't change owner of the working directory to user %q: %v", u, err)
+			c.log().Warn("failed to parse container user", "user", u, "error", err)
+			return nil
+		}
+		return owner
+	}
+	return nil
+}
+
+// parseUIDGID parses a user in the format "uid[:gid]".
+func parseUIDGID(u string) (*idtools.Identity, error) {
+	uidStr, gidStr, hasGid := strings.Cut(u, ":")
+	uid, err := strconv.Atoi(uidStr)
+	if err != nil {
+		return nil, errors.New("only numeric uid and gid are supported")
+	}
+	gid := uid
+	if hasGid {
+		gid, err = strconv.Atoi(gidStr)
+		if err != nil {
+			return nil, errors.New("only numeric uid and gid are supported")
+		}
+	}
+	return &idtools.Identity{UID: uid, GID: gid}, nil
+}
+
 // copyDirToContainer copies dir content to a container.
-func (c *runtimeContainer) copyDirToContainer(ctx context.Context, cid, srcPath, dstPath string) error {
-	return c.copyToContainer(ctx, cid, srcPath, filepath.Dir(dstPath), filepath.Base(dstPath))
+// If owner is set, the copied files are owned by it.
+func (c *runtimeContainer) copyDirToContainer(ctx context.Context, cid, title, srcPath, dstPath string, owner *idtools.Identity) error {
+	return c.copyToContainer(ctx, cid, title, srcPath, filepath.Dir(dstPath), filepath.Base(dstPath), owner)
 }
 
 // copyToContainer copies dir/file to a container. Directory will be copied as a subdirectory.
-func (c *runtimeContainer) copyToContainer(ctx context.Context, cid, srcPath, dstPath, rebaseName string) error {
+// The progress is printed with the title.
+func (c *runtimeContainer) copyToContainer(ctx context.Context, cid, title, srcPath, dstPath, rebaseName string, owner *idtools.Identity) error {
 	// Prepare destination copy info by stat-ing the container path.
 	dstInfo := archive.CopyInfo{Path: dstPath}
 	dstStat, err := c.driver.ContainerStatPath(ctx, cid, dstPath)
@@ -548,7 +1554,11 @@ func (c *runtimeContainer) copyToContainer(ctx context.Context, cid, srcPath, ds
 	}
 	srcInfo.RebaseName = rebaseName
 
-	srcArchive, err := archive.TarResource(srcInfo)
+	// Same as archive.TarResource, but with an ability to change the files owner.
+	srcDir, srcBase := archive.SplitPathDirEntry(srcInfo.Path)
+	tarOpts := archive.TarResourceRebaseOpts(srcBase, srcInfo.RebaseName)
+	tarOpts.ChownOpts = owner
+	srcArchive, err := archive.TarWithOptions(srcDir, tarOpts)
 	if err != nil {
 		return err
 	}
@@ -562,9 +1572,40 @@ func (c *runtimeContainer) copyToContainer(ctx context.Context, cid, srcPath, ds
 
 	options := types.CopyToContainerOptions{
 		AllowOverwriteDirWithFile: false,
-		CopyUIDGID:                false,
+		// Keep the owner from the archive if it was set explicitly.
+		CopyUIDGID: owner != nil,
 	}
-	return c.driver.CopyToContainer(ctx, cid, dstDir, preparedArchive, options)
+	progress := c.term().Progress(title, pathSize(srcInfo.Path))
+	err = c.driver.CopyToContainer(ctx, cid, dstDir, progress.Reader(preparedArchive), options)
+	if err != nil {
+		return err
+	}
+	c.reportCopy(title, progress.Done(), true)
+	return nil
+}
+
+// reportCopy prints the stats of a finished copy and adds them to the run usage.
+func (c *runtimeContainer) reportCopy(title string, stats launchr.ProgressStats, in bool) {
+	c.term().Info().Printfln("%s: copied %s", title, stats)
+	c.runUsage().addCopy(stats, in)
+}
+
+// pathSize returns a total size of the files in path, it's used to estimate the progress of a copy.
+func pathSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable files fail the copy, the estimation skips them.
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, errInfo := d.Info(); errInfo == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
 }
 
 func resolveLocalPath(localPath string) (absPath string, err error) {
@@ -574,7 +1615,8 @@ func resolveLocalPath(localPath string) (absPath string, err error) {
 	return archive.PreserveTrailingDotOrSeparator(absPath, localPath), nil
 }
 
-func (c *runtimeContainer) copyFromContainer(ctx context.Context, cid, srcPath, dstPath, rebaseName string) (err error) {
+// copyFromContainer copies dir/file from a container. The progress is printed with the title.
+func (c *runtimeContainer) copyFromContainer(ctx context.Context, cid, title, srcPath, dstPath, rebaseName string) (err error) {
 	// Get an absolute destination path.
 	dstPath, err = resolveLocalPath(dstPath)
 	if err != nil {
@@ -594,24 +1636,43 @@ func (c *runtimeContainer) copyFromContainer(ctx context.Context, cid, srcPath,
 		RebaseName: rebaseName,
 	}
 
-	preArchive := content
+	// The size of a directory content is unknown before the copy.
+	var total int64
+	if !srcInfo.IsDir {
+		total = stat.Size
+	}
+	progress := c.term().Progress(title, total)
+	preArchive := io.NopCloser(progress.Reader(content))
 	if len(srcInfo.RebaseName) != 0 {
 		_, srcBase := archive.SplitPathDirEntry(srcInfo.Path)
-		preArchive = archive.RebaseArchiveEntries(content, srcBase, srcInfo.RebaseName)
+		preArchive = archive.RebaseArchiveEntries(preArchive, srcBase, srcInfo.RebaseName)
 	}
 
-	return archive.CopyTo(preArchive, srcInfo, dstPath)
+	if err = archive.CopyTo(preArchive, srcInfo, dstPath); err != nil {
+		return err
+	}
+	c.reportCopy(title, progress.Done(), false)
+	return nil
+}
+
+// waitCondition returns the condition of waiting for the exit of the container.
+// An auto-removed container is waited to be removed unless only the exit is requested,
+// so the container name is free when the run finishes.
+// If the API doesn't support the wait conditions, only the exit is waited.
+func waitCondition(waitFor string, autoRemove bool, api driver.APIFeatures) types.WaitCondition {
+	if autoRemove && waitFor != WaitForExit && api.Supports(driver.APIFeatureWaitCondition) {
+		return types.WaitConditionRemoved
+	}
+	return types.WaitConditionNextExit
 }
 
-func (c *runtimeContainer) containerWait(ctx context.Context, cid string, opts *types.ContainerCreateOptions) <-chan int {
+func (c *runtimeContainer) containerWait(ctx context.Context, cid string, opts *types.ContainerCreateOptions, waitFor string) <-chan int {
 	log := c.log()
 	// Wait for the container to stop or catch error.
-	waitCond := types.WaitConditionNextExit
-	if opts.AutoRemove {
-		waitCond = types.WaitConditionRemoved
-	}
+	waitCond := waitCondition(waitFor, opts.AutoRemove, c.api)
 	resCh, errCh := c.driver.ContainerWait(ctx, cid, types.ContainerWaitOptions{Condition: waitCond})
-	statusC := make(chan int)
+	// The channel is buffered to not leak the goroutine if the status isn't read, e.g. on a start failure.
+	statusC := make(chan int, 1)
 	go func() {
 		select {
 		case err := <-errCh:
@@ -634,6 +1695,28 @@ func (c *runtimeContainer) containerWait(ctx context.Context, cid string, opts *
 	return statusC
 }
 
+// containerWaitHealthy waits for the health check of the container to pass.
+// An [launchr.ExitError] is returned if the container exits before or is unhealthy.
+func (c *runtimeContainer) containerWaitHealthy(ctx context.Context, cid string) <-chan error {
+	resCh, errCh := c.driver.ContainerWait(ctx, cid, types.ContainerWaitOptions{Condition: types.WaitConditionHealthy})
+	done := make(chan error, 1)
+	go func() {
+		select {
+		case err := <-errCh:
+			done <- fmt.Errorf("failed to wait for a healthy container: %w", err)
+		case res := <-resCh:
+			if res.Error != nil {
+				done <- launchr.NewExitError(max(res.StatusCode, 1), res.Error.Error())
+				return
+			}
+			done <- nil
+		case <-ctx.Done():
+			done <- ctx.Err()
+		}
+	}()
+	return done
+}
+
 func (c *runtimeContainer) attachContainer(ctx context.Context, streams launchr.Streams, cid string, opts *types.ContainerCreateOptions) (io.Closer, <-chan error, error) {
 	cio, errAttach := c.driver.ContainerAttach(ctx, cid, types.ContainerAttachOptions{
 		Stream: true,
@@ -652,6 +1735,70 @@ func (c *runtimeContainer) attachContainer(ctx context.Context, streams launchr.
 	return cio, errCh, nil
 }
 
+// mountBindFlags returns flags of the working and action directory binds.
+// The flags set by a user take precedence over the SELinux flags of the action.
+func (c *runtimeContainer) mountBindFlags(ctx context.Context, a *Action, def *DefRuntimeContainer) []string {
+	var flags []string
+	switch {
+	case c.mountFlags == mountFlagsNone:
+		return nil
+	case c.mountFlags != "":
+		flags = strings.Split(c.mountFlags, ",")
+	case c.isSELinuxEnabled(ctx):
+		// Use the lowercase z flag by default to allow concurrent actions access to the FS.
+		flag := "z"
+		if def.SELinuxLabel == SELinuxLabelPrivate {
+			flag = "Z"
+		}
+		flags = []string{flag}
+		c.term().Warning().Printfln(
+			"SELinux is detected. The volumes will be mounted with the %q flags, which will relabel your files.\n"+
+				"This process may take time or potentially break existing permissions. Use --%s to override the flags.",
+			":"+flag, containerFlagMountFlags,
+		)
+		c.log().Warn("using selinux flags", "flags", ":"+flag)
+	}
+	if slices.Contains(flags, "z") || slices.Contains(flags, "Z") {
+		if n, more := countFiles(a.WorkDir(), relabelWarnFiles); more {
+			c.term().Warning().Printfln(
+				"The working directory %q has more than %d files, relabeling may take a long time.", a.WorkDir(), n,
+			)
+		}
+	}
+	return flags
+}
+
+// countFiles counts files in the directory up to the limit, it returns true if the limit is exceeded.
+func countFiles(root string, limit int) (int, bool) {
+	n := 0
+	errLimit := errors.New("limit exceeded")
+	err := filepath.WalkDir(root, func(_ string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped.
+			return nil
+		}
+		if n >= limit {
+			return errLimit
+		}
+		n++
+		return nil
+	})
+	return n, errors.Is(err, errLimit)
+}
+
+// validateMountFlags checks the flags set by a user.
+func validateMountFlags(flags string) error {
+	if flags == "" || flags == mountFlagsNone {
+		return nil
+	}
+	for _, f := range strings.Split(flags, ",") {
+		if !slices.Contains(allowedMountFlags, f) {
+			return fmt.Errorf("mount flag %q is not supported, use %s or %q", f, strings.Join(allowedMountFlags, ", "), mountFlagsNone)
+		}
+	}
+	return nil
+}
+
 func (c *runtimeContainer) isSELinuxEnabled(ctx context.Context) bool {
 	// First, we check if it's enabled at the OS level, then if it's enabled in the container runner.
 	// If the feature is not enabled in the runner environment,
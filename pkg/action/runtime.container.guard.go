@@ -0,0 +1,91 @@
+package action
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+const (
+	// roFsMessage is a part of an error message on a write to a read-only file system.
+	roFsMessage = "read-only file system"
+	// maxWriteAttempts is a number of write attempts to report.
+	maxWriteAttempts = 10
+	// maxGuardLineLen is a length of a line buffered to search for write attempts.
+	maxGuardLineLen = 4096
+)
+
+// containerWriteGuard collects output lines reporting writes to a read-only file system.
+type containerWriteGuard struct {
+	mx       sync.Mutex
+	attempts []string
+}
+
+// Streams returns streams that inspect the output for the write attempts.
+func (g *containerWriteGuard) Streams(streams launchr.Streams) launchr.Streams {
+	// The guard is used only without TTY, the terminal information of the output is not needed.
+	return activityStreams{
+		Streams: streams,
+		out:     launchr.NewOut(&writeGuardWriter{w: streams.Out(), g: g}),
+		err:     &writeGuardWriter{w: streams.Err(), g: g},
+	}
+}
+
+func (g *containerWriteGuard) check(line string) {
+	if !strings.Contains(strings.ToLower(line), roFsMessage) {
+		return
+	}
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	line = strings.TrimSpace(line)
+	if len(g.attempts) < maxWriteAttempts && !slices.Contains(g.attempts, line) {
+		g.attempts = append(g.attempts, line)
+	}
+}
+
+// Attempts returns the collected write attempts.
+func (g *containerWriteGuard) Attempts() []string {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return slices.Clone(g.attempts)
+}
+
+// Report prints the collected write attempts.
+func (g *containerWriteGuard) Report(term *launchr.Terminal, a *Action) {
+	attempts := g.Attempts()
+	if len(attempts) == 0 {
+		return
+	}
+	term.Warning().Printfln("Action %q attempted to write outside of the working directory:", a.ID)
+	for _, line := range attempts {
+		term.Printfln("  %s", line)
+	}
+}
+
+// writeGuardWriter passes the output through and checks every line for write attempts.
+type writeGuardWriter struct {
+	w    io.Writer
+	g    *containerWriteGuard
+	line []byte
+}
+
+func (w *writeGuardWriter) Write(p []byte) (int, error) {
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i == -1 {
+			break
+		}
+		w.g.check(string(w.line[:i]))
+		w.line = w.line[i+1:]
+	}
+	if len(w.line) > maxGuardLineLen {
+		w.g.check(string(w.line))
+		w.line = w.line[:0]
+	}
+	return w.w.Write(p)
+}
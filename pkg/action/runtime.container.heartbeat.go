@@ -0,0 +1,95 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// activityWriter is a writer that remembers the time of the last write.
+type activityWriter struct {
+	w    io.Writer
+	last *atomic.Int64
+}
+
+func (w activityWriter) Write(p []byte) (int, error) {
+	w.last.Store(time.Now().UnixNano())
+	return w.w.Write(p)
+}
+
+// activityStreams wraps output streams to track the container activity.
+type activityStreams struct {
+	launchr.Streams
+	out *launchr.Out
+	err io.Writer
+}
+
+func (s activityStreams) Out() *launchr.Out { return s.out }
+func (s activityStreams) Err() io.Writer    { return s.err }
+
+// containerHeartbeat prints a status line when a container doesn't produce output for a while.
+type containerHeartbeat struct {
+	interval time.Duration
+	started  time.Time
+	last     atomic.Int64
+	// estimate is an expected duration of the run, the progress is shown if it's set.
+	estimate time.Duration
+}
+
+func newContainerHeartbeat(interval time.Duration) *containerHeartbeat {
+	h := &containerHeartbeat{interval: interval, started: time.Now()}
+	h.last.Store(h.started.UnixNano())
+	return h
+}
+
+// Streams returns streams that register the output activity.
+func (h *containerHeartbeat) Streams(streams launchr.Streams) launchr.Streams {
+	// Heartbeat is used only without TTY, the terminal information of the output is not needed.
+	return activityStreams{
+		Streams: streams,
+		out:     launchr.NewOut(activityWriter{w: streams.Out(), last: &h.last}),
+		err:     activityWriter{w: streams.Err(), last: &h.last},
+	}
+}
+
+// Watch prints a heartbeat on every interval of silence until the context is done.
+func (h *containerHeartbeat) Watch(ctx context.Context, c *runtimeContainer, a *Action, name string) {
+	ticker := time.NewTicker(h.interval / 2)
+	defer ticker.Stop()
+	var lastBeat time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			last := time.Unix(0, h.last.Load())
+			if lastBeat.After(last) {
+				// Count silence from the last printed heartbeat not to print it on every tick.
+				last = lastBeat
+			}
+			if now.Sub(last) < h.interval {
+				continue
+			}
+			silence := now.Sub(time.Unix(0, h.last.Load()))
+			status := "unknown"
+			if list := c.driver.ContainerList(ctx, types.ContainerListOptions{SearchName: name}); len(list) > 0 {
+				status = list[0].Status
+			}
+			elapsed := now.Sub(h.started)
+			progress := ""
+			if h.estimate > 0 {
+				progress = fmt.Sprintf(", %d%% of the usual ~%s", elapsed*100/h.estimate, FormatEstimate(h.estimate))
+			}
+			c.term().Info().Printfln(
+				"Action %q is still running (elapsed %s%s, no output for %s, container status: %s)",
+				a.ID, elapsed.Round(time.Second), progress, silence.Round(time.Second), status,
+			)
+			lastBeat = now
+		}
+	}
+}
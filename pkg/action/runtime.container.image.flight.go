@@ -0,0 +1,192 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// imageEnsureGroup deduplicates image preparation of concurrent runs in the process.
+var imageEnsureGroup = &imageEnsureFlight{calls: make(map[string]*imageEnsureCall)}
+
+type imageEnsureCall struct {
+	done     chan struct{}
+	err      error
+	progress *imageProgress
+	// canceled is set if the call failed because its caller was cancelled.
+	canceled bool
+}
+
+// imageEnsureFlight makes sure only one function is executed for a key at a time,
+// the concurrent callers wait for the result of the first one.
+type imageEnsureFlight struct {
+	mx    sync.Mutex
+	calls map[string]*imageEnsureCall
+}
+
+// Do executes fn for a key if it's not being executed, otherwise waits for the running call
+// and returns its result. The progress written by fn is available to the waiters in onWait.
+// A waiter stops waiting when ctx is done. If the running call fails only because
+// its caller was cancelled, the waiter executes fn itself.
+func (g *imageEnsureFlight) Do(ctx context.Context, key string, onWait func(progress io.Reader), fn func(progress io.Writer) error) (shared bool, err error) {
+	for {
+		g.mx.Lock()
+		c, ok := g.calls[key]
+		if !ok {
+			c = &imageEnsureCall{done: make(chan struct{}), progress: newImageProgress()}
+			g.calls[key] = c
+			g.mx.Unlock()
+			return shared, g.call(ctx, key, c, fn)
+		}
+		g.mx.Unlock()
+		shared = true
+		if err = g.wait(ctx, c, onWait); err != nil {
+			return shared, err
+		}
+		if !c.canceled {
+			return shared, c.err
+		}
+	}
+}
+
+// call executes fn as the first caller of the key.
+func (g *imageEnsureFlight) call(ctx context.Context, key string, c *imageEnsureCall, fn func(progress io.Writer) error) error {
+	defer func() {
+		g.mx.Lock()
+		delete(g.calls, key)
+		g.mx.Unlock()
+		c.progress.finish()
+		close(c.done)
+	}()
+	c.err = fn(c.progress)
+	c.canceled = c.err != nil && ctx.Err() != nil
+	return c.err
+}
+
+// wait waits for the call c showing its progress with onWait.
+func (g *imageEnsureFlight) wait(ctx context.Context, c *imageEnsureCall, onWait func(progress io.Reader)) error {
+	shown := make(chan struct{})
+	r := c.progress.reader()
+	if onWait != nil {
+		go func() {
+			defer close(shown)
+			onWait(r)
+		}()
+	} else {
+		close(shown)
+	}
+	defer func() {
+		_ = r.Close()
+		<-shown
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return nil
+	}
+}
+
+// imageProgress keeps the progress of image preparation for the waiting callers,
+// every reader gets the progress from the beginning. Writes never block on readers.
+type imageProgress struct {
+	mx   sync.Mutex
+	cond *sync.Cond
+	buf  []byte
+	done bool
+}
+
+func newImageProgress() *imageProgress {
+	p := &imageProgress{}
+	p.cond = sync.NewCond(&p.mx)
+	return p
+}
+
+// Write implements [io.Writer] interface.
+func (p *imageProgress) Write(b []byte) (int, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.buf = append(p.buf, b...)
+	p.cond.Broadcast()
+	return len(b), nil
+}
+
+// finish marks the end of the progress, the readers get [io.EOF].
+func (p *imageProgress) finish() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.done = true
+	p.cond.Broadcast()
+}
+
+// reader returns a new reader of the progress.
+func (p *imageProgress) reader() io.ReadCloser {
+	return &imageProgressReader{p: p}
+}
+
+type imageProgressReader struct {
+	p      *imageProgress
+	off    int
+	closed bool
+}
+
+// Read implements [io.Reader] interface, it blocks until the progress is written or finished.
+func (r *imageProgressReader) Read(b []byte) (int, error) {
+	p := r.p
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	for r.off >= len(p.buf) && !p.done && !r.closed {
+		p.cond.Wait()
+	}
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if r.off >= len(p.buf) {
+		return 0, io.EOF
+	}
+	n := copy(b, p.buf[r.off:])
+	r.off += n
+	return n, nil
+}
+
+// Close implements [io.Closer] interface, a blocked Read returns.
+func (r *imageProgressReader) Close() error {
+	r.p.mx.Lock()
+	defer r.p.mx.Unlock()
+	r.closed = true
+	r.p.cond.Broadcast()
+	return nil
+}
+
+type imageProgressKey struct{}
+
+// withImageProgress returns a context copying the progress of image preparation to w.
+func withImageProgress(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, imageProgressKey{}, w)
+}
+
+// teeImageProgress returns the progress of image preparation copied to the writer of ctx.
+func teeImageProgress(ctx context.Context, progress io.Reader) io.Reader {
+	if w, ok := ctx.Value(imageProgressKey{}).(io.Writer); ok {
+		return io.TeeReader(progress, w)
+	}
+	return progress
+}
+
+// lockImageEnsure acquires a file lock shared between processes to prepare an image.
+// It stops waiting for another process when ctx is done. The returned function releases the lock.
+func lockImageEnsure(ctx context.Context, key string) (unlock func(), err error) {
+	sum := sha256.Sum256([]byte(key))
+	fname := filepath.Join(os.TempDir(), "launchr", "images", hex.EncodeToString(sum[:8])+".lock")
+	f := launchr.NewLockedFile(fname)
+	if err = f.OpenContext(ctx, os.O_CREATE|os.O_RDWR, 0600); err != nil {
+		return nil, err
+	}
+	return func() { _ = f.Close() }, nil
+}
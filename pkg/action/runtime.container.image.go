@@ -2,11 +2,19 @@ package action
 
 import (
 	"bufio"
+	"cmp"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"golang.org/x/mod/sumdb/dirhash"
 
@@ -25,7 +33,54 @@ type ImageBuildResolver interface {
 	ImageBuildInfo(image string) *types.BuildDefinition
 }
 
-// ChainImageBuildResolver is a image build resolver that takes first available image in the chain.
+// Image build resolvers priorities. Resolvers with higher priority are asked first.
+const (
+	ImageBuildResolverPriorityAction = math.MaxInt // ImageBuildResolverPriorityAction - action build definition.
+	ImageBuildResolverPriorityConfig = 0           // ImageBuildResolverPriorityConfig - build definition in the config file.
+)
+
+// ImageBuildResolverInfo provides information about an [ImageBuildResolver].
+type ImageBuildResolverInfo struct {
+	Name     string // Name is shown in the image resolution trace.
+	Priority int    // Priority defines the order in the chain, higher goes first.
+}
+
+// ImageBuildResolverDescriber is an [ImageBuildResolver] describing itself.
+// If a resolver doesn't implement the interface, it has a type name and [ImageBuildResolverPriorityConfig] priority.
+type ImageBuildResolverDescriber interface {
+	ImageBuildResolver
+	// ImageBuildResolverInfo returns information about the resolver.
+	ImageBuildResolverInfo() ImageBuildResolverInfo
+}
+
+// ImageBuildResolverPlugin is a launchr plugin providing image build resolvers for container runtimes.
+type ImageBuildResolverPlugin interface {
+	launchr.Plugin
+	// ImageBuildResolvers returns resolvers to add to the chain.
+	// Implement [ImageBuildResolverDescriber] to set the resolver priority.
+	ImageBuildResolvers() []ImageBuildResolver
+}
+
+// GetImageBuildResolverInfo returns information about resolver r.
+func GetImageBuildResolverInfo(r ImageBuildResolver) ImageBuildResolverInfo {
+	if d, ok := r.(ImageBuildResolverDescriber); ok {
+		return d.ImageBuildResolverInfo()
+	}
+	return ImageBuildResolverInfo{
+		Name:     fmt.Sprintf("%T", r),
+		Priority: ImageBuildResolverPriorityConfig,
+	}
+}
+
+// ImageBuildResolveStep is a result of an [ImageBuildResolver] in the resolution chain.
+type ImageBuildResolveStep struct {
+	Resolver string // Resolver is a name of the resolver.
+	Found    bool   // Found is true if the resolver supplied the build definition.
+	Reason   string // Reason explains the result.
+}
+
+// ChainImageBuildResolver is an image build resolver that takes the first available image in the chain.
+// Resolvers are asked lazily, the rest of the chain is skipped on the first match.
 type ChainImageBuildResolver []ImageBuildResolver
 
 // ImageBuildInfo implements [ImageBuildResolver].
@@ -38,6 +93,41 @@ func (r ChainImageBuildResolver) ImageBuildInfo(image string) *types.BuildDefini
 	return nil
 }
 
+// ImageBuildInfoTrace resolves the build definition like [ChainImageBuildResolver.ImageBuildInfo]
+// and explains the result of every resolver in the chain.
+func (r ChainImageBuildResolver) ImageBuildInfoTrace(image string) (*types.BuildDefinition, []ImageBuildResolveStep) {
+	var res *types.BuildDefinition
+	var found string
+	trace := make([]ImageBuildResolveStep, 0, len(r))
+	for i := 0; i < len(r); i++ {
+		step := ImageBuildResolveStep{Resolver: GetImageBuildResolverInfo(r[i]).Name}
+		switch {
+		case res != nil:
+			step.Reason = fmt.Sprintf("skipped, resolved by %q with higher priority", found)
+		default:
+			res = r[i].ImageBuildInfo(image)
+			if res == nil {
+				step.Reason = "no build definition for the image"
+				break
+			}
+			found = step.Resolver
+			step.Found = true
+			step.Reason = fmt.Sprintf("supplied the build definition with context %q", res.Context)
+		}
+		trace = append(trace, step)
+	}
+	return res, trace
+}
+
+// Add adds a resolver to the chain keeping the order of priorities.
+func (r ChainImageBuildResolver) Add(res ImageBuildResolver) ChainImageBuildResolver {
+	r = append(r, res)
+	slices.SortStableFunc(r, func(a, b ImageBuildResolver) int {
+		return cmp.Compare(GetImageBuildResolverInfo(b).Priority, GetImageBuildResolverInfo(a).Priority)
+	})
+	return r
+}
+
 // ConfigImages is a container to parse [launchr.Config] in yaml format.
 type ConfigImages map[string]*types.BuildDefinition
 
@@ -46,6 +136,11 @@ type LaunchrConfigImageBuildResolver struct {
 	cfg launchr.Config
 }
 
+// ImageBuildResolverInfo implements [ImageBuildResolverDescriber].
+func (r LaunchrConfigImageBuildResolver) ImageBuildResolverInfo() ImageBuildResolverInfo {
+	return ImageBuildResolverInfo{Name: "config", Priority: ImageBuildResolverPriorityConfig}
+}
+
 // ImageBuildInfo implements [ImageBuildResolver].
 func (r LaunchrConfigImageBuildResolver) ImageBuildInfo(image string) *types.BuildDefinition {
 	if r.cfg == nil {
@@ -77,6 +172,7 @@ type ImageBuildCacheResolver struct {
 	items         map[string]string
 	requireUpdate bool
 	cfg           launchr.Config
+	mx            sync.Mutex
 }
 
 // NewImageBuildCacheResolver creates [ImageBuildCacheResolver] from global configuration.
@@ -92,6 +188,8 @@ func NewImageBuildCacheResolver(cfg launchr.Config) *ImageBuildCacheResolver {
 
 // EnsureLoaded makes sure the sum file is loaded.
 func (r *ImageBuildCacheResolver) EnsureLoaded() (err error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 	if r.items == nil {
 		r.items, err = r.readSums()
 	}
@@ -128,6 +226,8 @@ func (r *ImageBuildCacheResolver) DirHash(path string) (string, error) {
 
 // GetSum returns a sum for an image tag.
 func (r *ImageBuildCacheResolver) GetSum(tag string) string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 	r.assertLoaded()
 	if tag == "" {
 		panic("tag must not be empty")
@@ -141,6 +241,8 @@ func (r *ImageBuildCacheResolver) GetSum(tag string) string {
 
 // SetSum adds sum for a tag. Provide empty sum to remove it.
 func (r *ImageBuildCacheResolver) SetSum(tag string, sum string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 	r.assertLoaded()
 	if tag == "" {
 		panic("tag must not be empty")
@@ -152,6 +254,8 @@ func (r *ImageBuildCacheResolver) SetSum(tag string, sum string) {
 
 // Save saves the sum file to the persistent storage.
 func (r *ImageBuildCacheResolver) Save() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 	if !r.requireUpdate {
 		return nil
 	}
@@ -201,10 +305,79 @@ func (r *ImageBuildCacheResolver) Save() error {
 
 // Destroy removes the sum file from the persistent storage.
 func (r *ImageBuildCacheResolver) Destroy() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 	r.items = nil
 	return r.file.Remove()
 }
 
+// buildBaseImages returns base images used in the buildfile of the build definition.
+// Errors are ignored because the build reports them on its own.
+func buildBaseImages(bi *types.BuildDefinition) []string {
+	if bi == nil {
+		return nil
+	}
+	buildfile := bi.Buildfile
+	if buildfile == "" {
+		buildfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(buildfile) {
+		buildfile = filepath.Join(bi.Context, buildfile)
+	}
+	f, err := os.Open(buildfile) //nolint:gosec
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	images, _ := dockerfileBaseImages(f)
+	return images
+}
+
+// dockerfileBaseImages returns images used in FROM instructions of a Dockerfile.
+// Build stages, scratch and images defined with variables are skipped.
+func dockerfileBaseImages(r io.Reader) ([]string, error) {
+	var images []string
+	stages := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 2 || !strings.EqualFold(f[0], "FROM") {
+			continue
+		}
+		// Skip flags like --platform.
+		args := f[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		img := args[0]
+		_, isStage := stages[strings.ToLower(img)]
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = struct{}{}
+		}
+		if isStage || img == "scratch" || strings.Contains(img, "$") || slices.Contains(images, img) {
+			continue
+		}
+		images = append(images, img)
+	}
+	return images, scanner.Err()
+}
+
+// imageSumWithBases combines the sum of the build context with the sums of base images.
+func imageSumWithBases(sum string, baseSums map[string]string) string {
+	if len(baseSums) == 0 {
+		return sum
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n", sum)
+	for _, base := range slices.Sorted(maps.Keys(baseSums)) {
+		_, _ = fmt.Fprintf(h, "%s %s\n", base, baseSums[base])
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
 func parseSums(fname string, file io.Reader) (map[string]string, error) {
 	items := make(map[string]string)
 	scanner := bufio.NewScanner(file)
@@ -0,0 +1,238 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+const (
+	// runsDir is a directory in the config directory with records of recent container runs.
+	runsDir = "runs"
+	// runRecordsLimit is a maximum number of kept run records.
+	runRecordsLimit = 20
+	// maskedValue replaces secrets in run records.
+	maskedValue = "***"
+)
+
+// runRecordFormat is a format of the run records.
+var runRecordFormat = &launchr.StateFormat{Version: 1}
+
+// RunRecord describes a finished container run for troubleshooting, e.g. in bug reports.
+// Values of secret environment variables, build arguments and sensitive parameters are masked.
+type RunRecord struct {
+	// ID is the run id, the same as the container name.
+	ID       string `yaml:"id"`
+	ActionID string `yaml:"action_id"`
+	// ActionFile is a path of the action definition file.
+	ActionFile string `yaml:"action_file"`
+	// Definition is a content of the action definition file at the time of the run.
+	Definition string `yaml:"definition"`
+	// Container is the container definition rendered with the input.
+	Container *DefRuntimeContainer `yaml:"container"`
+	// Env is the environment of the container including the runtime flags.
+	Env      []string          `yaml:"env,omitempty"`
+	WorkDir  string            `yaml:"workdir"`
+	Labels   map[string]string `yaml:"labels,omitempty"`
+	Started  time.Time         `yaml:"started"`
+	Duration time.Duration     `yaml:"duration"`
+	ExitCode int               `yaml:"exit_code"`
+	Error    string            `yaml:"error,omitempty"`
+	// Output is the container output limited with [ConfigOutputLimit], it's not collected for interactive sessions.
+	Output string `yaml:"output,omitempty"`
+
+	secrets []string
+	mask    *SensitiveMask
+}
+
+// RunRecorder stores records of recent container runs in the config directory.
+type RunRecorder struct {
+	dir string
+}
+
+// NewRunRecorder creates [RunRecorder] from global configuration.
+func NewRunRecorder(cfg launchr.Config) *RunRecorder {
+	return &RunRecorder{dir: cfg.Path(runsDir)}
+}
+
+// Save writes the record and removes the oldest records over the limit.
+func (r *RunRecorder) Save(rec *RunRecord) error {
+	content, err := runRecordFormat.Encode(rec)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(r.dir, 0750); err != nil {
+		return err
+	}
+	if err = launchr.WriteFileAtomic(filepath.Join(r.dir, rec.ID+".yaml"), content, 0600); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// Load reads the record of run id.
+func (r *RunRecorder) Load(id string) (*RunRecord, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("run id %q is not valid", id)
+	}
+	content, err := os.ReadFile(filepath.Join(r.dir, id+".yaml")) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("run %q is not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec := &RunRecord{}
+	if err = runRecordFormat.Decode(content, rec); err != nil {
+		return nil, fmt.Errorf("failed to parse run %q: %w", id, err)
+	}
+	return rec, nil
+}
+
+// List returns the kept records, the most recent first. Unreadable records are skipped.
+func (r *RunRecorder) List() ([]*RunRecord, error) {
+	ids, err := r.ids()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*RunRecord, 0, len(ids))
+	for _, id := range ids {
+		rec, errRec := r.Load(id)
+		if errRec != nil {
+			launchr.Log().Debug("skipping run record", "run_id", id, "error", errRec)
+			continue
+		}
+		res = append(res, rec)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Started.After(res[j].Started)
+	})
+	return res, nil
+}
+
+func (r *RunRecorder) ids() ([]string, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".yaml") {
+			ids = append(ids, strings.TrimSuffix(e.Name(), ".yaml"))
+		}
+	}
+	return ids, nil
+}
+
+func (r *RunRecorder) prune() error {
+	recs, err := r.List()
+	if err != nil || len(recs) <= runRecordsLimit {
+		return err
+	}
+	var errs []error
+	for _, rec := range recs[runRecordsLimit:] {
+		if errRm := os.Remove(filepath.Join(r.dir, rec.ID+".yaml")); errRm != nil && !os.IsNotExist(errRm) {
+			errs = append(errs, errRm)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newRunRecord creates a record of the run of action a in container name.
+// The values of mask are replaced in the rendered definition, the environment and the output.
+func newRunRecord(a *Action, name string, env []string, labels map[string]string, started time.Time, mask *SensitiveMask) *RunRecord {
+	rec := &RunRecord{
+		ID:         name,
+		ActionID:   a.ID,
+		ActionFile: a.Filepath(),
+		WorkDir:    a.WorkDir(),
+		Labels:     labels,
+		Started:    started,
+		mask:       mask,
+	}
+	if content, err := a.DefinitionEncoded(); err == nil {
+		rec.Definition = string(content)
+	}
+	if def := a.RuntimeDef().Container; def != nil {
+		cdef := *def
+		cdef.Command = StrSliceOrStr(mask.MaskSlice(def.Command))
+		cdef.Env = rec.maskEnv(def.Env)
+		if def.Build != nil {
+			build := *def.Build
+			build.Args = make(map[string]*string, len(def.Build.Args))
+			for k, v := range def.Build.Args {
+				if v != nil && IsSecretName(k) {
+					rec.secrets = append(rec.secrets, *v)
+					masked := maskedValue
+					v = &masked
+				} else if v != nil {
+					masked := mask.Mask(*v)
+					v = &masked
+				}
+				build.Args[k] = v
+			}
+			cdef.Build = &build
+		}
+		rec.Container = &cdef
+	}
+	rec.Env = rec.maskEnv(env)
+	return rec
+}
+
+// finish sets the result of the run and masks the secrets in the output.
+func (rec *RunRecord) finish(err error, output string) {
+	rec.Duration = time.Since(rec.Started).Round(time.Millisecond)
+	if err != nil {
+		rec.Error = rec.mask.Mask(err.Error())
+		var exitErr launchr.ExitError
+		if errors.As(err, &exitErr) {
+			rec.ExitCode = exitErr.ExitCode()
+		}
+	}
+	for _, secret := range rec.secrets {
+		// Short values may be a part of normal output, they are not replaced.
+		if len(secret) >= 4 {
+			output = strings.ReplaceAll(output, secret, maskedValue)
+		}
+	}
+	rec.Output = rec.mask.Mask(output)
+}
+
+// maskEnv returns a copy of env with values of secret variables masked.
+// The values are kept to mask them in the output.
+func (rec *RunRecord) maskEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	res := make([]string, len(env))
+	for i, kv := range env {
+		if k, v, hasVal := strings.Cut(kv, "="); hasVal && IsSecretName(k) {
+			rec.secrets = append(rec.secrets, v)
+			kv = k + "=" + maskedValue
+		} else if hasVal {
+			kv = k + "=" + rec.mask.Mask(v)
+		}
+		res[i] = kv
+	}
+	return res
+}
+
+// captureStreams returns streams copying the output to w.
+func captureStreams(streams launchr.Streams, w io.Writer) launchr.Streams {
+	// The output is collected only without TTY, the terminal information of the output is not needed.
+	return activityStreams{
+		Streams: streams,
+		out:     launchr.NewOut(io.MultiWriter(streams.Out(), w)),
+		err:     io.MultiWriter(streams.Err(), w),
+	}
+}
@@ -0,0 +1,184 @@
+package action
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// EnvVarReport is an environment variable set in the action container when launchr parses
+// reports of the action from its output. The value is a prefix of a report line, e.g.:
+//
+//	echo "${LAUNCHR_REPORT}progress 50 Building sources"
+//	echo "${LAUNCHR_REPORT}output version=1.2.3"
+//	echo "${LAUNCHR_REPORT}warning Cache is empty"
+const EnvVarReport = "LAUNCHR_REPORT"
+
+// reportPrefix is a prefix of an output line with a report of the action.
+const reportPrefix = "::launchr::"
+
+const (
+	// maxReportLineLen is a maximum length of a report line, longer lines are printed as is.
+	maxReportLineLen = 4096
+	// maxReportWarnings is a maximum number of warnings kept for the run summary.
+	maxReportWarnings = 100
+)
+
+// Commands of a report line.
+const (
+	reportCmdProgress = "progress"
+	reportCmdOutput   = "output"
+	reportCmdWarning  = "warning"
+)
+
+// containerReport parses reports of the action from the container output.
+type containerReport struct {
+	mx       sync.Mutex
+	term     *launchr.Terminal
+	actionID string
+	mask     *SensitiveMask
+
+	progress int
+	outputs  map[string]string
+	warnings []string
+}
+
+func newContainerReport(term *launchr.Terminal, a *Action) *containerReport {
+	return &containerReport{term: term, actionID: a.ID, mask: a.SensitiveMask(), outputs: make(map[string]string)}
+}
+
+// Streams returns streams that remove report lines from the output and handle them.
+func (r *containerReport) Streams(streams launchr.Streams) launchr.Streams {
+	// The report is parsed only without TTY, the terminal information of the output is not needed.
+	return activityStreams{
+		Streams: streams,
+		out:     launchr.NewOut(&reportWriter{w: streams.Out(), r: r, start: true}),
+		err:     &reportWriter{w: streams.Err(), r: r, start: true},
+	}
+}
+
+// handle processes a report line and returns false if the line isn't a known report.
+func (r *containerReport) handle(line string) bool {
+	line = strings.TrimRight(strings.TrimPrefix(line, reportPrefix), "\r\n")
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = r.mask.Mask(strings.TrimSpace(arg))
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	switch cmd {
+	case reportCmdProgress:
+		val, msg, _ := strings.Cut(arg, " ")
+		pct, err := strconv.Atoi(strings.TrimSuffix(val, "%"))
+		if err != nil || pct < 0 || pct > 100 {
+			return false
+		}
+		r.progress = pct
+		if msg != "" {
+			r.term.Info().Printfln("Action %q progress: %d%% %s", r.actionID, pct, msg)
+		} else {
+			r.term.Info().Printfln("Action %q progress: %d%%", r.actionID, pct)
+		}
+	case reportCmdOutput:
+		key, val, ok := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return false
+		}
+		r.outputs[key] = val
+	case reportCmdWarning:
+		if arg == "" {
+			return false
+		}
+		r.term.Warning().Printfln("Action %q: %s", r.actionID, arg)
+		if len(r.warnings) < maxReportWarnings {
+			r.warnings = append(r.warnings, arg)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// Report returns the collected report of the run.
+func (r *containerReport) Report() *RunReport {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return &RunReport{
+		Progress: r.progress,
+		Outputs:  maps.Clone(r.outputs),
+		Warnings: slices.Clone(r.warnings),
+	}
+}
+
+// Summary prints the outputs reported by the action.
+func (r *containerReport) Summary() {
+	rep := r.Report()
+	if len(rep.Outputs) == 0 {
+		return
+	}
+	r.term.Info().Printfln("Action %q outputs:", r.actionID)
+	for _, k := range slices.Sorted(maps.Keys(rep.Outputs)) {
+		r.term.Printfln("  %s=%s", k, rep.Outputs[k])
+	}
+}
+
+// reportWriter passes the output through except the report lines.
+// Only the beginning of a line is buffered while it may be a report,
+// so the output without reports isn't delayed.
+type reportWriter struct {
+	w     io.Writer
+	r     *containerReport
+	line  []byte
+	start bool // start is set when the next byte begins a line.
+}
+
+func (w *reportWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	var out []byte
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		end := len(p)
+		if i != -1 {
+			end = i + 1
+		}
+		if !w.start {
+			out = append(out, p[:end]...)
+			p = p[end:]
+			w.start = i != -1
+			continue
+		}
+		w.line = append(w.line, p[:end]...)
+		p = p[end:]
+		if !isReportLine(w.line) || len(w.line) > maxReportLineLen {
+			out = append(out, w.line...)
+			w.line = w.line[:0]
+			w.start = i != -1
+			continue
+		}
+		if i != -1 {
+			if !w.r.handle(string(w.line)) {
+				out = append(out, w.line...)
+			}
+			w.line = w.line[:0]
+		}
+	}
+	if len(out) > 0 {
+		if _, err := w.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// isReportLine checks if the line is or may become a report line.
+func isReportLine(line []byte) bool {
+	if len(line) < len(reportPrefix) {
+		return strings.HasPrefix(reportPrefix, string(line))
+	}
+	return bytes.HasPrefix(line, []byte(reportPrefix))
+}
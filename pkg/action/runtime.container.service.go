@@ -0,0 +1,128 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// Restart defaults of service actions.
+const (
+	serviceMaxRestarts = 5
+	serviceBackoff     = time.Second
+	serviceMaxBackoff  = time.Minute
+)
+
+// Exit codes of containers stopped by a user, they are not restarted.
+const (
+	exitCodeInterrupted = 130 // SIGINT
+	exitCodeTerminated  = 143 // SIGTERM
+)
+
+// containerService keeps the state of a supervised service run.
+type containerService struct {
+	mx     sync.Mutex
+	status ServiceStatus
+}
+
+func (s *containerService) update(fn func(st *ServiceStatus)) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	fn(&s.status)
+}
+
+// Status returns a copy of the current state.
+func (s *containerService) Status() *ServiceStatus {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	st := s.status
+	return &st
+}
+
+// ServiceStatus implements [RuntimeServiceReporter] interface.
+func (c *runtimeContainer) ServiceStatus() *ServiceStatus {
+	svc := c.service.Load()
+	if svc == nil {
+		return nil
+	}
+	return svc.Status()
+}
+
+// superviseService calls run until it succeeds, it's stopped by a user or the restart budget is exhausted.
+// The delay between restarts is doubled after every failure up to a minute.
+func (c *runtimeContainer) superviseService(ctx context.Context, id string, rdef *DefContainerRestart, run func(context.Context) error) error {
+	maxRestarts, backoff := rdef.Budget()
+	svc := &containerService{status: ServiceStatus{MaxRestarts: maxRestarts}}
+	c.service.Store(svc)
+	log := c.services().Log().With("action_id", id)
+	for {
+		svc.update(func(st *ServiceStatus) {
+			st.State = ServiceStateRunning
+			st.NextRestart = time.Time{}
+		})
+		err := run(ctx)
+		code, ok := serviceFailureCode(ctx, err)
+		if !ok {
+			svc.update(func(st *ServiceStatus) {
+				st.State = ServiceStateStopped
+				if err != nil && ctx.Err() == nil && code == 0 {
+					st.State = ServiceStateFailed
+				}
+			})
+			return err
+		}
+		st := svc.Status()
+		if st.Restarts >= maxRestarts {
+			svc.update(func(st *ServiceStatus) {
+				st.State = ServiceStateFailed
+				st.LastExitCode = code
+			})
+			c.term().Error().Printfln("Service %q failed with exit code %d, no restarts left after %d restarts", id, code, st.Restarts)
+			return err
+		}
+		delay := serviceRestartDelay(backoff, st.Restarts)
+		svc.update(func(st *ServiceStatus) {
+			st.State = ServiceStateRestarting
+			st.Restarts++
+			st.LastExitCode = code
+			st.NextRestart = time.Now().Add(delay)
+		})
+		c.term().Warning().Printfln("Service %q exited with code %d, restarting in %s (%d/%d)", id, code, delay, st.Restarts+1, maxRestarts)
+		log.Info("restarting the service", "exit_code", code, "delay", delay, "restarts", st.Restarts+1)
+		select {
+		case <-ctx.Done():
+			svc.update(func(st *ServiceStatus) {
+				st.State = ServiceStateStopped
+				st.NextRestart = time.Time{}
+			})
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// serviceFailureCode returns the exit code of an unexpected exit of a service that must be restarted.
+// Successful runs, runs stopped by a user and errors of the environment are not restarted.
+func serviceFailureCode(ctx context.Context, err error) (int, bool) {
+	var exitErr launchr.ExitError
+	if err == nil || !errors.As(err, &exitErr) {
+		return 0, false
+	}
+	code := exitErr.ExitCode()
+	if ctx.Err() != nil || code == exitCodeInterrupted || code == exitCodeTerminated {
+		return code, false
+	}
+	return code, code != 0
+}
+
+// serviceRestartDelay returns the delay before the restart n+1.
+func serviceRestartDelay(backoff time.Duration, n int) time.Duration {
+	d := backoff
+	for i := 0; i < n && d < serviceMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, serviceMaxBackoff)
+}
@@ -0,0 +1,196 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// DefaultSessionImage is an image of the helper containers copying the working directory of a session.
+const DefaultSessionImage = "alpine:latest"
+
+// labelSessionImage is a label of the session volume keeping the image of the helper containers.
+const labelSessionImage = "launchr.session_image"
+
+// labelSessionVersion is a label of the session volume keeping the version of the volume layout.
+// The volumes created before the layout was versioned have version 1.
+const labelSessionVersion = "launchr.session_version"
+
+// sessionVersion is the current version of the session volume layout.
+const sessionVersion = 1
+
+// Session is a named volume keeping a copy of the working directory on the container engine.
+// The runs with the flag "use-volume-wd" in the working directory use the volume, so the
+// working directory is copied once when the session starts and back when it stops.
+type Session struct {
+	// Volume is a name of the volume.
+	Volume string
+	// WorkDir is an absolute path of the working directory.
+	WorkDir string
+	// Image is an image of the helper containers copying the working directory.
+	Image string
+}
+
+// SessionManager starts and stops sessions of working directories.
+type SessionManager struct {
+	c *runtimeContainer
+}
+
+// NewSessionManager creates a [SessionManager] using the container runner d.
+func NewSessionManager(d driver.ContainerRunner) *SessionManager {
+	return &SessionManager{c: &runtimeContainer{driver: d}}
+}
+
+func (m *SessionManager) volumes() (driver.ContainerRunnerVolumes, error) {
+	vd, ok := m.c.driver.(driver.ContainerRunnerVolumes)
+	if !ok {
+		return nil, errors.New("the container engine doesn't support managing volumes")
+	}
+	return vd, nil
+}
+
+// Find returns the session of the working directory wd or nil if the session isn't started.
+func (m *SessionManager) Find(ctx context.Context, wd string) (*Session, error) {
+	vd, err := m.volumes()
+	if err != nil {
+		return nil, err
+	}
+	vols, err := vd.VolumeList(ctx, types.VolumeListOptions{
+		Labels: []string{
+			LabelApp + "=" + launchr.Version().Name,
+			LabelSession,
+			LabelWorkDirSum + "=" + workDirSum(wd),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	if len(vols) == 0 {
+		return nil, nil
+	}
+	v := vols[0]
+	if ver, ok := v.Labels[labelSessionVersion]; ok {
+		if n, errVer := strconv.Atoi(ver); errVer != nil || n > sessionVersion {
+			return nil, fmt.Errorf("session volume %q has version %q, supported %d: %w", v.Name, ver, sessionVersion, launchr.ErrStateVersion)
+		}
+	}
+	return &Session{Volume: v.Name, WorkDir: v.Labels[LabelSession], Image: v.Labels[labelSessionImage]}, nil
+}
+
+// Start creates a session volume of the working directory wd and copies the directory into it.
+// The image is used by the helper containers copying the files, it must exist on the container engine
+// or in a registry.
+func (m *SessionManager) Start(ctx context.Context, wd, image string) (*Session, error) {
+	wd = launchr.MustAbs(wd)
+	vd, err := m.volumes()
+	if err != nil {
+		return nil, err
+	}
+	if s, err := m.Find(ctx, wd); err != nil || s != nil {
+		if s != nil {
+			err = fmt.Errorf("session of %q is already started in volume %q", wd, s.Volume)
+		}
+		return nil, err
+	}
+	if image == "" {
+		image = DefaultSessionImage
+	}
+	s := &Session{Volume: sessionVolumeName(wd), WorkDir: wd, Image: image}
+	err = vd.VolumeCreate(ctx, types.VolumeCreateOptions{
+		Name: s.Volume,
+		Labels: mergeLabels(appLabels(), map[string]string{
+			LabelSession:        wd,
+			LabelWorkDirSum:     workDirSum(wd),
+			labelSessionImage:   image,
+			labelSessionVersion: strconv.Itoa(sessionVersion),
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the session volume: %w", err)
+	}
+	err = m.withHelper(ctx, s, func(cid string) error {
+		return m.c.copyDirToContainer(ctx, cid, "Copying the working directory", wd, containerHostMount, nil)
+	})
+	if err != nil {
+		if errRm := vd.VolumeRemove(context.WithoutCancel(ctx), s.Volume); errRm != nil {
+			m.c.log().Error("failed to remove the session volume", "volume", s.Volume, "error", errRm)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// Stop copies the working directory back from the session volume of wd and removes the volume.
+// If discard is set, the changes made in the session are not copied back.
+func (m *SessionManager) Stop(ctx context.Context, wd string, discard bool) (*Session, error) {
+	wd = launchr.MustAbs(wd)
+	vd, err := m.volumes()
+	if err != nil {
+		return nil, err
+	}
+	s, err := m.Find(ctx, wd)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("session of %q is not started", wd)
+	}
+	if !discard {
+		err = m.withHelper(ctx, s, func(cid string) error {
+			return m.c.copyFromContainer(ctx, cid, "Copying back the working directory", containerHostMount, filepath.Dir(wd), filepath.Base(wd))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = vd.VolumeRemove(ctx, s.Volume); err != nil {
+		return nil, fmt.Errorf("failed to remove the session volume: %w", err)
+	}
+	return s, nil
+}
+
+// withHelper calls fn with a helper container mounting the session volume.
+// The container isn't started, the files are copied with the container engine.
+func (m *SessionManager) withHelper(ctx context.Context, s *Session, fn func(cid string) error) error {
+	streams := launchr.NoopStreams()
+	if err := m.c.ensureImage(ctx, streams, nil, s.Image, nil, nil); err != nil {
+		return err
+	}
+	cid, err := m.c.driver.ContainerCreate(ctx, types.ContainerCreateOptions{
+		Image:       s.Image,
+		NetworkMode: types.NetworkModeNone,
+		Labels:      mergeLabels(appLabels(), map[string]string{LabelSession: s.WorkDir}),
+		Binds:       []string{s.Volume + ":" + containerHostMount},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create a helper container: %w", err)
+	}
+	defer m.c.containerRemoveWithVolumes(ctx, cid)
+	return fn(cid)
+}
+
+// findSession returns the session of the working directory of action a.
+// The sessions are used only when the working directory is copied to the container.
+func (c *runtimeContainer) findSession(ctx context.Context, a *Action) (*Session, error) {
+	if _, ok := c.driver.(driver.ContainerRunnerVolumes); !ok || !c.useVolWD {
+		return nil, nil
+	}
+	return (&SessionManager{c: c}).Find(ctx, launchr.MustAbs(a.WorkDir()))
+}
+
+// sessionVolumeName returns a name of the session volume of the working directory wd.
+func sessionVolumeName(wd string) string {
+	return fmt.Sprintf("%s_session_%s", launchr.Version().Name, workDirSum(wd)[:12])
+}
+
+// workDirSum returns a sha256 hash of the working directory path.
+func workDirSum(wd string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(wd)))
+}
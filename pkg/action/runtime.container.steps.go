@@ -0,0 +1,62 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// containerStepsKeepAlive is a command keeping the container running while the steps are executed.
+var containerStepsKeepAlive = []string{"tail", "-f", "/dev/null"}
+
+// hasSteps checks if the steps are executed in the container instead of the command.
+// The command given in the exec mode replaces the steps.
+func (c *runtimeContainer) hasSteps(def *DefRuntimeContainer) bool {
+	return len(def.Steps) > 0 && !c.exec
+}
+
+// executeSteps executes the steps of the action one by one in the running container.
+// It stops on the first failed step unless the action continues on errors.
+// The exit code of the first failed step is returned.
+func (c *runtimeContainer) executeSteps(ctx context.Context, a *Action, cid string, runConfig *types.ContainerCreateOptions, streams launchr.Streams) (int, error) {
+	d, ok := c.driver.(driver.ContainerRunnerExec)
+	if !ok {
+		return 0, fmt.Errorf("container environment %q doesn't support executing steps", c.dtype)
+	}
+	def := a.RuntimeDef().Container
+	mask := a.SensitiveMask()
+	status := 0
+	for i, step := range def.Steps {
+		name := step.StepName(i)
+		cmd := step.ExecCommand()
+		c.term().Info().Printfln("Step %q: %s", name, formatCommand(mask.MaskSlice(cmd)))
+		opts := types.ContainerExecOptions{
+			User:         runConfig.User,
+			Tty:          runConfig.Tty,
+			AttachStdin:  runConfig.AttachStdin,
+			AttachStdout: true,
+			AttachStderr: true,
+			Env:          runConfig.Env,
+			Cmd:          cmd,
+		}
+		exitCode, err := c.execCommand(ctx, d, cid, opts, streams)
+		if err != nil {
+			return 0, fmt.Errorf("step %q failed: %w", name, err)
+		}
+		if exitCode == 0 {
+			continue
+		}
+		if !def.ContinueOnError {
+			c.term().Error().Printfln("Step %q failed with exit code %d", name, exitCode)
+			return exitCode, nil
+		}
+		c.term().Warning().Printfln("Step %q failed with exit code %d, continuing with the next step", name, exitCode)
+		if status == 0 {
+			status = exitCode
+		}
+	}
+	return status, nil
+}
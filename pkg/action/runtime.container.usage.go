@@ -0,0 +1,65 @@
+package action
+
+import (
+	"context"
+	"sync"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// containerUsage collects resource usage of a container from the stats samples.
+type containerUsage struct {
+	mx    sync.Mutex
+	usage RunUsage
+	seen  bool
+}
+
+// Watch collects the samples until the container stops or the context is done.
+func (u *containerUsage) Watch(ctx context.Context, d driver.ContainerRunnerStats, cid string) error {
+	ch, err := d.ContainerStats(ctx, cid)
+	if err != nil {
+		return err
+	}
+	for s := range ch {
+		u.add(s)
+	}
+	return nil
+}
+
+func (u *containerUsage) add(s types.ContainerStats) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.seen = true
+	// CPU time and network counters are cumulative, only the last values are kept.
+	// Samples of a stopped container are zero, they must not reset the counters.
+	u.usage.CPUTime = max(u.usage.CPUTime, s.CPUTime)
+	u.usage.NetworkRx = max(u.usage.NetworkRx, s.NetworkRx)
+	u.usage.NetworkTx = max(u.usage.NetworkTx, s.NetworkTx)
+	u.usage.MaxMemory = max(u.usage.MaxMemory, s.Memory, s.MaxMemory)
+}
+
+// addCopy adds the stats of a copy operation, in is true for a copy to the container.
+func (u *containerUsage) addCopy(stats launchr.ProgressStats, in bool) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.seen = true
+	if in {
+		u.usage.CopiedIn += uint64(stats.Bytes) //nolint:gosec // Bytes is never negative.
+	} else {
+		u.usage.CopiedOut += uint64(stats.Bytes) //nolint:gosec // Bytes is never negative.
+	}
+	u.usage.CopyTime += stats.Duration
+}
+
+// Usage returns the collected usage or nil if nothing was collected.
+func (u *containerUsage) Usage() *RunUsage {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	if !u.seen {
+		return nil
+	}
+	res := u.usage
+	return &res
+}
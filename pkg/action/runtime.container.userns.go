@@ -0,0 +1,111 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// containerRootUser is a root user of a container.
+const containerRootUser = "0:0"
+
+// containerUserMapping defines how the user of a container is mapped to the current host user,
+// so the files created in the mounted directories are owned by the host user.
+type containerUserMapping struct {
+	// User is a user of the container.
+	User string
+	// UsernsMode is a user namespace mode of the container.
+	UsernsMode string
+	// Chown is set when the files created by root must be given back to the host user after the run.
+	Chown bool
+	// ChownUsernsMode is a user namespace mode of the container changing the owner.
+	ChownUsernsMode string
+}
+
+// userMapping selects the mapping of the container user based on the capabilities of the container engine.
+// Rootless engines map the container root to the host user. Podman keeps the host user with "keep-id".
+// Other engines run root as the host root, the owner of the created files is changed after the run.
+func (c *runtimeContainer) userMapping(ctx context.Context, def *DefRuntimeContainer, hostUser string) containerUserMapping {
+	m := containerUserMapping{User: hostUser}
+	if def.RunAsRoot {
+		m.User = containerRootUser
+	}
+	if c.useVolWD || hostUser == "" {
+		// The copied files are owned by the host user, and there is no owner to keep on other systems.
+		return m
+	}
+	var ns driver.UserNamespace
+	if d, ok := c.driver.(driver.ContainerRunnerUserns); ok {
+		var err error
+		if ns, err = d.UserNamespace(ctx); err != nil {
+			c.log().Debug("failed to get the user namespace of the container engine", "error", err)
+		}
+	}
+	switch {
+	case def.RunAsRoot && ns.Rootless:
+		// The container root is the host user.
+	case def.RunAsRoot && runtime.GOOS == "linux":
+		// Docker Desktop on macOS gives the shared files to the host user itself.
+		m.Chown = true
+		if ns.Remapped {
+			// The owner can't be set to the host user from the remapped namespace.
+			m.ChownUsernsMode = driver.UsernsModeHost
+		}
+	case !def.RunAsRoot && ns.KeepID:
+		m.UsernsMode = driver.UsernsModeKeepID
+	}
+	c.log().Debug("selected user mapping of the container", "user", m.User, "userns", m.UsernsMode, "chown", m.Chown)
+	return m
+}
+
+// chownMounts gives the files created by the container root in the mounted directories to the host user.
+// A helper container of the action image runs the change as root.
+func (c *runtimeContainer) chownMounts(ctx context.Context, a *Action, name string, owner string, usernsMode string) error {
+	runDef := a.RuntimeDef()
+	flags := c.mountBindFlags(ctx, a, runDef.Container)
+	opts := types.ContainerCreateOptions{
+		ContainerName: name + "-chown",
+		Image:         runDef.Container.Image,
+		Entrypoint:    []string{"find"},
+		Cmd:           []string{containerHostMount, containerActionMount, "-user", "0", "-exec", "chown", "-h", owner, "{}", "+"},
+		User:          containerRootUser,
+		NetworkMode:   types.NetworkModeNone,
+		UsernsMode:    usernsMode,
+		Labels:        mergeLabels(c.labels, containerLabels(a, name)),
+		Binds: []string{
+			launchr.MustAbs(a.WorkDir()) + ":" + containerHostMount + bindFlags(flags),
+			launchr.MustAbs(a.Dir()) + ":" + containerActionMount + bindFlags(flags),
+		},
+	}
+	cid, err := c.driver.ContainerCreate(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errRm := c.driver.ContainerRemove(ctx, cid, types.ContainerRemoveOptions{}); errRm != nil {
+			c.log().Debug("failed to remove the container changing the owner", "error", errRm)
+		}
+	}()
+	resCh, errCh := c.driver.ContainerWait(ctx, cid, types.ContainerWaitOptions{Condition: types.WaitConditionNextExit})
+	if err = c.driver.ContainerStart(ctx, cid, types.ContainerStartOptions{}); err != nil {
+		return err
+	}
+	select {
+	case err = <-errCh:
+		return err
+	case res := <-resCh:
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.StatusCode != 0 {
+			return fmt.Errorf("the command exited with code %d", res.StatusCode)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
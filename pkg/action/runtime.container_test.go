@@ -3,20 +3,33 @@ package action
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
 	"time"
 
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
 
 	"github.com/launchrctl/launchr/internal/launchr"
 	"github.com/launchrctl/launchr/pkg/driver"
@@ -87,14 +100,12 @@ func testContainerAction(cdef *DefRuntimeContainer) *Action {
 }
 
 func testContainerIO() *driver.ContainerInOut {
-	outBytes := []byte("0test stdOut")
-	// Set row header for moby.stdCopy proper parsing of combined streams.
-	outBytes[0] = byte(stdcopy.Stdout)
+	// Write a frame with a header for moby.stdCopy proper parsing of combined streams.
+	out := &bytes.Buffer{}
+	_, _ = stdcopy.NewStdWriter(out, stdcopy.Stdout).Write([]byte("test stdOut"))
 	return &driver.ContainerInOut{
-		In: &fakeWriter{
-			Buffer: bytes.NewBuffer([]byte{}),
-		},
-		Out: bytes.NewBuffer(outBytes),
+		In:  &fakeWriter{},
+		Out: out,
 	}
 }
 
@@ -193,6 +204,9 @@ func Test_ContainerExec_imageEnsure(t *testing.T) {
 			act.input = NewInput(act, nil, nil, launchr.NoopStreams())
 			run := act.RuntimeDef().Container
 			imgOpts := types.ImageOptions{Name: run.Image, Build: tt.expBuild}
+			if tt.expBuild != nil {
+				imgOpts.Labels = appLabels()
+			}
 			d.EXPECT().
 				ImageEnsure(ctx, eqImageOpts{imgOpts}).
 				Return(tt.ret...)
@@ -202,6 +216,145 @@ func Test_ContainerExec_imageEnsure(t *testing.T) {
 	}
 }
 
+func Test_ContainerExec_imageEnsureFlight(t *testing.T) {
+	t.Parallel()
+	g := &imageEnsureFlight{calls: make(map[string]*imageEnsureCall)}
+	ctx := context.Background()
+	release := make(chan struct{})
+	expErr := errors.New("ensure error")
+	var calls, waits atomic.Int32
+	var wg sync.WaitGroup
+	results := make(chan error, 3)
+	shown := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := g.Do(ctx, "img", func(progress io.Reader) {
+				waits.Add(1)
+				b, _ := io.ReadAll(progress)
+				shown <- string(b)
+			}, func(progress io.Writer) error {
+				calls.Add(1)
+				_, _ = progress.Write([]byte("progress"))
+				<-release
+				return expErr
+			})
+			results <- err
+		}()
+	}
+	// Wait for all callers to join the first call.
+	require.Eventually(t, func() bool { return calls.Load()+waits.Load() == 3 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+	close(shown)
+	assert.Equal(t, int32(1), calls.Load())
+	for err := range results {
+		assert.Equal(t, expErr, err)
+	}
+	// The waiters get the progress of the call.
+	for s := range shown {
+		assert.Equal(t, "progress", s)
+	}
+
+	// The key is released after the call.
+	shared, err := g.Do(ctx, "img", nil, func(io.Writer) error { return nil })
+	assert.False(t, shared)
+	assert.NoError(t, err)
+}
+
+func Test_ContainerExec_imageEnsureFlightCancel(t *testing.T) {
+	t.Parallel()
+	g := &imageEnsureFlight{calls: make(map[string]*imageEnsureCall)}
+	started := make(chan struct{})
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := g.Do(leaderCtx, "img", nil, func(io.Writer) error {
+			close(started)
+			<-leaderCtx.Done()
+			return leaderCtx.Err()
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	// A waiter stops waiting when its context is done.
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWait()
+	shared, err := g.Do(waitCtx, "img", nil, func(io.Writer) error { return nil })
+	assert.True(t, shared)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// A waiter runs the function itself if the first caller was cancelled.
+	waiting := make(chan struct{})
+	var calls atomic.Int32
+	go func() {
+		<-waiting
+		cancelLeader()
+	}()
+	shared, err = g.Do(context.Background(), "img", func(io.Reader) { close(waiting) }, func(io.Writer) error {
+		calls.Add(1)
+		return nil
+	})
+	assert.True(t, shared)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.ErrorIs(t, <-leaderDone, context.Canceled)
+}
+
+func Test_ContainerExec_lockImageEnsure(t *testing.T) {
+	// Keep the locks in the test directory.
+	t.Setenv("TMPDIR", t.TempDir())
+	key := "docker:alpine:latest"
+	unlock, err := lockImageEnsure(context.Background(), key)
+	require.NoError(t, err)
+
+	// Waiting for the lock held by another process stops when the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = lockImageEnsure(ctx, key)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The lock is acquired when it's released.
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		unlock()
+		close(released)
+	}()
+	unlockNext, err := lockImageEnsure(context.Background(), key)
+	require.NoError(t, err)
+	<-released
+	unlockNext()
+}
+
+func Test_ContainerExec_parseUIDGID(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name   string
+		user   string
+		exp    *idtools.Identity
+		expErr bool
+	}
+	tts := []testCase{
+		{"uid and gid", "1000:1001", &idtools.Identity{UID: 1000, GID: 1001}, false},
+		{"uid only", "1000", &idtools.Identity{UID: 1000, GID: 1000}, false},
+		{"user name", "root", nil, true},
+		{"group name", "1000:staff", nil, true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			owner, err := parseUIDGID(tt.user)
+			assert.Equal(t, tt.exp, owner)
+			assert.Equal(t, tt.expErr, err != nil)
+		})
+	}
+}
+
 func Test_ContainerExec_imageRemove(t *testing.T) {
 	t.Parallel()
 
@@ -327,6 +480,27 @@ func Test_ContainerExec_containerCreate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(expCid, cid)
 
+	// Create with restricted writes.
+	r.restrictWr = true
+	eqRoCfg := eqCfg
+	eqRoCfg.ReadonlyRootfs = true
+	eqRoCfg.Tmpfs = map[string]string{"/tmp": ""}
+	eqRoCfg.Binds = []string{
+		wd + ":" + containerHostMount,
+		launchr.MustAbs(a.Dir()) + ":" + containerActionMount + ":ro",
+	}
+	d.EXPECT().
+		ImageEnsure(ctx, types.ImageOptions{Name: run.Container.Image}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	d.EXPECT().
+		ContainerCreate(ctx, gomock.Eq(eqRoCfg)).
+		Return(expCid, nil)
+
+	cid, err = r.containerCreate(ctx, a, runCfg)
+	require.NoError(t, err)
+	assert.Equal(expCid, cid)
+	r.restrictWr = false
+
 	// Create with anonymous volumes.
 	r.useVolWD = true
 	eqCfg.Binds = nil
@@ -345,6 +519,61 @@ func Test_ContainerExec_containerCreate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(expCid, cid)
 
+	// Create with cache volumes and the docker socket.
+	run.Container.Cache = []DefContainerCache{{Name: "build", Path: "/cache"}, {Name: "go", Path: "/go", Shared: true}}
+	run.Container.DockerSocket = true
+	eqCfg.Binds = []string{
+		"launchr_cache_action_test_9f86d081_build:/cache",
+		"launchr_cache_shared_go:/go",
+		"/var/run/docker.sock:/var/run/docker.sock",
+	}
+	d.EXPECT().
+		ImageEnsure(ctx, types.ImageOptions{Name: run.Container.Image}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	d.EXPECT().
+		ContainerCreate(ctx, gomock.Eq(eqCfg)).
+		Return(expCid, nil)
+
+	cid, err = r.containerCreate(ctx, a, runCfg)
+	require.NoError(t, err)
+	assert.Equal(expCid, cid)
+	run.Container.Cache = nil
+	run.Container.DockerSocket = false
+
+	// Create with overridden image defaults, the entrypoint flag takes precedence.
+	r.rtcfg.ImagesOverrides = ConfigImagesOverrides{run.Container.Image: {Entrypoint: []string{"/bin/sh", "-c"}, User: "1000:1000"}}
+	eqOvCfg := eqCfg
+	eqOvCfg.Binds = nil
+	eqOvCfg.Entrypoint = []string{"/bin/sh", "-c"}
+	eqOvCfg.User = "1000:1000"
+	d.EXPECT().
+		ImageEnsure(ctx, types.ImageOptions{Name: run.Container.Image}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	d.EXPECT().
+		ContainerCreate(ctx, gomock.Eq(eqOvCfg)).
+		Return(expCid, nil)
+
+	cid, err = r.containerCreate(ctx, a, runCfg)
+	require.NoError(t, err)
+	assert.Equal(expCid, cid)
+
+	r.entrypointSet = true
+	ovRunCfg := *runCfg
+	ovRunCfg.Entrypoint = []string{"/entrypoint"}
+	eqOvCfg.Entrypoint = []string{"/entrypoint"}
+	d.EXPECT().
+		ImageEnsure(ctx, types.ImageOptions{Name: run.Container.Image}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	d.EXPECT().
+		ContainerCreate(ctx, gomock.Eq(eqOvCfg)).
+		Return(expCid, nil)
+
+	cid, err = r.containerCreate(ctx, a, &ovRunCfg)
+	require.NoError(t, err)
+	assert.Equal(expCid, cid)
+	r.entrypointSet = false
+	r.rtcfg.ImagesOverrides = nil
+
 	// Image ensure fail.
 	errImg := fmt.Errorf("error on image ensure")
 	d.EXPECT().
@@ -446,18 +675,116 @@ func Test_ContainerExec_containerWait(t *testing.T) {
 				autoRemove = true
 			}
 			runCfg := &types.ContainerCreateOptions{AutoRemove: autoRemove}
-			ch := r.containerWait(ctx, cid, runCfg)
+			ch := r.containerWait(ctx, cid, runCfg, "")
 			assert.Equal(tt.expStatus, <-ch)
 		})
 	}
 }
 
+func Test_ContainerExec_waitCondition(t *testing.T) {
+	t.Parallel()
+	api := driver.APIFeatures{}
+	assert.Equal(t, types.WaitConditionNextExit, waitCondition("", false, api))
+	assert.Equal(t, types.WaitConditionRemoved, waitCondition("", true, api))
+	assert.Equal(t, types.WaitConditionNextExit, waitCondition(WaitForExit, true, api))
+	assert.Equal(t, types.WaitConditionRemoved, waitCondition(WaitForRemoved, true, api))
+	assert.Equal(t, types.WaitConditionNextExit, waitCondition(WaitForRemoved, false, api))
+	// Old API doesn't support waiting for the removal.
+	assert.Equal(t, types.WaitConditionNextExit, waitCondition(WaitForRemoved, true, driver.NewAPIFeatures("1.29")))
+}
+
+func Test_ContainerExec_containerWaitHealthy(t *testing.T) {
+	t.Parallel()
+	assert, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+
+	tts := []struct {
+		name    string
+		chanFn  func(resCh chan types.ContainerWaitResponse, errCh chan error)
+		expCode int
+		expErr  string
+	}{
+		{
+			"healthy",
+			func(resCh chan types.ContainerWaitResponse, _ chan error) {
+				resCh <- types.ContainerWaitResponse{}
+			},
+			0,
+			"",
+		},
+		{
+			"unhealthy",
+			func(resCh chan types.ContainerWaitResponse, _ chan error) {
+				resCh <- types.ContainerWaitResponse{StatusCode: 1, Error: errors.New("container is unhealthy")}
+			},
+			1,
+			"container is unhealthy",
+		},
+		{
+			"exited before healthy",
+			func(resCh chan types.ContainerWaitResponse, _ chan error) {
+				resCh <- types.ContainerWaitResponse{StatusCode: 2, Error: errors.New("container exited with code 2 before it became healthy")}
+			},
+			2,
+			"container exited with code 2 before it became healthy",
+		},
+		{
+			"wait error",
+			func(_ chan types.ContainerWaitResponse, errCh chan error) {
+				errCh <- errors.New("fail")
+			},
+			-1,
+			"failed to wait for a healthy container: fail",
+		},
+	}
+
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			cid := ""
+			resCh, errCh := make(chan types.ContainerWaitResponse, 1), make(chan error, 1)
+			tt.chanFn(resCh, errCh)
+			d.EXPECT().
+				ContainerWait(ctx, cid, types.ContainerWaitOptions{Condition: types.WaitConditionHealthy}).
+				Return(resCh, errCh)
+
+			err := <-r.containerWaitHealthy(ctx, cid)
+			if tt.expErr == "" {
+				assert.NoError(err)
+				return
+			}
+			assert.EqualError(err, tt.expErr)
+			var exitErr launchr.ExitError
+			if tt.expCode >= 0 {
+				assert.True(errors.As(err, &exitErr))
+				assert.Equal(tt.expCode, exitErr.ExitCode())
+			} else {
+				assert.False(errors.As(err, &exitErr))
+			}
+		})
+	}
+}
+
 type fakeWriter struct {
-	*bytes.Buffer
+	buf bytes.Buffer
+	mx  sync.Mutex
+}
+
+func (f *fakeWriter) Write(p []byte) (int, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.buf.Write(p)
 }
 
 func (f *fakeWriter) Close() error {
-	f.Buffer.Reset()
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.buf.Reset()
 	return nil
 }
 
@@ -545,8 +872,16 @@ func Test_ContainerExec(t *testing.T) {
 		AttachStdout: true,
 		AttachStderr: true,
 		Tty:          false,
-		Env:          runConf.Env,
+		Env:          append(slices.Clone(runConf.Env), EnvVarReport+"="+reportPrefix),
 		User:         getCurrentUser(),
+		Labels: map[string]string{
+			LabelApp:        launchr.Version().Name,
+			LabelVersion:    launchr.Version().Version,
+			LabelActionID:   act.ID,
+			LabelRunID:      nprv.Get(act.ID),
+			LabelWorkDirSum: fmt.Sprintf("%x", sha256.Sum256([]byte(act.WorkDir()))),
+			LabelInputSum:   (&runtimeContainer{}).containerInputSum(act, false),
+		},
 	}
 	attOpts := types.ContainerAttachOptions{
 		Stream: true,
@@ -709,8 +1044,12 @@ func Test_ContainerExec(t *testing.T) {
 			var prev *gomock.Call
 			d.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(nil) // @todo test different container names
 			for _, step := range tt.steps {
-				if step.fn == "ContainerWait" { //nolint:goconst
+				switch step.fn {
+				case "ContainerWait": //nolint:goconst
 					step.ret = []any{resCh, errCh}
+				case "ContainerAttach":
+					// Streams must not be shared between parallel runs.
+					step.ret = []any{testContainerIO(), step.ret[1]}
 				}
 				prev = callContainerDriverMockFn(d, step, prev)
 			}
@@ -817,44 +1156,1447 @@ func Test_ConfigImageBuildInfo(t *testing.T) {
 	}
 }
 
-const cfgYaml = `
-images:
-  build:config: ./config
-`
+type testImgResolver struct {
+	info  ImageBuildResolverInfo
+	image string
+}
 
-const validImgsYaml = `
-images:
-  my/image:version:
-    context: ./
-    buildfile: test1.Dockerfile
-    args:
-      arg1: val1
-      arg2: val2
-    tags:
-      - my/image:version2
-      - my/image:version3
-  my/image2:version:
-    context: ./
-    buildfile: test2.Dockerfile
-    args:
-      arg1: val1
-      arg2: val2
-`
+func (r testImgResolver) ImageBuildResolverInfo() ImageBuildResolverInfo { return r.info }
 
-const invalidImgsYaml = `
-images:
-  - context: ./
-    buildfile: test1.Dockerfile
-    args:
-      arg1: val1
-      arg2: val2
-    tags:
-      - my/image:version2
-      - my/image:version3
-  - context: ./
-    buildfile: test2.Dockerfile
-    args:
-      arg1: val1
-      arg2: val2
-  - ./
+func (r testImgResolver) ImageBuildInfo(image string) *types.BuildDefinition {
+	if image != r.image {
+		return nil
+	}
+	return &types.BuildDefinition{Context: r.info.Name}
+}
+
+func Test_ChainImageBuildResolverTrace(t *testing.T) {
+	t.Parallel()
+	high := testImgResolver{ImageBuildResolverInfo{"high", 10}, "my/image:high"}
+	low := testImgResolver{ImageBuildResolverInfo{"low", -10}, "my/image:version"}
+	cfg := launchr.ConfigFromFS(fsmy{"config.yaml": validImgsYaml}.MapFS())
+	var chain ChainImageBuildResolver
+	chain = chain.Add(low)
+	chain = chain.Add(LaunchrConfigImageBuildResolver{cfg})
+	chain = chain.Add(high)
+	names := make([]string, 0, len(chain))
+	for _, r := range chain {
+		names = append(names, GetImageBuildResolverInfo(r).Name)
+	}
+	assert.Equal(t, []string{"high", "config", "low"}, names)
+
+	// Config has higher priority than the low resolver.
+	b, trace := chain.ImageBuildInfoTrace("my/image:version")
+	require.NotNil(t, b)
+	assert.Equal(t, chain.ImageBuildInfo("my/image:version"), b)
+	assert.Equal(t, []ImageBuildResolveStep{
+		{Resolver: "high", Reason: "no build definition for the image"},
+		{Resolver: "config", Found: true, Reason: fmt.Sprintf("supplied the build definition with context %q", b.Context)},
+		{Resolver: "low", Reason: `skipped, resolved by "config" with higher priority`},
+	}, trace)
+
+	// Nothing is found.
+	b, trace = chain.ImageBuildInfoTrace("my/image:unknown")
+	assert.Nil(t, b)
+	assert.Len(t, trace, 3)
+	for _, step := range trace {
+		assert.False(t, step.Found)
+	}
+}
+
+func Test_DockerfileBaseImages(t *testing.T) {
+	t.Parallel()
+	dockerfile := `
+ARG VERSION
+FROM --platform=linux/amd64 my/base:latest AS builder
+RUN make
+FROM builder AS test
+FROM scratch
+FROM alpine:${VERSION}
+from my/runtime:1
+COPY --from=builder /app /app
+FROM my/base:latest
 `
+	images, err := dockerfileBaseImages(strings.NewReader(dockerfile))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"my/base:latest", "my/runtime:1"}, images)
+}
+
+func Test_ImageSumWithBases(t *testing.T) {
+	t.Parallel()
+	sum := "h1:dirsum"
+	assert.Equal(t, sum, imageSumWithBases(sum, nil))
+	withBase := imageSumWithBases(sum, map[string]string{"my/base:1": "h1:base1"})
+	assert.NotEqual(t, sum, withBase)
+	assert.Equal(t, withBase, imageSumWithBases(sum, map[string]string{"my/base:1": "h1:base1"}))
+	// Base image rebuild changes the sum of a derived image.
+	assert.NotEqual(t, withBase, imageSumWithBases(sum, map[string]string{"my/base:1": "h1:base2"}))
+}
+
+func Test_ContainerExec_imageEnsureBase(t *testing.T) {
+	t.Parallel()
+	assert, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM my/base:1\nFROM alpine\n"), 0600)
+	require.NoError(t, err)
+	base := testImgResolver{ImageBuildResolverInfo{"base", 0}, "my/base:1"}
+	r.AddImageBuildResolver(base)
+
+	ctx := context.Background()
+	act := testContainerAction(&DefRuntimeContainer{
+		Image: "my/derived:1",
+		Build: &types.BuildDefinition{Context: dir},
+	})
+	act.input = NewInput(act, nil, nil, launchr.NoopStreams())
+	exists := []any{&types.ImageStatusResponse{Status: types.ImageExists}, nil}
+	gomock.InOrder(
+		d.EXPECT().
+			ImageEnsure(ctx, eqImageOpts{types.ImageOptions{Name: "my/base:1", Build: base.ImageBuildInfo("my/base:1"), Labels: appLabels()}}).
+			Return(exists...),
+		d.EXPECT().
+			ImageEnsure(ctx, eqImageOpts{types.ImageOptions{Name: "my/derived:1", Build: act.ImageBuildInfo("my/derived:1"), Labels: appLabels()}}).
+			Return(exists...),
+	)
+	assert.NoError(r.imageEnsure(ctx, act))
+}
+
+func Test_ContainerWriteGuard(t *testing.T) {
+	t.Parallel()
+	g := &containerWriteGuard{}
+	out := &bytes.Buffer{}
+	streams := g.Streams(activityStreams{Streams: launchr.NoopStreams(), out: launchr.NewOut(out), err: out})
+	output := "ok\ntouch: /etc/file: Read-only file system\nmkdir: can't create directory '/opt/dir': Read-only"
+	for _, chunk := range strings.SplitAfter(output, " ") {
+		_, err := streams.Out().Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	_, err := streams.Err().Write([]byte(" file system\ntouch: /etc/file: Read-only file system\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "ok\ntouch: /etc/file: Read-only file system\nmkdir: can't create directory '/opt/dir': Read-only"+
+		" file system\ntouch: /etc/file: Read-only file system\n", out.String())
+	// Streams are inspected separately, the line split between them is not detected.
+	assert.Equal(t, []string{"touch: /etc/file: Read-only file system"}, g.Attempts())
+}
+
+func Test_ContainerReport(t *testing.T) {
+	t.Parallel()
+	var termOut bytes.Buffer
+	term := launchr.NewTerminal()
+	term.SetOutput(&termOut)
+	term.EnableOutput()
+	a := NewFromYAML("test", []byte(`
+action:
+  title: Report
+  options:
+    - name: token
+      sensitive: true
+      default: secretvalue
+runtime:
+  type: container
+  image: alpine
+  command: [ls]
+`))
+	require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+	r := newContainerReport(term, a)
+	out := &bytes.Buffer{}
+	streams := r.Streams(activityStreams{Streams: launchr.NoopStreams(), out: launchr.NewOut(out), err: out})
+	output := "start\n::launchr::progress 50 Building\n::launchr::output version=1.2.3\n" +
+		"::launchr::warning token secretvalue is used\n::launchr::unknown x\n::launchr::progress x\n" +
+		"a ::launchr::output no=1\n::lau\nend"
+	for _, chunk := range strings.SplitAfter(output, ":") {
+		_, err := streams.Out().Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "start\n::launchr::unknown x\n::launchr::progress x\na ::launchr::output no=1\n::lau\nend", out.String())
+	assert.Equal(t, &RunReport{
+		Progress: 50,
+		Outputs:  map[string]string{"version": "1.2.3"},
+		Warnings: []string{"token *** is used"},
+	}, r.Report())
+	assert.Contains(t, termOut.String(), "progress: 50% Building")
+	assert.Contains(t, termOut.String(), "token *** is used")
+}
+
+func Test_ApplySecurityProfile(t *testing.T) {
+	t.Parallel()
+	strict := ConfigSecurity{DropCapabilities: true, ReadonlyRootfs: true, NoNewPrivileges: true, NonRoot: true}
+
+	type testCase struct {
+		name    string
+		profile ConfigSecurity
+		relax   *DefContainerSecurity
+		user    string
+		exp     types.ContainerCreateOptions
+		expErr  bool
+		socket  bool
+	}
+
+	tts := []testCase{
+		{"no profile", ConfigSecurity{}, nil, "", types.ContainerCreateOptions{}, false, false},
+		{"strict profile", strict, nil, "1000:1000", types.ContainerCreateOptions{
+			User:           "1000:1000",
+			CapDrop:        []string{"ALL"},
+			ReadonlyRootfs: true,
+			Tmpfs:          map[string]string{"/tmp": ""},
+			SecurityOpt:    []string{"no-new-privileges"},
+		}, false, false},
+		{"root user", strict, nil, "0:0", types.ContainerCreateOptions{}, true, false},
+		{"default user", strict, nil, "", types.ContainerCreateOptions{}, true, false},
+		{"relaxed", strict, &DefContainerSecurity{
+			CapAdd:                   []string{"NET_ADMIN"},
+			WritableRootfs:           true,
+			AllowPrivilegeEscalation: true,
+			AllowRoot:                true,
+		}, "root", types.ContainerCreateOptions{
+			User:    "root",
+			CapDrop: []string{"ALL"},
+			CapAdd:  []string{"NET_ADMIN"},
+		}, false, false},
+		{"docker socket without profile", ConfigSecurity{}, nil, "", types.ContainerCreateOptions{}, false, true},
+		{"docker socket denied", strict, nil, "1000", types.ContainerCreateOptions{}, true, true},
+		{"docker socket allowed", ConfigSecurity{NonRoot: true, AllowDockerSocket: true}, nil, "1000", types.ContainerCreateOptions{User: "1000"}, false, true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := types.ContainerCreateOptions{User: tt.user}
+			err := applySecurityProfile(tt.profile, &DefRuntimeContainer{Security: tt.relax, DockerSocket: tt.socket}, &opts)
+			if tt.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.exp, opts)
+		})
+	}
+}
+
+func Test_ApplyMemoryMounts(t *testing.T) {
+	t.Parallel()
+	def := &DefRuntimeContainer{
+		ShmSize: "1g",
+		Tmpfs: []DefContainerTmpfs{
+			{Path: "/tmp", Size: "512m"},
+			{Path: "/run"},
+		},
+	}
+	// The size declared in the definition overrides the tmpfs of the security profile.
+	opts := types.ContainerCreateOptions{Tmpfs: map[string]string{"/tmp": ""}}
+	require.NoError(t, applyMemoryMounts(def, &opts))
+	assert.Equal(t, types.ContainerCreateOptions{
+		ShmSize: 1 << 30,
+		Tmpfs:   map[string]string{"/tmp": "size=536870912", "/run": ""},
+	}, opts)
+
+	opts = types.ContainerCreateOptions{}
+	require.NoError(t, applyMemoryMounts(&DefRuntimeContainer{}, &opts))
+	assert.Equal(t, types.ContainerCreateOptions{}, opts)
+}
+
+func Test_ContainerExec_envFlags(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	err := os.WriteFile(envFile, []byte("# comment\n\nFILE_VAR=file\nOVERRIDE=file\nEMPTY=\nHOME\nLAUNCHR_TEST_NOT_SET\n"), 0600)
+	require.NoError(t, err)
+	fileEnv := []string{"FILE_VAR=file", "OVERRIDE=file", "EMPTY="}
+	if home, ok := os.LookupEnv("HOME"); ok {
+		fileEnv = append(fileEnv, "HOME="+home)
+	}
+	invalidFile := filepath.Join(dir, ".env.invalid")
+	err = os.WriteFile(invalidFile, []byte("VALID=1\nINVALID KEY=2\n"), 0600)
+	require.NoError(t, err)
+
+	type testCase struct {
+		name   string
+		flags  InputParams
+		expEnv []string
+		expErr bool
+	}
+	tts := []testCase{
+		{"no flags", InputParams{}, nil, false},
+		{"env flag", InputParams{containerFlagEnv: []string{"A=1", "B=2,3", "4", "C="}}, []string{"A=1", "B=2,3,4", "C="}, false},
+		{"env flag any slice", InputParams{containerFlagEnv: []any{"A=1"}}, []string{"A=1"}, false},
+		{"invalid env flag", InputParams{containerFlagEnv: []string{"A"}}, nil, true},
+		{
+			"env file",
+			InputParams{containerFlagEnvFile: envFile},
+			fileEnv,
+			false,
+		},
+		{
+			"env flag overrides env file",
+			InputParams{containerFlagEnvFile: envFile, containerFlagEnv: []string{"OVERRIDE=flag", "NEW=flag"}},
+			append(mergeEnv(fileEnv, []string{"OVERRIDE=flag"}), "NEW=flag"),
+			false,
+		},
+		{"env file not exists", InputParams{containerFlagEnvFile: filepath.Join(dir, "missing")}, nil, true},
+		{"env file invalid", InputParams{containerFlagEnvFile: invalidFile}, nil, true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{}
+			err := r.UseFlags(tt.flags)
+			if tt.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expEnv, r.env)
+		})
+	}
+
+	// Flags override the action environment.
+	assert.Equal(t, []string{"A=flag", "B=action", "C=flag"}, mergeEnv([]string{"A=action", "B=action"}, []string{"A=flag", "C=flag"}))
+	assert.Equal(t, []string{"A=action"}, mergeEnv([]string{"A=action"}, nil))
+}
+
+func Test_ContainerNameProvider(t *testing.T) {
+	// Not parallel, the environment is changed.
+	usr, err := osuser.Current()
+	require.NoError(t, err)
+
+	assert.Equal(t, "launchr_my_action_v1", ContainerNameProvider{Prefix: "launchr_"}.Get("my-action:v1"))
+	assert.Regexp(t, `^launchr_my_action_[a-z]+_[a-z]+$`, ContainerNameProvider{Prefix: "launchr_", RandomSuffix: true}.Get("my-action"))
+
+	tpl := ContainerNameProvider{Prefix: "launchr_", Template: "{action}-{user}-{hash}"}
+	assert.Regexp(t, `^my_action-`+regexp.QuoteMeta(rgxInvalidNameChars.ReplaceAllString(usr.Username, "_"))+`-[0-9a-f]{8}$`, tpl.Get("my-action"))
+	assert.NotEqual(t, tpl.Get("my-action"), tpl.Get("my-action"))
+	assert.Equal(t, "launchr_a_b_", ContainerNameProvider{Prefix: "launchr_", Template: "{prefix}a b/"}.Get("test"))
+
+	det := ContainerNameProvider{Prefix: "launchr_", Template: "{prefix}{action}_{random}_{hash}", RunID: "42"}
+	assert.Equal(t, det.Get("my-action"), det.Get("my-action"))
+	assert.NotEqual(t, det.Get("my-action"), det.Get("other"))
+	det2 := det
+	det2.RunID = "43"
+	assert.NotEqual(t, det.Get("my-action"), det2.Get("my-action"))
+	assert.Regexp(t, `^launchr_my_action_[0-9a-f]{8}_[0-9a-f]{8}$`, det.Get("my-action"))
+	// Without a template, the random suffix is a hash in the deterministic mode.
+	assert.Equal(t, "launchr_my_action_"+det.hash("my-action"), ContainerNameProvider{Prefix: "launchr_", RandomSuffix: true, RunID: "42"}.Get("my-action"))
+
+	t.Setenv(EnvVarRunID, "ci-1")
+	assert.Equal(t, "ci-1", NewContainerNameProvider("launchr_", ConfigContainerName{Deterministic: true}).RunID)
+	assert.Equal(t, "", NewContainerNameProvider("launchr_", ConfigContainerName{}).RunID)
+}
+
+func Test_ContainerExec_containerName(t *testing.T) {
+	t.Parallel()
+	list := func(state string, names ...string) []types.ContainerListResult {
+		res := make([]types.ContainerListResult, len(names))
+		for i, n := range names {
+			res[i] = types.ContainerListResult{ID: n + "_id", Names: []string{"/" + n}, State: state}
+		}
+		return res
+	}
+	withLabels := func(list []types.ContainerListResult, actionID, inputSum string) []types.ContainerListResult {
+		for i := range list {
+			list[i].Labels = map[string]string{LabelActionID: actionID, LabelInputSum: inputSum}
+		}
+		return list
+	}
+	retries := make([]string, 0, containerNameRetries+1)
+	retries = append(retries, "launchr_test")
+	for i := 2; i <= containerNameRetries+1; i++ {
+		retries = append(retries, fmt.Sprintf("launchr_test_%d", i))
+	}
+	errRemove := errors.New("remove error")
+
+	type testCase struct {
+		name      string
+		policy    ContainerStalePolicy
+		list      []types.ContainerListResult
+		removeErr error
+		expName   string
+		expReuse  string
+		expRemove bool
+		expErr    bool
+	}
+	tts := []testCase{
+		{name: "free", expName: "launchr_test"},
+		{name: "similar names", list: list("running", "launchr_test_old", "launchr_test_2"), expName: "launchr_test"},
+		{name: "in use", list: list("running", "launchr_test", "launchr_test_2"), expName: "launchr_test_3"},
+		{name: "all in use", list: list("running", retries...), expErr: true},
+		{name: "stale default policy", list: list("exited", "launchr_test"), expName: "launchr_test_2"},
+		{name: "stale remove", policy: ContainerStaleRemove, list: list("dead", "launchr_test"), expName: "launchr_test", expRemove: true},
+		{name: "stale remove error", policy: ContainerStaleRemove, list: list("exited", "launchr_test"), removeErr: errRemove, expRemove: true, expErr: true},
+		{name: "stale reuse", policy: ContainerStaleReuse, list: withLabels(list("created", "launchr_test"), "test", "sum"), expName: "launchr_test", expReuse: "launchr_test_id"},
+		{name: "stale reuse changed input", policy: ContainerStaleReuse, list: withLabels(list("exited", "launchr_test"), "test", "old"), expName: "launchr_test", expRemove: true},
+		{name: "stale reuse other action", policy: ContainerStaleReuse, list: withLabels(list("exited", "launchr_test"), "other", "sum"), expName: "launchr_test", expRemove: true},
+		{name: "stale reuse no labels", policy: ContainerStaleReuse, list: list("exited", "launchr_test"), expName: "launchr_test", expRemove: true},
+		{name: "stale fail", policy: ContainerStaleFail, list: list("exited", "launchr_test"), expErr: true},
+		{name: "running not stale", policy: ContainerStaleFail, list: list("running", "launchr_test"), expName: "launchr_test_2"},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert, ctrl, d, r := prepareContainerTestSuite(t)
+			defer ctrl.Finish()
+			defer r.Close()
+			r.SetContainerNameProvider(ContainerNameProvider{Prefix: "launchr_"})
+			r.rtcfg.ContainerName.StalePolicy = tt.policy
+			d.EXPECT().ContainerList(gomock.Any(), types.ContainerListOptions{SearchName: "launchr_test"}).Return(tt.list)
+			if tt.expRemove {
+				d.EXPECT().ContainerRemove(gomock.Any(), "launchr_test_id", types.ContainerRemoveOptions{}).Return(tt.removeErr)
+			}
+			name, reuse, err := r.containerName(context.Background(), testContainerAction(nil), "sum")
+			assert.Equal(tt.expName, name)
+			assert.Equal(tt.expReuse, reuse)
+			assert.Equal(tt.expErr, err != nil)
+		})
+	}
+}
+
+func Test_ContainerExec_containerInputSum(t *testing.T) {
+	t.Parallel()
+	a := testContainerAction(nil)
+	r := &runtimeContainer{}
+	sum := r.containerInputSum(a, false)
+	assert.Equal(t, sum, r.containerInputSum(a, false))
+	assert.NotEqual(t, sum, r.containerInputSum(a, true))
+	assert.NotEqual(t, sum, (&runtimeContainer{env: []string{"A=b"}}).containerInputSum(a, false))
+	assert.NotEqual(t, sum, (&runtimeContainer{entrypointSet: true, entrypoint: "sh"}).containerInputSum(a, false))
+}
+
+func Test_ContainerExec_mountFlags(t *testing.T) {
+	t.Parallel()
+	a := testContainerAction(nil)
+	type testCase struct {
+		name     string
+		flags    InputParams
+		expFlags []string
+		expErr   bool
+	}
+	tts := []testCase{
+		{"no flags", InputParams{}, nil, false},
+		{"private label", InputParams{containerFlagMountFlags: "Z"}, []string{"Z"}, false},
+		{"multiple flags", InputParams{containerFlagMountFlags: "z,cached"}, []string{"z", "cached"}, false},
+		{"no relabeling", InputParams{containerFlagMountFlags: mountFlagsNone}, nil, false},
+		{"unsupported flag", InputParams{containerFlagMountFlags: "z,exec"}, nil, true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{}
+			err := r.UseFlags(tt.flags)
+			if tt.expErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expFlags, r.mountBindFlags(context.Background(), a, a.RuntimeDef().Container))
+		})
+	}
+
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), nil, 0600))
+	}
+	n, more := countFiles(dir, 10)
+	assert.Equal(t, 6, n)
+	assert.False(t, more)
+	n, more = countFiles(dir, 3)
+	assert.Equal(t, 3, n)
+	assert.True(t, more)
+	n, more = countFiles(filepath.Join(dir, "missing"), 3)
+	assert.Equal(t, 0, n)
+	assert.False(t, more)
+}
+
+func Test_ContainerExec_labelFlags(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name      string
+		flags     InputParams
+		expLabels map[string]string
+		expErr    string
+	}
+	tts := []testCase{
+		{"no flags", InputParams{}, nil, ""},
+		{"label flag", InputParams{containerFlagLabel: []string{"ticket=PLT-123", "hosts=a", "b", "empty="}}, map[string]string{"ticket": "PLT-123", "hosts": "a,b", "empty": ""}, ""},
+		{"label flag any slice", InputParams{containerFlagLabel: []any{"a=1"}}, map[string]string{"a": "1"}, ""},
+		{"invalid label", InputParams{containerFlagLabel: []string{"ticket"}}, nil, `invalid label "ticket", expected KEY=VALUE`},
+		{"empty key", InputParams{containerFlagLabel: []string{"=1"}}, nil, `invalid label "=1", expected KEY=VALUE`},
+		{"reserved label", InputParams{containerFlagLabel: []string{LabelRunID + "=1"}}, nil, `label "launchr.run_id" is reserved, the prefix "launchr." is used by the app`},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{}
+			err := r.UseFlags(tt.flags)
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expLabels, r.RunLabels())
+		})
+	}
+
+	// App labels take precedence.
+	assert.Equal(t, map[string]string{"a": "user", "b": "app"}, mergeLabels(map[string]string{"a": "user", "b": "user"}, map[string]string{"b": "app"}))
+}
+
+func Test_PrintResolvedCommand(t *testing.T) {
+	t.Parallel()
+	mask := NewSensitiveMask("p4ssw0rd")
+	var buf bytes.Buffer
+	printResolvedCommand(&buf, mask, nil, []string{"login", "--password=p4ssw0rd", "hello world", ""})
+	assert.Equal(t, "Entrypoint: (image default)\nCommand:    login --password=*** \"hello world\" \"\"\n", buf.String())
+
+	buf.Reset()
+	printResolvedCommand(&buf, nil, []string{"/bin/sh", "-c"}, []string{`echo "$HOME"`})
+	assert.Equal(t, "Entrypoint: /bin/sh -c\nCommand:    \"echo \\\"$HOME\\\"\"\n", buf.String())
+
+	r := &runtimeContainer{}
+	require.NoError(t, r.UseFlags(InputParams{containerFlagShowCmd: true}))
+	assert.True(t, r.isCommandShown())
+	assert.True(t, (&runtimeContainer{rtcfg: ConfigRuntime{ShowCommand: true}}).isCommandShown())
+	assert.False(t, (&runtimeContainer{}).isCommandShown())
+}
+
+func Test_ConfigRuntime(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+		fs   fsmy
+		exp  ConfigRuntime
+	}
+
+	limit := DefaultConfigRuntime().OutputLimit
+	tts := []testCase{
+		{"no config", fsmy{}, DefaultConfigRuntime()},
+		{"empty config", fsmy{"config.yaml": ""}, DefaultConfigRuntime()},
+		{"heartbeat interval", fsmy{"config.yaml": validRuntimeHeartbeatYaml}, ConfigRuntime{OutputLimit: limit, HeartbeatInterval: 15 * time.Second}},
+		{"heartbeat disabled", fsmy{"config.yaml": validRuntimeNoHeartbeatYaml}, ConfigRuntime{OutputLimit: limit, HeartbeatInterval: 0}},
+		{"invalid config", fsmy{"config.yaml": invalidRuntimeYaml}, DefaultConfigRuntime()},
+		{"timeouts", fsmy{"config.yaml": validRuntimeTimeoutsYaml}, ConfigRuntime{
+			HeartbeatInterval: defaultHeartbeatInterval,
+			OutputLimit:       limit,
+			Timeouts: driver.Timeouts{
+				ImagePull:       10 * time.Minute,
+				ContainerCreate: 30 * time.Second,
+				ContainerAttach: 30 * time.Second,
+			},
+		}},
+		{"security", fsmy{"config.yaml": validRuntimeSecurityYaml}, ConfigRuntime{
+			HeartbeatInterval: defaultHeartbeatInterval,
+			OutputLimit:       limit,
+			Security:          ConfigSecurity{DropCapabilities: true, ReadonlyRootfs: true, NoNewPrivileges: true, NonRoot: true},
+		}},
+		{"container name", fsmy{"config.yaml": validRuntimeContainerNameYaml}, ConfigRuntime{
+			HeartbeatInterval: defaultHeartbeatInterval,
+			OutputLimit:       limit,
+			ContainerName:     ConfigContainerName{Template: "{action}_{hash}", Deterministic: true, StalePolicy: ContainerStaleRemove},
+		}},
+		{"show command", fsmy{"config.yaml": "runtime:\n  show_command: true\n"}, ConfigRuntime{OutputLimit: limit, HeartbeatInterval: defaultHeartbeatInterval, ShowCommand: true}},
+		{"unknown stale policy", fsmy{"config.yaml": "runtime:\n  container_name:\n    stale_policy: keep\n"}, DefaultConfigRuntime()},
+		{"output limit", fsmy{"config.yaml": "runtime:\n  output_limit:\n    head: 0\n    tail: 1024\n"}, ConfigRuntime{
+			HeartbeatInterval: defaultHeartbeatInterval,
+			OutputLimit:       ConfigOutputLimit{Tail: 1024},
+		}},
+		{"negative output limit", fsmy{"config.yaml": "runtime:\n  output_limit:\n    tail: -1\n"}, DefaultConfigRuntime()},
+		{"images overrides", fsmy{"config.yaml": validImagesOverridesYaml}, ConfigRuntime{
+			HeartbeatInterval: defaultHeartbeatInterval,
+			OutputLimit:       limit,
+			ImagesOverrides: ConfigImagesOverrides{
+				"corp/base":     {Entrypoint: []string{"/bin/sh", "-c"}},
+				"corp/base:1.0": {User: "1000"},
+			},
+		}},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := launchr.ConfigFromFS(tt.fs.MapFS())
+			assert.Equal(t, tt.exp, LaunchrConfigRuntime(cfg))
+		})
+	}
+}
+
+const validImagesOverridesYaml = `
+images_overrides:
+  corp/base:
+    entrypoint: ["/bin/sh", "-c"]
+  "corp/base:1.0":
+    user: "1000"
+`
+
+func Test_ConfigImagesOverrides(t *testing.T) {
+	t.Parallel()
+	o := ConfigImagesOverrides{
+		"corp/base":                  {User: "1000"},
+		"corp/base:1.0":              {User: "2000"},
+		"registry.local:5000/corp/x": {User: "3000"},
+	}
+	tts := []struct {
+		image string
+		exp   string
+	}{
+		{"corp/base", "1000"},
+		{"corp/base:2.0", "1000"},
+		{"corp/base:1.0", "2000"},
+		{"corp/base@sha256:abcd", "1000"},
+		{"registry.local:5000/corp/x:1.0", "3000"},
+		{"registry.local:5000/corp/x", "3000"},
+		{"corp/other:1.0", ""},
+	}
+	for _, tt := range tts {
+		ov, ok := o.Find(tt.image)
+		assert.Equal(t, tt.exp != "", ok, tt.image)
+		assert.Equal(t, tt.exp, ov.User, tt.image)
+	}
+}
+
+const validRuntimeContainerNameYaml = `
+runtime:
+  container_name:
+    template: "{action}_{hash}"
+    deterministic: true
+    stale_policy: remove
+`
+
+const validRuntimeSecurityYaml = `
+runtime:
+  security:
+    drop_capabilities: true
+    readonly_rootfs: true
+    no_new_privileges: true
+    non_root: true
+`
+
+const validRuntimeHeartbeatYaml = `
+runtime:
+  heartbeat_interval: 15s
+`
+
+const validRuntimeNoHeartbeatYaml = `
+runtime:
+  heartbeat_interval: 0
+`
+
+const validRuntimeTimeoutsYaml = `
+runtime:
+  timeouts:
+    image_pull: 10m
+    container_create: 30s
+    container_attach: 30s
+`
+
+const invalidRuntimeYaml = `
+runtime:
+  heartbeat_interval: [15s]
+`
+
+const cfgYaml = `
+images:
+  build:config: ./config
+`
+
+const validImgsYaml = `
+images:
+  my/image:version:
+    context: ./
+    buildfile: test1.Dockerfile
+    args:
+      arg1: val1
+      arg2: val2
+    tags:
+      - my/image:version2
+      - my/image:version3
+  my/image2:version:
+    context: ./
+    buildfile: test2.Dockerfile
+    args:
+      arg1: val1
+      arg2: val2
+`
+
+const invalidImgsYaml = `
+images:
+  - context: ./
+    buildfile: test1.Dockerfile
+    args:
+      arg1: val1
+      arg2: val2
+    tags:
+      - my/image:version2
+      - my/image:version3
+  - context: ./
+    buildfile: test2.Dockerfile
+    args:
+      arg1: val1
+      arg2: val2
+  - ./
+`
+
+// execDriver is a container runner supporting execution in running containers.
+type execDriver struct {
+	*mockdriver.MockContainerRunner
+	cid      string
+	opts     types.ContainerExecOptions
+	exitCode int
+}
+
+func (d *execDriver) ContainerExecCreate(_ context.Context, cid string, opts types.ContainerExecOptions) (string, error) {
+	d.cid = cid
+	d.opts = opts
+	return "exec_id", nil
+}
+
+func (d *execDriver) ContainerExecAttach(_ context.Context, _ string, _ types.ContainerExecAttachOptions) (*driver.ContainerInOut, error) {
+	return testContainerIO(), nil
+}
+
+func (d *execDriver) ContainerExecInspect(_ context.Context, execID string) (types.ContainerExecInspect, error) {
+	return types.ContainerExecInspect{ExecID: execID, ExitCode: d.exitCode}, nil
+}
+
+func Test_ContainerExec_execIn(t *testing.T) {
+	t.Parallel()
+	devCtr := types.ContainerListResult{ID: "dev_id", Names: []string{"/dev"}}
+	devCtrPrefixed := types.ContainerListResult{ID: "dev2_id", Names: []string{"/dev2"}}
+
+	type testCase struct {
+		name     string
+		selector string
+		expList  types.ContainerListOptions
+		list     []types.ContainerListResult
+		exitCode int
+		expCid   string
+		expErr   bool
+	}
+	tts := []testCase{
+		{"by name", "dev", types.ContainerListOptions{SearchName: "dev", Running: true}, []types.ContainerListResult{devCtr, devCtrPrefixed}, 0, "dev_id", false},
+		{"by label", "app=dev", types.ContainerListOptions{Labels: []string{"app=dev"}, Running: true}, []types.ContainerListResult{devCtr}, 0, "dev_id", false},
+		{"not found", "dev", types.ContainerListOptions{SearchName: "dev", Running: true}, []types.ContainerListResult{devCtrPrefixed}, 0, "", true},
+		{"ambiguous label", "app=dev", types.ContainerListOptions{Labels: []string{"app=dev"}, Running: true}, []types.ContainerListResult{devCtr, devCtrPrefixed}, 0, "", true},
+		{"exit code", "dev", types.ContainerListOptions{SearchName: "dev", Running: true}, []types.ContainerListResult{devCtr}, 2, "dev_id", true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, ctrl, d, r := prepareContainerTestSuite(t)
+			defer ctrl.Finish()
+			defer r.Close()
+			ed := &execDriver{MockContainerRunner: d, exitCode: tt.exitCode}
+			r.driver = ed
+			a := testContainerAction(&DefRuntimeContainer{
+				Image:   "myimage",
+				Command: []string{"make", "test"},
+				Env:     []string{"A=action"},
+			})
+			a.input = NewInput(a, nil, nil, launchr.NoopStreams())
+			require.NoError(t, r.UseFlags(InputParams{containerFlagExecIn: tt.selector, containerFlagEnv: []string{"B=flag"}}))
+			d.EXPECT().ContainerList(gomock.Any(), tt.expList).Return(tt.list)
+
+			err := r.Execute(context.Background(), a)
+			if tt.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expCid, ed.cid)
+			if ed.cid != "" {
+				assert.Equal(t, []string{"make", "test"}, ed.opts.Cmd)
+				assert.Equal(t, []string{"A=action", "B=flag"}, ed.opts.Env)
+			}
+		})
+	}
+
+	// The driver must support the execution.
+	_, ctrl, _, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+	a := testContainerAction(nil)
+	a.input = NewInput(a, nil, nil, launchr.NoopStreams())
+	r.execIn = "dev"
+	assert.Error(t, r.Execute(context.Background(), a))
+}
+
+func Test_ContainerCollectArtifacts(t *testing.T) {
+	t.Parallel()
+	_, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+	a := testContainerAction(&DefRuntimeContainer{
+		Image:     "myimage",
+		Artifacts: []string{"dist", "/var/log/test.log", "/missing"},
+	})
+	a.wd = t.TempDir()
+
+	tarOf := func(pairs ...string) io.ReadCloser {
+		content, err := archive.Generate(pairs...)
+		require.NoError(t, err)
+		return io.NopCloser(content)
+	}
+	d.EXPECT().
+		CopyFromContainer(gomock.Any(), "cid", "/host/dist").
+		Return(tarOf("dist/app", "binary"), types.ContainerPathStat{Name: "dist", Mode: os.ModeDir | 0755}, nil)
+	d.EXPECT().
+		CopyFromContainer(gomock.Any(), "cid", "/var/log/test.log").
+		Return(tarOf("test.log", "ok\n"), types.ContainerPathStat{Name: "test.log", Size: 3}, nil)
+	d.EXPECT().
+		CopyFromContainer(gomock.Any(), "cid", "/missing").
+		Return(nil, types.ContainerPathStat{}, errors.New("not found"))
+
+	dir, err := r.collectArtifacts(context.Background(), a, "cid", "run1")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(a.wd, defaultArtifactsDir, "run1"), dir)
+	content, err := os.ReadFile(filepath.Join(dir, "dist", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(content))
+
+	var m ArtifactsManifest
+	content, err = os.ReadFile(filepath.Join(dir, ArtifactsManifestName))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &m))
+	assert.Equal(t, "test", m.ActionID)
+	assert.Equal(t, "run1", m.RunID)
+	assert.Equal(t, []ArtifactFile{
+		{Artifact: "dist", Path: "dist/app", Size: 6, SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("binary")))},
+		{Artifact: "/var/log/test.log", Path: "var/log/test.log", Size: 3, SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("ok\n")))},
+	}, m.Files)
+	assert.Equal(t, []string{"/missing"}, m.Missing)
+}
+
+// stepsDriver is a container runner executing the steps with the given exit codes.
+type stepsDriver struct {
+	*mockdriver.MockContainerRunner
+	cmds      [][]string
+	exitCodes []int
+}
+
+func (d *stepsDriver) ContainerExecCreate(_ context.Context, _ string, opts types.ContainerExecOptions) (string, error) {
+	d.cmds = append(d.cmds, opts.Cmd)
+	return strconv.Itoa(len(d.cmds) - 1), nil
+}
+
+func (d *stepsDriver) ContainerExecAttach(_ context.Context, _ string, _ types.ContainerExecAttachOptions) (*driver.ContainerInOut, error) {
+	return testContainerIO(), nil
+}
+
+func (d *stepsDriver) ContainerExecInspect(_ context.Context, execID string) (types.ContainerExecInspect, error) {
+	i, _ := strconv.Atoi(execID)
+	return types.ContainerExecInspect{ExecID: execID, ExitCode: d.exitCodes[i]}, nil
+}
+
+func Test_ContainerExecuteSteps(t *testing.T) {
+	t.Parallel()
+	steps := []DefContainerStep{
+		{Name: "lint", Command: []string{"make lint"}},
+		{Command: []string{"make", "test"}},
+		{Name: "build", Command: []string{"make", "build"}},
+	}
+	lint := []string{"sh", "-c", "make lint"}
+	test := []string{"make", "test"}
+	build := []string{"make", "build"}
+
+	type testCase struct {
+		name            string
+		exitCodes       []int
+		continueOnError bool
+		expCmds         [][]string
+		expStatus       int
+	}
+	tts := []testCase{
+		{"all succeed", []int{0, 0, 0}, false, [][]string{lint, test, build}, 0},
+		{"fail fast", []int{0, 2, 0}, false, [][]string{lint, test}, 2},
+		{"continue on error", []int{3, 2, 0}, true, [][]string{lint, test, build}, 3},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, ctrl, d, r := prepareContainerTestSuite(t)
+			defer ctrl.Finish()
+			defer r.Close()
+			sd := &stepsDriver{MockContainerRunner: d, exitCodes: tt.exitCodes}
+			r.driver = sd
+			a := testContainerAction(&DefRuntimeContainer{
+				Image:           "myimage",
+				Steps:           steps,
+				ContinueOnError: tt.continueOnError,
+			})
+			a.input = NewInput(a, nil, nil, launchr.NoopStreams())
+			status, err := r.executeSteps(context.Background(), a, "cid", &types.ContainerCreateOptions{}, launchr.NoopStreams())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expStatus, status)
+			assert.Equal(t, tt.expCmds, sd.cmds)
+		})
+	}
+
+	// The driver must support the execution.
+	_, ctrl, _, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+	a := testContainerAction(&DefRuntimeContainer{Image: "myimage", Steps: steps})
+	a.input = NewInput(a, nil, nil, launchr.NoopStreams())
+	_, err := r.executeSteps(context.Background(), a, "cid", &types.ContainerCreateOptions{}, launchr.NoopStreams())
+	assert.Error(t, err)
+}
+
+func Test_DevcontainerImageBuildResolver(t *testing.T) {
+	t.Parallel()
+	writeFiles := func(t *testing.T, files map[string]string) string {
+		dir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		}
+		return dir
+	}
+
+	// Image without features is used as is.
+	dir := writeFiles(t, map[string]string{
+		DevcontainerPath: `{
+  // Base image.
+  "image": "mcr.microsoft.com/devcontainers/go:1", /* inline */
+}`,
+	})
+	r, err := newDevcontainerImageBuildResolver(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "mcr.microsoft.com/devcontainers/go:1", r.image)
+	assert.Nil(t, r.ImageBuildInfo(r.image))
+
+	// Features published in registries are not supported.
+	dir = writeFiles(t, map[string]string{
+		DevcontainerPath: `{
+  "image": "mcr.microsoft.com/devcontainers/go:1",
+  "features": {"ghcr.io/devcontainers/features/node:1": {}},
+}`,
+	})
+	_, err = newDevcontainerImageBuildResolver(dir)
+	assert.ErrorContains(t, err, `dev container feature "ghcr.io/devcontainers/features/node:1" is not supported`)
+
+	// Dockerfile with local features is built in 2 steps.
+	dir = writeFiles(t, map[string]string{
+		DevcontainerPath: `{
+  "build": {"dockerfile": "Dockerfile", "context": "..", "args": {"VARIANT": "1.23"}},
+  "features": {
+    "./tools": {"version": "2", "name": "it's"},
+  },
+}`,
+		".devcontainer/Dockerfile":                      "FROM golang\n",
+		".devcontainer/tools/install.sh":                "#!/bin/sh\n",
+		".devcontainer/tools/devcontainer-feature.json": `{"id": "tools", "options": {"version": {"default": "1"}, "debug": {"default": false}}}`,
+	})
+	r, err = newDevcontainerImageBuildResolver(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, r.base, r.image)
+	variant := "1.23"
+	assert.Equal(t, &types.BuildDefinition{
+		Context:   dir,
+		Buildfile: filepath.Join(".devcontainer", "Dockerfile"),
+		Args:      map[string]*string{"VARIANT": &variant},
+		Tags:      []string{r.base},
+	}, r.ImageBuildInfo(r.base))
+	require.NoError(t, r.prepare())
+	t.Cleanup(func() { _ = os.RemoveAll(r.ctxDir) })
+	bi := r.ImageBuildInfo(r.image)
+	require.NotNil(t, bi)
+	assert.Equal(t, []string{r.base}, buildBaseImages(bi))
+	dockerfile, err := os.ReadFile(filepath.Join(bi.Context, bi.Buildfile))
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "COPY 0-tools /tmp/launchr-features/0-tools\n"+
+		`RUN cd /tmp/launchr-features/0-tools && chmod +x install.sh && DEBUG='false' NAME='it'\''s' VERSION='2' ./install.sh`)
+	assert.FileExists(t, filepath.Join(bi.Context, "0-tools", "install.sh"))
+
+	// Missing definition.
+	_, err = newDevcontainerImageBuildResolver(t.TempDir())
+	assert.Error(t, err)
+}
+
+func Test_ContainerExec_imageEnsureDevcontainer(t *testing.T) {
+	t.Parallel()
+	assert, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0750))
+	err := os.WriteFile(filepath.Join(dir, DevcontainerPath), []byte(`{"image": "my/devcontainer:1"}`), 0600)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	act := testContainerAction(&DefRuntimeContainer{Image: DevcontainerImage})
+	act.wd = dir
+	act.input = NewInput(act, nil, nil, launchr.NoopStreams())
+	d.EXPECT().
+		ImageEnsure(ctx, eqImageOpts{types.ImageOptions{Name: "my/devcontainer:1"}}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	assert.NoError(r.imageEnsure(ctx, act))
+	assert.Equal("my/devcontainer:1", act.RuntimeDef().Container.Image)
+}
+
+func Test_ContainerPrepareImage(t *testing.T) {
+	t.Parallel()
+	assert, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+
+	ctx := context.Background()
+	act := testContainerAction(&DefRuntimeContainer{Image: "myimage"})
+	act.input = NewInput(act, nil, nil, launchr.NoopStreams())
+	d.EXPECT().
+		ImageEnsure(gomock.Any(), eqImageOpts{types.ImageOptions{Name: "myimage"}}).
+		Return(&types.ImageStatusResponse{Status: types.ImageExists}, nil)
+	assert.NoError(r.PrepareImage(ctx, act))
+
+	// The dev container image is resolved on execution.
+	act = testContainerAction(&DefRuntimeContainer{Image: DevcontainerImage})
+	assert.NoError(r.PrepareImage(ctx, act))
+}
+
+func Test_JSONCToJSON(t *testing.T) {
+	t.Parallel()
+	in := `{
+  // comment "quoted"
+  "a": "http://example.com", /* block
+  comment */ "b": ["x\"//", "y",],
+}`
+	var v map[string]any
+	require.NoError(t, json.Unmarshal(jsoncToJSON([]byte(in)), &v))
+	assert.Equal(t, map[string]any{"a": "http://example.com", "b": []any{`x"//`, "y"}}, v)
+}
+
+func Test_IsTtyRequested(t *testing.T) {
+	t.Parallel()
+	newStreams := func(inTerm, outTerm bool) launchr.Streams {
+		in := launchr.NewIn(io.NopCloser(&bytes.Buffer{}))
+		in.SetIsTerminal(inTerm)
+		out := launchr.NewOut(io.Discard)
+		out.SetIsTerminal(outTerm)
+		return ttyStreams{in: in, out: out}
+	}
+	assert.True(t, isTtyRequested(newStreams(true, true)))
+	// Output is piped, e.g. to grep or tee.
+	assert.False(t, isTtyRequested(newStreams(true, false)))
+	// Input is piped.
+	assert.False(t, isTtyRequested(newStreams(false, true)))
+	assert.False(t, isTtyRequested(newStreams(false, false)))
+}
+
+type ttyStreams struct {
+	in  *launchr.In
+	out *launchr.Out
+}
+
+func (s ttyStreams) In() *launchr.In   { return s.in }
+func (s ttyStreams) Out() *launchr.Out { return s.out }
+func (s ttyStreams) Err() io.Writer    { return io.Discard }
+
+// statsDriver is a container runner reporting predefined resource usage samples.
+type statsDriver struct {
+	*mockdriver.MockContainerRunner
+	samples []types.ContainerStats
+}
+
+func (d *statsDriver) ContainerStats(_ context.Context, _ string) (<-chan types.ContainerStats, error) {
+	ch := make(chan types.ContainerStats, len(d.samples))
+	for _, s := range d.samples {
+		ch <- s
+	}
+	close(ch)
+	return ch, nil
+}
+
+func Test_ContainerUsage(t *testing.T) {
+	t.Parallel()
+	u := &containerUsage{}
+	assert.Nil(t, u.Usage())
+
+	d := &statsDriver{samples: []types.ContainerStats{
+		{CPUTime: time.Second, Memory: 100, NetworkRx: 10, NetworkTx: 5},
+		{CPUTime: 3 * time.Second, Memory: 300, NetworkRx: 20, NetworkTx: 7},
+		{CPUTime: 4 * time.Second, Memory: 200, NetworkRx: 30, NetworkTx: 9},
+		// A stopped container reports empty stats.
+		{},
+	}}
+	require.NoError(t, u.Watch(context.Background(), d, "cid"))
+	assert.Equal(t, &RunUsage{CPUTime: 4 * time.Second, MaxMemory: 300, NetworkRx: 30, NetworkTx: 9}, u.Usage())
+}
+
+func Test_ContainerCopyUsage(t *testing.T) {
+	t.Parallel()
+	u := &containerUsage{}
+	u.addCopy(launchr.ProgressStats{Bytes: 100, Duration: time.Second}, true)
+	u.addCopy(launchr.ProgressStats{Bytes: 50, Duration: 2 * time.Second}, false)
+	u.addCopy(launchr.ProgressStats{Bytes: 10, Duration: time.Second}, true)
+	assert.Equal(t, &RunUsage{CopiedIn: 110, CopiedOut: 50, CopyTime: 4 * time.Second}, u.Usage())
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 20), 0600))
+	assert.Equal(t, int64(120), pathSize(dir))
+	assert.Equal(t, int64(20), pathSize(filepath.Join(dir, "sub", "b")))
+	assert.Equal(t, int64(0), pathSize(filepath.Join(dir, "missing")))
+}
+
+// apiVersionDriver is a container runner reporting a predefined API version.
+type apiVersionDriver struct {
+	*mockdriver.MockContainerRunner
+	version string
+	err     error
+}
+
+func (d *apiVersionDriver) APIVersion(_ context.Context) (string, error) {
+	return d.version, d.err
+}
+
+func Test_ContainerAPIVersion(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name    string
+		version string
+		err     error
+		chown   bool
+		expErr  string
+		expAuto bool
+	}
+	tts := []testCase{
+		{name: "current", version: "1.47", chown: true, expAuto: true},
+		{name: "no auto remove", version: "1.24", expAuto: false},
+		{name: "no copy owner", version: "1.29", chown: true, expErr: "flag --chown-volume-wd can't be used: docker API version 1.29 " +
+			"doesn't support copying files with the owner, API version 1.30 or newer is required, upgrade the docker daemon"},
+		{name: "no copy owner without chown", version: "1.29", expAuto: true},
+		{name: "daemon is not available", err: errors.New("connection refused"), expErr: "failed to connect to the container engine: connection refused"},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{
+				driver:   &apiVersionDriver{version: tt.version, err: tt.err},
+				useVolWD: tt.chown,
+				chownWD:  tt.chown,
+			}
+			err := r.Init(context.Background(), nil)
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, r.api.Version())
+			assert.Equal(t, tt.expAuto, r.api.Supports(driver.APIFeatureAutoRemove))
+		})
+	}
+	// The version is unknown, all features are considered supported.
+	assert.True(t, driver.APIFeatures{}.Supports(driver.APIFeatureCopyOwner))
+	assert.NoError(t, driver.APIFeatures{}.Require(driver.APIFeatureCopyOwner))
+}
+
+func Test_ContainerService(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name     string
+		codes    []int
+		expCalls int
+		expState string
+		expErr   bool
+	}
+	tts := []testCase{
+		{name: "finished", codes: []int{0}, expCalls: 1, expState: ServiceStateStopped},
+		{name: "restarted", codes: []int{1, 2, 0}, expCalls: 3, expState: ServiceStateStopped},
+		{name: "interrupted", codes: []int{exitCodeInterrupted}, expCalls: 1, expState: ServiceStateStopped, expErr: true},
+		{name: "no restarts left", codes: []int{1, 1, 1, 0}, expCalls: 3, expState: ServiceStateFailed, expErr: true},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{}
+			calls := 0
+			err := r.superviseService(context.Background(), "test", &DefContainerRestart{MaxRestarts: 2, Backoff: "1ms"}, func(context.Context) error {
+				code := tt.codes[calls]
+				calls++
+				assert.Equal(t, ServiceStateRunning, r.ServiceStatus().State)
+				if code != 0 {
+					return launchr.NewExitError(code, "failed")
+				}
+				return nil
+			})
+			assert.Equal(t, tt.expErr, err != nil)
+			assert.Equal(t, tt.expCalls, calls)
+			st := r.ServiceStatus()
+			require.NotNil(t, st)
+			assert.Equal(t, tt.expState, st.State)
+			assert.Equal(t, tt.expCalls-1, st.Restarts)
+			assert.Equal(t, 2, st.MaxRestarts)
+		})
+	}
+
+	// Other errors are not restarted.
+	r := &runtimeContainer{}
+	err := r.superviseService(context.Background(), "test", nil, func(context.Context) error {
+		return errors.New("failed to create a container")
+	})
+	require.Error(t, err)
+	assert.Equal(t, &ServiceStatus{State: ServiceStateFailed, MaxRestarts: serviceMaxRestarts}, r.ServiceStatus())
+	assert.Nil(t, (&runtimeContainer{}).ServiceStatus())
+
+	// The status is polled while the service is started.
+	r = &runtimeContainer{}
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		for i := 0; i < 100; i++ {
+			_ = r.ServiceStatus()
+		}
+	}()
+	_ = r.superviseService(context.Background(), "test", nil, func(context.Context) error { return nil })
+	<-polled
+
+	// The delay is doubled up to the limit.
+	assert.Equal(t, time.Second, serviceRestartDelay(time.Second, 0))
+	assert.Equal(t, 4*time.Second, serviceRestartDelay(time.Second, 2))
+	assert.Equal(t, serviceMaxBackoff, serviceRestartDelay(time.Second, 10))
+}
+
+func Test_RunRecorder(t *testing.T) {
+	t.Parallel()
+	secret := "s3cr3t-value"
+	a := testContainerAction(&DefRuntimeContainer{
+		Image:   "myimage",
+		Command: []string{"ls"},
+		Env:     []string{"API_TOKEN=" + secret, "DEBUG=1"},
+		Build:   &types.BuildDefinition{Context: ".", Args: map[string]*string{"NPM_TOKEN": &secret}},
+	})
+	started := time.Now()
+	rec := newRunRecord(a, "launchr_test_1", []string{"API_TOKEN=" + secret, "DEBUG=1", "PASSWORD"}, map[string]string{"ticket": "1"}, started, nil)
+	rec.finish(launchr.NewExitError(2, "failed"), "using token "+secret+"\n")
+	assert.Equal(t, []string{"API_TOKEN=***", "DEBUG=1", "PASSWORD"}, rec.Env)
+	assert.Equal(t, EnvSlice{"API_TOKEN=***", "DEBUG=1"}, rec.Container.Env)
+	assert.Equal(t, "***", *rec.Container.Build.Args["NPM_TOKEN"])
+	assert.Equal(t, "using token ***\n", rec.Output)
+	assert.Equal(t, 2, rec.ExitCode)
+	assert.Equal(t, "failed", rec.Error)
+	// The action definition is not modified.
+	assert.Equal(t, secret, *a.RuntimeDef().Container.Build.Args["NPM_TOKEN"])
+
+	// Values of sensitive parameters are masked.
+	a = testContainerAction(&DefRuntimeContainer{Image: "myimage", Command: []string{"login", "--password", "p4ssw0rd"}})
+	rec = newRunRecord(a, "launchr_test_1", []string{"PASS=p4ssw0rd"}, nil, started, NewSensitiveMask("p4ssw0rd"))
+	rec.finish(errors.New("bad password p4ssw0rd"), "p4ssw0rd\n")
+	assert.Equal(t, StrSliceOrStr{"login", "--password", "***"}, rec.Container.Command)
+	assert.Equal(t, []string{"PASS=***"}, rec.Env)
+	assert.Equal(t, "bad password ***", rec.Error)
+	assert.Equal(t, "***\n", rec.Output)
+
+	rr := &RunRecorder{dir: filepath.Join(t.TempDir(), runsDir)}
+	recs, err := rr.List()
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+	require.NoError(t, rr.Save(rec))
+	loaded, err := rr.Load(rec.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rec.Output, loaded.Output)
+	assert.Equal(t, rec.Container.Image, loaded.Container.Image)
+	assert.True(t, rec.Started.Equal(loaded.Started))
+	_, err = rr.Load("../config")
+	assert.EqualError(t, err, `run id "../config" is not valid`)
+	_, err = rr.Load("missing")
+	assert.EqualError(t, err, `run "missing" is not found`)
+
+	// The oldest records are removed.
+	for i := 1; i <= runRecordsLimit; i++ {
+		r := newRunRecord(a, fmt.Sprintf("launchr_test_%d", i+1), nil, nil, started.Add(time.Duration(i)*time.Second), nil)
+		require.NoError(t, rr.Save(r))
+	}
+	recs, err = rr.List()
+	require.NoError(t, err)
+	require.Len(t, recs, runRecordsLimit)
+	assert.Equal(t, "launchr_test_21", recs[0].ID)
+	assert.Equal(t, "launchr_test_2", recs[runRecordsLimit-1].ID)
+}
+
+// volumesDriver is a container driver managing volumes in memory.
+type volumesDriver struct {
+	*mockdriver.MockContainerRunner
+	opts    types.VolumeListOptions
+	vols    []types.VolumeListResult
+	removed []string
+}
+
+func (d *volumesDriver) VolumeList(_ context.Context, opts types.VolumeListOptions) ([]types.VolumeListResult, error) {
+	d.opts = opts
+	return d.vols, nil
+}
+
+func (d *volumesDriver) VolumeRemove(_ context.Context, name string) error {
+	if name == "busy" {
+		return errors.New("volume is in use")
+	}
+	d.removed = append(d.removed, name)
+	return nil
+}
+
+func (d *volumesDriver) VolumeCreate(_ context.Context, opts types.VolumeCreateOptions) error {
+	d.vols = append(d.vols, types.VolumeListResult{Name: opts.Name, Labels: opts.Labels})
+	return nil
+}
+
+func Test_RemoveOrphanedVolumes(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	_, err := RemoveOrphanedVolumes(context.Background(), mockdriver.NewMockContainerRunner(ctrl))
+	assert.Error(t, err)
+
+	d := &volumesDriver{vols: []types.VolumeListResult{
+		{Name: "v1", Labels: map[string]string{LabelRunID: "run1"}},
+		{Name: "busy", Labels: map[string]string{LabelRunID: "run2"}},
+		{Name: "v2", Labels: map[string]string{LabelRunID: "run2"}},
+	}}
+	removed, err := RemoveOrphanedVolumes(context.Background(), d)
+	assert.EqualError(t, err, `failed to remove volume "busy" of run "run2": volume is in use`)
+	assert.Equal(t, []string{"v1", "v2"}, removed)
+	assert.Equal(t, types.VolumeListOptions{
+		Labels:   []string{LabelApp + "=" + launchr.Version().Name, LabelRunID},
+		Dangling: true,
+	}, d.opts)
+}
+
+func Test_SessionManager(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	_, err := NewSessionManager(mockdriver.NewMockContainerRunner(ctrl)).Find(context.Background(), "/work")
+	assert.Error(t, err)
+
+	wd := "/work"
+	d := &volumesDriver{}
+	m := NewSessionManager(d)
+	s, err := m.Find(context.Background(), wd)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+	assert.Equal(t, []string{
+		LabelApp + "=" + launchr.Version().Name,
+		LabelSession,
+		LabelWorkDirSum + "=" + workDirSum(wd),
+	}, d.opts.Labels)
+	_, err = m.Stop(context.Background(), wd, true)
+	assert.Error(t, err)
+
+	// The volume of the session is found by the working directory.
+	vol := sessionVolumeName(wd)
+	require.NoError(t, d.VolumeCreate(context.Background(), types.VolumeCreateOptions{
+		Name:   vol,
+		Labels: map[string]string{LabelSession: wd, labelSessionImage: "alpine"},
+	}))
+	s, err = m.Find(context.Background(), wd)
+	require.NoError(t, err)
+	assert.Equal(t, &Session{Volume: vol, WorkDir: wd, Image: "alpine"}, s)
+	_, err = m.Start(context.Background(), wd, "")
+	assert.Error(t, err)
+
+	// The changes are discarded without copying back.
+	s, err = m.Stop(context.Background(), wd, true)
+	require.NoError(t, err)
+	assert.Equal(t, vol, s.Volume)
+	assert.Equal(t, []string{vol}, d.removed)
+
+	// The session started by a newer version isn't used.
+	d.vols = []types.VolumeListResult{{Name: vol, Labels: map[string]string{LabelSession: wd, labelSessionVersion: "2"}}}
+	_, err = m.Find(context.Background(), wd)
+	assert.ErrorIs(t, err, launchr.ErrStateVersion)
+}
+
+// usernsDriver is a container runner reporting a predefined user namespace.
+type usernsDriver struct {
+	*mockdriver.MockContainerRunner
+	ns driver.UserNamespace
+}
+
+func (d *usernsDriver) UserNamespace(_ context.Context) (driver.UserNamespace, error) {
+	return d.ns, nil
+}
+
+func Test_ContainerUserMapping(t *testing.T) {
+	t.Parallel()
+	// Docker Desktop on macOS gives the files to the host user itself.
+	chown := goruntime.GOOS == "linux"
+	chownUserns := ""
+	if chown {
+		chownUserns = driver.UsernsModeHost
+	}
+	type testCase struct {
+		name     string
+		root     bool
+		ns       driver.UserNamespace
+		useVolWD bool
+		hostUser string
+		exp      containerUserMapping
+	}
+	tts := []testCase{
+		{name: "host user", hostUser: "1000:1000", exp: containerUserMapping{User: "1000:1000"}},
+		{name: "podman keeps the host user", ns: driver.UserNamespace{Rootless: true, KeepID: true}, hostUser: "1000:1000",
+			exp: containerUserMapping{User: "1000:1000", UsernsMode: driver.UsernsModeKeepID}},
+		{name: "root in rootless engine", root: true, ns: driver.UserNamespace{Rootless: true, KeepID: true}, hostUser: "1000:1000",
+			exp: containerUserMapping{User: containerRootUser}},
+		{name: "root in rootful engine", root: true, hostUser: "1000:1000",
+			exp: containerUserMapping{User: containerRootUser, Chown: chown}},
+		{name: "root in remapped engine", root: true, ns: driver.UserNamespace{Remapped: true}, hostUser: "1000:1000",
+			exp: containerUserMapping{User: containerRootUser, Chown: chown, ChownUsernsMode: chownUserns}},
+		{name: "root with copied working directory", root: true, useVolWD: true, hostUser: "1000:1000",
+			exp: containerUserMapping{User: containerRootUser}},
+		{name: "root without host user", root: true, exp: containerUserMapping{User: containerRootUser}},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runtimeContainer{driver: &usernsDriver{ns: tt.ns}, useVolWD: tt.useVolWD}
+			m := r.userMapping(context.Background(), &DefRuntimeContainer{RunAsRoot: tt.root}, tt.hostUser)
+			assert.Equal(t, tt.exp, m)
+		})
+	}
+}
+
+func Test_ContainerChownMounts(t *testing.T) {
+	t.Parallel()
+	_, ctrl, d, r := prepareContainerTestSuite(t)
+	defer ctrl.Finish()
+	defer r.Close()
+	r.mountFlags = mountFlagsNone
+	a := testContainerAction(nil)
+	ctx := context.Background()
+	resCh := make(chan types.ContainerWaitResponse, 1)
+	resCh <- types.ContainerWaitResponse{StatusCode: 0}
+	d.EXPECT().ContainerCreate(ctx, types.ContainerCreateOptions{
+		ContainerName: "run-chown",
+		Image:         "myimage",
+		Entrypoint:    []string{"find"},
+		Cmd:           []string{containerHostMount, containerActionMount, "-user", "0", "-exec", "chown", "-h", "1000:1000", "{}", "+"},
+		User:          containerRootUser,
+		NetworkMode:   types.NetworkModeNone,
+		UsernsMode:    driver.UsernsModeHost,
+		Labels:        containerLabels(a, "run"),
+		Binds: []string{
+			launchr.MustAbs(a.WorkDir()) + ":" + containerHostMount,
+			launchr.MustAbs(a.Dir()) + ":" + containerActionMount,
+		},
+	}).Return("cid", nil)
+	d.EXPECT().ContainerWait(ctx, "cid", types.ContainerWaitOptions{Condition: types.WaitConditionNextExit}).Return(resCh, nil)
+	d.EXPECT().ContainerStart(ctx, "cid", types.ContainerStartOptions{}).Return(nil)
+	d.EXPECT().ContainerRemove(ctx, "cid", types.ContainerRemoveOptions{}).Return(nil)
+	require.NoError(t, r.chownMounts(ctx, a, "run", "1000:1000", driver.UsernsModeHost))
+
+	resCh <- types.ContainerWaitResponse{StatusCode: 1}
+	d.EXPECT().ContainerCreate(ctx, gomock.Any()).Return("cid", nil)
+	d.EXPECT().ContainerWait(ctx, "cid", gomock.Any()).Return(resCh, nil)
+	d.EXPECT().ContainerStart(ctx, "cid", types.ContainerStartOptions{}).Return(nil)
+	d.EXPECT().ContainerRemove(ctx, "cid", types.ContainerRemoveOptions{}).Return(nil)
+	assert.EqualError(t, r.chownMounts(ctx, a, "run", "1000:1000", ""), "the command exited with code 1")
+}
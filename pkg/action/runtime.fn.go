@@ -26,7 +26,7 @@ func (fn FnRuntime) Init(_ context.Context, _ *Action) error {
 
 // Execute implements [Runtime] interface.
 func (fn FnRuntime) Execute(ctx context.Context, a *Action) error {
-	launchr.Log().Debug("starting execution of the action", "run_env", "fn", "action_id", a.ID)
+	launchr.LogFromContext(ctx).Debug("starting execution of the action", "run_env", "fn", "action_id", a.ID)
 	return fn(ctx, a)
 }
 
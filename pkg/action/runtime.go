@@ -16,6 +16,41 @@ type Runtime interface {
 	Clone() Runtime
 }
 
+// RuntimeUsageReporter is a [Runtime] reporting resource usage of the last execution.
+type RuntimeUsageReporter interface {
+	Runtime
+	// Usage returns resource usage of the last execution or nil if it wasn't collected.
+	Usage() *RunUsage
+}
+
+// RuntimeReporter is a [Runtime] collecting reports of an action about its last execution.
+type RuntimeReporter interface {
+	Runtime
+	// Report returns the report of the last execution or nil if it wasn't collected.
+	Report() *RunReport
+}
+
+// RuntimeServiceReporter is a [Runtime] supervising long-running service actions.
+type RuntimeServiceReporter interface {
+	Runtime
+	// ServiceStatus returns the state of the current or the last service run or nil if the action isn't a service.
+	ServiceStatus() *ServiceStatus
+}
+
+// RuntimeRunLabeler is an interface for runtimes supporting user metadata of a run.
+type RuntimeRunLabeler interface {
+	Runtime
+	// RunLabels returns labels of the next execution set by a user.
+	RunLabels() map[string]string
+}
+
+// RuntimeImagePreparer is a [Runtime] preparing an image of an action before the run.
+type RuntimeImagePreparer interface {
+	Runtime
+	// PrepareImage pulls or builds the image of action a, so the run doesn't wait for it.
+	PrepareImage(ctx context.Context, a *Action) error
+}
+
 // RuntimeFlags is an interface to define environment specific runtime configuration.
 type RuntimeFlags interface {
 	Runtime
@@ -37,4 +72,8 @@ type ContainerRuntime interface {
 	// SetImageBuildCacheResolver sets an image build cache resolver
 	// to check when image must be rebuilt.
 	SetImageBuildCacheResolver(*ImageBuildCacheResolver)
+	// SetRuntimeConfig sets runtime configuration.
+	SetRuntimeConfig(ConfigRuntime)
+	// SetRunRecorder sets a storage of run records.
+	SetRunRecorder(*RunRecorder)
 }
@@ -0,0 +1,134 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// metaStackKey is a context key of the meta actions being executed, it's used to detect cycles.
+type metaStackKey struct{}
+
+// runtimeMeta executes the steps of a meta action with the [Manager].
+type runtimeMeta struct {
+	m Manager
+}
+
+// NewMetaRuntime creates a runtime of meta actions running other actions of the manager.
+func NewMetaRuntime(m Manager) Runtime {
+	return &runtimeMeta{m: m}
+}
+
+// Clone implements [Runtime] interface.
+func (r *runtimeMeta) Clone() Runtime {
+	return NewMetaRuntime(r.m)
+}
+
+// Init implements [Runtime] interface.
+func (r *runtimeMeta) Init(_ context.Context, _ *Action) error {
+	return nil
+}
+
+// Close implements [Runtime] interface.
+func (r *runtimeMeta) Close() error {
+	return nil
+}
+
+// Execute implements [Runtime] interface.
+// The steps run one by one, on a failure the run is stopped unless the errors are allowed.
+// With allowed errors, the run fails with the highest exit code of the failed steps.
+func (r *runtimeMeta) Execute(ctx context.Context, a *Action) error {
+	def := a.RuntimeDef().Meta
+	if def == nil {
+		return errors.New("action meta configuration is not set, use different runtime")
+	}
+	stack, _ := ctx.Value(metaStackKey{}).([]string)
+	if slices.Contains(stack, a.ID) {
+		return fmt.Errorf("meta action cycle: %s -> %s", strings.Join(stack, " -> "), a.ID)
+	}
+	ctx = context.WithValue(ctx, metaStackKey{}, append(slices.Clone(stack), a.ID))
+	launchr.LogFromContext(ctx).Debug("starting execution of the action", "run_env", "meta", "action_id", a.ID)
+	if def.Parallel() {
+		return r.executeParallel(ctx, a, def)
+	}
+
+	failed, code := 0, 0
+	for i, step := range def.Steps {
+		err := r.runStep(ctx, a, step)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("step %d %q of action %q failed: %w", i+1, step.Action, a.ID, err)
+		if !def.ContinueOnError || ctx.Err() != nil {
+			return err
+		}
+		launchr.TermFromContext(ctx).Error().Println(err)
+		failed++
+		stepCode := 1
+		var exitErr launchr.ExitError
+		if errors.As(err, &exitErr) {
+			stepCode = exitErr.ExitCode()
+		}
+		code = max(code, stepCode)
+	}
+	if failed > 0 {
+		return launchr.NewExitError(code, fmt.Sprintf("%d of %d steps of action %q failed", failed, len(def.Steps), a.ID))
+	}
+	return nil
+}
+
+// executeParallel runs all steps at once with [Manager.RunAll] and prints the summary of the steps.
+func (r *runtimeMeta) executeParallel(ctx context.Context, a *Action, def *DefRuntimeMeta) error {
+	steps := make([]*Action, len(def.Steps))
+	for i, step := range def.Steps {
+		sa, err := r.stepAction(a, step)
+		if err != nil {
+			return fmt.Errorf("step %d %q of action %q failed: %w", i+1, step.Action, a.ID, err)
+		}
+		steps[i] = sa
+	}
+	summary, err := r.m.RunAll(ctx, steps, RunAllOptions{
+		MaxParallel:       def.MaxParallel,
+		MaxParallelImages: def.MaxParallelImages,
+		ContinueOnError:   def.ContinueOnError,
+	})
+	_ = summary.Write(launchr.TermFromContext(ctx))
+	failed := summary.Count(RunResultFailure)
+	if failed == 0 {
+		return err
+	}
+	if def.ContinueOnError {
+		return launchr.NewExitError(summary.ExitCode(), fmt.Sprintf("%d of %d steps of action %q failed", failed, len(def.Steps), a.ID))
+	}
+	for i, res := range summary.Results {
+		if res.Status == RunResultFailure {
+			return fmt.Errorf("step %d %q of action %q failed: %w", i+1, def.Steps[i].Action, a.ID, res.Err)
+		}
+	}
+	return err
+}
+
+func (r *runtimeMeta) runStep(ctx context.Context, a *Action, step DefDependency) error {
+	sa, err := r.stepAction(a, step)
+	if err != nil {
+		return err
+	}
+	_, err = r.m.Run(ctx, sa)
+	return err
+}
+
+// stepAction returns the action of the step with the input set.
+func (r *runtimeMeta) stepAction(a *Action, step DefDependency) (*Action, error) {
+	sa, ok := r.m.Get(r.m.GetIDFromAlias(step.Action))
+	if !ok {
+		return nil, fmt.Errorf("action %q is not found", step.Action)
+	}
+	if err := setDependencyInput(sa, step, a.Input()); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
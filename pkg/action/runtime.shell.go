@@ -0,0 +1,208 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+// shellWaitDelay is a time given to the command to exit after the run is canceled before it's killed.
+const shellWaitDelay = 10 * time.Second
+
+// runtimeShell executes the action command on the host.
+type runtimeShell struct{}
+
+// NewShellRuntime creates a runtime executing actions on the host without a container.
+func NewShellRuntime() Runtime {
+	return &runtimeShell{}
+}
+
+// Clone implements [Runtime] interface.
+func (r *runtimeShell) Clone() Runtime {
+	return NewShellRuntime()
+}
+
+// Init implements [Runtime] interface.
+func (r *runtimeShell) Init(_ context.Context, _ *Action) error {
+	return nil
+}
+
+// Close implements [Runtime] interface.
+func (r *runtimeShell) Close() error {
+	return nil
+}
+
+// Execute implements [Runtime] interface.
+func (r *runtimeShell) Execute(ctx context.Context, a *Action) error {
+	def := a.RuntimeDef().Shell
+	if def == nil {
+		return errors.New("action shell configuration is not set, use different runtime")
+	}
+	log := launchr.LogFromContext(ctx).With("run_env", "shell", "action_id", a.ID)
+	log.Debug("starting execution of the action")
+
+	args := shellCommand(def.Command, def.Env)
+	streams := a.Input().Streams()
+	// Interactive commands run in the foreground and receive signals from the terminal directly.
+	interactive := streams.In().IsTerminal()
+
+	var runDir string
+	if def.Isolated() {
+		var err error
+		runDir, err = os.MkdirTemp("", "launchr-shell-")
+		if err != nil {
+			return fmt.Errorf("failed to create a temporary directory of the run: %w", err)
+		}
+		defer func() {
+			if errRm := os.RemoveAll(runDir); errRm != nil {
+				log.Warn("failed to remove the temporary directory of the run", "dir", runDir, "error", errRm)
+			}
+		}()
+	}
+	env, err := shellEnv(def, os.Environ(), runDir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = shellWorkDir(a, def)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = shellStreams(streams)
+	cmd.SysProcAttr = shellSysProcAttr(interactive)
+	cmd.Cancel = func() error {
+		return shellTerminate(cmd.Process)
+	}
+	cmd.WaitDelay = shellWaitDelay
+
+	log.Debug("watching command signals")
+	sigc := driver.NotifyAllSignals()
+	defer driver.StopCatchSignals(sigc)
+	log.Debug("starting command", "command", a.SensitiveMask().MaskSlice(args), "workdir", cmd.Dir)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the command of action %q: %w", a.ID, err)
+	}
+	var skip []os.Signal
+	if interactive {
+		skip = shellTerminalSignals
+	}
+	fwdCtx, cancelFwd := context.WithCancel(ctx)
+	defer cancelFwd()
+	go driver.ForwardAllSignalsToProcess(fwdCtx, cmd.Process, sigc, skip...)
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status := shellExitCode(exitErr)
+		log.Info("action finished with the exit code", "exit_code", status)
+		return launchr.NewExitError(status, fmt.Sprintf("action %q finished with exit code %d", a.ID, status))
+	}
+	if err != nil {
+		return err
+	}
+	log.Info("action finished with the exit code", "exit_code", 0)
+	return nil
+}
+
+// rgxShellVar matches variables like $VAR and ${VAR}.
+var rgxShellVar = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// shellCommand returns the command with substituted variables of the action environment env.
+// A single string is executed with the system shell as is, the shell expands the variables itself,
+// so the values aren't interpreted as a part of the command line.
+// Variables are substituted only in the arguments of a command list, other variables are kept.
+func shellCommand(command []string, env []string) []string {
+	if len(command) == 1 {
+		return append(shellCommandLine(), command[0])
+	}
+	vars := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		vars[k] = v
+	}
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = rgxShellVar.ReplaceAllStringFunc(arg, func(m string) string {
+			sm := rgxShellVar.FindStringSubmatch(m)
+			if v, ok := vars[sm[1]+sm[2]]; ok {
+				return v
+			}
+			return m
+		})
+	}
+	return args
+}
+
+// shellEnv returns the environment of the command.
+// The host environment hostEnv is filtered by the allowlist, the temporary directories are created in runDir.
+// The variables of the action environment take priority.
+func shellEnv(def *DefRuntimeShell, hostEnv []string, runDir string) ([]string, error) {
+	env := hostEnv
+	if def.EnvAllowlist != nil {
+		env = make([]string, 0, len(def.EnvAllowlist))
+		for _, kv := range hostEnv {
+			k, _, _ := strings.Cut(kv, "=")
+			if slices.ContainsFunc(def.EnvAllowlist, func(name string) bool { return shellEnvNameEqual(name, k) }) {
+				env = append(env, kv)
+			}
+		}
+	}
+	isolated := []struct {
+		enabled bool
+		name    string
+		vars    []string
+	}{
+		{def.TempHome, "home", shellHomeVars},
+		{def.TempDir, "tmp", shellTmpVars},
+	}
+	for _, dir := range isolated {
+		if !dir.enabled {
+			continue
+		}
+		path := filepath.Join(runDir, dir.name)
+		if err := os.Mkdir(path, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create a temporary %s directory: %w", dir.name, err)
+		}
+		for _, v := range dir.vars {
+			env = append(env, v+"="+path)
+		}
+	}
+	return append(env, def.Env...), nil
+}
+
+// shellWorkDir returns the working directory of the command.
+func shellWorkDir(a *Action, def *DefRuntimeShell) string {
+	if def.WorkDir == "" {
+		return a.WorkDir()
+	}
+	if filepath.IsAbs(def.WorkDir) {
+		return def.WorkDir
+	}
+	return filepath.Join(a.WorkDir(), def.WorkDir)
+}
+
+// shellStreams returns the streams for the command.
+// The files are passed as is, so the command may detect the terminal.
+func shellStreams(streams launchr.Streams) (io.Reader, io.Writer, io.Writer) {
+	var (
+		in  io.Reader = streams.In()
+		out io.Writer = streams.Out()
+	)
+	if f := streams.In().File(); f != nil {
+		in = f
+	}
+	if f := streams.Out().File(); f != nil {
+		out = f
+	}
+	return in, out, streams.Err()
+}
@@ -0,0 +1,147 @@
+//go:build unix
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+func Test_ShellRuntime(t *testing.T) {
+	// The host environment is passed to the command.
+	t.Setenv("USER_NAME", "world")
+	const shellYaml = `
+action:
+  title: Shell
+runtime:
+  type: shell
+  workdir: %s
+  env:
+    GREETING: hello
+    UNSAFE: "x; echo injected"
+  command: %s
+`
+	wd := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(wd, "sub"), 0750))
+
+	tt := []struct {
+		name    string
+		workdir string
+		command string
+		out     string
+		stderr  string
+		code    int
+	}{
+		{"shell string with env", `""`, `'echo "$GREETING $USER_NAME" && pwd'`, "hello world\n" + wd + "\n", "", 0},
+		{"command list", "sub", `[ "sh", "-c", "pwd; echo $0", "$GREETING" ]`, filepath.Join(wd, "sub") + "\nhello\n", "", 0},
+		{"value isn't executed", `""`, `'echo "$UNSAFE"'`, "x; echo injected\n", "", 0},
+		{"single quotes aren't expanded", `""`, `'echo ''$GREETING'''`, "$GREETING\n", "", 0},
+		{"braced variable", `""`, `'echo ${GREETING}s'`, "hellos\n", "", 0},
+		{"exit code", `""`, `'echo failed >&2; exit 3'`, "", "failed\n", 3},
+		{"killed by signal", `""`, `'kill -TERM $$'`, "", "", 143},
+	}
+	for _, tt := range tt {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewFromYAML("shell", []byte(fmt.Sprintf(shellYaml, tt.workdir, tt.command)))
+			a.SetWorkDir(wd)
+			a.SetRuntime(NewShellRuntime())
+			var out, stderr bytes.Buffer
+			streams := activityStreams{Streams: launchr.NoopStreams(), out: launchr.NewOut(&out), err: &stderr}
+			require.NoError(t, a.SetInput(NewInput(a, nil, nil, streams)))
+
+			err := a.Execute(context.Background())
+			assert.Equal(t, tt.out, out.String())
+			assert.Equal(t, tt.stderr, stderr.String())
+			if tt.code == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var exitErr launchr.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, tt.code, exitErr.ExitCode())
+		})
+	}
+}
+
+func Test_ShellRuntimeCancel(t *testing.T) {
+	t.Parallel()
+	a := NewFromYAML("shell", []byte(`
+action:
+  title: Shell
+runtime:
+  type: shell
+  command: [ "sleep", "10" ]
+`))
+	a.SetRuntime(NewShellRuntime())
+	require.NoError(t, a.SetInput(NewInput(a, nil, nil, launchr.NoopStreams())))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := a.Execute(ctx)
+	var exitErr launchr.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 143, exitErr.ExitCode())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func Test_ShellRuntimeIsolation(t *testing.T) {
+	t.Setenv("SHELL_ALLOWED", "allowed")
+	t.Setenv("SHELL_DENIED", "denied")
+	a := NewFromYAML("shell", []byte(`
+action:
+  title: Shell
+runtime:
+  type: shell
+  env_allowlist: [PATH, SHELL_ALLOWED]
+  temp_home: true
+  temp_dir: true
+  env:
+    GREETING: hello
+  command: 'echo "$GREETING $SHELL_ALLOWED $SHELL_DENIED"; echo "$HOME"; echo "$TMPDIR"; touch "$HOME/f" "$TMPDIR/f"'
+`))
+	a.SetRuntime(NewShellRuntime())
+	var out, stderr bytes.Buffer
+	streams := activityStreams{Streams: launchr.NoopStreams(), out: launchr.NewOut(&out), err: &stderr}
+	require.NoError(t, a.SetInput(NewInput(a, nil, nil, streams)))
+	require.NoError(t, a.Execute(context.Background()))
+	assert.Empty(t, stderr.String())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "hello allowed ", lines[0])
+	home, tmp := lines[1], lines[2]
+	assert.Equal(t, "home", filepath.Base(home))
+	assert.Equal(t, "tmp", filepath.Base(tmp))
+	assert.Equal(t, filepath.Dir(home), filepath.Dir(tmp))
+	// The directories are removed after the run.
+	assert.NoDirExists(t, filepath.Dir(home))
+}
+
+func Test_ShellEnv(t *testing.T) {
+	t.Parallel()
+	host := []string{"PATH=/bin", "HOME=/home/user", "SECRET=value"}
+	env, err := shellEnv(&DefRuntimeShell{}, host, "")
+	require.NoError(t, err)
+	assert.Equal(t, host, env)
+
+	env, err = shellEnv(&DefRuntimeShell{EnvAllowlist: []string{}, Env: EnvSlice{"A=b"}}, host, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A=b"}, env)
+
+	dir := t.TempDir()
+	env, err = shellEnv(&DefRuntimeShell{EnvAllowlist: []string{"PATH", "HOME"}, TempHome: true}, host, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PATH=/bin", "HOME=/home/user", "HOME=" + filepath.Join(dir, "home")}, env)
+	assert.DirExists(t, filepath.Join(dir, "home"))
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+package action
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// shellTerminalSignals are sent by the terminal to all processes in the foreground.
+var shellTerminalSignals = []os.Signal{syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTSTP, syscall.SIGWINCH}
+
+// shellHomeVars and shellTmpVars are variables of the home and temporary directories.
+var (
+	shellHomeVars = []string{"HOME"}
+	shellTmpVars  = []string{"TMPDIR"}
+)
+
+func shellEnvNameEqual(a, b string) bool {
+	return a == b
+}
+
+func shellCommandLine() []string {
+	return []string{"/bin/sh", "-c"}
+}
+
+func shellSysProcAttr(interactive bool) *syscall.SysProcAttr {
+	if interactive {
+		return nil
+	}
+	// Signals are forwarded to the command, a separate process group prevents receiving them twice.
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+func shellTerminate(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
+
+func shellExitCode(err *exec.ExitError) int {
+	if ws, ok := err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return err.ExitCode()
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package action
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// shellTerminalSignals are sent by the terminal to all processes in the foreground.
+var shellTerminalSignals = []os.Signal{os.Interrupt}
+
+// shellHomeVars and shellTmpVars are variables of the home and temporary directories.
+var (
+	shellHomeVars = []string{"USERPROFILE", "HOME"}
+	shellTmpVars  = []string{"TEMP", "TMP"}
+)
+
+// shellEnvNameEqual compares the names of the variables, they are case-insensitive on Windows.
+func shellEnvNameEqual(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+func shellCommandLine() []string {
+	return []string{"cmd", "/C"}
+}
+
+func shellSysProcAttr(_ bool) *syscall.SysProcAttr {
+	return nil
+}
+
+func shellTerminate(p *os.Process) error {
+	return p.Kill()
+}
+
+func shellExitCode(err *exec.ExitError) int {
+	return err.ExitCode()
+}
@@ -0,0 +1,13 @@
+package action
+
+import (
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// State returns the state of action runs kept in the config directory.
+func State() []launchr.StateEntry {
+	return []launchr.StateEntry{
+		{Path: runsDir, Description: "Records of recent container runs", Format: runRecordFormat},
+		{Path: sumFilename, Description: "Checksums of built images to rebuild changed images"},
+	}
+}
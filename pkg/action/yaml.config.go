@@ -0,0 +1,82 @@
+package action
+
+import (
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// ConfigActionsKey is a field name in [launchr.Config] file for actions defined inline.
+const ConfigActionsKey = "actions"
+
+// configActionFile is a pseudo file name of actions defined in [launchr.Config].
+const configActionFile = "config.yaml"
+
+// DiscoverConfigActions creates actions defined in [launchr.Config].
+// The actions are defined as a map of action id to action definition:
+//
+//	actions:
+//	  my-action:
+//	    action:
+//	      title: My action
+//	    runtime:
+//	      type: container
+//	      image: alpine:latest
+//	      command: [ls, -lah]
+//
+// The directory of the config is used as the action directory.
+func DiscoverConfigActions(cfg launchr.Config, wd string) []*Action {
+	var items map[string]any
+	err := cfg.Get(ConfigActionsKey, &items)
+	if err != nil {
+		launchr.Term().Warning().Printfln("configuration file field %q is malformed", ConfigActionsKey)
+		launchr.Log().Warn("failed to parse actions in config", "error", err)
+		return nil
+	}
+	defs := make(map[string]map[string]any)
+	collectConfigActions(defs, "", items)
+
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	actions := make([]*Action, 0, len(ids))
+	for _, id := range ids {
+		b, err := yaml.Marshal(defs[id])
+		if err != nil {
+			launchr.Log().Warn("action in config was skipped due to error", "action_id", id, "error", err)
+			continue
+		}
+		loader := &YamlLoader{
+			Bytes:     b,
+			Processor: NewPipeProcessor(envProcessor{}, inputProcessor{}),
+		}
+		a := New(StringID(id), loader, cfg.DirPath(), configActionFile)
+		a.SetWorkDir(wd)
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// collectConfigActions collects action definitions from a config map.
+// Config keys are split by a dot, so the ids with dots are restored from the nested maps.
+func collectConfigActions(res map[string]map[string]any, prefix string, items map[string]any) {
+	for k, v := range items {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		id := k
+		if prefix != "" {
+			id = prefix + "." + k
+		}
+		if _, isDef := m["action"]; isDef {
+			res[id] = m
+			continue
+		}
+		collectConfigActions(res, id, m)
+	}
+}
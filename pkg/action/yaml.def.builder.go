@@ -0,0 +1,251 @@
+package action
+
+import (
+	"errors"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+	"github.com/launchrctl/launchr/pkg/types"
+)
+
+// DefinitionBuilder constructs an action definition programmatically.
+// The definition is validated the same way as action.yaml files on [DefinitionBuilder.Build].
+//
+// Example:
+//
+//	a, err := action.NewDefinitionBuilder().
+//		Title("Deploy").
+//		Arg(action.NewParameterBuilder("env").Enum("dev", "prod")).
+//		Option(action.NewParameterBuilder("dry-run").Type(jsonschema.Boolean).Default(false)).
+//		ContainerImage("alpine:3.20").
+//		Command("sh", "-c", "echo {{ .env }}").
+//		BuildAction("deploy")
+type DefinitionBuilder struct {
+	action  map[string]any
+	args    []map[string]any
+	opts    []map[string]any
+	runtime map[string]any
+}
+
+// NewDefinitionBuilder creates a new [DefinitionBuilder].
+func NewDefinitionBuilder() *DefinitionBuilder {
+	return &DefinitionBuilder{action: make(map[string]any)}
+}
+
+// Title sets the action title.
+func (b *DefinitionBuilder) Title(title string) *DefinitionBuilder {
+	b.action["title"] = title
+	return b
+}
+
+// Description sets the action description.
+func (b *DefinitionBuilder) Description(desc string) *DefinitionBuilder {
+	b.action["description"] = desc
+	return b
+}
+
+// Alias adds aliases of the action.
+func (b *DefinitionBuilder) Alias(aliases ...string) *DefinitionBuilder {
+	b.action["alias"] = append(b.strings("alias"), aliases...)
+	return b
+}
+
+// Tags adds tags of the action.
+func (b *DefinitionBuilder) Tags(tags ...string) *DefinitionBuilder {
+	b.action["tags"] = append(b.strings("tags"), tags...)
+	return b
+}
+
+// Version sets the action version.
+func (b *DefinitionBuilder) Version(v string) *DefinitionBuilder {
+	b.action["version"] = v
+	return b
+}
+
+// Arg adds a positional argument.
+func (b *DefinitionBuilder) Arg(p *ParameterBuilder) *DefinitionBuilder {
+	b.args = append(b.args, p.p)
+	return b
+}
+
+// Option adds an option.
+func (b *DefinitionBuilder) Option(p *ParameterBuilder) *DefinitionBuilder {
+	b.opts = append(b.opts, p.p)
+	return b
+}
+
+// PluginRuntime sets the plugin runtime, the runtime must be set to the action with [Action.SetRuntime].
+func (b *DefinitionBuilder) PluginRuntime() *DefinitionBuilder {
+	b.runtime = map[string]any{"type": runtimeTypePlugin}
+	return b
+}
+
+// ContainerImage sets the container runtime with the image.
+func (b *DefinitionBuilder) ContainerImage(image string) *DefinitionBuilder {
+	b.container()["image"] = image
+	return b
+}
+
+// ContainerBuild sets the build definition of the container image.
+func (b *DefinitionBuilder) ContainerBuild(build *types.BuildDefinition) *DefinitionBuilder {
+	b.container()["build"] = build
+	return b
+}
+
+// Command sets the command of the container.
+func (b *DefinitionBuilder) Command(cmd ...string) *DefinitionBuilder {
+	b.container()["command"] = cmd
+	return b
+}
+
+// Env adds an environment variable to the container.
+func (b *DefinitionBuilder) Env(key, value string) *DefinitionBuilder {
+	rt := b.container()
+	env, _ := rt["env"].([]string)
+	rt["env"] = append(env, key+"="+value)
+	return b
+}
+
+// ExtraHosts adds hosts resolved inside the container.
+func (b *DefinitionBuilder) ExtraHosts(hosts ...string) *DefinitionBuilder {
+	rt := b.container()
+	h, _ := rt["extra_hosts"].([]string)
+	rt["extra_hosts"] = append(h, hosts...)
+	return b
+}
+
+// User sets the container user.
+func (b *DefinitionBuilder) User(user string) *DefinitionBuilder {
+	b.container()["user"] = user
+	return b
+}
+
+func (b *DefinitionBuilder) container() map[string]any {
+	if b.runtime == nil || b.runtime["type"] != runtimeTypeContainer {
+		b.runtime = map[string]any{"type": runtimeTypeContainer}
+	}
+	return b.runtime
+}
+
+func (b *DefinitionBuilder) strings(key string) []string {
+	s, _ := b.action[key].([]string)
+	return s
+}
+
+// YAML returns the definition in action.yaml format.
+func (b *DefinitionBuilder) YAML() ([]byte, error) {
+	if b.runtime == nil {
+		return nil, errors.New("action runtime is not set")
+	}
+	act := make(map[string]any, len(b.action)+2)
+	for k, v := range b.action {
+		act[k] = v
+	}
+	if len(b.args) > 0 {
+		act["arguments"] = b.args
+	}
+	if len(b.opts) > 0 {
+		act["options"] = b.opts
+	}
+	return yaml.Marshal(map[string]any{
+		"action":  act,
+		"runtime": b.runtime,
+	})
+}
+
+// Build validates and returns the definition.
+func (b *DefinitionBuilder) Build() (*Definition, error) {
+	content, err := b.YAML()
+	if err != nil {
+		return nil, err
+	}
+	return NewDefFromYamlTpl(content)
+}
+
+// BuildAction validates the definition and creates an action with id.
+// Templates and environment variables in the definition are processed on run as in action.yaml files.
+func (b *DefinitionBuilder) BuildAction(id string) (*Action, error) {
+	content, err := b.YAML()
+	if err != nil {
+		return nil, err
+	}
+	loader := &YamlLoader{
+		Bytes:     content,
+		Processor: NewPipeProcessor(envProcessor{}, inputProcessor{}),
+	}
+	a := New(StringID(id), loader, "", "")
+	if _, err = a.Raw(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ParameterBuilder constructs an argument or an option of [DefinitionBuilder].
+type ParameterBuilder struct {
+	p map[string]any
+}
+
+// NewParameterBuilder creates a new [ParameterBuilder] of a string parameter.
+func NewParameterBuilder(name string) *ParameterBuilder {
+	return &ParameterBuilder{p: map[string]any{"name": name}}
+}
+
+// Title sets the parameter title.
+func (b *ParameterBuilder) Title(title string) *ParameterBuilder {
+	b.p["title"] = title
+	return b
+}
+
+// Description sets the parameter description.
+func (b *ParameterBuilder) Description(desc string) *ParameterBuilder {
+	b.p["description"] = desc
+	return b
+}
+
+// Type sets the parameter type.
+func (b *ParameterBuilder) Type(t jsonschema.Type) *ParameterBuilder {
+	b.p["type"] = t
+	return b
+}
+
+// Items sets the type of array items.
+func (b *ParameterBuilder) Items(t jsonschema.Type) *ParameterBuilder {
+	b.p["items"] = map[string]any{"type": t}
+	return b
+}
+
+// Default sets the default value.
+func (b *ParameterBuilder) Default(v any) *ParameterBuilder {
+	b.p["default"] = v
+	return b
+}
+
+// Enum sets the allowed values.
+func (b *ParameterBuilder) Enum(values ...any) *ParameterBuilder {
+	b.p["enum"] = values
+	return b
+}
+
+// Shorthand sets a short name of the option.
+func (b *ParameterBuilder) Shorthand(s string) *ParameterBuilder {
+	b.p["shorthand"] = s
+	return b
+}
+
+// Required marks the parameter as mandatory.
+func (b *ParameterBuilder) Required() *ParameterBuilder {
+	b.p["required"] = true
+	return b
+}
+
+// Process adds a value processor with options, opts may be nil.
+func (b *ParameterBuilder) Process(id string, opts map[string]any) *ParameterBuilder {
+	proc := map[string]any{"processor": id}
+	if opts != nil {
+		proc["options"] = opts
+	}
+	list, _ := b.p["process"].([]map[string]any)
+	b.p["process"] = append(list, proc)
+	return b
+}
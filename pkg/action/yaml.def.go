@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/docker/go-units"
 	"gopkg.in/yaml.v3"
 
 	"github.com/launchrctl/launchr/pkg/jsonschema"
@@ -26,10 +32,39 @@ const (
 	sErrDupActionParamName     = "parameter name %q is already defined, a variable name must be unique in the action definition"
 	sErrActionDefMissing       = "action definition is missing in the declaration"
 	sErrEmptyProcessorID       = "invalid configuration, processor ID is required"
+	sErrInvalidRequirement     = "invalid launchr version requirement %q"
+	sErrInvalidActionID        = "action id %q is not valid"
+	sErrInvalidCacheName       = "cache name %q is not valid"
+	sErrInvalidCachePath       = "cache path %q must be absolute"
+	sErrInvalidTmpfsPath       = "tmpfs path %q must be absolute"
+	sErrInvalidMemorySize      = "size %q is not valid, use a number with an optional unit, e.g. \"64m\" or \"1g\""
+	sErrInvalidSELinuxLabel    = "selinux label %q is not valid, use \"shared\" or \"private\""
+	sErrEmptyDependency        = "dependency action is required"
+	sErrEmptyMetaSteps         = "steps field cannot be empty"
+	sErrCommandAndSteps        = "command and steps can't be defined together"
+	sErrEmptyStepCmd           = "command of step %q cannot be empty"
+	sErrDupStepName            = "step name %q is already defined"
+	sErrStepsService           = "steps can't be used with a service or a healthy wait condition"
+	sErrInvalidMaxRestarts     = "max restarts %d must not be negative"
+	sErrInvalidRestartBackoff  = "restart backoff %q is not valid, use a positive duration, e.g. \"1s\" or \"1m\""
+	sErrInvalidMetaStrategy    = "strategy %q is not valid, use \"sequential\" or \"parallel\""
+	sErrInvalidMaxParallel     = "max parallel %d must not be negative"
+	sErrInvalidWaitFor         = "wait condition %q is not valid, use \"exit\", \"removed\" or \"healthy\""
+	sErrHealthyService         = "a service can't wait for the container to be healthy, it's supervised until it exits"
+	sErrInvalidRunTimeout      = "timeout %q is not valid, use a positive duration, e.g. \"30s\" or \"1h\""
+	sErrInvalidRunBudget       = "budget %q is not valid, use a positive duration, e.g. \"30s\" or \"1h\""
+	sErrInvalidArtifactPath    = "artifact path %q is not valid, a relative path must be inside of the working directory"
+	sErrArtifactsHealthy       = "artifacts can't be collected with a healthy wait condition, the container keeps running"
 
 	// Runtime types.
 	runtimeTypePlugin    DefRuntimeType = "plugin"
 	runtimeTypeContainer DefRuntimeType = "container"
+	runtimeTypeMeta      DefRuntimeType = "meta"
+	runtimeTypeShell     DefRuntimeType = "shell"
+
+	// Strategies of meta actions.
+	metaStrategySequential = "sequential"
+	metaStrategyParallel   = "parallel"
 )
 
 type errUnsupportedActionVersion struct {
@@ -48,10 +83,27 @@ func (err errUnsupportedActionVersion) Is(cmp error) bool {
 	return ok && errCmp == err
 }
 
+// ErrLaunchrVersionRequirement is returned when the running launchr doesn't satisfy
+// the version required by an action.
+type ErrLaunchrVersionRequirement struct {
+	Required string
+	Current  string
+}
+
+// Error implements error interface.
+func (err ErrLaunchrVersionRequirement) Error() string {
+	return fmt.Sprintf(
+		"the action requires launchr %s, current version is %s, please upgrade launchr to use the action",
+		err.Required, err.Current,
+	)
+}
+
 var (
 	rgxUnescTplRow = regexp.MustCompile(`(?:-|\S+:)(?:\s*)?({{.*}}.*)`)
 	rgxTplRow      = regexp.MustCompile(`({{.*}}.*)`)
 	rgxVarName     = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_\\-]*$`)
+	rgxCacheName   = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	rgxActionID    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:-]*$`)
 )
 
 // NewDefFromYaml creates an action file definition from yaml configuration.
@@ -62,21 +114,41 @@ func NewDefFromYaml(b []byte) (*Definition, error) {
 	decoder := yaml.NewDecoder(r)
 	err := decoder.Decode(&d)
 	if err != nil {
-		return nil, err
+		return nil, requirementErrOr(b, err)
 	}
 
 	// Validate required fields
 	switch d.Version {
 	case "1":
 		if err = validateV1(&d); err != nil {
-			return nil, err
+			return nil, requirementErrOr(b, err)
 		}
 	default:
-		return nil, errUnsupportedActionVersion{d.Version}
+		return nil, requirementErrOr(b, errUnsupportedActionVersion{d.Version})
 	}
 	return &d, nil
 }
 
+// requirementErrOr returns an error of unsatisfied launchr version requirement if it's declared
+// in the action file, otherwise it returns the given error.
+// A definition written for a newer launchr may fail to parse, so the requirement
+// is read separately to give a meaningful message.
+func requirementErrOr(b []byte, err error) error {
+	var req struct {
+		Action struct {
+			RequiresLaunchr string `yaml:"requires_launchr"`
+		} `yaml:"action"`
+	}
+	if errReq := yaml.Unmarshal(b, &req); errReq != nil {
+		return err
+	}
+	var errVer ErrLaunchrVersionRequirement
+	if errReq := checkLaunchrRequirement(req.Action.RequiresLaunchr, currentLaunchrVersion()); errors.As(errReq, &errVer) {
+		return errReq
+	}
+	return err
+}
+
 // NewDefFromYamlTpl creates an action file definition from yaml configuration
 // as [NewDefFromYaml] but considers that it has unescaped template values.
 func NewDefFromYamlTpl(b []byte) (*Definition, error) {
@@ -164,16 +236,32 @@ func validateV1(d *Definition) error {
 	if d.Action == nil {
 		return errors.New(sErrActionDefMissing)
 	}
+	if req := d.Action.RequiresLaunchr; req != "" {
+		if _, err := parseVersionConstraints(req); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // DefAction holds action configuration.
 type DefAction struct {
+	// ID is an explicit action id taking priority over the id derived from the action path.
+	ID          string         `yaml:"id"`
 	Title       string         `yaml:"title"`
 	Description string         `yaml:"description"`
 	Aliases     []string       `yaml:"alias"`
+	Tags        []string       `yaml:"tags"`
 	Arguments   ParametersList `yaml:"arguments"`
 	Options     ParametersList `yaml:"options"`
+	Deprecated  string         `yaml:"deprecated"`
+	ReplacedBy  string         `yaml:"replaced_by"`
+	Version     string         `yaml:"version"`
+	Changelog   []DefChangelog `yaml:"changelog"`
+
+	RequiresLaunchr string `yaml:"requires_launchr"`
+	// DependsOn is a list of actions running before the action.
+	DependsOn []DefDependency `yaml:"depends_on"`
 
 	// @todo remove deprecated
 	Command    StrSliceOrStr          `yaml:"command"`     // Deprecated: use [Definition.Runtime]
@@ -191,10 +279,150 @@ func (a *DefAction) UnmarshalYAML(n *yaml.Node) (err error) {
 	if err = n.Decode(&y); err != nil {
 		return err
 	}
+	if y.ID != "" && !rgxActionID.MatchString(y.ID) {
+		l, c := yamlNodeLineCol(n, "id")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidActionID, y.ID), l, c)
+	}
 	*a = DefAction(y)
 	return nil
 }
 
+// DefDependency is an action running before the dependent action.
+type DefDependency struct {
+	// Action is an id or an alias of the action.
+	Action string `yaml:"action"`
+	// Args and Options set the input of the action. The parameters not set here take the values
+	// of the dependent action parameters with the same name or the default values.
+	Args    map[string]any `yaml:"args"`
+	Options map[string]any `yaml:"options"`
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse a dependency as an action id or an object.
+func (d *DefDependency) UnmarshalYAML(n *yaml.Node) (err error) {
+	if n.Kind == yaml.ScalarNode {
+		*d = DefDependency{Action: n.Value}
+	} else {
+		type yamlT DefDependency
+		var y yamlT
+		if err = n.Decode(&y); err != nil {
+			return err
+		}
+		*d = DefDependency(y)
+	}
+	if d.Action == "" {
+		return yamlTypeErrorLine(sErrEmptyDependency, n.Line, n.Column)
+	}
+	return nil
+}
+
+// HasTag returns true if the action is tagged with the tag.
+func (a *DefAction) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDeprecated returns true if the action is marked as deprecated or has a replacement.
+func (a *DefAction) IsDeprecated() bool {
+	return a.Deprecated != "" || a.ReplacedBy != ""
+}
+
+// DeprecationMessage returns a human-readable message about the action deprecation.
+func (a *DefAction) DeprecationMessage() string {
+	if !a.IsDeprecated() {
+		return ""
+	}
+	msg := "the action is deprecated"
+	if a.Deprecated != "" {
+		msg += ": " + a.Deprecated
+	}
+	if a.ReplacedBy != "" {
+		msg += fmt.Sprintf(", use %q instead", a.ReplacedBy)
+	}
+	return msg
+}
+
+// CheckRequirements checks that the running launchr satisfies the action requirements.
+func (a *DefAction) CheckRequirements() error {
+	return checkLaunchrRequirement(a.RequiresLaunchr, currentLaunchrVersion())
+}
+
+// DefChangelog is a changelog entry of an action version.
+type DefChangelog struct {
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+// IncompatibleChanges compares parameters of the action with a previous definition
+// and returns descriptions of changes breaking existing calls of the action.
+func (a *DefAction) IncompatibleChanges(prev *DefAction) []string {
+	var res []string
+	// Arguments are positional, the order matters.
+	for i, p := range prev.Arguments {
+		if i >= len(a.Arguments) {
+			res = append(res, fmt.Sprintf("argument %q is removed", p.Name))
+			continue
+		}
+		if a.Arguments[i].Name != p.Name {
+			res = append(res, fmt.Sprintf("argument %q at position %d is replaced with %q", p.Name, i+1, a.Arguments[i].Name))
+			continue
+		}
+		res = append(res, paramIncompatibleChanges("argument", a.Arguments[i], p)...)
+	}
+	for _, p := range a.Arguments[min(len(prev.Arguments), len(a.Arguments)):] {
+		if p.Required {
+			res = append(res, fmt.Sprintf("required argument %q is added", p.Name))
+		}
+	}
+	for _, p := range prev.Options {
+		cur := findParam(a.Options, p.Name)
+		if cur == nil {
+			res = append(res, fmt.Sprintf("option %q is removed", p.Name))
+			continue
+		}
+		res = append(res, paramIncompatibleChanges("option", cur, p)...)
+	}
+	for _, p := range a.Options {
+		if p.Required && findParam(prev.Options, p.Name) == nil {
+			res = append(res, fmt.Sprintf("required option %q is added", p.Name))
+		}
+	}
+	return res
+}
+
+func findParam(l ParametersList, name string) *DefParameter {
+	for _, p := range l {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func paramIncompatibleChanges(kind string, cur, prev *DefParameter) []string {
+	var res []string
+	if cur.Type != prev.Type {
+		res = append(res, fmt.Sprintf("%s %q type is changed from %q to %q", kind, cur.Name, prev.Type, cur.Type))
+	}
+	if cur.Required && !prev.Required {
+		res = append(res, fmt.Sprintf("%s %q is required now", kind, cur.Name))
+	}
+	if len(cur.Enum) > 0 {
+		for _, v := range prev.Enum {
+			if !slices.Contains(cur.Enum, v) {
+				res = append(res, fmt.Sprintf("%s %q doesn't accept value %v anymore", kind, cur.Name, v))
+			}
+		}
+		if len(prev.Enum) == 0 {
+			res = append(res, fmt.Sprintf("%s %q accepts only values %v now", kind, cur.Name, cur.Enum))
+		}
+	}
+	return res
+}
+
 // DefRuntimeType is a runtime type.
 type DefRuntimeType string
 
@@ -206,7 +434,7 @@ func (r *DefRuntimeType) UnmarshalYAML(n *yaml.Node) (err error) {
 	}
 	*r = DefRuntimeType(s)
 	switch *r {
-	case runtimeTypePlugin, runtimeTypeContainer:
+	case runtimeTypePlugin, runtimeTypeContainer, runtimeTypeMeta, runtimeTypeShell:
 		return nil
 	case "":
 		return yamlTypeErrorLine("empty runtime type", n.Line, n.Column)
@@ -223,6 +451,181 @@ type DefRuntimeContainer struct {
 	ExtraHosts StrSlice               `yaml:"extra_hosts"`
 	Env        EnvSlice               `yaml:"env"`
 	User       string                 `yaml:"user"`
+	Security   *DefContainerSecurity  `yaml:"security"`
+	// RunAsRoot runs the command as root in the container. The files created by it in the mounted
+	// directories are owned by the current host user.
+	RunAsRoot bool `yaml:"run_as_root"`
+	// Cache is a list of volumes kept between runs, e.g. for build caches.
+	Cache []DefContainerCache `yaml:"cache"`
+	// DockerSocket mounts the docker socket of the host for actions running docker.
+	DockerSocket bool `yaml:"docker_socket"`
+	// Tmpfs is a list of writable in-memory mounts.
+	Tmpfs []DefContainerTmpfs `yaml:"tmpfs"`
+	// ShmSize is a size of /dev/shm, e.g. "1g", the default of the container engine is usually 64MB.
+	ShmSize string `yaml:"shm_size"`
+	// SELinuxLabel is a label of the mounted directories on hosts with SELinux,
+	// "shared" relabels them with ":z", "private" with ":Z".
+	SELinuxLabel string `yaml:"selinux_label"`
+	// Service marks a long-running action, the container is restarted when it exits with an error.
+	Service bool `yaml:"service"`
+	// Restart configures restarts of a service.
+	Restart *DefContainerRestart `yaml:"restart"`
+	// WaitFor is a condition finishing the run, one of WaitFor constants, [WaitForExit] by default.
+	WaitFor string `yaml:"wait_for"`
+	// Steps are commands executed one by one in the same container instead of Command.
+	Steps []DefContainerStep `yaml:"steps"`
+	// ContinueOnError runs the next steps when a step fails.
+	ContinueOnError bool `yaml:"continue_on_error"`
+	// Artifacts are paths in the container collected to the host after the run,
+	// relative paths are relative to the working directory.
+	Artifacts StrSlice `yaml:"artifacts"`
+}
+
+// DefContainerStep is a command executed in the container of the action.
+type DefContainerStep struct {
+	// Name is a name of the step shown in the output, the step number is used if empty.
+	Name string `yaml:"name"`
+	// Command is executed directly, a single string is executed with "sh -c".
+	Command StrSliceOrStr `yaml:"command"`
+}
+
+// Conditions finishing a container run.
+const (
+	WaitForExit    = "exit"    // WaitForExit finishes the run when the command exits.
+	WaitForRemoved = "removed" // WaitForRemoved finishes the run when the container is removed after the exit.
+	WaitForHealthy = "healthy" // WaitForHealthy finishes the run when the health check passes, the container keeps running.
+)
+
+// SELinux labels of mounted directories.
+const (
+	SELinuxLabelShared  = "shared"  // SELinuxLabelShared allows concurrent containers to access the directories.
+	SELinuxLabelPrivate = "private" // SELinuxLabelPrivate restricts access to the directories to the container.
+)
+
+// DefContainerCache is a volume mounted to a container and kept between runs.
+type DefContainerCache struct {
+	// Name is a name of the cache unique in the action.
+	Name string `yaml:"name"`
+	// Path is an absolute path in the container.
+	Path string `yaml:"path"`
+	// Shared makes the cache available to all actions declaring the same name.
+	Shared bool `yaml:"shared"`
+}
+
+// DefContainerTmpfs is an in-memory file system mounted to a container.
+type DefContainerTmpfs struct {
+	// Path is an absolute path in the container.
+	Path string `yaml:"path"`
+	// Size limits the size of the file system, e.g. "512m". By default, it's limited by the engine.
+	Size string `yaml:"size"`
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse a tmpfs definition.
+func (t *DefContainerTmpfs) UnmarshalYAML(n *yaml.Node) (err error) {
+	type yamlT DefContainerTmpfs
+	var y yamlT
+	if err = n.Decode(&y); err != nil {
+		return err
+	}
+	*t = DefContainerTmpfs(y)
+	if !path.IsAbs(t.Path) {
+		l, col := yamlNodeLineCol(n, "path")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidTmpfsPath, t.Path), l, col)
+	}
+	if _, errSize := parseMemorySize(t.Size); errSize != nil {
+		l, col := yamlNodeLineCol(n, "size")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMemorySize, t.Size), l, col)
+	}
+	return nil
+}
+
+// DefContainerRestart configures restarts of a service container exited with an error.
+type DefContainerRestart struct {
+	// MaxRestarts is a maximum number of restarts of a run, 5 by default.
+	MaxRestarts int `yaml:"max_restarts"`
+	// Backoff is a delay before the first restart, e.g. "5s", it's doubled after every restart. 1s by default.
+	Backoff string `yaml:"backoff"`
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse a restart definition.
+func (r *DefContainerRestart) UnmarshalYAML(n *yaml.Node) (err error) {
+	type yamlT DefContainerRestart
+	var y yamlT
+	if err = n.Decode(&y); err != nil {
+		return err
+	}
+	*r = DefContainerRestart(y)
+	if r.MaxRestarts < 0 {
+		l, col := yamlNodeLineCol(n, "max_restarts")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMaxRestarts, r.MaxRestarts), l, col)
+	}
+	if d, errDur := parseDuration(r.Backoff); errDur != nil || d < 0 {
+		l, col := yamlNodeLineCol(n, "backoff")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRestartBackoff, r.Backoff), l, col)
+	}
+	return nil
+}
+
+// Budget returns the maximum number of restarts and the delay before the first restart with defaults applied.
+func (r *DefContainerRestart) Budget() (int, time.Duration) {
+	maxRestarts, backoff := serviceMaxRestarts, serviceBackoff
+	if r == nil {
+		return maxRestarts, backoff
+	}
+	if r.MaxRestarts > 0 {
+		maxRestarts = r.MaxRestarts
+	}
+	if d, _ := parseDuration(r.Backoff); d > 0 {
+		backoff = d
+	}
+	return maxRestarts, backoff
+}
+
+// parseDuration parses a duration, an empty value is zero.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseMemorySize parses a human-readable size in bytes, an empty value is zero.
+func parseMemorySize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return units.RAMInBytes(s)
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse a container cache definition.
+func (c *DefContainerCache) UnmarshalYAML(n *yaml.Node) (err error) {
+	type yamlT DefContainerCache
+	var y yamlT
+	if err = n.Decode(&y); err != nil {
+		return err
+	}
+	*c = DefContainerCache(y)
+	if !rgxCacheName.MatchString(c.Name) {
+		l, col := yamlNodeLineCol(n, "name")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidCacheName, c.Name), l, col)
+	}
+	if !path.IsAbs(c.Path) {
+		l, col := yamlNodeLineCol(n, "path")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidCachePath, c.Path), l, col)
+	}
+	return nil
+}
+
+// DefContainerSecurity relaxes the security profile of the global configuration for an action.
+type DefContainerSecurity struct {
+	// CapAdd is a list of capabilities kept when all capabilities are dropped.
+	CapAdd []string `yaml:"cap_add"`
+	// WritableRootfs allows writes to the container root filesystem.
+	WritableRootfs bool `yaml:"writable_rootfs"`
+	// AllowPrivilegeEscalation allows processes to gain new privileges.
+	AllowPrivilegeEscalation bool `yaml:"allow_privilege_escalation"`
+	// AllowRoot allows running the container as root.
+	AllowRoot bool `yaml:"allow_root"`
 }
 
 // UnmarshalYAML implements [yaml.Unmarshaler] to parse runtime container definition.
@@ -237,17 +640,191 @@ func (r *DefRuntimeContainer) UnmarshalYAML(n *yaml.Node) (err error) {
 		l, c := yamlNodeLineCol(n, "image")
 		return yamlTypeErrorLine(sErrEmptyRuntimeImg, l, c)
 	}
-	if len(r.Command) == 0 {
+	if len(r.Steps) > 0 {
+		if err = r.validateSteps(n); err != nil {
+			return err
+		}
+	} else if len(r.Command) == 0 {
 		l, c := yamlNodeLineCol(n, "command")
 		return yamlTypeErrorLine(sErrEmptyRuntimeCmd, l, c)
 	}
+	if err = r.validateArtifacts(n); err != nil {
+		return err
+	}
+	if _, errSize := parseMemorySize(r.ShmSize); errSize != nil {
+		l, c := yamlNodeLineCol(n, "shm_size")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMemorySize, r.ShmSize), l, c)
+	}
+	switch r.SELinuxLabel {
+	case "", SELinuxLabelShared, SELinuxLabelPrivate:
+	default:
+		l, c := yamlNodeLineCol(n, "selinux_label")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidSELinuxLabel, r.SELinuxLabel), l, c)
+	}
+	switch r.WaitFor {
+	case "", WaitForExit, WaitForRemoved:
+	case WaitForHealthy:
+		if r.Service {
+			l, c := yamlNodeLineCol(n, "wait_for")
+			return yamlTypeErrorLine(sErrHealthyService, l, c)
+		}
+	default:
+		l, c := yamlNodeLineCol(n, "wait_for")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidWaitFor, r.WaitFor), l, c)
+	}
 	return err
 }
 
+func (r *DefRuntimeContainer) validateSteps(n *yaml.Node) error {
+	l, c := yamlNodeLineCol(n, "steps")
+	if len(r.Command) > 0 {
+		return yamlTypeErrorLine(sErrCommandAndSteps, l, c)
+	}
+	if r.Service || r.WaitFor == WaitForHealthy {
+		return yamlTypeErrorLine(sErrStepsService, l, c)
+	}
+	names := make(map[string]struct{}, len(r.Steps))
+	for i, s := range r.Steps {
+		name := s.StepName(i)
+		if _, ok := names[name]; ok {
+			return yamlTypeErrorLine(fmt.Sprintf(sErrDupStepName, name), l, c)
+		}
+		names[name] = struct{}{}
+		if len(s.Command) == 0 {
+			return yamlTypeErrorLine(fmt.Sprintf(sErrEmptyStepCmd, name), l, c)
+		}
+	}
+	return nil
+}
+
+func (r *DefRuntimeContainer) validateArtifacts(n *yaml.Node) error {
+	if len(r.Artifacts) == 0 {
+		return nil
+	}
+	l, c := yamlNodeLineCol(n, "artifacts")
+	if r.WaitFor == WaitForHealthy {
+		return yamlTypeErrorLine(sErrArtifactsHealthy, l, c)
+	}
+	for _, p := range r.Artifacts {
+		if p == "" || !path.IsAbs(p) && (path.Clean(p) == ".." || strings.HasPrefix(path.Clean(p), "../")) {
+			return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidArtifactPath, p), l, c)
+		}
+	}
+	return nil
+}
+
+// StepName returns the name of the step or its number if the name isn't set, i is the index of the step.
+func (s DefContainerStep) StepName(i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// ExecCommand returns the command to execute, a single string is executed with the shell.
+func (s DefContainerStep) ExecCommand() []string {
+	if len(s.Command) == 1 {
+		return []string{"sh", "-c", s.Command[0]}
+	}
+	return s.Command
+}
+
 // DefRuntime contains action runtime configuration.
 type DefRuntime struct {
-	Type      DefRuntimeType `yaml:"type"`
-	Container *DefRuntimeContainer
+	Type DefRuntimeType `yaml:"type"`
+	// Timeout limits the duration of the run, the run is cancelled when it elapses.
+	Timeout string `yaml:"timeout"`
+	// Budget is an expected maximum duration of the run, a warning is logged when the run exceeds it.
+	Budget string `yaml:"budget"`
+	// BudgetKill stops the run when it exceeds the budget.
+	BudgetKill bool `yaml:"budget_kill"`
+	Container  *DefRuntimeContainer
+	Meta       *DefRuntimeMeta
+	Shell      *DefRuntimeShell
+}
+
+// DefRuntimeShell has configuration of an action running on the host without a container.
+type DefRuntimeShell struct {
+	// Command is executed directly, a single string is executed with the system shell.
+	// Variables of Env like $VAR are substituted in the arguments of a list, the shell expands them in a string.
+	Command StrSliceOrStr `yaml:"command"`
+	// Env is a list of environment variables added to the environment of the host.
+	Env EnvSlice `yaml:"env"`
+	// WorkDir is a working directory of the command, relative paths are resolved from the app working directory.
+	WorkDir string `yaml:"workdir"`
+	// EnvAllowlist limits the environment of the host passed to the command to the listed variables.
+	// The whole environment is passed if not set, an empty list passes only Env.
+	EnvAllowlist []string `yaml:"env_allowlist"`
+	// TempHome sets the home directory of the command to a temporary directory removed after the run.
+	TempHome bool `yaml:"temp_home"`
+	// TempDir sets the temporary directory of the command to a directory removed after the run.
+	TempDir bool `yaml:"temp_dir"`
+}
+
+// Isolated checks if the command needs a directory of the run.
+func (r *DefRuntimeShell) Isolated() bool {
+	return r.TempHome || r.TempDir
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse runtime shell definition.
+func (r *DefRuntimeShell) UnmarshalYAML(n *yaml.Node) (err error) {
+	type yamlT DefRuntimeShell
+	var y yamlT
+	if err = n.Decode(&y); err != nil {
+		return err
+	}
+	*r = DefRuntimeShell(y)
+	if len(r.Command) == 0 {
+		l, c := yamlNodeLineCol(n, "command")
+		return yamlTypeErrorLine(sErrEmptyRuntimeCmd, l, c)
+	}
+	return nil
+}
+
+// DefRuntimeMeta has configuration of a meta action running other actions.
+type DefRuntimeMeta struct {
+	// Steps are actions executed one by one, a step is declared the same way as a dependency.
+	Steps []DefDependency `yaml:"steps"`
+	// ContinueOnError runs the next steps when a step fails.
+	ContinueOnError bool `yaml:"continue_on_error"`
+	// Strategy is "sequential" (default) or "parallel" to run the steps concurrently.
+	Strategy string `yaml:"strategy"`
+	// MaxParallel limits the number of steps running at once with the parallel strategy, 0 is no limit.
+	MaxParallel int `yaml:"max_parallel"`
+	// MaxParallelImages limits the number of images prepared at once before the parallel steps, 0 is no limit.
+	MaxParallelImages int `yaml:"max_parallel_images"`
+}
+
+// Parallel checks if the steps run concurrently.
+func (r *DefRuntimeMeta) Parallel() bool {
+	return r.Strategy == metaStrategyParallel
+}
+
+// UnmarshalYAML implements [yaml.Unmarshaler] to parse runtime meta definition.
+func (r *DefRuntimeMeta) UnmarshalYAML(n *yaml.Node) (err error) {
+	type yamlT DefRuntimeMeta
+	var y yamlT
+	if err = n.Decode(&y); err != nil {
+		return err
+	}
+	*r = DefRuntimeMeta(y)
+	if len(r.Steps) == 0 {
+		l, c := yamlNodeLineCol(n, "steps")
+		return yamlTypeErrorLine(sErrEmptyMetaSteps, l, c)
+	}
+	if r.Strategy != "" && r.Strategy != metaStrategySequential && r.Strategy != metaStrategyParallel {
+		l, c := yamlNodeLineCol(n, "strategy")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMetaStrategy, r.Strategy), l, c)
+	}
+	if r.MaxParallel < 0 {
+		l, c := yamlNodeLineCol(n, "max_parallel")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMaxParallel, r.MaxParallel), l, c)
+	}
+	if r.MaxParallelImages < 0 {
+		l, c := yamlNodeLineCol(n, "max_parallel_images")
+		return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMaxParallel, r.MaxParallelImages), l, c)
+	}
+	return nil
 }
 
 // UnmarshalYAML implements [yaml.Unmarshaler] to parse runtime definition.
@@ -274,18 +851,63 @@ func (r *DefRuntime) UnmarshalYAML(n *yaml.Node) (err error) {
 
 	// Parse runtime configuration.
 	r.Type = rtype
+	if ntimeout := yamlFindNodeByKey(n, "timeout"); ntimeout != nil {
+		if err = ntimeout.Decode(&r.Timeout); err != nil {
+			return err
+		}
+		if d, errDur := parseDuration(r.Timeout); errDur != nil || d < 0 {
+			return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRunTimeout, r.Timeout), ntimeout.Line, ntimeout.Column)
+		}
+	}
+	if nbudget := yamlFindNodeByKey(n, "budget"); nbudget != nil {
+		if err = nbudget.Decode(&r.Budget); err != nil {
+			return err
+		}
+		if d, errDur := parseDuration(r.Budget); errDur != nil || d < 0 {
+			return yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRunBudget, r.Budget), nbudget.Line, nbudget.Column)
+		}
+	}
+	if nkill := yamlFindNodeByKey(n, "budget_kill"); nkill != nil {
+		if err = nkill.Decode(&r.BudgetKill); err != nil {
+			return err
+		}
+	}
 	switch r.Type {
 	case runtimeTypePlugin:
 		return nil
 	case runtimeTypeContainer:
 		err = n.Decode(&r.Container)
 		return err
+	case runtimeTypeMeta:
+		err = n.Decode(&r.Meta)
+		return err
+	case runtimeTypeShell:
+		err = n.Decode(&r.Shell)
+		return err
 	default:
 		// Error is already returned on runtime type parsing.
 		panic(fmt.Sprintf("runtime type not implemented: %s", r.Type))
 	}
 }
 
+// RunTimeout returns the maximum duration of the run, zero means no limit.
+func (r *DefRuntime) RunTimeout() time.Duration {
+	if r == nil {
+		return 0
+	}
+	d, _ := parseDuration(r.Timeout)
+	return d
+}
+
+// RunBudget returns the expected maximum duration of the run, zero means no budget.
+func (r *DefRuntime) RunBudget() time.Duration {
+	if r == nil {
+		return 0
+	}
+	d, _ := parseDuration(r.Budget)
+	return d
+}
+
 // StrSlice is an array of strings for command execution.
 type StrSlice []string
 
@@ -386,6 +1008,8 @@ type DefParameter struct {
 	Required bool `yaml:"required"`
 	// Process is an array of [ValueProcessor] to a value.
 	Process []DefValueProcessor `yaml:"process"`
+	// Sensitive marks secret values, they are masked in logs, summaries and run records, see [Action.SensitiveMask].
+	Sensitive bool `yaml:"sensitive"`
 	// processors is an instantiated list of processor handlers.
 	processors []ValueProcessorHandler
 	// raw is a raw parameter declaration to support all JSON Schema features.
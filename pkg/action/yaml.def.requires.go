@@ -0,0 +1,79 @@
+package action
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// currentLaunchrVersion returns the version of launchr core used by the running binary.
+var currentLaunchrVersion = func() string {
+	return launchr.Version().CoreVersion
+}
+
+var rgxVersionConstraint = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*v?(\d+(?:\.\d+){0,2}(?:-[0-9A-Za-z.-]+)?)$`)
+
+type versionConstraint struct {
+	op  string
+	ver string
+}
+
+func (c versionConstraint) check(ver string) bool {
+	cmp := semver.Compare(ver, c.ver)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseVersionConstraints parses a requirement like ">=0.18, <1.0".
+// All constraints must be satisfied.
+func parseVersionConstraints(req string) ([]versionConstraint, error) {
+	parts := strings.Split(req, ",")
+	res := make([]versionConstraint, 0, len(parts))
+	for _, p := range parts {
+		m := rgxVersionConstraint.FindStringSubmatch(strings.TrimSpace(p))
+		if m == nil || !semver.IsValid("v"+m[2]) {
+			return nil, fmt.Errorf(sErrInvalidRequirement, req)
+		}
+		res = append(res, versionConstraint{op: m[1], ver: "v" + m[2]})
+	}
+	return res, nil
+}
+
+// checkLaunchrRequirement checks that launchr version cur satisfies the requirement.
+// Development builds without a valid version are not checked.
+func checkLaunchrRequirement(req, cur string) error {
+	if req == "" {
+		return nil
+	}
+	constraints, err := parseVersionConstraints(req)
+	if err != nil {
+		return err
+	}
+	ver := cur
+	if !strings.HasPrefix(ver, "v") {
+		ver = "v" + ver
+	}
+	if !semver.IsValid(ver) {
+		return nil
+	}
+	for _, c := range constraints {
+		if !c.check(ver) {
+			return ErrLaunchrVersionRequirement{Required: req, Current: cur}
+		}
+	}
+	return nil
+}
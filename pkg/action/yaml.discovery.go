@@ -3,6 +3,7 @@ package action
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"regexp"
 	"sync"
@@ -91,6 +92,29 @@ func (l *YamlLoader) Load(ctx LoadContext) (res *Definition, err error) {
 	return res, err
 }
 
+// LoadStages implements [StagedLoader] interface.
+func (l *YamlLoader) LoadStages(ctx LoadContext) ([]LoadStage, error) {
+	c, err := l.Content()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, len(c))
+	copy(buf, c)
+	res := []LoadStage{{Name: "source", Content: c}}
+	if l.Processor != nil {
+		stages, err := processStages(l.Processor, ctx, buf)
+		res = append(res, stages...)
+		if err != nil {
+			return res, err
+		}
+		buf = res[len(res)-1].Content
+	}
+	if _, err = NewDefFromYaml(buf); err != nil {
+		return res, fmt.Errorf("parsing: %w", err)
+	}
+	return res, nil
+}
+
 // YamlFileLoader loads action yaml from a file.
 type YamlFileLoader struct {
 	YamlLoader
@@ -116,6 +140,16 @@ func (l *YamlFileLoader) Load(ctx LoadContext) (res *Definition, err error) {
 	return l.YamlLoader.Load(ctx)
 }
 
+// LoadStages implements [StagedLoader] interface.
+func (l *YamlFileLoader) LoadStages(ctx LoadContext) ([]LoadStage, error) {
+	// Open a file and cache content for future reads.
+	_, err := l.Content()
+	if err != nil {
+		return nil, err
+	}
+	return l.YamlLoader.LoadStages(ctx)
+}
+
 // Content implements [Loader] interface.
 func (l *YamlFileLoader) Content() ([]byte, error) {
 	l.mx.Lock()
@@ -139,6 +173,8 @@ func (l *YamlFileLoader) Content() ([]byte, error) {
 
 type escapeYamlTplCommentsProcessor struct{}
 
+func (p escapeYamlTplCommentsProcessor) String() string { return "comments removal" }
+
 func (p escapeYamlTplCommentsProcessor) Process(_ LoadContext, b []byte) ([]byte, error) {
 	// Read by line.
 	scanner := bufio.NewScanner(bytes.NewBuffer(b))
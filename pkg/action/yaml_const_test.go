@@ -82,6 +82,357 @@ runtime:
     - "${TEST_ENV_1} ${TEST_ENV_UND}"
 `
 
+const validDeprecatedYaml = `
+runtime: plugin
+action:
+  title: Title
+  deprecated: The action is not maintained anymore
+  replaced_by: new:action
+`
+
+const validTagsYaml = `
+runtime: plugin
+action:
+  title: Title
+  tags: [build, deploy]
+`
+
+const validVersionYaml = `
+runtime: plugin
+action:
+  title: Title
+  version: "1.1.0"
+  changelog:
+    - version: "1.1.0"
+      description: Add option opt1
+    - version: "1.0.0"
+      description: Initial release
+`
+
+const validRequiresLaunchrYaml = `
+runtime: plugin
+action:
+  title: Title
+  requires_launchr: ">=0.18, <1.0"
+`
+
+const invalidRequiresLaunchrYaml = `
+runtime: plugin
+action:
+  title: Title
+  requires_launchr: "~0.18"
+`
+
+const validCacheYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: docker:27-cli
+  command: docker build .
+  docker_socket: true
+  cache:
+    - name: buildx
+      path: /root/.cache/buildx
+    - name: go-mod
+      path: /go/pkg/mod
+      shared: true
+`
+
+const validSELinuxLabelYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  selinux_label: private
+`
+
+const invalidSELinuxLabelYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  selinux_label: strict
+`
+
+const invalidWaitForYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  wait_for: started
+`
+
+const invalidHealthyServiceYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  service: true
+  wait_for: healthy
+`
+
+const invalidActionIDYaml = `
+action:
+  id: "build app"
+  title: Title
+runtime: plugin
+`
+
+const validTmpfsYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  shm_size: 1g
+  tmpfs:
+    - path: /tmp
+      size: 512m
+    - path: /run
+`
+
+const invalidTmpfsPathYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  tmpfs:
+    - path: tmp
+`
+
+const invalidTmpfsSizeYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  tmpfs:
+    - path: /tmp
+      size: lots
+`
+
+const invalidDependencyYaml = `
+action:
+  title: Title
+  depends_on:
+    - options:
+        mode: release
+runtime: plugin
+`
+
+const invalidMetaStepsYaml = `
+action:
+  title: Title
+runtime:
+  type: meta
+  steps: []
+`
+
+const invalidShellCommandYaml = `
+action:
+  title: Title
+runtime:
+  type: shell
+  workdir: sub
+`
+
+const invalidMetaStrategyYaml = `
+action:
+  title: Title
+runtime:
+  type: meta
+  strategy: random
+  steps: [ foo ]
+`
+
+const invalidMetaMaxParallelYaml = `
+action:
+  title: Title
+runtime:
+  type: meta
+  strategy: parallel
+  max_parallel: -2
+  steps: [ foo ]
+`
+
+const invalidMaxRestartsYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  service: true
+  restart:
+    max_restarts: -1
+`
+
+const invalidRestartBackoffYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  service: true
+  restart:
+    backoff: soon
+`
+
+const validRunTimeoutYaml = `
+action:
+  title: Title
+runtime:
+  type: shell
+  timeout: 1m30s
+  command: ls
+`
+
+const invalidRunTimeoutYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  timeout: forever
+`
+
+const validStepsYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  continue_on_error: true
+  steps:
+    - name: lint
+      command: make lint
+    - command: [make, test]
+`
+
+const invalidStepsCommandYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  steps:
+    - command: make lint
+`
+
+const invalidStepsDupNameYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  steps:
+    - name: lint
+      command: make lint
+    - name: lint
+      command: make vet
+`
+
+const invalidStepsEmptyCmdYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  steps:
+    - name: lint
+`
+
+const validArtifactsYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: make build
+  artifacts: [dist, /var/log/build.log]
+`
+
+const invalidArtifactsPathYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: make build
+  artifacts: [../dist]
+`
+
+const invalidArtifactsHealthyYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: make serve
+  wait_for: healthy
+  artifacts: [dist]
+`
+
+const invalidRunBudgetYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  budget: nightly
+`
+
+const invalidShmSizeYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  shm_size: -1
+`
+
+const invalidCacheNameYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  cache:
+    - name: my cache
+      path: /cache
+`
+
+const invalidCachePathYaml = `
+action:
+  title: Title
+runtime:
+  type: container
+  image: alpine
+  command: ls
+  cache:
+    - name: cache
+      path: cache
+`
+
 const validCmdArrYaml = `
 action:
   title: Title
@@ -463,6 +814,20 @@ action:
       required: true
 `
 
+const validSensitiveParams = `
+runtime: plugin
+action:
+  title: Title
+  arguments:
+    - name: user
+    - name: password
+      sensitive: true
+  options:
+    - name: tokens
+      type: array
+      sensitive: true
+`
+
 const validArgBoolean = `
 runtime: plugin
 action:
@@ -3,10 +3,13 @@ package action
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/launchrctl/launchr/internal/launchr"
 	"github.com/launchrctl/launchr/pkg/jsonschema"
 )
 
@@ -28,6 +31,10 @@ func Test_CreateFromYaml(t *testing.T) {
 		{"valid empty version yaml v1", validEmptyVersionYaml, nil},
 		// Version >v1 is unsupported.
 		{"unsupported version >=1", unsupportedVersionYaml, errUnsupportedActionVersion{"2"}},
+		// Deprecated action with a replacement.
+		{"valid deprecated action", validDeprecatedYaml, nil},
+		// Action tags.
+		{"valid tags", validTagsYaml, nil},
 
 		// Image field in not provided v1.
 		{"empty image field v1", invalidEmptyImgYaml, yamlTypeErrorLine(sErrEmptyRuntimeImg, 7, 3)},
@@ -61,6 +68,41 @@ func Test_CreateFromYaml(t *testing.T) {
 		)},
 		{"invalid json schema type", invalidJSONSchemaTypeYaml, yamlTypeErrorLine(fmt.Sprintf("json schema type %q is unsupported", "unsup"), 8, 13)},
 
+		// Launchr version requirement.
+		{"valid launchr requirement", validRequiresLaunchrYaml, nil},
+		{"invalid launchr requirement", invalidRequiresLaunchrYaml, fmt.Errorf(sErrInvalidRequirement, "~0.18")},
+
+		// Container cache volumes.
+		{"valid cache", validCacheYaml, nil},
+		{"invalid cache name", invalidCacheNameYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidCacheName, "my cache"), 9, 13)},
+		{"invalid cache path", invalidCachePathYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidCachePath, "cache"), 10, 13)},
+		{"valid selinux label", validSELinuxLabelYaml, nil},
+		{"invalid selinux label", invalidSELinuxLabelYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidSELinuxLabel, "strict"), 8, 18)},
+		{"invalid wait condition", invalidWaitForYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidWaitFor, "started"), 8, 13)},
+		{"healthy service", invalidHealthyServiceYaml, yamlTypeErrorLine(sErrHealthyService, 9, 13)},
+		{"invalid action id", invalidActionIDYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidActionID, "build app"), 3, 7)},
+		{"valid tmpfs and shm size", validTmpfsYaml, nil},
+		{"invalid tmpfs path", invalidTmpfsPathYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidTmpfsPath, "tmp"), 9, 13)},
+		{"invalid tmpfs size", invalidTmpfsSizeYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMemorySize, "lots"), 10, 13)},
+		{"invalid shm size", invalidShmSizeYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMemorySize, "-1"), 8, 13)},
+		{"empty dependency action", invalidDependencyYaml, yamlTypeErrorLine(sErrEmptyDependency, 5, 7)},
+		{"empty meta steps", invalidMetaStepsYaml, yamlTypeErrorLine(sErrEmptyMetaSteps, 6, 10)},
+		{"empty shell command", invalidShellCommandYaml, yamlTypeErrorLine(sErrEmptyRuntimeCmd, 5, 3)},
+		{"invalid meta strategy", invalidMetaStrategyYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMetaStrategy, "random"), 6, 13)},
+		{"invalid meta max parallel", invalidMetaMaxParallelYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMaxParallel, -2), 7, 17)},
+		{"invalid max restarts", invalidMaxRestartsYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidMaxRestarts, -1), 10, 19)},
+		{"invalid restart backoff", invalidRestartBackoffYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRestartBackoff, "soon"), 10, 14)},
+		{"valid run timeout", validRunTimeoutYaml, nil},
+		{"invalid run budget", invalidRunBudgetYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRunBudget, "nightly"), 8, 11)},
+		{"invalid run timeout", invalidRunTimeoutYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidRunTimeout, "forever"), 8, 12)},
+		{"valid artifacts", validArtifactsYaml, nil},
+		{"invalid artifact path", invalidArtifactsPathYaml, yamlTypeErrorLine(fmt.Sprintf(sErrInvalidArtifactPath, "../dist"), 8, 14)},
+		{"artifacts of healthy container", invalidArtifactsHealthyYaml, yamlTypeErrorLine(sErrArtifactsHealthy, 9, 14)},
+		{"valid steps", validStepsYaml, nil},
+		{"invalid steps with command", invalidStepsCommandYaml, yamlTypeErrorLine(sErrCommandAndSteps, 9, 5)},
+		{"invalid steps duplicate name", invalidStepsDupNameYaml, yamlTypeErrorLine(fmt.Sprintf(sErrDupStepName, "lint"), 8, 5)},
+		{"invalid steps empty command", invalidStepsEmptyCmdYaml, yamlTypeErrorLine(fmt.Sprintf(sErrEmptyStepCmd, "lint"), 8, 5)},
+
 		// Command declaration as array of strings.
 		{"valid command - strings array", validCmdArrYaml, nil},
 		{"invalid command - object", invalidCmdObjYaml, yamlTypeErrorLine(sErrArrOrStrEl, 8, 5)},
@@ -102,6 +144,19 @@ func Test_CreateFromYaml(t *testing.T) {
 	// @todo test that the content is in place
 }
 
+func Test_DefActionDeprecation(t *testing.T) {
+	t.Parallel()
+	def, err := NewDefFromYaml([]byte(validDeprecatedYaml))
+	assert.NoError(t, err)
+	assert.True(t, def.Action.IsDeprecated())
+	assert.Equal(t, "the action is deprecated: The action is not maintained anymore, use \"new:action\" instead", def.Action.DeprecationMessage())
+
+	def, err = NewDefFromYaml([]byte(validEmptyVersionYaml))
+	assert.NoError(t, err)
+	assert.False(t, def.Action.IsDeprecated())
+	assert.Equal(t, "", def.Action.DeprecationMessage())
+}
+
 func Test_CreateFromYamlTpl(t *testing.T) {
 	t.Parallel()
 
@@ -130,3 +185,145 @@ func Test_CreateFromYamlTpl(t *testing.T) {
 		})
 	}
 }
+
+func Test_DefActionTags(t *testing.T) {
+	t.Parallel()
+	def, err := NewDefFromYaml([]byte(validTagsYaml))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build", "deploy"}, def.Action.Tags)
+	assert.True(t, def.Action.HasTag("deploy"))
+	assert.False(t, def.Action.HasTag("db"))
+}
+
+func Test_DefActionVersion(t *testing.T) {
+	t.Parallel()
+	def, err := NewDefFromYaml([]byte(validVersionYaml))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0", def.Action.Version)
+	assert.Equal(t, []DefChangelog{
+		{Version: "1.1.0", Description: "Add option opt1"},
+		{Version: "1.0.0", Description: "Initial release"},
+	}, def.Action.Changelog)
+}
+
+func Test_CheckLaunchrRequirement(t *testing.T) {
+	t.Parallel()
+	type testCase struct {
+		name   string
+		req    string
+		cur    string
+		expErr bool
+	}
+	tt := []testCase{
+		{"no requirement", "", "v0.1.0", false},
+		{"satisfied", ">=0.18", "v0.18.0", false},
+		{"satisfied without prefix", ">=v0.18", "0.19.2", false},
+		{"not satisfied", ">=0.18", "v0.17.5", true},
+		{"range satisfied", ">=0.18, <1.0", "v0.20.1", false},
+		{"range not satisfied", ">=0.18, <1.0", "v1.0.0", true},
+		{"exact", "0.18.1", "v0.18.1", false},
+		{"exact not satisfied", "=0.18.1", "v0.18.2", true},
+		{"development build", ">=0.18", "(devel)", false},
+	}
+	for _, tt := range tt {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkLaunchrRequirement(tt.req, tt.cur)
+			if !tt.expErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, ErrLaunchrVersionRequirement{Required: tt.req, Current: tt.cur}, err)
+		})
+	}
+	assert.Error(t, checkLaunchrRequirement(">=0.18 || <0.1", "v0.18.0"))
+}
+
+func Test_DefActionIncompatibleChanges(t *testing.T) {
+	t.Parallel()
+	prev := &DefAction{
+		Arguments: ParametersList{
+			{Name: "arg1", Type: jsonschema.String},
+			{Name: "arg2", Type: jsonschema.String, Enum: []any{"a", "b"}},
+			{Name: "arg3", Type: jsonschema.String},
+		},
+		Options: ParametersList{
+			{Name: "opt1", Type: jsonschema.String},
+			{Name: "opt2", Type: jsonschema.Boolean},
+			{Name: "opt3", Type: jsonschema.String},
+		},
+	}
+	assert.Empty(t, prev.IncompatibleChanges(prev))
+
+	compatible := &DefAction{
+		Arguments: append(slices.Clone(prev.Arguments), &DefParameter{Name: "arg4", Type: jsonschema.String}),
+		Options:   append(slices.Clone(prev.Options), &DefParameter{Name: "opt4", Type: jsonschema.String}),
+	}
+	assert.Empty(t, compatible.IncompatibleChanges(prev))
+
+	cur := &DefAction{
+		Arguments: ParametersList{
+			{Name: "arg1", Type: jsonschema.Integer},
+			{Name: "arg2", Type: jsonschema.String, Enum: []any{"a"}},
+		},
+		Options: ParametersList{
+			{Name: "opt1", Type: jsonschema.String, Required: true},
+			{Name: "opt3", Type: jsonschema.String, Enum: []any{"x"}},
+			{Name: "opt4", Type: jsonschema.String, Required: true},
+		},
+	}
+	assert.Equal(t, []string{
+		`argument "arg1" type is changed from "string" to "integer"`,
+		`argument "arg2" doesn't accept value b anymore`,
+		`argument "arg3" is removed`,
+		`option "opt1" is required now`,
+		`option "opt2" is removed`,
+		`option "opt3" accepts only values [x] now`,
+		`required option "opt4" is added`,
+	}, cur.IncompatibleChanges(prev))
+}
+
+func Test_DefinitionBuilder(t *testing.T) {
+	t.Parallel()
+	a, err := NewDefinitionBuilder().
+		Title("Deploy").
+		Alias("d").
+		Tags("deploy").
+		Arg(NewParameterBuilder("env").Enum("dev", "prod").Required()).
+		Option(NewParameterBuilder("dry-run").Type(jsonschema.Boolean).Default(false)).
+		Option(NewParameterBuilder("list").Type(jsonschema.Array).Items(jsonschema.Integer)).
+		ContainerImage("alpine:3.20").
+		Command("sh", "-c", "echo {{ .env }}").
+		Env("MY_ENV", "value").
+		BuildAction("deploy")
+	require.NoError(t, err)
+	def := a.ActionDef()
+	assert.Equal(t, "Deploy", def.Title)
+	assert.Equal(t, []string{"d"}, def.Aliases)
+	require.Len(t, def.Arguments, 1)
+	assert.Equal(t, []any{"dev", "prod"}, def.Arguments[0].Enum)
+	assert.True(t, def.Arguments[0].Required)
+	require.Len(t, def.Options, 2)
+	assert.Equal(t, false, def.Options[0].Default)
+	assert.Equal(t, jsonschema.Integer, def.Options[1].Items.Type)
+
+	input := NewInput(a, InputParams{"env": "prod"}, nil, launchr.NoopStreams())
+	require.NoError(t, a.SetInput(input))
+	rdef := a.RuntimeDef()
+	assert.Equal(t, "alpine:3.20", rdef.Container.Image)
+	assert.Equal(t, StrSliceOrStr{"sh", "-c", "echo prod"}, rdef.Container.Command)
+	assert.Equal(t, EnvSlice{"MY_ENV=value"}, rdef.Container.Env)
+
+	// Invalid definitions are reported on build.
+	_, err = NewDefinitionBuilder().Title("No runtime").Build()
+	assert.Error(t, err)
+	_, err = NewDefinitionBuilder().PluginRuntime().Arg(NewParameterBuilder("invalid name")).Build()
+	assert.Error(t, err)
+	_, err = NewDefinitionBuilder().PluginRuntime().
+		Option(NewParameterBuilder("num").Type(jsonschema.Integer).Default("str")).Build()
+	assert.Error(t, err)
+	d, err := NewDefinitionBuilder().PluginRuntime().Build()
+	require.NoError(t, err)
+	assert.Equal(t, runtimeTypePlugin, d.Runtime.Type)
+}
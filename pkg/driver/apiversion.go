@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/versions"
+)
+
+// APIFeature is a feature of the container engine API used by launchr.
+type APIFeature struct {
+	// Name describes the feature in error messages.
+	Name string
+	// MinVersion is the first API version supporting the feature.
+	MinVersion string
+}
+
+// Features of the docker API depending on the daemon version.
+var (
+	// APIFeatureAutoRemove removes containers on the daemon side when they exit.
+	APIFeatureAutoRemove = APIFeature{Name: "automatic removal of containers", MinVersion: "1.25"}
+	// APIFeatureWaitCondition waits for a container to be removed, not only to exit.
+	APIFeatureWaitCondition = APIFeature{Name: "waiting for container removal", MinVersion: "1.30"}
+	// APIFeatureCopyOwner keeps the owner of the files copied to a container.
+	APIFeatureCopyOwner = APIFeature{Name: "copying files with the owner", MinVersion: "1.30"}
+)
+
+// ContainerRunnerAPIVersion defines a container runner reporting the API version of the daemon.
+type ContainerRunnerAPIVersion interface {
+	// APIVersion returns the API version negotiated with the daemon.
+	APIVersion(ctx context.Context) (string, error)
+}
+
+// APIFeatures checks the features supported by an API version.
+// The zero value supports all features, it's used when the version is unknown.
+type APIFeatures struct {
+	version string
+}
+
+// NewAPIFeatures creates [APIFeatures] of the API version.
+func NewAPIFeatures(version string) APIFeatures {
+	return APIFeatures{version: version}
+}
+
+// Version returns the API version.
+func (f APIFeatures) Version() string {
+	return f.version
+}
+
+// Supports checks if the feature is supported.
+func (f APIFeatures) Supports(feat APIFeature) bool {
+	return f.version == "" || !versions.LessThan(f.version, feat.MinVersion)
+}
+
+// Require returns an error naming the feature if it's not supported.
+func (f APIFeatures) Require(feat APIFeature) error {
+	if f.Supports(feat) {
+		return nil
+	}
+	return fmt.Errorf(
+		"docker API version %s doesn't support %s, API version %s or newer is required, upgrade the docker daemon",
+		f.version, feat.Name, feat.MinVersion,
+	)
+}
@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// buildContextExcludes reads the exclude patterns of .dockerignore in the build context dir.
+// The build file and .dockerignore are always sent, the same as docker CLI does.
+func buildContextExcludes(dir, buildfile string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	excludes, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(excludes) == 0 {
+		return nil, nil
+	}
+	if buildfile == "" {
+		buildfile = "Dockerfile"
+	}
+	return append(excludes, "!"+filepath.ToSlash(filepath.Clean(buildfile)), "!.dockerignore"), nil
+}
+
+// buildContextSize returns the size of the files of the build context dir not matching the excludes.
+func buildContextSize(dir string, excludes []string) (int64, error) {
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		skip, err := pm.MatchesOrParentMatches(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		if skip {
+			if d.IsDir() && !hasExclusionsIn(pm, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// hasExclusionsIn checks if files of the excluded dir may be included back, e.g. with "!dir/file".
+func hasExclusionsIn(pm *patternmatcher.PatternMatcher, dir string) bool {
+	prefix := filepath.ToSlash(dir) + "/"
+	for _, p := range pm.Patterns() {
+		if p.Exclusion() && strings.HasPrefix(p.String()+"/", prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BuildContext(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":         "FROM alpine",
+		"build.Dockerfile":   "FROM alpine",
+		".dockerignore":      "# data\n/data\n*.Dockerfile\n!data/keep.txt\n",
+		"main.go":            "package main",
+		"data/big.bin":       "0123456789",
+		"data/keep.txt":      "keep",
+		"data/nested/other":  "0123456789",
+		"vendor/lib/lib.txt": "lib",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	excludes, err := buildContextExcludes(dir, "build.Dockerfile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data", "*.Dockerfile", "!data/keep.txt", "!build.Dockerfile", "!.dockerignore"}, excludes)
+	size, err := buildContextSize(dir, excludes)
+	require.NoError(t, err)
+	expected := 0
+	for _, name := range []string{"Dockerfile", "build.Dockerfile", ".dockerignore", "main.go", "data/keep.txt", "vendor/lib/lib.txt"} {
+		expected += len(files[name])
+	}
+	assert.Equal(t, int64(expected), size)
+
+	// Without .dockerignore the whole context is sent.
+	require.NoError(t, os.Remove(filepath.Join(dir, ".dockerignore")))
+	excludes, err = buildContextExcludes(dir, "")
+	require.NoError(t, err)
+	assert.Nil(t, excludes)
+	size, err = buildContextSize(dir, excludes)
+	require.NoError(t, err)
+	expected = 0
+	for name, content := range files {
+		if name != ".dockerignore" {
+			expected += len(content)
+		}
+	}
+	assert.Equal(t, int64(expected), size)
+}
@@ -2,13 +2,21 @@ package driver
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/archive"
@@ -17,20 +25,48 @@ import (
 )
 
 type dockerDriver struct {
-	cli client.APIClient
+	cli      client.APIClient
+	timeouts Timeouts
 }
 
 // NewDockerDriver creates a docker driver.
 func NewDockerDriver() (ContainerRunner, error) {
-	// @todo it doesn't work with Colima or with non-default context.
-	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return NewDockerDriverWithOptions(DockerOptions{})
+}
 
+// NewDockerDriverWithOptions creates a docker driver connecting to the host resolved
+// with [ResolveDockerEndpoint].
+func NewDockerDriverWithOptions(opts DockerOptions) (ContainerRunner, error) {
+	ep, err := ResolveDockerEndpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	copts, err := ep.clientOpts()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewClientWithOpts(copts...)
 	if err != nil {
 		return nil, err
 	}
 	return &dockerDriver{cli: c}, nil
 }
 
+// SetTimeouts implements [ContainerRunnerTimeouts] interface.
+func (d *dockerDriver) SetTimeouts(t Timeouts) {
+	d.timeouts = t
+}
+
+// APIVersion implements [ContainerRunnerAPIVersion] interface.
+func (d *dockerDriver) APIVersion(ctx context.Context) (string, error) {
+	ping, err := d.cli.Ping(ctx)
+	if err != nil {
+		return "", err
+	}
+	d.cli.NegotiateAPIVersionPing(ping)
+	return d.cli.ClientVersion(), nil
+}
+
 func (d *dockerDriver) Info(ctx context.Context) (types.SystemInfo, error) {
 	info, err := d.cli.Info(ctx)
 	if err != nil {
@@ -64,12 +100,48 @@ func (d *dockerDriver) IsSELinuxSupported(ctx context.Context) bool {
 	return false
 }
 
+// UserNamespace implements [ContainerRunnerUserns] interface.
+func (d *dockerDriver) UserNamespace(ctx context.Context) (UserNamespace, error) {
+	info, err := d.cli.Info(ctx)
+	if err != nil {
+		return UserNamespace{}, err
+	}
+	var ns UserNamespace
+	for _, opt := range info.SecurityOptions {
+		switch opt {
+		case "name=rootless":
+			ns.Rootless = true
+		case "name=userns":
+			ns.Remapped = true
+		}
+	}
+	if !ns.Rootless {
+		// Podman keeps the user id only in the rootless mode.
+		return ns, nil
+	}
+	v, err := d.cli.ServerVersion(ctx)
+	if err != nil {
+		return UserNamespace{}, err
+	}
+	for _, c := range v.Components {
+		if strings.HasPrefix(c.Name, "Podman") {
+			ns.KeepID = true
+		}
+	}
+	return ns, nil
+}
+
 func (d *dockerDriver) ContainerList(ctx context.Context, opts types.ContainerListOptions) []types.ContainerListResult {
 	f := filters.NewArgs()
-	f.Add("name", opts.SearchName)
+	if opts.SearchName != "" {
+		f.Add("name", opts.SearchName)
+	}
+	for _, l := range opts.Labels {
+		f.Add("label", l)
+	}
 	l, err := d.cli.ContainerList(ctx, container.ListOptions{
 		Filters: f,
-		All:     true,
+		All:     !opts.Running,
 	})
 	if err != nil {
 		return nil
@@ -80,11 +152,41 @@ func (d *dockerDriver) ContainerList(ctx context.Context, opts types.ContainerLi
 			ID:     c.ID,
 			Names:  c.Names,
 			Status: c.Status,
+			State:  c.State,
+			Labels: c.Labels,
 		}
 	}
 	return lp
 }
 
+func (d *dockerDriver) VolumeList(ctx context.Context, opts types.VolumeListOptions) ([]types.VolumeListResult, error) {
+	f := filters.NewArgs()
+	for _, l := range opts.Labels {
+		f.Add("label", l)
+	}
+	if opts.Dangling {
+		f.Add("dangling", "true")
+	}
+	resp, err := d.cli.VolumeList(ctx, volume.ListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.VolumeListResult, len(resp.Volumes))
+	for i, v := range resp.Volumes {
+		res[i] = types.VolumeListResult{Name: v.Name, Labels: v.Labels}
+	}
+	return res, nil
+}
+
+func (d *dockerDriver) VolumeRemove(ctx context.Context, name string) error {
+	return d.cli.VolumeRemove(ctx, name, false)
+}
+
+func (d *dockerDriver) VolumeCreate(ctx context.Context, opts types.VolumeCreateOptions) error {
+	_, err := d.cli.VolumeCreate(ctx, volume.CreateOptions{Name: opts.Name, Labels: opts.Labels})
+	return err
+}
+
 func (d *dockerDriver) ImageEnsure(ctx context.Context, imgOpts types.ImageOptions) (*types.ImageStatusResponse, error) {
 	// Check if the image already exists.
 	insp, _, err := d.cli.ImageInspectWithRaw(ctx, imgOpts.Name)
@@ -99,27 +201,45 @@ func (d *dockerDriver) ImageEnsure(ctx context.Context, imgOpts types.ImageOptio
 	}
 	// Build the image if it doesn't exist.
 	if imgOpts.Build != nil {
-		buildContext, errTar := archive.TarWithOptions(imgOpts.Build.Context, &archive.TarOptions{})
+		excludes, errIgnore := buildContextExcludes(imgOpts.Build.Context, imgOpts.Build.Buildfile)
+		if errIgnore != nil {
+			return nil, fmt.Errorf("failed to read .dockerignore of build context %q: %w", imgOpts.Build.Context, errIgnore)
+		}
+		ctxSize, errSize := buildContextSize(imgOpts.Build.Context, excludes)
+		if errSize != nil {
+			return nil, errSize
+		}
+		buildContext, errTar := archive.TarWithOptions(imgOpts.Build.Context, &archive.TarOptions{ExcludePatterns: excludes})
 		if errTar != nil {
 			return nil, errTar
 		}
-		resp, errBuild := d.cli.ImageBuild(ctx, buildContext, dockertypes.ImageBuildOptions{
+		// The timeout includes streaming of the build output, the context is canceled when the output is closed.
+		buildCtx, cancel := withTimeout(ctx, d.timeouts.ImageBuild)
+		resp, errBuild := d.cli.ImageBuild(buildCtx, buildContext, dockertypes.ImageBuildOptions{
 			Tags:       []string{imgOpts.Name},
 			BuildArgs:  imgOpts.Build.Args,
 			Dockerfile: imgOpts.Build.Buildfile,
 			NoCache:    imgOpts.NoCache,
+			Labels:     imgOpts.Labels,
 		})
 		if errBuild != nil {
-			return nil, errBuild
+			cancel()
+			return nil, timeoutError(buildCtx, "image build", d.timeouts.ImageBuild, errBuild)
 		}
-		return &types.ImageStatusResponse{Status: types.ImageBuild, Progress: resp.Body}, nil
+		return &types.ImageStatusResponse{
+			Status:      types.ImageBuild,
+			Progress:    cancelOnClose{resp.Body, cancel},
+			ContextSize: ctxSize,
+		}, nil
 	}
 	// Pull the specified image.
-	reader, err := d.cli.ImagePull(ctx, imgOpts.Name, image.PullOptions{})
+	pullCtx, cancel := withTimeout(ctx, d.timeouts.ImagePull)
+	reader, err := d.cli.ImagePull(pullCtx, imgOpts.Name, image.PullOptions{})
 	if err != nil {
-		return &types.ImageStatusResponse{Status: types.ImageUnexpectedError}, err
+		cancel()
+		return &types.ImageStatusResponse{Status: types.ImageUnexpectedError}, timeoutError(pullCtx, "image pull", d.timeouts.ImagePull, err)
 	}
-	return &types.ImageStatusResponse{Status: types.ImagePull, Progress: reader}, nil
+	return &types.ImageStatusResponse{Status: types.ImagePull, Progress: cancelOnClose{reader, cancel}}, nil
 }
 
 func (d *dockerDriver) ImageRemove(ctx context.Context, img string, options types.ImageRemoveOptions) (*types.ImageRemoveResponse, error) {
@@ -152,8 +272,33 @@ func (d *dockerDriver) ContainerCreate(ctx context.Context, opts types.Container
 		ExtraHosts:  opts.ExtraHosts,
 		NetworkMode: container.NetworkMode(opts.NetworkMode),
 		Binds:       opts.Binds,
+
+		ReadonlyRootfs: opts.ReadonlyRootfs,
+		Tmpfs:          opts.Tmpfs,
+		ShmSize:        opts.ShmSize,
+		CapDrop:        opts.CapDrop,
+		CapAdd:         opts.CapAdd,
+		SecurityOpt:    opts.SecurityOpt,
+		UsernsMode:     container.UsernsMode(opts.UsernsMode),
+	}
+	if opts.Init {
+		hostCfg.Init = &opts.Init
+	}
+	volumes := opts.Volumes
+	if len(opts.VolumeLabels) > 0 {
+		// Anonymous volumes can be labeled only as mounts.
+		volumes = nil
+		for _, target := range slices.Sorted(maps.Keys(opts.Volumes)) {
+			hostCfg.Mounts = append(hostCfg.Mounts, mount.Mount{
+				Type:          mount.TypeVolume,
+				Target:        target,
+				VolumeOptions: &mount.VolumeOptions{Labels: opts.VolumeLabels},
+			})
+		}
 	}
 
+	ctx, cancel := withTimeout(ctx, d.timeouts.ContainerCreate)
+	defer cancel()
 	resp, err := d.cli.ContainerCreate(
 		ctx,
 		&container.Config{
@@ -169,14 +314,15 @@ func (d *dockerDriver) ContainerCreate(ctx context.Context, opts types.Container
 			Tty:          opts.Tty,
 			Env:          opts.Env,
 			User:         opts.User,
-			Volumes:      opts.Volumes,
+			Volumes:      volumes,
 			Entrypoint:   opts.Entrypoint,
+			Labels:       opts.Labels,
 		},
 		hostCfg,
 		nil, nil, opts.ContainerName,
 	)
 	if err != nil {
-		return "", err
+		return "", timeoutError(ctx, "container create", d.timeouts.ContainerCreate, err)
 	}
 
 	return resp.ID, nil
@@ -187,11 +333,21 @@ func (d *dockerDriver) ContainerStart(ctx context.Context, cid string, _ types.C
 }
 
 func (d *dockerDriver) ContainerWait(ctx context.Context, cid string, opts types.ContainerWaitOptions) (<-chan types.ContainerWaitResponse, <-chan error) {
+	if opts.Condition == types.WaitConditionHealthy {
+		return d.containerWaitHealthy(ctx, cid)
+	}
 	statusCh, errCh := d.cli.ContainerWait(ctx, cid, container.WaitCondition(opts.Condition))
 
-	wrappedStCh := make(chan types.ContainerWaitResponse)
+	// The channel is buffered to not block if the result isn't read, e.g. after an error.
+	wrappedStCh := make(chan types.ContainerWaitResponse, 1)
 	go func() {
-		st := <-statusCh
+		var st container.WaitResponse
+		select {
+		case st = <-statusCh:
+		case <-ctx.Done():
+			// The error is sent to errCh by the client.
+			return
+		}
 		var err error
 		if st.Error != nil {
 			err = errors.New(st.Error.Message)
@@ -205,10 +361,76 @@ func (d *dockerDriver) ContainerWait(ctx context.Context, cid string, opts types
 	return wrappedStCh, errCh
 }
 
+// healthPollInterval is a period of checking the health status of a container.
+const healthPollInterval = 500 * time.Millisecond
+
+// containerWaitHealthy polls the state of the container until it's healthy or exits.
+func (d *dockerDriver) containerWaitHealthy(ctx context.Context, cid string) (<-chan types.ContainerWaitResponse, <-chan error) {
+	statusCh := make(chan types.ContainerWaitResponse, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		ticker := time.NewTicker(healthPollInterval)
+		defer ticker.Stop()
+		for {
+			info, err := d.cli.ContainerInspect(ctx, cid)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			res, done, err := healthWaitResult(info.State)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if done {
+				statusCh <- res
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return statusCh, errCh
+}
+
+// healthWaitResult returns the result of waiting for a healthy container with the state.
+// The wait is done when the container is healthy or exited.
+func healthWaitResult(st *dockertypes.ContainerState) (types.ContainerWaitResponse, bool, error) {
+	switch {
+	case st == nil || st.Status == "created":
+		// The health check starts with the container.
+		return types.ContainerWaitResponse{}, false, nil
+	case st.Status == "exited" || st.Status == "dead":
+		return types.ContainerWaitResponse{
+			StatusCode: st.ExitCode,
+			Error:      fmt.Errorf("container exited with code %d before it became healthy", st.ExitCode),
+		}, true, nil
+	case st.Health == nil || st.Health.Status == dockertypes.NoHealthcheck:
+		return types.ContainerWaitResponse{}, false, errors.New("container has no health check")
+	case st.Health.Status == dockertypes.Healthy:
+		return types.ContainerWaitResponse{}, true, nil
+	case st.Health.Status == dockertypes.Unhealthy:
+		msg := "container is unhealthy"
+		if l := len(st.Health.Log); l > 0 && st.Health.Log[l-1] != nil {
+			msg += ": " + strings.TrimSpace(st.Health.Log[l-1].Output)
+		}
+		return types.ContainerWaitResponse{StatusCode: 1, Error: errors.New(msg)}, true, nil
+	default:
+		return types.ContainerWaitResponse{}, false, nil
+	}
+}
+
 func (d *dockerDriver) ContainerAttach(ctx context.Context, containerID string, options types.ContainerAttachOptions) (*ContainerInOut, error) {
+	// The hijacked connection is not bound to the context, only the connection is limited.
+	ctx, cancel := withTimeout(ctx, d.timeouts.ContainerAttach)
+	defer cancel()
 	resp, err := d.cli.ContainerAttach(ctx, containerID, container.AttachOptions(options))
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "container attach", d.timeouts.ContainerAttach, err)
 	}
 
 	return &ContainerInOut{In: resp.Conn, Out: resp.Reader}, nil
@@ -218,8 +440,8 @@ func (d *dockerDriver) ContainerStop(ctx context.Context, cid string) error {
 	return d.cli.ContainerStop(ctx, cid, container.StopOptions{})
 }
 
-func (d *dockerDriver) ContainerRemove(ctx context.Context, cid string, _ types.ContainerRemoveOptions) error {
-	return d.cli.ContainerRemove(ctx, cid, container.RemoveOptions{})
+func (d *dockerDriver) ContainerRemove(ctx context.Context, cid string, opts types.ContainerRemoveOptions) error {
+	return d.cli.ContainerRemove(ctx, cid, opts)
 }
 
 func (d *dockerDriver) ContainerKill(ctx context.Context, containerID, signal string) error {
@@ -240,6 +462,67 @@ func (d *dockerDriver) ContainerExecResize(ctx context.Context, cid string, opts
 	})
 }
 
+func (d *dockerDriver) ContainerExecCreate(ctx context.Context, cid string, opts types.ContainerExecOptions) (string, error) {
+	ctx, cancel := withTimeout(ctx, d.timeouts.ContainerExec)
+	defer cancel()
+	resp, err := d.cli.ContainerExecCreate(ctx, cid, opts)
+	if err != nil {
+		return "", timeoutError(ctx, "container exec", d.timeouts.ContainerExec, err)
+	}
+	return resp.ID, nil
+}
+
+func (d *dockerDriver) ContainerExecAttach(ctx context.Context, execID string, opts types.ContainerExecAttachOptions) (*ContainerInOut, error) {
+	// Attaching starts the command.
+	ctx, cancel := withTimeout(ctx, d.timeouts.ContainerExec)
+	defer cancel()
+	resp, err := d.cli.ContainerExecAttach(ctx, execID, opts)
+	if err != nil {
+		return nil, timeoutError(ctx, "container exec", d.timeouts.ContainerExec, err)
+	}
+
+	return &ContainerInOut{In: resp.Conn, Out: resp.Reader}, nil
+}
+
+func (d *dockerDriver) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	return d.cli.ContainerExecInspect(ctx, execID)
+}
+
+// ContainerStats implements [ContainerRunnerStats] interface.
+func (d *dockerDriver) ContainerStats(ctx context.Context, cid string) (<-chan types.ContainerStats, error) {
+	resp, err := d.cli.ContainerStats(ctx, cid, true)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan types.ContainerStats)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var s container.StatsResponse
+			if err := dec.Decode(&s); err != nil {
+				return
+			}
+			sample := types.ContainerStats{
+				CPUTime:   time.Duration(s.CPUStats.CPUUsage.TotalUsage), //nolint:gosec // Nanoseconds fit.
+				Memory:    s.MemoryStats.Usage,
+				MaxMemory: s.MemoryStats.MaxUsage,
+			}
+			for _, n := range s.Networks {
+				sample.NetworkRx += n.RxBytes
+				sample.NetworkTx += n.TxBytes
+			}
+			select {
+			case ch <- sample:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
 // Close closes docker cli connection.
 func (d *dockerDriver) Close() error {
 	return d.cli.Close()
@@ -0,0 +1,208 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// Sources of a resolved docker host.
+const (
+	DockerHostSourceConfig  = "config"      // DockerHostSourceConfig is the host set in the launchr config.
+	DockerHostSourceEnv     = "DOCKER_HOST" // DockerHostSourceEnv is the host set in the environment.
+	DockerHostSourceDefault = "default"     // DockerHostSourceDefault is the default host of the platform.
+	DockerHostSourceSocket  = "socket"      // DockerHostSourceSocket is a known socket of a docker distribution.
+)
+
+// dockerDefaultContext is a name of the docker context using the environment and the default host.
+const dockerDefaultContext = "default"
+
+// DockerOptions configure the docker driver.
+type DockerOptions struct {
+	// Host is an address of the docker daemon, e.g. "unix:///var/run/docker.sock" or "tcp://host:2376".
+	// It overrides DOCKER_HOST and the docker context.
+	Host string `yaml:"host"`
+}
+
+// DockerEndpoint is a resolved address of the docker daemon.
+type DockerEndpoint struct {
+	// Host is an address of the daemon, it's empty if the default host of the client is used.
+	Host string
+	// Source is where the host is taken from, one of DockerHostSource constants or a context name.
+	Source string
+	// TLSDir is a directory of the context with TLS files "ca.pem", "cert.pem" and "key.pem".
+	TLSDir string
+	// SkipTLSVerify disables verification of the daemon certificate.
+	SkipTLSVerify bool
+}
+
+// dockerContextMeta is a metadata file of a docker context.
+type dockerContextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// ResolveDockerEndpoint finds the address of the docker daemon the same way as the docker CLI.
+// The host of opts is used first, then DOCKER_HOST, then the context set in DOCKER_CONTEXT or
+// in the docker CLI config, e.g. a context created by Colima. Without a context, known sockets
+// of docker distributions are checked if the default socket doesn't exist.
+func ResolveDockerEndpoint(opts DockerOptions) (DockerEndpoint, error) {
+	if opts.Host != "" {
+		return DockerEndpoint{Host: opts.Host, Source: DockerHostSourceConfig}, nil
+	}
+	if host := os.Getenv(client.EnvOverrideHost); host != "" {
+		return DockerEndpoint{Host: host, Source: DockerHostSourceEnv}, nil
+	}
+	cfgDir := dockerConfigDir()
+	name := os.Getenv("DOCKER_CONTEXT")
+	if name == "" {
+		var err error
+		if name, err = dockerCurrentContext(cfgDir); err != nil {
+			return DockerEndpoint{}, err
+		}
+	}
+	if name != "" && name != dockerDefaultContext {
+		return dockerContextEndpoint(cfgDir, name)
+	}
+	if host := dockerKnownSocket(); host != "" {
+		return DockerEndpoint{Host: host, Source: DockerHostSourceSocket}, nil
+	}
+	return DockerEndpoint{Source: DockerHostSourceDefault}, nil
+}
+
+// dockerConfigDir returns the config directory of the docker CLI.
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// dockerCurrentContext reads the current context from the docker CLI config.
+func dockerCurrentContext(cfgDir string) (string, error) {
+	if cfgDir == "" {
+		return "", nil
+	}
+	content, err := os.ReadFile(filepath.Join(cfgDir, "config.json")) //nolint:gosec
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err = json.Unmarshal(content, &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return cfg.CurrentContext, nil
+}
+
+// dockerContextEndpoint reads the docker endpoint of the context from its metadata.
+func dockerContextEndpoint(cfgDir, name string) (DockerEndpoint, error) {
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	content, err := os.ReadFile(filepath.Join(cfgDir, "contexts", "meta", id, "meta.json")) //nolint:gosec
+	if os.IsNotExist(err) {
+		return DockerEndpoint{}, fmt.Errorf("docker context %q is not found", name)
+	}
+	if err != nil {
+		return DockerEndpoint{}, err
+	}
+	var meta dockerContextMeta
+	if err = json.Unmarshal(content, &meta); err != nil {
+		return DockerEndpoint{}, fmt.Errorf("failed to parse docker context %q: %w", name, err)
+	}
+	ep, ok := meta.Endpoints["docker"]
+	if !ok || ep.Host == "" {
+		return DockerEndpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+	res := DockerEndpoint{Host: ep.Host, Source: name, SkipTLSVerify: ep.SkipTLSVerify}
+	tlsDir := filepath.Join(cfgDir, "contexts", "tls", id, "docker")
+	if _, err = os.Stat(tlsDir); err == nil {
+		res.TLSDir = tlsDir
+	}
+	return res, nil
+}
+
+// dockerKnownSocket returns a socket of a docker distribution if the default socket doesn't exist,
+// e.g. of Colima or Docker Desktop without the system socket.
+func dockerKnownSocket() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	if _, err := os.Stat(strings.TrimPrefix(client.DefaultDockerHost, "unix://")); err == nil {
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	candidates := []string{
+		filepath.Join(home, ".colima", "default", "docker.sock"),
+		filepath.Join(home, ".colima", "docker.sock"),
+		filepath.Join(home, ".docker", "run", "docker.sock"),
+		filepath.Join(home, ".docker", "desktop", "docker.sock"),
+		filepath.Join(home, ".rd", "docker.sock"),
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		// Rootless docker.
+		candidates = append(candidates, filepath.Join(dir, "docker.sock"))
+	}
+	for _, p := range candidates {
+		if fi, err := os.Stat(p); err == nil && fi.Mode().Type() == os.ModeSocket {
+			return "unix://" + p
+		}
+	}
+	return ""
+}
+
+// clientOpts returns options of the docker client connecting to the endpoint.
+// The environment is still used for the API version and the TLS of DOCKER_HOST.
+func (ep DockerEndpoint) clientOpts() ([]client.Opt, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if ep.Host == "" || ep.Source == DockerHostSourceEnv {
+		return opts, nil
+	}
+	if strings.HasPrefix(ep.Host, "ssh://") {
+		return nil, fmt.Errorf("docker host %q of %s is not supported, ssh connections require the docker CLI", ep.Host, ep.Source)
+	}
+	if ep.TLSDir != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(ep.TLSDir, "ca.pem"),
+			filepath.Join(ep.TLSDir, "cert.pem"),
+			filepath.Join(ep.TLSDir, "key.pem"),
+		))
+	}
+	if ep.SkipTLSVerify {
+		opts = append(opts, withSkipTLSVerify)
+	}
+	return append(opts, client.WithHost(ep.Host)), nil
+}
+
+// withSkipTLSVerify disables verification of the daemon certificate.
+func withSkipTLSVerify(c *client.Client) error {
+	transport, ok := c.HTTPClient().Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		return errors.New("failed to skip TLS verification, the docker client has no TLS configuration")
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true //nolint:gosec
+	return nil
+}
@@ -0,0 +1,72 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResolveDockerEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	contextDir := func(kind, name string) string {
+		sum := sha256.Sum256([]byte(name))
+		return filepath.Join(dir, "contexts", kind, hex.EncodeToString(sum[:]))
+	}
+	writeFile(filepath.Join(dir, "config.json"), `{"currentContext": "colima"}`)
+	writeFile(filepath.Join(contextDir("meta", "colima"), "meta.json"),
+		`{"Name":"colima","Endpoints":{"docker":{"Host":"unix:///home/me/.colima/default/docker.sock","SkipTLSVerify":false}}}`)
+	writeFile(filepath.Join(contextDir("meta", "remote"), "meta.json"),
+		`{"Name":"remote","Endpoints":{"docker":{"Host":"tcp://remote:2376","SkipTLSVerify":true}}}`)
+	writeFile(filepath.Join(contextDir("tls", "remote"), "docker", "ca.pem"), "")
+	writeFile(filepath.Join(contextDir("meta", "ssh"), "meta.json"),
+		`{"Name":"ssh","Endpoints":{"docker":{"Host":"ssh://me@remote"}}}`)
+
+	type testCase struct {
+		name    string
+		opts    DockerOptions
+		env     map[string]string
+		exp     DockerEndpoint
+		expErr  string
+		expOpts string
+	}
+	tt := []testCase{
+		{"config host", DockerOptions{Host: "tcp://cfg:2375"}, map[string]string{"DOCKER_HOST": "tcp://env:2375"}, DockerEndpoint{Host: "tcp://cfg:2375", Source: DockerHostSourceConfig}, "", ""},
+		{"env host", DockerOptions{}, map[string]string{"DOCKER_HOST": "tcp://env:2375", "DOCKER_CONTEXT": "remote"}, DockerEndpoint{Host: "tcp://env:2375", Source: DockerHostSourceEnv}, "", ""},
+		{"current context", DockerOptions{}, nil, DockerEndpoint{Host: "unix:///home/me/.colima/default/docker.sock", Source: "colima"}, "", ""},
+		{"env context with tls", DockerOptions{}, map[string]string{"DOCKER_CONTEXT": "remote"}, DockerEndpoint{Host: "tcp://remote:2376", Source: "remote", TLSDir: filepath.Join(contextDir("tls", "remote"), "docker"), SkipTLSVerify: true}, "", ""},
+		{"unknown context", DockerOptions{}, map[string]string{"DOCKER_CONTEXT": "unknown"}, DockerEndpoint{}, `docker context "unknown" is not found`, ""},
+		{"ssh context", DockerOptions{}, map[string]string{"DOCKER_CONTEXT": "ssh"}, DockerEndpoint{Host: "ssh://me@remote", Source: "ssh"}, "", "ssh connections require the docker CLI"},
+	}
+	for _, tt := range tt {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_CONFIG", dir)
+			t.Setenv("DOCKER_HOST", "")
+			t.Setenv("DOCKER_CONTEXT", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			ep, err := ResolveDockerEndpoint(tt.opts)
+			if tt.expErr != "" {
+				assert.ErrorContains(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.exp, ep)
+			_, err = ep.clientOpts()
+			if tt.expOpts != "" {
+				assert.ErrorContains(t, err, tt.expOpts)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
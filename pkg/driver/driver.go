@@ -5,7 +5,10 @@ package driver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/launchrctl/launchr/pkg/types"
 )
@@ -35,3 +38,93 @@ type ContainerRunner interface {
 type ContainerRunnerSELinux interface {
 	IsSELinuxSupported(ctx context.Context) bool
 }
+
+// ContainerRunnerUserns defines a container runner reporting how users of containers are mapped to users of the host.
+type ContainerRunnerUserns interface {
+	UserNamespace(ctx context.Context) (UserNamespace, error)
+}
+
+// User namespace modes of containers.
+const (
+	UsernsModeHost   = "host"    // UsernsModeHost disables remapping of users for the container.
+	UsernsModeKeepID = "keep-id" // UsernsModeKeepID maps the current host user to the same user in the container (Podman).
+)
+
+// UserNamespace describes how users of containers are mapped to users of the host.
+type UserNamespace struct {
+	// Rootless is set when the engine runs without root, the container root is the current host user.
+	Rootless bool
+	// Remapped is set when the engine maps the container users to subordinate users of the host (userns-remap).
+	Remapped bool
+	// KeepID is set when the engine supports [UsernsModeKeepID].
+	KeepID bool
+}
+
+// ContainerRunnerExec defines a container runner able to execute commands in running containers.
+type ContainerRunnerExec interface {
+	ContainerExecCreate(ctx context.Context, cid string, opts types.ContainerExecOptions) (string, error)
+	ContainerExecAttach(ctx context.Context, execID string, opts types.ContainerExecAttachOptions) (*ContainerInOut, error)
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+}
+
+// ContainerRunnerStats defines a container runner reporting resource usage of containers.
+type ContainerRunnerStats interface {
+	// ContainerStats streams usage samples until the container stops or the context is done.
+	ContainerStats(ctx context.Context, cid string) (<-chan types.ContainerStats, error)
+}
+
+// ContainerRunnerVolumes defines a container runner managing volumes.
+type ContainerRunnerVolumes interface {
+	VolumeList(ctx context.Context, opts types.VolumeListOptions) ([]types.VolumeListResult, error)
+	VolumeRemove(ctx context.Context, name string) error
+	VolumeCreate(ctx context.Context, opts types.VolumeCreateOptions) error
+}
+
+// ContainerRunnerTimeouts defines a container runner with configurable timeouts of operations.
+type ContainerRunnerTimeouts interface {
+	SetTimeouts(t Timeouts)
+}
+
+// Timeouts defines maximum durations of container runner operations.
+// Zero value means no timeout.
+type Timeouts struct {
+	// ImagePull limits pulling an image including the download.
+	ImagePull time.Duration `yaml:"image_pull"`
+	// ImageBuild limits building an image including the build output.
+	ImageBuild time.Duration `yaml:"image_build"`
+	// ContainerCreate limits creating a container.
+	ContainerCreate time.Duration `yaml:"container_create"`
+	// ContainerAttach limits establishing a connection to container streams,
+	// the attached session itself is not limited.
+	ContainerAttach time.Duration `yaml:"container_attach"`
+	// ContainerExec limits creating and attaching to a command in a running container.
+	ContainerExec time.Duration `yaml:"container_exec"`
+}
+
+// withTimeout returns a context with the timeout if it's set.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError explains the error if the operation exceeded the timeout.
+func timeoutError(ctx context.Context, op string, timeout time.Duration, err error) error {
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s: %w", op, timeout, err)
+	}
+	return err
+}
+
+// cancelOnClose cancels the context of a streamed response when the response is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements [io.Closer] interface.
+func (r cancelOnClose) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
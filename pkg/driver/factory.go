@@ -11,11 +11,22 @@ const (
 	Docker Type = "docker" // Docker driver
 )
 
+// Options configure the created driver.
+type Options struct {
+	// Docker configures the docker driver.
+	Docker DockerOptions `yaml:"docker"`
+}
+
 // New creates a new driver based on a type.
 func New(t Type) (ContainerRunner, error) {
+	return NewWithOptions(t, Options{})
+}
+
+// NewWithOptions creates a new driver based on a type with the options.
+func NewWithOptions(t Type, opts Options) (ContainerRunner, error) {
 	switch t {
 	case Docker:
-		return NewDockerDriver()
+		return NewDockerDriverWithOptions(opts.Docker)
 	default:
 		panic(fmt.Sprintf("driver %q is not implemented", t))
 	}
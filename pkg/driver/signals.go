@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	gosignal "os/signal"
+	"slices"
 
 	"github.com/moby/sys/signal"
 
@@ -14,6 +15,28 @@ import (
 //
 // The channel you pass in must already be setup to receive any signals you want to forward.
 func ForwardAllSignals(ctx context.Context, cli ContainerRunner, cid string, sigc <-chan os.Signal) {
+	forwardSignals(ctx, sigc, func(_ os.Signal, sig string) {
+		if err := cli.ContainerKill(ctx, cid, sig); err != nil {
+			launchr.Log().Debug("error sending signal", "cid", cid, "error", err)
+		}
+	})
+}
+
+// ForwardAllSignalsToProcess forwards signals to the process except the skipped ones.
+//
+// The channel you pass in must already be setup to receive any signals you want to forward.
+func ForwardAllSignalsToProcess(ctx context.Context, p *os.Process, sigc <-chan os.Signal, skip ...os.Signal) {
+	forwardSignals(ctx, sigc, func(s os.Signal, _ string) {
+		if slices.Contains(skip, s) {
+			return
+		}
+		if err := p.Signal(s); err != nil {
+			launchr.Log().Debug("error sending signal", "pid", p.Pid, "error", err)
+		}
+	})
+}
+
+func forwardSignals(ctx context.Context, sigc <-chan os.Signal, send func(s os.Signal, sig string)) {
 	var (
 		s  os.Signal
 		ok bool
@@ -47,10 +70,7 @@ func ForwardAllSignals(ctx context.Context, cli ContainerRunner, cid string, sig
 		if sig == "" {
 			continue
 		}
-
-		if err := cli.ContainerKill(ctx, cid, sig); err != nil {
-			launchr.Log().Debug("error sending signal", "cid", cid, "error", err)
-		}
+		send(s, sig)
 	}
 }
 
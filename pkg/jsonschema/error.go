@@ -1,11 +1,13 @@
 package jsonschema
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
 
 	"github.com/launchrctl/launchr/internal/launchr"
 )
@@ -38,11 +40,30 @@ type ErrSchemaValidation struct {
 	Path []string
 	// Msg is an error message.
 	Msg string
+	// SchemaPath is a key path to the failed keyword in the schema.
+	SchemaPath []string
+	// Keyword is the failed keyword, e.g. "type" or "enum".
+	Keyword string
+	// Properties are the missing or not allowed properties for "required" and "additionalProperties" keywords.
+	Properties []string
+	// Expected are the expected types for "type" keyword or the allowed values for "enum" and "const".
+	Expected []any
 
 	// key is a sortable key.
 	key string
 }
 
+// errSchemaValidationJSON is a machine-readable representation of [ErrSchemaValidation].
+type errSchemaValidationJSON struct {
+	Instance   string   `json:"instance"`
+	Schema     string   `json:"schema"`
+	Keyword    string   `json:"keyword,omitempty"`
+	Param      string   `json:"param,omitempty"`
+	Properties []string `json:"properties,omitempty"`
+	Expected   []any    `json:"expected,omitempty"`
+	Message    string   `json:"message"`
+}
+
 // Error implements error interface.
 func (err ErrSchemaValidationArray) Error() string {
 	msgs := make([]string, len(err))
@@ -66,6 +87,74 @@ func (err ErrSchemaValidation) Error() string {
 	return fmt.Sprintf("%s: %s", err.Path, err.Msg)
 }
 
+// Param returns a name of the action parameter, e.g. "name" for path "arguments/name".
+// It's empty if the error is related to several parameters.
+func (err ErrSchemaValidation) Param() string {
+	if len(err.Path) > 1 {
+		return err.Path[1]
+	}
+	if len(err.Properties) == 1 {
+		return err.Properties[0]
+	}
+	return ""
+}
+
+// MarshalJSON implements [json.Marshaler] interface.
+// The property and the keyword are serialized as JSON pointers, see RFC 6901.
+func (err ErrSchemaValidation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errSchemaValidationJSON{
+		Instance:   jsonPointer(err.Path),
+		Schema:     jsonPointer(err.SchemaPath),
+		Keyword:    err.Keyword,
+		Param:      err.Param(),
+		Properties: err.Properties,
+		Expected:   err.Expected,
+		Message:    err.Msg,
+	})
+}
+
+// jsonPointer creates a JSON pointer from the path.
+func jsonPointer(path []string) string {
+	var b strings.Builder
+	r := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, p := range path {
+		b.WriteByte('/')
+		b.WriteString(r.Replace(p))
+	}
+	return b.String()
+}
+
+// newSchemaValidationError creates our error from a leaf error of jsonschema lib.
+func newSchemaValidationError(err *jsonschema.ValidationError) ErrSchemaValidation {
+	res := NewErrSchemaValidation(err.InstanceLocation, err.ErrorKind.LocalizedString(launchr.DefaultTextPrinter))
+	kwPath := err.ErrorKind.KeywordPath()
+	if len(kwPath) > 0 {
+		res.Keyword = kwPath[len(kwPath)-1]
+	}
+	// The schema url has the location of the schema as a fragment, e.g. "file.yaml#/properties/arguments".
+	var schemaPath []string
+	if _, frag, ok := strings.Cut(err.SchemaURL, "#"); ok && frag != "" && frag != "/" {
+		schemaPath = strings.Split(strings.TrimPrefix(frag, "/"), "/")
+	}
+	res.SchemaPath = append(schemaPath, kwPath...)
+	switch k := err.ErrorKind.(type) {
+	case *kind.Type:
+		res.Expected = make([]any, len(k.Want))
+		for i := range k.Want {
+			res.Expected[i] = k.Want[i]
+		}
+	case *kind.Enum:
+		res.Expected = k.Want
+	case *kind.Const:
+		res.Expected = []any{k.Want}
+	case *kind.Required:
+		res.Properties = k.Missing
+	case *kind.AdditionalProperties:
+		res.Properties = k.Properties
+	}
+	return res
+}
+
 // newSchemaValidationErrors creates our error from jsonschema lib.
 func newSchemaValidationErrors(err *jsonschema.ValidationError) ErrSchemaValidationArray {
 	sl := collectNestedValidationErrors(err)
@@ -79,9 +168,7 @@ func newSchemaValidationErrors(err *jsonschema.ValidationError) ErrSchemaValidat
 // collectNestedValidationErrors creates a plain slice of nested validation errors.
 func collectNestedValidationErrors(err *jsonschema.ValidationError) []ErrSchemaValidation {
 	if err.Causes == nil {
-		return []ErrSchemaValidation{
-			NewErrSchemaValidation(err.InstanceLocation, err.ErrorKind.LocalizedString(launchr.DefaultTextPrinter)),
-		}
+		return []ErrSchemaValidation{newSchemaValidationError(err)}
 	}
 	res := make([]ErrSchemaValidation, 0, len(err.Causes))
 	for i := 0; i < len(err.Causes); i++ {
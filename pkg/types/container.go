@@ -62,6 +62,7 @@ type ImageOptions struct {
 	Build        *BuildDefinition
 	NoCache      bool
 	ForceRebuild bool
+	Labels       map[string]string
 }
 
 // ImageRemoveOptions stores options for removing an image.
@@ -96,6 +97,31 @@ type SystemInfo struct {
 // ContainerListOptions stores options to request container list.
 type ContainerListOptions struct {
 	SearchName string
+	// Labels filter containers by labels in a format "KEY" or "KEY=VALUE".
+	Labels []string
+	// Running filters only running containers.
+	Running bool
+}
+
+// VolumeListOptions stores options to request volume list.
+type VolumeListOptions struct {
+	// Labels filter volumes by labels in a format "KEY" or "KEY=VALUE".
+	Labels []string
+	// Dangling filters only volumes not used by any container.
+	Dangling bool
+}
+
+// VolumeCreateOptions stores options for creating a named volume.
+type VolumeCreateOptions struct {
+	Name string
+	// Labels are metadata set on the volume.
+	Labels map[string]string
+}
+
+// VolumeListResult defines volume list result.
+type VolumeListResult struct {
+	Name   string
+	Labels map[string]string
 }
 
 // ContainerListResult defines container list result.
@@ -103,12 +129,17 @@ type ContainerListResult struct {
 	ID     string
 	Names  []string
 	Status string
+	// State is a machine-readable state, e.g. "created", "running", "exited" or "dead".
+	State  string
+	Labels map[string]string
 }
 
 // ImageStatusResponse stores response when getting the image.
 type ImageStatusResponse struct {
 	Status   ImageStatus
 	Progress io.ReadCloser
+	// ContextSize is the size of the build context sent to the container engine, files excluded with .dockerignore are not counted.
+	ContextSize int64
 }
 
 // ImageRemoveResponse stores response when removing the image.
@@ -127,6 +158,7 @@ type NetworkMode = typescontainer.NetworkMode
 
 const (
 	NetworkModeHost NetworkMode = "host" // NetworkModeHost for host network.
+	NetworkModeNone NetworkMode = "none" // NetworkModeNone for no network.
 )
 
 // ContainerCreateOptions stores options for creating a new container.
@@ -150,6 +182,26 @@ type ContainerCreateOptions struct {
 	Env           []string
 	User          string
 	Entrypoint    []string
+	// ReadonlyRootfs mounts the container root filesystem as read-only.
+	ReadonlyRootfs bool
+	// Tmpfs is a map of writable in-memory mounts with their options.
+	Tmpfs map[string]string
+	// ShmSize is a size of /dev/shm in bytes, zero uses the default of the container engine.
+	ShmSize int64
+	// Labels are metadata set on the container.
+	Labels map[string]string
+	// VolumeLabels are metadata set on the anonymous volumes of Volumes, e.g. to find them after a crash.
+	VolumeLabels map[string]string
+	// CapDrop is a list of kernel capabilities to drop, "ALL" drops all of them.
+	CapDrop []string
+	// CapAdd is a list of kernel capabilities to add.
+	CapAdd []string
+	// SecurityOpt is a list of security options, e.g. "no-new-privileges".
+	SecurityOpt []string
+	// UsernsMode is a user namespace mode of the container, e.g. "host" or "keep-id".
+	UsernsMode string
+	// Init runs an init process in the container forwarding signals and reaping processes.
+	Init bool
 }
 
 // ContainerStartOptions stores options for starting a container.
@@ -168,6 +220,10 @@ const (
 	WaitConditionNotRunning WaitCondition = typescontainer.WaitConditionNotRunning // WaitConditionNotRunning when container exits when running.
 	WaitConditionNextExit   WaitCondition = typescontainer.WaitConditionNextExit   // WaitConditionNextExit when container exits after next start.
 	WaitConditionRemoved    WaitCondition = typescontainer.WaitConditionRemoved    // WaitConditionRemoved when container is removed.
+	// WaitConditionHealthy when the health check of the container passes.
+	// The status code is 0 when the container is healthy. If the container exits before, the response
+	// has its exit code and an error. The wait fails if the container has no health check.
+	WaitConditionHealthy WaitCondition = "healthy"
 )
 
 // ContainerWaitResponse stores response given by wait result.
@@ -176,6 +232,29 @@ type ContainerWaitResponse struct {
 	Error      error
 }
 
+// ContainerExecOptions stores options to execute a command in a running container.
+type ContainerExecOptions = typescontainer.ExecOptions
+
+// ContainerExecAttachOptions stores options to attach to an executed command.
+type ContainerExecAttachOptions = typescontainer.ExecAttachOptions
+
+// ContainerExecInspect stores information about a command executed in a container.
+type ContainerExecInspect = typescontainer.ExecInspect
+
+// ContainerStats is a sample of container resource usage.
+type ContainerStats struct {
+	// CPUTime is a total CPU time consumed by the container.
+	CPUTime time.Duration
+	// Memory is the current memory usage in bytes.
+	Memory uint64
+	// MaxMemory is the maximum memory usage in bytes, it's reported only by some engines.
+	MaxMemory uint64
+	// NetworkRx is a number of bytes received over all networks.
+	NetworkRx uint64
+	// NetworkTx is a number of bytes sent over all networks.
+	NetworkTx uint64
+}
+
 // ContainerAttachOptions stores options for attaching to a running container.
 type ContainerAttachOptions = typescontainer.AttachOptions
 
@@ -2,6 +2,7 @@
 package actionnaming
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/launchrctl/launchr/internal/launchr"
@@ -9,7 +10,8 @@ import (
 )
 
 type launchrCfg struct {
-	ActionsNaming []actionsNaming `yaml:"actions_naming"`
+	ActionsIDProvider string          `yaml:"actions_id_provider"`
+	ActionsNaming     []actionsNaming `yaml:"actions_naming"`
 }
 
 type actionsNaming struct {
@@ -44,15 +46,24 @@ func (p Plugin) OnAppInit(app launchr.App) error {
 	if err != nil {
 		return err
 	}
-	// Override action id provider.
-	if len(launchrConfig.ActionsNaming) == 0 {
-		// No need to override.
+	// Override action id provider, a provider set by the app or other plugins is kept without the configuration.
+	if launchrConfig.ActionsIDProvider == "" && len(launchrConfig.ActionsNaming) == 0 {
 		return nil
 	}
-	am.SetActionIDProvider(&ConfigActionIDProvider{
-		parent: am.GetActionIDProvider(),
-		naming: launchrConfig.ActionsNaming,
-	})
+	idp := am.GetActionIDProvider()
+	if launchrConfig.ActionsIDProvider != "" {
+		idp, err = action.NewIDProvider(launchrConfig.ActionsIDProvider)
+		if err != nil {
+			return err
+		}
+	}
+	if len(launchrConfig.ActionsNaming) > 0 {
+		idp = &ConfigActionIDProvider{
+			parent: idp,
+			naming: launchrConfig.ActionsNaming,
+		}
+	}
+	am.SetActionIDProvider(idp)
 	return nil
 }
 
@@ -71,3 +82,12 @@ func (idp *ConfigActionIDProvider) GetID(a *action.Action) string {
 	}
 	return newID
 }
+
+// DescribeID implements [action.IDDescriber] interface.
+func (idp *ConfigActionIDProvider) DescribeID(a *action.Action) string {
+	desc := action.DescribeID(idp.parent, a)
+	if id := idp.parent.GetID(a); id != idp.GetID(a) {
+		desc += fmt.Sprintf(", renamed from %q by actions_naming", id)
+	}
+	return desc
+}
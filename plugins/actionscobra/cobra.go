@@ -1,6 +1,7 @@
 package actionscobra
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
@@ -9,11 +10,26 @@ import (
 
 	"github.com/launchrctl/launchr/internal/launchr"
 	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/action/output"
 	"github.com/launchrctl/launchr/pkg/jsonschema"
 )
 
+const (
+	flagUseReplacement = "use-replacement"
+	flagOutput         = "output"
+	flagTimeout        = "timeout"
+)
+
 // CobraImpl returns cobra command implementation for an action command.
+// The action is executed directly, its dependencies and the timeout are handled only
+// when the command is created with an action manager, see [CobraImplWithManager].
 func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, error) {
+	return CobraImplWithManager(a, streams, nil)
+}
+
+// CobraImplWithManager returns cobra command implementation for an action command
+// like [CobraImpl]. The action manager am is used to forward a deprecated action to its replacement.
+func CobraImplWithManager(a *action.Action, streams launchr.Streams, am action.Manager) (*launchr.Command, error) {
 	def := a.ActionDef()
 	argsDef := def.Arguments
 	use := a.ID
@@ -22,15 +38,39 @@ func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, err
 	}
 	options := make(action.InputParams)
 	runOpts := make(action.InputParams)
+	short := getDesc(def.Title, def.Description)
+	if def.IsDeprecated() {
+		short = "[deprecated] " + short
+	}
+	var useReplacement *bool
 	cmd := &launchr.Command{
 		Use: use,
 		// @todo: maybe we need a long template for arguments description
 		// @todo: have aliases documented in help
-		Short:   getDesc(def.Title, def.Description),
+		Short:   short,
+		Long:    getLongDesc(short, def),
 		Aliases: def.Aliases,
 		RunE: func(cmd *launchr.Command, args []string) (err error) {
 			// Don't show usage help on a runtime error.
 			cmd.SilenceUsage = true
+			if useReplacement != nil && *useReplacement {
+				return runReplacement(cmd, args, a, streams, am)
+			}
+			runStreams := streams
+			if outputFormat(cmd) == output.FormatJSON {
+				// Keep the output parsable, the messages of the app are printed to stderr.
+				launchr.TermFromContext(cmd.Context()).SetOutput(streams.Err())
+				launchr.LogFromContext(cmd.Context()).SetOutput(streams.Err())
+				e := output.NewEmitter(streams.Out(), a.ID)
+				runStreams = e.Streams(streams)
+				_ = e.Start()
+				defer func() {
+					_ = e.Exit(err)
+				}()
+			}
+			if def.IsDeprecated() {
+				launchr.Term().Warning().Printfln("Action %q: %s.", a.ID, def.DeprecationMessage())
+			}
 
 			// Set action input.
 			argsNamed, err := action.ArgsPosToNamed(a, args)
@@ -38,7 +78,7 @@ func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, err
 				return err
 			}
 			optsChanged := derefOpts(filterChangedFlags(cmd, options))
-			input := action.NewInput(a, argsNamed, optsChanged, streams)
+			input := action.NewInput(a, argsNamed, optsChanged, runStreams)
 			// Pass to the runtime its flags.
 			if r, ok := a.Runtime().(action.RuntimeFlags); ok {
 				runOpts = derefOpts(filterChangedFlags(cmd, runOpts))
@@ -56,8 +96,12 @@ func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, err
 				return err
 			}
 
-			// @todo can we use action manager here and Manager.Run()
-			return a.Execute(cmd.Context())
+			if am == nil {
+				// The action isn't managed, e.g. the command is created with [CobraImpl].
+				return a.Execute(runContext(cmd))
+			}
+			_, err = am.Run(runContext(cmd), a)
+			return err
 		},
 	}
 
@@ -68,6 +112,10 @@ func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, err
 	}
 	// Collect runtime flags.
 	globalFlags := []string{"help"}
+	if def.ReplacedBy != "" && am != nil {
+		useReplacement = cmd.Flags().Bool(flagUseReplacement, false, fmt.Sprintf("Run the replacement action %q instead", def.ReplacedBy))
+		globalFlags = append(globalFlags, flagUseReplacement)
+	}
 
 	if env, ok := a.Runtime().(action.RuntimeFlags); ok {
 		err = setCommandOptions(cmd, env.FlagsDefinition(), runOpts)
@@ -86,6 +134,65 @@ func CobraImpl(a *action.Action, streams launchr.Streams) (*launchr.Command, err
 	return cmd, nil
 }
 
+// outputFormat returns the output format of the action run set with the persistent flag of the root command.
+func outputFormat(cmd *launchr.Command) output.Format {
+	f := cmd.Flags().Lookup(flagOutput)
+	if f == nil {
+		return output.FormatText
+	}
+	if v, ok := f.Value.(*output.Format); ok {
+		return *v
+	}
+	return output.FormatText
+}
+
+// runContext returns the context of the action run with the timeout of the command line if it's set.
+func runContext(cmd *launchr.Command) context.Context {
+	ctx := cmd.Context()
+	f := cmd.Flags().Lookup(flagTimeout)
+	if f == nil || !f.Changed {
+		return ctx
+	}
+	if timeout, err := cmd.Flags().GetDuration(flagTimeout); err == nil {
+		ctx = action.WithRunTimeout(ctx, timeout)
+	}
+	return ctx
+}
+
+// runReplacement runs the replacement action of a deprecated action with the same arguments and flags.
+func runReplacement(cmd *launchr.Command, args []string, a *action.Action, streams launchr.Streams, am action.Manager) error {
+	id := a.ActionDef().ReplacedBy
+	ra, ok := am.Get(am.GetIDFromAlias(id))
+	if !ok {
+		return fmt.Errorf("replacement action %q of the action %q is not found", id, a.ID)
+	}
+	rcmd, err := CobraImplWithManager(ra, streams, am)
+	if err != nil {
+		return err
+	}
+	// Forward the changed flags known by the replacement action.
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		rf := rcmd.Flags().Lookup(f.Name)
+		if err != nil || rf == nil || f.Name == flagUseReplacement {
+			return
+		}
+		if sv, okSlice := f.Value.(pflag.SliceValue); okSlice {
+			if rsv, okRSlice := rf.Value.(pflag.SliceValue); okRSlice {
+				err = rsv.Replace(sv.GetSlice())
+				rf.Changed = true
+				return
+			}
+		}
+		err = rcmd.Flags().Set(f.Name, f.Value.String())
+	})
+	if err != nil {
+		return fmt.Errorf("can't forward flags to the replacement action %q: %w", ra.ID, err)
+	}
+	launchr.Term().Info().Printfln("Action %q is deprecated, running the replacement %q.", a.ID, ra.ID)
+	rcmd.SetContext(cmd.Context())
+	return rcmd.RunE(rcmd, args)
+}
+
 func updateUsageTemplate(cmd *launchr.Command, globalOpts []string) {
 	cmd.InitDefaultHelpFlag()
 	originalFlags := cmd.LocalFlags()
@@ -185,6 +292,25 @@ func getDesc(title string, desc string) string {
 	return strings.Join(parts, ": ")
 }
 
+// getLongDesc returns the action description with the version and changelog if they are defined.
+func getLongDesc(short string, def *action.DefAction) string {
+	if def.Version == "" && len(def.Changelog) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(short)
+	if def.Version != "" {
+		b.WriteString("\n\nVersion: " + def.Version)
+	}
+	if len(def.Changelog) > 0 {
+		b.WriteString("\n\nChangelog:")
+		for _, c := range def.Changelog {
+			b.WriteString("\n  " + c.Version + ": " + c.Description)
+		}
+	}
+	return b.String()
+}
+
 func setFlag(cmd *launchr.Command, opt *action.DefParameter) (any, error) {
 	var val any
 	desc := getDesc(opt.Title, opt.Description)
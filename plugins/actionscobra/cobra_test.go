@@ -0,0 +1,27 @@
+package actionscobra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_CobraImplWithoutManager(t *testing.T) {
+	t.Parallel()
+	a := action.NewFromYAML("greet", []byte("runtime: plugin\naction:\n  title: Greet\n  arguments:\n    - name: name\n"))
+	var got any
+	a.SetRuntime(action.NewFnRuntime(func(_ context.Context, a *action.Action) error {
+		got = a.Input().Arg("name")
+		return nil
+	}))
+	cmd, err := CobraImpl(a, launchr.NoopStreams())
+	require.NoError(t, err)
+	cmd.SetArgs([]string{"world"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "world", got)
+}
@@ -0,0 +1,462 @@
+package actionscobra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/action/output"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+// historyFilename is a file in the config directory with the history of action runs and favorite actions.
+const historyFilename = "history.yaml"
+
+// historyLimit is a maximum number of actions kept in the history.
+const historyLimit = 100
+
+// historyDurationsLimit is a maximum number of kept durations of successful runs of an action.
+const historyDurationsLimit = 20
+
+// estimateMinRuns is a minimum number of successful runs to estimate the duration of an action.
+const estimateMinRuns = 3
+
+// historyFormat is a format of the history file.
+var historyFormat = &launchr.StateFormat{Version: 1}
+
+// FavoritesGroup is a command group of favorite actions shown before other actions.
+var FavoritesGroup = &launchr.CommandGroup{
+	ID:    "favorites",
+	Title: "Favorite actions:",
+}
+
+// actionHistory stores recently run and favorite actions.
+type actionHistory struct {
+	Favorites []string               `yaml:"favorites,omitempty"`
+	Runs      map[string]*actionRuns `yaml:"runs,omitempty"`
+}
+
+// actionRuns stores run statistics of an action.
+type actionRuns struct {
+	Count int       `yaml:"count"`
+	Last  time.Time `yaml:"last"`
+	// Durations are the durations of the last successful runs, the oldest first.
+	Durations []time.Duration `yaml:"durations,omitempty"`
+	// Succeeded and Failed are the numbers of finished runs, interrupted runs are not counted.
+	Succeeded int `yaml:"succeeded,omitempty"`
+	Failed    int `yaml:"failed,omitempty"`
+	// LastFailure is a time of the last failed run and LastError is its error.
+	LastFailure time.Time `yaml:"last_failure,omitempty"`
+	LastError   string    `yaml:"last_error,omitempty"`
+	// Params are the parameters of the action at the last run.
+	Params *actionParams `yaml:"params,omitempty"`
+}
+
+// actionParams is a copy of the parameters of an action to find incompatible changes between runs.
+type actionParams struct {
+	Arguments []paramCopy `yaml:"arguments,omitempty"`
+	Options   []paramCopy `yaml:"options,omitempty"`
+}
+
+// paramCopy keeps the properties of a parameter affecting the calls of the action.
+type paramCopy struct {
+	Name     string          `yaml:"name"`
+	Type     jsonschema.Type `yaml:"type"`
+	Required bool            `yaml:"required,omitempty"`
+	Enum     []any           `yaml:"enum,omitempty"`
+}
+
+// newActionParams returns a copy of the parameters of action definition def.
+func newActionParams(def *action.DefAction) *actionParams {
+	copyList := func(l action.ParametersList) []paramCopy {
+		res := make([]paramCopy, len(l))
+		for i, p := range l {
+			res[i] = paramCopy{Name: p.Name, Type: p.Type, Required: p.Required, Enum: p.Enum}
+		}
+		return res
+	}
+	return &actionParams{Arguments: copyList(def.Arguments), Options: copyList(def.Options)}
+}
+
+// def returns an action definition with the parameters.
+func (ap *actionParams) def() *action.DefAction {
+	defList := func(l []paramCopy) action.ParametersList {
+		res := make(action.ParametersList, len(l))
+		for i, p := range l {
+			res[i] = &action.DefParameter{Name: p.Name, Type: p.Type, Required: p.Required, Enum: p.Enum}
+		}
+		return res
+	}
+	return &action.DefAction{Arguments: defList(ap.Arguments), Options: defList(ap.Options)}
+}
+
+// record adds a run of action id.
+func (h *actionHistory) record(id string, t time.Time) {
+	if h.Runs == nil {
+		h.Runs = make(map[string]*actionRuns)
+	}
+	r, ok := h.Runs[id]
+	if !ok {
+		r = &actionRuns{}
+		h.Runs[id] = r
+	}
+	r.Count++
+	r.Last = t
+	// Forget the least recently run actions.
+	if len(h.Runs) > historyLimit {
+		for _, old := range h.recent()[historyLimit:] {
+			delete(h.Runs, old)
+		}
+	}
+}
+
+// finish records the result of a run of action id finished at t which took d.
+func (h *actionHistory) finish(id string, t time.Time, d time.Duration, err error) {
+	r, ok := h.Runs[id]
+	if !ok {
+		// The action is forgotten while it was running.
+		return
+	}
+	if err != nil {
+		r.Failed++
+		r.LastFailure = t
+		r.LastError = err.Error()
+		return
+	}
+	r.Succeeded++
+	r.Durations = append(r.Durations, d.Round(time.Millisecond))
+	if len(r.Durations) > historyDurationsLimit {
+		r.Durations = r.Durations[len(r.Durations)-historyDurationsLimit:]
+	}
+}
+
+// updateParams keeps the parameters of action id and returns the changes of the parameters
+// since the last run breaking the existing calls of the action.
+func (h *actionHistory) updateParams(id string, params *actionParams) []string {
+	r, ok := h.Runs[id]
+	if !ok {
+		return nil
+	}
+	prev := r.Params
+	r.Params = params
+	if prev == nil {
+		return nil
+	}
+	return params.def().IncompatibleChanges(prev.def())
+}
+
+// estimate returns the median duration of the successful runs of action id
+// or 0 if there are not enough runs to estimate it.
+func (h *actionHistory) estimate(id string) time.Duration {
+	r, ok := h.Runs[id]
+	if !ok || len(r.Durations) < estimateMinRuns {
+		return 0
+	}
+	return percentile(r.Durations, 50)
+}
+
+// percentile returns the nearest-rank percentile p of durations ds.
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
+
+// recent returns action ids sorted by the last run, the most recent first.
+func (h *actionHistory) recent() []string {
+	ids := h.ids()
+	sort.SliceStable(ids, func(i, j int) bool {
+		return h.Runs[ids[i]].Last.After(h.Runs[ids[j]].Last)
+	})
+	return ids
+}
+
+// frequent returns action ids sorted by the number of runs, the most frequent first.
+func (h *actionHistory) frequent() []string {
+	ids := h.recent()
+	sort.SliceStable(ids, func(i, j int) bool {
+		return h.Runs[ids[i]].Count > h.Runs[ids[j]].Count
+	})
+	return ids
+}
+
+func (h *actionHistory) ids() []string {
+	ids := make([]string, 0, len(h.Runs))
+	for id := range h.Runs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// isFavorite checks if action id is a favorite.
+func (h *actionHistory) isFavorite(id string) bool {
+	return slices.Contains(h.Favorites, id)
+}
+
+// readHistory reads the history from file fname. A missing file is an empty history.
+func readHistory(fname string) (*actionHistory, error) {
+	content, err := os.ReadFile(fname) //nolint:gosec
+	if os.IsNotExist(err) {
+		return &actionHistory{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseHistory(content, fname)
+}
+
+func parseHistory(content []byte, fname string) (*actionHistory, error) {
+	h := &actionHistory{}
+	if err := historyFormat.Decode(content, h); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", fname, err)
+	}
+	return h, nil
+}
+
+// updateHistory updates the history in file fname with fn.
+// The file is locked during the update for concurrent runs.
+func updateHistory(fname string, fn func(h *actionHistory) error) error {
+	f := launchr.NewLockedFile(fname)
+	if err := f.Open(os.O_RDWR|os.O_CREATE, 0600); err != nil {
+		return err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	h, err := parseHistory(content, fname)
+	if err != nil {
+		return err
+	}
+	if err = fn(h); err != nil {
+		return err
+	}
+	content, err = historyFormat.Encode(h)
+	if err != nil {
+		return err
+	}
+	// The file is written in place, the lock is kept on the file.
+	return os.WriteFile(fname, content, 0600)
+}
+
+// recordRun adds a run of action a to the history. It returns the estimated duration of the run
+// and the incompatible changes of the parameters since the last run. A failure doesn't prevent the action run.
+func (p *Plugin) recordRun(a *action.Action) (estimate time.Duration, changes []string) {
+	err := updateHistory(p.cfg.Path(historyFilename), func(h *actionHistory) error {
+		estimate = h.estimate(a.ID)
+		h.record(a.ID, time.Now())
+		changes = h.updateParams(a.ID, newActionParams(a.ActionDef()))
+		return nil
+	})
+	if err != nil {
+		launchr.Log().Debug("failed to record the action run", "action_id", a.ID, "error", err)
+	}
+	return estimate, changes
+}
+
+// recordResult adds the result of the run of action a to the history.
+// The sensitive values are masked in the error of the run.
+func (p *Plugin) recordResult(a *action.Action, d time.Duration, runErr error) {
+	if runErr != nil {
+		runErr = errors.New(a.SensitiveMask().Mask(runErr.Error()))
+	}
+	err := updateHistory(p.cfg.Path(historyFilename), func(h *actionHistory) error {
+		h.finish(a.ID, time.Now(), d, runErr)
+		return nil
+	})
+	if err != nil {
+		launchr.Log().Debug("failed to record the result of the action run", "action_id", a.ID, "error", err)
+	}
+}
+
+// favorites returns favorite actions, it's empty if the history can't be read.
+func (p *Plugin) favorites() []string {
+	h, err := readHistory(p.cfg.Path(historyFilename))
+	if err != nil {
+		launchr.Log().Debug("failed to read the favorite actions", "error", err)
+		return nil
+	}
+	return h.Favorites
+}
+
+// recentCommand returns a command to list recently run actions.
+func (p *Plugin) recentCommand() *launchr.Command {
+	var limit int
+	var frequent bool
+	cmd := &launchr.Command{
+		Use:   "recent",
+		Short: "List recently run actions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			h, err := readHistory(p.cfg.Path(historyFilename))
+			if err != nil {
+				return err
+			}
+			ids := h.recent()
+			if frequent {
+				ids = h.frequent()
+			}
+			all := p.am.All()
+			data := pterm.TableData{{"ID", "Runs", "Last run", "Favorite"}}
+			for _, id := range ids {
+				if len(data) > limit {
+					break
+				}
+				// Skip the actions not available anymore.
+				if _, ok := all[id]; !ok {
+					continue
+				}
+				fav := ""
+				if h.isFavorite(id) {
+					fav = "yes"
+				}
+				r := h.Runs[id]
+				data = append(data, []string{id, strconv.Itoa(r.Count), r.Last.Local().Format(time.DateTime), fav})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 10, "Maximum number of actions to show")
+	cmd.Flags().BoolVar(&frequent, "frequent", false, "Sort by the number of runs")
+	return cmd
+}
+
+// favoritesCommand returns a command to manage favorite actions.
+func (p *Plugin) favoritesCommand() *launchr.Command {
+	cmd := &launchr.Command{
+		Use:   "favorites",
+		Short: "Manage favorite actions",
+		Long:  "Manage favorite actions, they are shown first in the help.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	complete := func(_ *launchr.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		ids := make([]string, 0, len(p.am.All()))
+		for _, a := range p.sortedActions() {
+			ids = append(ids, a.ID)
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+	cmd.AddCommand(&launchr.Command{
+		Use:   "list",
+		Short: "List favorite actions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			data := pterm.TableData{{"ID", "Title"}}
+			for _, id := range p.favorites() {
+				title := ""
+				if a, ok := p.am.All()[id]; ok {
+					title = a.ActionDef().Title
+				}
+				data = append(data, []string{id, title})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	})
+	cmd.AddCommand(&launchr.Command{
+		Use:               "add action_id...",
+		Short:             "Add actions to favorites",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: complete,
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ids := make([]string, len(args))
+			for i, arg := range args {
+				ids[i] = p.am.GetIDFromAlias(arg)
+				if _, ok := p.am.All()[ids[i]]; !ok {
+					return fmt.Errorf("action %q is not found", arg)
+				}
+			}
+			return updateHistory(p.cfg.Path(historyFilename), func(h *actionHistory) error {
+				for _, id := range ids {
+					if !h.isFavorite(id) {
+						h.Favorites = append(h.Favorites, id)
+					}
+				}
+				return nil
+			})
+		},
+	})
+	cmd.AddCommand(&launchr.Command{
+		Use:   "remove action_id...",
+		Short: "Remove actions from favorites",
+		Args:  cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(_ *launchr.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return p.favorites(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return updateHistory(p.cfg.Path(historyFilename), func(h *actionHistory) error {
+				for _, arg := range args {
+					id := p.am.GetIDFromAlias(arg)
+					if !h.isFavorite(id) {
+						return fmt.Errorf("action %q is not a favorite", arg)
+					}
+					h.Favorites = slices.DeleteFunc(h.Favorites, func(f string) bool { return f == id })
+				}
+				return nil
+			})
+		},
+	})
+	return cmd
+}
+
+// loadedFavorites returns favorite actions having commands.
+func (p *Plugin) loadedFavorites(actions map[string]*action.Action) []string {
+	var res []string
+	for _, id := range p.favorites() {
+		if _, ok := actions[id]; ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// recordRuns wraps the command of action a to record its runs in the history.
+// The expected duration of the run is printed when the action has enough successful runs.
+// A warning is printed if the parameters of the action are changed since the last run
+// so the existing calls of the action may break, e.g. after an update of the action from a remote source.
+func (p *Plugin) recordRuns(cmd *launchr.Command, a *action.Action) {
+	run := cmd.RunE
+	cmd.RunE = func(cmd *launchr.Command, args []string) error {
+		estimate, changes := p.recordRun(a)
+		// Keep the output parsable, the messages are logged instead.
+		jsonOut := outputFormat(cmd) == output.FormatJSON
+		if len(changes) > 0 && jsonOut {
+			launchr.Log().Warn("parameters of the action are changed incompatibly since the last run", "action_id", a.ID, "changes", changes)
+		} else if len(changes) > 0 {
+			launchr.Term().Warning().Printfln("Parameters of action %q are changed incompatibly since the last run:\n- %s", a.ID, strings.Join(changes, "\n- "))
+		}
+		if estimate > 0 && !action.LaunchrConfigRuntime(p.cfg).HideEstimates {
+			if !jsonOut {
+				launchr.Term().Info().Printfln("Action %q usually takes ~%s.", a.ID, action.FormatEstimate(estimate))
+			}
+			cmd.SetContext(action.WithDurationEstimate(cmd.Context(), a.ID, estimate))
+		}
+		started := time.Now()
+		err := run(cmd, args)
+		p.recordResult(a, time.Since(started), err)
+		return err
+	}
+}
@@ -0,0 +1,139 @@
+package actionscobra
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+func Test_ActionHistory(t *testing.T) {
+	t.Parallel()
+	start := time.Unix(1700000000, 0).UTC()
+	h := &actionHistory{}
+	h.record("build", start)
+	h.record("deploy", start.Add(time.Minute))
+	h.record("build", start.Add(2*time.Minute))
+	h.record("test", start.Add(3*time.Minute))
+	h.record("deploy", start.Add(4*time.Minute))
+	h.record("deploy", start.Add(5*time.Minute))
+
+	assert.Equal(t, []string{"deploy", "test", "build"}, h.recent())
+	assert.Equal(t, []string{"deploy", "build", "test"}, h.frequent())
+	assert.Equal(t, &actionRuns{Count: 3, Last: start.Add(5 * time.Minute)}, h.Runs["deploy"])
+
+	// The least recently run actions are forgotten.
+	for i := 0; i < historyLimit; i++ {
+		h.record("action"+strconv.Itoa(i), start.Add(time.Hour+time.Duration(i)*time.Second))
+	}
+	assert.Len(t, h.Runs, historyLimit)
+	assert.NotContains(t, h.Runs, "build")
+	assert.Equal(t, "action99", h.recent()[0])
+}
+
+func Test_ActionHistoryEstimate(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	h := &actionHistory{}
+	h.record("build", now)
+	h.finish("build", now, 2*time.Minute, nil)
+	h.finish("build", now, 4*time.Minute, nil)
+	// Not enough runs to estimate, failed runs are not counted.
+	h.finish("build", now, time.Second, errors.New("failed"))
+	assert.Zero(t, h.estimate("build"))
+	h.finish("build", now, 10*time.Minute, nil)
+	assert.Equal(t, 4*time.Minute, h.estimate("build"))
+	assert.Zero(t, h.estimate("deploy"))
+
+	// Only the last durations are kept.
+	for i := 0; i < historyDurationsLimit; i++ {
+		h.finish("build", now, time.Minute, nil)
+	}
+	assert.Len(t, h.Runs["build"].Durations, historyDurationsLimit)
+	assert.Equal(t, time.Minute, h.estimate("build"))
+
+	// A forgotten action isn't recorded.
+	h.finish("deploy", now, time.Minute, nil)
+	assert.NotContains(t, h.Runs, "deploy")
+
+	ds := []time.Duration{5, 1, 4, 2, 3, 10, 9, 8, 7, 6}
+	assert.Equal(t, time.Duration(5), percentile(ds, 50))
+	assert.Equal(t, time.Duration(10), percentile(ds, 95))
+	assert.Equal(t, time.Duration(1), percentile(ds, 0))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func Test_ActionHistoryParams(t *testing.T) {
+	t.Parallel()
+	h := &actionHistory{}
+	def := &action.DefAction{
+		Arguments: action.ParametersList{{Name: "env", Type: jsonschema.String, Enum: []any{"dev", "prod"}}},
+		Options:   action.ParametersList{{Name: "force", Type: jsonschema.Boolean}},
+	}
+	h.record("deploy", time.Now())
+	assert.Empty(t, h.updateParams("deploy", newActionParams(def)))
+	assert.Empty(t, h.updateParams("deploy", newActionParams(def)))
+
+	// The parameters are compared with the copy kept in the file.
+	content, err := historyFormat.Encode(h)
+	require.NoError(t, err)
+	h = &actionHistory{}
+	require.NoError(t, historyFormat.Decode(content, h))
+	changed := &action.DefAction{
+		Arguments: action.ParametersList{{Name: "env", Type: jsonschema.String, Enum: []any{"prod"}}},
+	}
+	assert.Equal(t, []string{
+		`argument "env" doesn't accept value dev anymore`,
+		`option "force" is removed`,
+	}, h.updateParams("deploy", newActionParams(changed)))
+	// The change is reported once.
+	assert.Empty(t, h.updateParams("deploy", newActionParams(changed)))
+	assert.Empty(t, h.updateParams("unknown", newActionParams(changed)))
+}
+
+func Test_UpdateHistory(t *testing.T) {
+	t.Parallel()
+	fname := filepath.Join(t.TempDir(), "state", historyFilename)
+	h, err := readHistory(fname)
+	require.NoError(t, err)
+	assert.Equal(t, &actionHistory{}, h)
+
+	// Concurrent runs don't lose the updates.
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, updateHistory(fname, func(h *actionHistory) error {
+				h.record("build", time.Now())
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, updateHistory(fname, func(h *actionHistory) error {
+		h.Favorites = append(h.Favorites, "deploy")
+		return nil
+	}))
+	// A failed update isn't saved.
+	err = updateHistory(fname, func(h *actionHistory) error {
+		h.Favorites = nil
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+
+	h, err = readHistory(fname)
+	require.NoError(t, err)
+	assert.Equal(t, 10, h.Runs["build"].Count)
+	assert.Equal(t, []string{"deploy"}, h.Favorites)
+	assert.True(t, h.isFavorite("deploy"))
+	assert.False(t, h.isFavorite("build"))
+}
@@ -0,0 +1,66 @@
+package actionscobra
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// actionsIDsCommand returns a command showing how the ids of discovered actions are derived.
+func (p *Plugin) actionsIDsCommand() *launchr.Command {
+	return &launchr.Command{
+		Use:   "ids",
+		Short: "Show how action ids are derived and report collisions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			idp := p.am.GetActionIDProvider()
+			collisions := idCollisions(p.discovered)
+			data := pterm.TableData{{"ID", "File", "Derived from", "Collides with"}}
+			for _, a := range p.discovered {
+				var others []string
+				for _, f := range collisions[a.ID] {
+					if f != relPath(a.Filepath()) {
+						others = append(others, f)
+					}
+				}
+				data = append(data, []string{a.ID, relPath(a.Filepath()), action.DescribeID(idp, a), strings.Join(others, ", ")})
+			}
+			if err := pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render(); err != nil {
+				return err
+			}
+			if len(collisions) > 0 {
+				return fmt.Errorf("found %d colliding action ids, set an explicit id in the action definition or rename the directories", len(collisions))
+			}
+			return nil
+		},
+	}
+}
+
+// idCollisions returns the files of actions having the same id.
+func idCollisions(actions []*action.Action) map[string][]string {
+	files := make(map[string][]string)
+	for _, a := range actions {
+		files[a.ID] = append(files[a.ID], relPath(a.Filepath()))
+	}
+	for id, f := range files {
+		if len(f) < 2 {
+			delete(files, id)
+		}
+	}
+	return files
+}
+
+// relPath returns a path relative to the working directory if possible.
+func relPath(path string) string {
+	if rel, err := filepath.Rel(launchr.MustAbs("."), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
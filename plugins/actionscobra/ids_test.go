@@ -0,0 +1,25 @@
+package actionscobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_IDCollisions(t *testing.T) {
+	t.Parallel()
+	newAction := func(id, file string) *action.Action {
+		return action.New(action.StringID(id), &action.YamlLoader{Bytes: []byte("runtime: plugin\naction:\n  title: A\n")}, "/project", file)
+	}
+	actions := []*action.Action{
+		newAction("build", "a/actions/build/action.yaml"),
+		newAction("build", "b/actions/build/action.yaml"),
+		newAction("deploy", "a/actions/deploy/action.yaml"),
+	}
+	assert.Equal(t, map[string][]string{
+		"build": {"/project/a/actions/build/action.yaml", "/project/b/actions/build/action.yaml"},
+	}, idCollisions(actions))
+	assert.Empty(t, idCollisions(actions[1:]))
+}
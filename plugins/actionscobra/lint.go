@@ -0,0 +1,129 @@
+package actionscobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func (p *Plugin) actionsLintCommand() *launchr.Command {
+	var baseline string
+	var updateBaseline bool
+	cmd := &launchr.Command{
+		Use:   "lint [action...]",
+		Short: "Check container definitions of actions for common mistakes",
+		Long: `Check container definitions of actions for common mistakes:
+unpinned images, missing build contexts, privileged capabilities,
+secrets in environment literals and undefined template variables.
+Issues listed in the baseline file are not reported, it helps to adopt the checks gradually.
+The command fails if errors are found.`,
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			actions := p.sortedActions()
+			if len(args) > 0 {
+				actions = actions[:0]
+				for _, id := range args {
+					a, ok := p.am.Get(p.am.GetIDFromAlias(id))
+					if !ok {
+						return fmt.Errorf("action %q is not found", id)
+					}
+					actions = append(actions, a)
+				}
+			}
+			var issues []action.LintIssue
+			for _, a := range actions {
+				issues = append(issues, action.Lint(a)...)
+			}
+			if updateBaseline {
+				if baseline == "" {
+					return errors.New("baseline file must be set to update it")
+				}
+				if err := writeLintBaseline(baseline, issues); err != nil {
+					return err
+				}
+				launchr.Term().Info().Printfln("Baseline %q is updated with %d issues", baseline, len(issues))
+				return nil
+			}
+			if baseline != "" {
+				known, err := readLintBaseline(baseline)
+				if err != nil {
+					return err
+				}
+				issues = filterLintIssues(issues, known)
+			}
+			if len(issues) == 0 {
+				launchr.Term().Success().Println("No issues found")
+				return nil
+			}
+			data := pterm.TableData{{"Action", "Severity", "Rule", "Message"}}
+			errCount := 0
+			for _, i := range issues {
+				data = append(data, []string{i.Action, string(i.Severity), i.Rule, i.Message})
+				if i.Severity == action.LintError {
+					errCount++
+				}
+			}
+			if err := pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render(); err != nil {
+				return err
+			}
+			if errCount > 0 {
+				return fmt.Errorf("found %d errors in actions", errCount)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(_ *launchr.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			ids := make([]string, 0, len(p.am.All()))
+			for _, a := range p.sortedActions() {
+				ids = append(ids, a.ID)
+			}
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVarP(&baseline, "baseline", "b", "", "Baseline file with known issues to ignore")
+	cmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "Write all found issues to the baseline file")
+	return cmd
+}
+
+func readLintBaseline(path string) ([]action.LintIssue, error) {
+	content, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []action.LintIssue
+	if err = yaml.Unmarshal(content, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %q: %w", path, err)
+	}
+	return res, nil
+}
+
+func writeLintBaseline(path string, issues []action.LintIssue) error {
+	content, err := yaml.Marshal(issues)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// filterLintIssues returns the issues not listed in the baseline.
+func filterLintIssues(issues, known []action.LintIssue) []action.LintIssue {
+	res := make([]action.LintIssue, 0, len(issues))
+	for _, i := range issues {
+		if !slices.ContainsFunc(known, func(k action.LintIssue) bool {
+			return k.Action == i.Action && k.Rule == i.Rule && k.Message == i.Message
+		}) {
+			res = append(res, i)
+		}
+	}
+	return res
+}
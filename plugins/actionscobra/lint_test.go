@@ -0,0 +1,32 @@
+package actionscobra
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_LintBaseline(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	known := []action.LintIssue{
+		{Action: "a", Rule: action.LintRuleImageLatest, Severity: action.LintWarning, Message: "image"},
+	}
+	res, err := readLintBaseline(path)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	require.NoError(t, writeLintBaseline(path, known))
+	res, err = readLintBaseline(path)
+	require.NoError(t, err)
+
+	issues := []action.LintIssue{
+		{Action: "a", Rule: action.LintRuleImageLatest, Severity: action.LintWarning, Message: "image"},
+		{Action: "b", Rule: action.LintRuleImageLatest, Severity: action.LintWarning, Message: "image"},
+		{Action: "a", Rule: action.LintRuleEnvSecret, Severity: action.LintError, Message: "secret"},
+	}
+	assert.Equal(t, issues[1:], filterLintIssues(issues, res))
+}
@@ -0,0 +1,100 @@
+package actionscobra
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// actionsCommand returns a command to inspect available actions.
+func (p *Plugin) actionsCommand() *launchr.Command {
+	cmd := &launchr.Command{
+		Use:   "actions",
+		Short: "Inspect available actions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(p.actionsListCommand())
+	cmd.AddCommand(p.actionsSearchCommand())
+	cmd.AddCommand(p.actionsLintCommand())
+	cmd.AddCommand(p.actionsIDsCommand())
+	cmd.AddCommand(p.actionsRenderCommand())
+	return cmd
+}
+
+func (p *Plugin) actionsListCommand() *launchr.Command {
+	var tags []string
+	cmd := &launchr.Command{
+		Use:   "list",
+		Short: "List available actions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			data := pterm.TableData{{"ID", "Version", "Title", "Tags"}}
+			for _, a := range p.sortedActions() {
+				def := a.ActionDef()
+				if !hasAllTags(def, tags) {
+					continue
+				}
+				title := def.Title
+				if def.IsDeprecated() {
+					title = "[deprecated] " + title
+				}
+				data = append(data, []string{a.ID, def.Version, title, strings.Join(def.Tags, ", ")})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Show only actions having the tag, may be specified multiple times")
+	_ = cmd.RegisterFlagCompletionFunc("tag", func(_ *launchr.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return p.allTags(toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// sortedActions returns all valid actions sorted by id.
+func (p *Plugin) sortedActions() []*action.Action {
+	all := p.am.All()
+	res := make([]*action.Action, 0, len(all))
+	for _, a := range all {
+		res = append(res, a)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// allTags returns sorted unique tags of all actions starting with a prefix.
+func (p *Plugin) allTags(prefix string) []string {
+	uniq := make(map[string]struct{})
+	for _, a := range p.am.All() {
+		for _, t := range a.ActionDef().Tags {
+			if strings.HasPrefix(t, prefix) {
+				uniq[t] = struct{}{}
+			}
+		}
+	}
+	res := make([]string, 0, len(uniq))
+	for t := range uniq {
+		res = append(res, t)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func hasAllTags(def *action.DefAction, tags []string) bool {
+	for _, t := range tags {
+		if !def.HasTag(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"errors"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/launchrctl/launchr/internal/launchr"
 	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/action/output"
 )
 
 var (
@@ -30,6 +32,10 @@ type Plugin struct {
 	app launchr.AppInternal
 	am  action.Manager
 	pm  launchr.PluginManager
+	cfg launchr.Config
+
+	// discovered contains all discovered actions including the ones with colliding ids.
+	discovered []*action.Action
 }
 
 // PluginInfo implements [launchr.Plugin] interface.
@@ -45,9 +51,17 @@ func (p *Plugin) OnAppInit(app launchr.App) error {
 	p.app = app.(launchr.AppInternal)
 	app.GetService(&p.am)
 	app.GetService(&p.pm)
+	app.GetService(&p.cfg)
 	return p.discoverActions()
 }
 
+// State implements [launchr.StatePlugin] interface.
+func (p *Plugin) State() []launchr.StateEntry {
+	return []launchr.StateEntry{
+		{Path: historyFilename, Description: "Recently run and favorite actions", Format: historyFormat},
+	}
+}
+
 func (p *Plugin) discoverActions() (err error) {
 	app := p.app
 	early := app.CmdEarlyParsed()
@@ -72,6 +86,11 @@ func (p *Plugin) discoverActions() (err error) {
 		return errors.New(errDiscoveryTimeout)
 	}
 
+	p.discovered = discovered
+	for id, files := range idCollisions(discovered) {
+		launchr.Log().Warn("action id is defined by several files, only one is used", "action_id", id, "files", files)
+	}
+
 	// Add discovered actions.
 	for _, a := range discovered {
 		err = p.am.Add(a)
@@ -97,6 +116,16 @@ func (p *Plugin) discoverActions() (err error) {
 func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
 	app := p.app
 	early := app.CmdEarlyParsed()
+	// Add the output format of action runs.
+	format := output.FormatText
+	rootCmd.PersistentFlags().Var(&format, flagOutput, "output format of action runs, may be text or json")
+	// Add the timeout of action runs overriding the timeout of the action definition.
+	rootCmd.PersistentFlags().Duration(flagTimeout, 0, "timeout of action runs, e.g. 30s or 1h, 0 disables the timeout of the action")
+	// Add commands to inspect actions.
+	rootCmd.AddCommand(p.actionsCommand())
+	rootCmd.AddCommand(p.recentCommand())
+	rootCmd.AddCommand(p.favoritesCommand())
+	rootCmd.AddCommand(p.statsCommand())
 	// Convert actions to cobra commands.
 	// Check the requested command to see what actions we must actually load.
 	var actions map[string]*action.Action
@@ -117,18 +146,27 @@ func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
 	}
 
 	// @todo consider cobra completion and caching between runs.
+	// Favorite actions are shown first.
+	favorites := p.loadedFavorites(actions)
+	if len(favorites) > 0 {
+		rootCmd.AddGroup(FavoritesGroup)
+	}
 	if len(actions) > 0 {
 		rootCmd.AddGroup(ActionsGroup)
 	}
 	streams := p.app.Streams()
 	for _, a := range actions {
-		cmd, err := CobraImpl(a, streams)
+		cmd, err := CobraImplWithManager(a, streams, p.am)
 		if err != nil {
 			launchr.Log().Warn("action was skipped due to error", "action_id", a.ID, "error", err)
 			launchr.Term().Warning().Printfln("Action %q was skipped:\n%v", a.ID, err)
 			continue
 		}
 		cmd.GroupID = ActionsGroup.ID
+		if slices.Contains(favorites, a.ID) {
+			cmd.GroupID = FavoritesGroup.ID
+		}
+		p.recordRuns(cmd, a)
 		rootCmd.AddCommand(cmd)
 	}
 	return nil
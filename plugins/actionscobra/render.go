@@ -0,0 +1,202 @@
+package actionscobra
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+// actionsRenderCommand returns a command showing the action definition after each processing stage.
+func (p *Plugin) actionsRenderCommand() *launchr.Command {
+	var set []string
+	var full bool
+	cmd := &launchr.Command{
+		Use:   "render action",
+		Short: "Show the action definition after each processing stage",
+		Long: `Show the action definition after each processing stage: comments removal,
+env expansion and template execution. The first stage is printed as is,
+the next stages are printed as changes of the previous stage, so it's visible
+which stage produced an unexpected value. Input values are set with --set,
+they are shown after value processors. Values of sensitive parameters are masked.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			a, ok := p.am.Get(p.am.GetIDFromAlias(args[0]))
+			if !ok {
+				return fmt.Errorf("action %q is not found", args[0])
+			}
+			inputArgs, inputOpts, err := parseRenderInput(a.ActionDef(), set)
+			if err != nil {
+				return err
+			}
+			input := action.NewInput(a, inputArgs, inputOpts, launchr.NoopStreams())
+			stages, err := a.LoadStages(input)
+			if len(stages) == 0 && err != nil {
+				return err
+			}
+			w := cmd.OutOrStdout()
+			mask := a.SensitiveMask()
+			printRenderInput(w, a, mask)
+			printRenderStages(w, stages, mask, full)
+			if err != nil {
+				return fmt.Errorf("stage %d failed: %w", len(stages)+1, err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&set, "set", nil, "Set an argument or an option as name=value, may be specified multiple times")
+	cmd.Flags().BoolVar(&full, "full", false, "Print the full definition after each stage instead of the changes")
+	return cmd
+}
+
+// parseRenderInput parses "name=value" pairs to arguments and options of the action.
+func parseRenderInput(def *action.DefAction, set []string) (action.InputParams, action.InputParams, error) {
+	args := make(action.InputParams)
+	opts := make(action.InputParams)
+	for _, kv := range set {
+		name, val, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("value %q must be set as name=value", kv)
+		}
+		params := args
+		pdef := findParam(def.Arguments, name)
+		if pdef == nil {
+			params = opts
+			pdef = findParam(def.Options, name)
+		}
+		if pdef == nil {
+			return nil, nil, fmt.Errorf("action has no argument or option %q", name)
+		}
+		v, err := parseParamValue(val, pdef)
+		if err != nil {
+			return nil, nil, fmt.Errorf("value of %q is not valid: %w", name, err)
+		}
+		params[name] = v
+	}
+	return args, opts, nil
+}
+
+func findParam(list action.ParametersList, name string) *action.DefParameter {
+	for _, p := range list {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// parseParamValue converts the value to the type of the parameter, array items are separated by commas.
+func parseParamValue(v string, pdef *action.DefParameter) (any, error) {
+	if pdef.Type != jsonschema.Array {
+		return jsonschema.ConvertStringToType(v, pdef.Type)
+	}
+	items := strings.Split(v, ",")
+	res := make([]any, len(items))
+	for i, item := range items {
+		var err error
+		if res[i], err = jsonschema.ConvertStringToType(item, pdef.Items.Type); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// printRenderInput prints the input values after value processors.
+func printRenderInput(w io.Writer, a *action.Action, mask *action.SensitiveMask) {
+	def := a.ActionDef()
+	input := a.Input()
+	_, _ = fmt.Fprintln(w, pterm.Bold.Sprint("Input after value processors"))
+	for _, list := range []struct {
+		title  string
+		def    action.ParametersList
+		values action.InputParams
+	}{{"arguments", def.Arguments, input.Args()}, {"options", def.Options, input.Opts()}} {
+		if len(list.def) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %s:\n", list.title)
+		for _, pdef := range list.def {
+			_, _ = fmt.Fprintf(w, "    %s: %s\n", pdef.Name, mask.Mask(fmt.Sprintf("%v", list.values[pdef.Name])))
+		}
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+// printRenderStages prints the first stage and the changes of each next stage.
+func printRenderStages(w io.Writer, stages []action.LoadStage, mask *action.SensitiveMask, full bool) {
+	var prev []string
+	for i, s := range stages {
+		lines := strings.Split(strings.TrimRight(mask.Mask(string(s.Content)), "\n"), "\n")
+		_, _ = fmt.Fprintln(w, pterm.Bold.Sprintf("Stage %d: %s", i+1, s.Name))
+		switch {
+		case i == 0 || full:
+			for n, l := range lines {
+				_, _ = fmt.Fprintf(w, "%4d   %s\n", n+1, l)
+			}
+		default:
+			changes := diffLines(prev, lines)
+			if len(changes) == 0 {
+				_, _ = fmt.Fprintln(w, "       no changes")
+			}
+			for _, c := range changes {
+				line := fmt.Sprintf("%4d %c %s", c.line, c.op, c.text)
+				if c.op == '-' {
+					line = pterm.FgRed.Sprint(line)
+				} else {
+					line = pterm.FgGreen.Sprint(line)
+				}
+				_, _ = fmt.Fprintln(w, line)
+			}
+		}
+		_, _ = fmt.Fprintln(w)
+		prev = lines
+	}
+}
+
+// lineChange is a removed or an added line.
+type lineChange struct {
+	op   byte // op is '-' for a removed line and '+' for an added line.
+	line int  // line is a number of the line in the old content for removed lines and in the new one for added.
+	text string
+}
+
+// diffLines returns the changes turning lines a into lines b.
+// Action files are small, so the longest common subsequence is found without optimizations.
+func diffLines(a, b []string) []lineChange {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var res []lineChange
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			res = append(res, lineChange{op: '+', line: j + 1, text: b[j]})
+			j++
+		default:
+			res = append(res, lineChange{op: '-', line: i + 1, text: a[i]})
+			i++
+		}
+	}
+	return res
+}
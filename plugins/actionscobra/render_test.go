@@ -0,0 +1,21 @@
+package actionscobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffLines(t *testing.T) {
+	t.Parallel()
+	a := []string{"image: {{ .img }}", "command:", "  - {{ .cmd }}", "env: x"}
+	b := []string{"image: alpine", "command:", "  - ls", "  - -la", "env: x"}
+	assert.Equal(t, []lineChange{
+		{'-', 1, "image: {{ .img }}"},
+		{'+', 1, "image: alpine"},
+		{'-', 3, "  - {{ .cmd }}"},
+		{'+', 3, "  - ls"},
+		{'+', 4, "  - -la"},
+	}, diffLines(a, b))
+	assert.Empty(t, diffLines(a, a))
+}
@@ -0,0 +1,122 @@
+package actionscobra
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// Search scores of a matched field, the higher the better.
+const (
+	scoreIDExact      = 100
+	scoreIDPrefix     = 80
+	scoreAliasExact   = 70
+	scoreIDSubstr     = 60
+	scoreAliasSubstr  = 50
+	scoreTitleSubstr  = 40
+	scoreDescSubstr   = 20
+	scoreIDFuzzy      = 10
+	scoreTitleFuzzy   = 5
+	scoreNotFound     = 0
+	searchResultLimit = 20
+)
+
+func (p *Plugin) actionsSearchCommand() *launchr.Command {
+	var limit int
+	cmd := &launchr.Command{
+		Use:   "search query",
+		Short: "Search actions by id, alias, title and description",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			query := strings.Join(args, " ")
+			found := searchActions(p.sortedActions(), query)
+			if len(found) == 0 {
+				launchr.Term().Info().Printfln("No actions found for %q", query)
+				return nil
+			}
+			if limit > 0 && len(found) > limit {
+				found = found[:limit]
+			}
+			data := pterm.TableData{{"ID", "Title", "Description"}}
+			for _, a := range found {
+				def := a.ActionDef()
+				data = append(data, []string{a.ID, def.Title, def.Description})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "l", searchResultLimit, "Maximum number of results, 0 to show all")
+	return cmd
+}
+
+// searchActions returns actions matching the query sorted by relevance.
+func searchActions(actions []*action.Action, query string) []*action.Action {
+	type scored struct {
+		a     *action.Action
+		score int
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	res := make([]scored, 0, len(actions))
+	for _, a := range actions {
+		if s := scoreAction(a, query); s > scoreNotFound {
+			res = append(res, scored{a, s})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].score > res[j].score
+	})
+	found := make([]*action.Action, len(res))
+	for i := range res {
+		found[i] = res[i].a
+	}
+	return found
+}
+
+// scoreAction returns the best score of the action fields matching the query.
+func scoreAction(a *action.Action, query string) int {
+	if query == "" {
+		return scoreNotFound
+	}
+	def := a.ActionDef()
+	id := strings.ToLower(a.ID)
+	title := strings.ToLower(def.Title)
+	score := scoreNotFound
+	best := func(s int, ok bool) {
+		if ok && s > score {
+			score = s
+		}
+	}
+	best(scoreIDExact, id == query)
+	best(scoreIDPrefix, strings.HasPrefix(id, query))
+	best(scoreIDSubstr, strings.Contains(id, query))
+	for _, alias := range def.Aliases {
+		alias = strings.ToLower(alias)
+		best(scoreAliasExact, alias == query)
+		best(scoreAliasSubstr, strings.Contains(alias, query))
+	}
+	best(scoreTitleSubstr, strings.Contains(title, query))
+	best(scoreDescSubstr, strings.Contains(strings.ToLower(def.Description), query))
+	best(scoreIDFuzzy, isFuzzyMatch(id, query))
+	best(scoreTitleFuzzy, isFuzzyMatch(title, query))
+	return score
+}
+
+// isFuzzyMatch checks if all characters of the query appear in s in the same order.
+func isFuzzyMatch(s, query string) bool {
+	i := 0
+	for _, r := range s {
+		if i == len(query) {
+			break
+		}
+		if strings.HasPrefix(query[i:], string(r)) {
+			i += len(string(r))
+		}
+	}
+	return i == len(query)
+}
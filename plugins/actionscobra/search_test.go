@@ -0,0 +1,60 @@
+package actionscobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_SearchActions(t *testing.T) {
+	t.Parallel()
+	newAction := func(id, title, desc string, aliases ...string) *action.Action {
+		y := "runtime: plugin\naction:\n  title: " + title + "\n  description: " + desc + "\n"
+		if len(aliases) > 0 {
+			y += "  alias:\n"
+			for _, al := range aliases {
+				y += "    - " + al + "\n"
+			}
+		}
+		return action.NewFromYAML(id, []byte(y))
+	}
+	actions := []*action.Action{
+		newAction("platform:deploy", "Deploy platform", "Deploys all components"),
+		newAction("platform:bump", "Bump version", "Updates versions before deploy", "bump"),
+		newAction("db:migrate", "Migrate database", "Applies migrations"),
+		newAction("deploy", "Deploy", "Deploys the app"),
+	}
+	// Load definitions before parallel access.
+	for _, a := range actions {
+		_, err := a.Raw()
+		assert.NoError(t, err)
+	}
+
+	type testCase struct {
+		name  string
+		query string
+		exp   []string
+	}
+	tts := []testCase{
+		{"exact id first", "deploy", []string{"deploy", "platform:deploy", "platform:bump"}},
+		{"alias", "BUMP", []string{"platform:bump"}},
+		{"title", "database", []string{"db:migrate"}},
+		{"fuzzy id", "dbmig", []string{"db:migrate"}},
+		{"not found", "kubernetes", []string{}},
+		{"empty", " ", []string{}},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			found := searchActions(actions, tt.query)
+			ids := make([]string, 0, len(found))
+			for _, a := range found {
+				ids = append(ids, a.ID)
+			}
+			assert.Equal(t, tt.exp, ids)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package actionscobra
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// Formats of the statistics of actions.
+const (
+	statsFormatTable = "table"
+	statsFormatJSON  = "json"
+)
+
+// actionStats is a summary of the runs of an action in the history.
+type actionStats struct {
+	ID string `json:"id"`
+	// Runs is a number of started runs.
+	Runs int `json:"runs"`
+	// SuccessRate is a share of the successful runs of the finished runs, it's nil without finished runs.
+	SuccessRate *float64 `json:"success_rate"`
+	// P50 and P95 are percentiles of the durations of the last successful runs in seconds.
+	P50         float64    `json:"p50_seconds"`
+	P95         float64    `json:"p95_seconds"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// stats returns the statistics of the actions in the history, the most frequent first.
+func (h *actionHistory) stats() []actionStats {
+	ids := h.frequent()
+	res := make([]actionStats, 0, len(ids))
+	for _, id := range ids {
+		r := h.Runs[id]
+		s := actionStats{
+			ID:        id,
+			Runs:      r.Count,
+			P50:       percentile(r.Durations, 50).Seconds(),
+			P95:       percentile(r.Durations, 95).Seconds(),
+			LastError: r.LastError,
+		}
+		if finished := r.Succeeded + r.Failed; finished > 0 {
+			rate := float64(r.Succeeded) / float64(finished)
+			s.SuccessRate = &rate
+		}
+		if !r.LastFailure.IsZero() {
+			last := r.LastFailure
+			s.LastFailure = &last
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// statsTable returns the statistics as table rows with a header.
+func statsTable(stats []actionStats) pterm.TableData {
+	data := pterm.TableData{{"ID", "Runs", "Success", "p50", "p95", "Last failure"}}
+	for _, s := range stats {
+		rate := "-"
+		if s.SuccessRate != nil {
+			rate = fmt.Sprintf("%.0f%%", *s.SuccessRate*100)
+		}
+		lastFailure := ""
+		if s.LastFailure != nil {
+			msg, _, _ := strings.Cut(s.LastError, "\n")
+			lastFailure = s.LastFailure.Local().Format(time.DateTime) + " " + msg
+		}
+		data = append(data, []string{
+			s.ID,
+			strconv.Itoa(s.Runs),
+			rate,
+			formatStatsDuration(s.P50),
+			formatStatsDuration(s.P95),
+			lastFailure,
+		})
+	}
+	return data
+}
+
+func formatStatsDuration(sec float64) string {
+	if sec == 0 {
+		return "-"
+	}
+	return time.Duration(sec * float64(time.Second)).Round(time.Second / 10).String()
+}
+
+// statsCommand returns a command to show the statistics of action runs.
+func (p *Plugin) statsCommand() *launchr.Command {
+	var format string
+	cmd := &launchr.Command{
+		Use:   "stats",
+		Short: "Show statistics of action runs",
+		Long: `Show statistics of action runs recorded in the history: the number of runs, the success rate,
+the median (p50) and the 95th percentile (p95) of the durations of the last successful runs and the last failure.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			if format != statsFormatTable && format != statsFormatJSON {
+				return fmt.Errorf("format %q is not supported, use %q or %q", format, statsFormatTable, statsFormatJSON)
+			}
+			cmd.SilenceUsage = true
+			h, err := readHistory(p.cfg.Path(historyFilename))
+			if err != nil {
+				return err
+			}
+			stats := h.stats()
+			if format == statsFormatJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(statsTable(stats)).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", statsFormatTable, `Output format, "table" or "json"`)
+	return cmd
+}
@@ -0,0 +1,46 @@
+package actionscobra
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ActionStats(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 0).UTC()
+	h := &actionHistory{}
+	for i := 1; i <= 4; i++ {
+		h.record("build", now)
+		h.finish("build", now, time.Duration(i)*time.Minute, nil)
+	}
+	h.record("build", now)
+	h.finish("build", now.Add(time.Hour), 0, errors.New("exit status 2\ndetails"))
+	// The run is interrupted.
+	h.record("deploy", now)
+
+	stats := h.stats()
+	require.Len(t, stats, 2)
+	build := stats[0]
+	assert.Equal(t, "build", build.ID)
+	assert.Equal(t, 5, build.Runs)
+	require.NotNil(t, build.SuccessRate)
+	assert.InDelta(t, 0.8, *build.SuccessRate, 0.001)
+	assert.Equal(t, (2 * time.Minute).Seconds(), build.P50)
+	assert.Equal(t, (4 * time.Minute).Seconds(), build.P95)
+	assert.Equal(t, now.Add(time.Hour), *build.LastFailure)
+	assert.Equal(t, actionStats{ID: "deploy", Runs: 1}, stats[1])
+
+	data := statsTable(stats)
+	require.Len(t, data, 3)
+	assert.Equal(t, []string{"build", "5", "80%", "2m0s", "4m0s", now.Add(time.Hour).Local().Format(time.DateTime) + " exit status 2"}, data[1])
+	assert.Equal(t, []string{"deploy", "1", "-", "-", "-", ""}, data[2])
+
+	content, err := json.Marshal(stats[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"deploy","runs":1,"success_rate":null,"p50_seconds":0,"p95_seconds":0}`, string(content))
+}
@@ -0,0 +1,228 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+// Statuses of run results.
+const (
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+// maxRequestSize is a maximum size of a request line.
+const maxRequestSize = 1024 * 1024
+
+// runRequest is a request to run an action, one per input line.
+type runRequest struct {
+	ID      string             `json:"id,omitempty"`
+	Action  string             `json:"action"`
+	Args    action.InputParams `json:"args,omitempty"`
+	Options action.InputParams `json:"options,omitempty"`
+}
+
+// runResult is a result of a run request, one per output line.
+type runResult struct {
+	ID       string `json:"id"`
+	Action   string `json:"action"`
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+	Stderr   string `json:"stderr"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// batch runs the requests read from the input and writes the results to the output.
+type batch struct {
+	am       action.Manager
+	parallel int
+	// limit limits the captured output of every request.
+	limit action.ConfigOutputLimit
+
+	mx  sync.Mutex // mx guards writes to out.
+	out io.Writer
+}
+
+// run reads the requests line by line and runs them with at most b.parallel concurrent runs.
+// The results are written in the order of completion. An error is returned if any request fails.
+func (b *batch) run(ctx context.Context, in io.Reader) error {
+	sem := make(chan struct{}, max(b.parallel, 1))
+	wg := sync.WaitGroup{}
+	var failed atomic.Int32
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxRequestSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		req, err := parseRequest(raw, line)
+		if err != nil {
+			res := &runResult{ID: req.ID, Action: req.Action, Status: statusFailure, ExitCode: 1, Error: err.Error()}
+			failed.Add(1)
+			if err = b.write(res); err != nil {
+				return err
+			}
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res := b.runRequest(ctx, req)
+			if res.Status != statusSuccess {
+				failed.Add(1)
+			}
+			if err := b.write(res); err != nil {
+				launchr.LogFromContext(ctx).Error("failed to write the batch result", "id", res.ID, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read requests: %w", err)
+	}
+	if n := failed.Load(); n > 0 {
+		return launchr.NewExitError(1, fmt.Sprintf("%d of the batch requests failed", n))
+	}
+	return nil
+}
+
+// parseRequest parses a request line. The line number is used as the request id if it's not set.
+func parseRequest(raw []byte, line int) (runRequest, error) {
+	req := runRequest{}
+	err := json.Unmarshal(raw, &req)
+	if req.ID == "" {
+		req.ID = strconv.Itoa(line)
+	}
+	if err != nil {
+		return req, fmt.Errorf("failed to parse request on line %d: %w", line, err)
+	}
+	if req.Action == "" {
+		return req, fmt.Errorf("action is not set in request on line %d", line)
+	}
+	return req, nil
+}
+
+// runRequest runs the action of the request and captures its output.
+func (b *batch) runRequest(ctx context.Context, req runRequest) *runResult {
+	start := time.Now()
+	out := b.limit.NewBuffer()
+	stderr := b.limit.NewBuffer()
+	err := b.runAction(ctx, req, batchStreams{
+		in:  launchr.NoopStreams().In(),
+		out: launchr.NewOut(out),
+		err: stderr,
+	})
+	res := &runResult{
+		ID:       req.ID,
+		Action:   req.Action,
+		Status:   statusSuccess,
+		Output:   out.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		res.Status = statusFailure
+		res.Error = err.Error()
+		res.ExitCode = 1
+		var exitErr launchr.ExitError
+		if errors.As(err, &exitErr) {
+			res.ExitCode = exitErr.ExitCode()
+		}
+	}
+	return res
+}
+
+func (b *batch) runAction(ctx context.Context, req runRequest, streams launchr.Streams) error {
+	a, ok := b.am.Get(b.am.GetIDFromAlias(req.Action))
+	if !ok {
+		return fmt.Errorf("action %q is not found", req.Action)
+	}
+	def := a.ActionDef()
+	input := action.NewInput(a, castNumbers(req.Args, def.Arguments), castNumbers(req.Options, def.Options), streams)
+	if rt, ok := a.Runtime().(action.RuntimeFlags); ok {
+		if err := rt.UseFlags(action.InputParams{}); err != nil {
+			return err
+		}
+		if err := rt.ValidateInput(a, input); err != nil {
+			return err
+		}
+	}
+	if err := a.SetInput(input); err != nil {
+		return err
+	}
+	_, err := b.am.Run(ctx, a)
+	return err
+}
+
+// castNumbers converts JSON numbers to integers for integer parameters.
+func castNumbers(params action.InputParams, defs action.ParametersList) action.InputParams {
+	for _, p := range defs {
+		switch v := params[p.Name].(type) {
+		case float64:
+			if p.Type == jsonschema.Integer && v == math.Trunc(v) {
+				params[p.Name] = int(v)
+			}
+		case []any:
+			if p.Type == jsonschema.Array && p.Items != nil && p.Items.Type == jsonschema.Integer {
+				for i, item := range v {
+					if f, ok := item.(float64); ok && f == math.Trunc(f) {
+						v[i] = int(f)
+					}
+				}
+			}
+		}
+	}
+	return params
+}
+
+// write writes a result as a JSON line.
+func (b *batch) write(res *runResult) error {
+	content, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	_, err = b.out.Write(append(content, '\n'))
+	return err
+}
+
+// batchStreams captures the action output, the input is empty because stdin is used for the requests.
+type batchStreams struct {
+	in  *launchr.In
+	out *launchr.Out
+	err io.Writer
+}
+
+func (s batchStreams) In() *launchr.In   { return s.in }
+func (s batchStreams) Out() *launchr.Out { return s.out }
+func (s batchStreams) Err() io.Writer    { return s.err }
@@ -0,0 +1,124 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func testManager(t *testing.T, fn action.FnRuntime) action.Manager {
+	am := action.NewManager()
+	a := action.NewFromYAML("echo", []byte(`runtime: plugin
+action:
+  title: Echo
+  arguments:
+    - name: msg
+  options:
+    - name: code
+      type: integer
+      default: 0
+`))
+	a.SetRuntime(action.NewFnRuntime(fn))
+	require.NoError(t, am.Add(a))
+	return am
+}
+
+func echo(_ context.Context, a *action.Action) error {
+	input := a.Input()
+	_, err := fmt.Fprint(input.Streams().Out(), input.Arg("msg"))
+	if err != nil {
+		return err
+	}
+	if code := input.Opt("code").(int); code != 0 {
+		_, _ = fmt.Fprint(input.Streams().Err(), "failed")
+		return launchr.NewExitError(code, fmt.Sprintf("exit code %d", code))
+	}
+	return nil
+}
+
+func parseResults(t *testing.T, out string) []runResult {
+	var res []runResult
+	for _, l := range strings.Split(strings.TrimSpace(out), "\n") {
+		r := runResult{}
+		require.NoError(t, json.Unmarshal([]byte(l), &r))
+		r.Duration = 0
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func Test_Batch(t *testing.T) {
+	t.Parallel()
+	in := `{"id": "a", "action": "echo", "args": {"msg": "hello"}}
+
+{"action": "echo", "args": {"msg": "bye"}, "options": {"code": 3}}
+{"action": "unknown"}
+{"action": "echo"}
+not json
+{"args": {"msg": "hello"}}
+`
+	out := &bytes.Buffer{}
+	b := &batch{am: testManager(t, echo), parallel: 2, out: out}
+	err := b.run(context.Background(), strings.NewReader(in))
+	assert.EqualError(t, err, "5 of the batch requests failed")
+	res := parseResults(t, out.String())
+	require.Len(t, res, 6)
+	assert.Equal(t, runResult{ID: "3", Action: "echo", Status: statusFailure, ExitCode: 3, Output: "bye", Stderr: "failed", Error: "exit code 3"}, res[0])
+	assert.Equal(t, statusFailure, res[1].Status)
+	assert.Equal(t, `action "unknown" is not found`, res[1].Error)
+	assert.Equal(t, "5", res[2].ID)
+	assert.Contains(t, res[2].Error, "msg")
+	assert.Equal(t, "6", res[3].ID)
+	assert.Contains(t, res[3].Error, "failed to parse request on line 6")
+	assert.Equal(t, runResult{ID: "7", Status: statusFailure, ExitCode: 1, Error: "action is not set in request on line 7"}, res[4])
+	assert.Equal(t, runResult{ID: "a", Action: "echo", Status: statusSuccess, Output: "hello"}, res[5])
+
+	out.Reset()
+	err = b.run(context.Background(), strings.NewReader(`{"action": "echo", "args": {"msg": "ok"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []runResult{{ID: "1", Action: "echo", Status: statusSuccess, Output: "ok"}}, parseResults(t, out.String()))
+
+	// The output is limited.
+	out.Reset()
+	b.limit = action.ConfigOutputLimit{Head: 2, Tail: 2}
+	err = b.run(context.Background(), strings.NewReader(`{"action": "echo", "args": {"msg": "abcdef"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "ab\n[... 2 bytes of output truncated ...]\nef", parseResults(t, out.String())[0].Output)
+}
+
+func Test_BatchParallel(t *testing.T) {
+	t.Parallel()
+	var running, maxRunning atomic.Int32
+	am := testManager(t, func(ctx context.Context, a *action.Action) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return echo(ctx, a)
+	})
+	in := strings.Repeat(`{"action": "echo", "args": {"msg": "hi"}}`+"\n", 6)
+	out := &bytes.Buffer{}
+	b := &batch{am: am, parallel: 3, out: out}
+	require.NoError(t, b.run(context.Background(), strings.NewReader(in)))
+	assert.Len(t, parseResults(t, out.String()), 6)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	assert.Greater(t, maxRunning.Load(), int32(1))
+}
@@ -0,0 +1,64 @@
+// Package batch implements a launchr plugin to run actions requested on stdin.
+package batch
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing a command to run actions in batch.
+type Plugin struct {
+	app launchr.App
+	am  action.Manager
+	cfg launchr.Config
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	p.app = app
+	app.GetService(&p.am)
+	app.GetService(&p.cfg)
+	return nil
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add the batch command.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	var parallel int
+	cmd := &launchr.Command{
+		Use:   "batch",
+		Short: "Run actions requested on stdin",
+		Long: `Run actions requested on stdin as newline-delimited JSON, one request per line:
+  {"id": "1", "action": "deploy:app", "args": {"name": "app"}, "options": {"dry-run": true}}
+
+A JSON result is printed for every request in the order of completion:
+  {"id": "1", "action": "deploy:app", "status": "success", "exit_code": 0, "output": "...", "stderr": "", "duration_ms": 1200}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			streams := p.app.Streams()
+			// Keep stdout for the results only.
+			launchr.Term().SetOutput(streams.Err())
+			b := &batch{
+				am:       p.am,
+				parallel: parallel,
+				limit:    action.LaunchrConfigRuntime(p.cfg).OutputLimit,
+				out:      streams.Out(),
+			}
+			return b.run(cmd.Context(), streams.In())
+		},
+	}
+	cmd.Flags().IntVarP(&parallel, "parallel", "p", 1, "Number of actions running in parallel")
+	rootCmd.AddCommand(cmd)
+	return nil
+}
@@ -0,0 +1,153 @@
+package builtinprocessors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+const procGetGitValue = "git.GetValue"
+
+// Git values available in `git.GetValue` processor.
+const (
+	gitFieldBranch       = "branch"
+	gitFieldSha          = "sha"
+	gitFieldShortSha     = "short_sha"
+	gitFieldDirty        = "dirty"
+	gitFieldRemoteURL    = "remote_url"
+	gitFieldChangedFiles = "changed_files"
+)
+
+var gitFields = []string{gitFieldBranch, gitFieldSha, gitFieldShortSha, gitFieldDirty, gitFieldRemoteURL, gitFieldChangedFiles}
+
+// gitDefaultBase is the default branch of the remote "origin" set on clone.
+const gitDefaultBase = "origin/HEAD"
+
+// gitCmdTimeout limits a git command not to block the action start.
+const gitCmdTimeout = 10 * time.Second
+
+// gitRunner runs a git command in a directory and returns the trimmed output.
+type gitRunner func(dir string, args ...string) (string, error)
+
+// GitGetProcessorOptions is an options struct for `git.GetValue`.
+type GitGetProcessorOptions struct {
+	Field  string `yaml:"field"`
+	Remote string `yaml:"remote"`
+	Base   string `yaml:"base"`
+}
+
+// Validate implements [action.ValueProcessorOptions] interface.
+func (o *GitGetProcessorOptions) Validate() error {
+	if !slices.Contains(gitFields, o.Field) {
+		return fmt.Errorf(`option "field" of %q processor must be one of: %s`, procGetGitValue, strings.Join(gitFields, ", "))
+	}
+	if o.Remote == "" {
+		o.Remote = "origin"
+	}
+	if o.Base == "" {
+		o.Base = gitDefaultBase
+	}
+	return nil
+}
+
+func newGitValueProcessor(git gitRunner) action.ValueProcessor {
+	return action.GenericValueProcessor[*GitGetProcessorOptions]{
+		Fn: func(v any, opts *GitGetProcessorOptions, ctx action.ValueProcessorContext) (any, error) {
+			return processorGitGetValue(v, opts, ctx, git)
+		},
+	}
+}
+
+func processorGitGetValue(v any, opts *GitGetProcessorOptions, ctx action.ValueProcessorContext, git gitRunner) (any, error) {
+	// If value is provided by user, do not override.
+	if ctx.IsChanged {
+		return v, nil
+	}
+	dir := ctx.Action.WorkDir()
+	var res any
+	var err error
+	switch opts.Field {
+	case gitFieldBranch:
+		res, err = git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	case gitFieldSha:
+		res, err = git(dir, "rev-parse", "HEAD")
+	case gitFieldShortSha:
+		res, err = git(dir, "rev-parse", "--short", "HEAD")
+	case gitFieldDirty:
+		var out string
+		out, err = git(dir, "status", "--porcelain")
+		res = out != ""
+	case gitFieldRemoteURL:
+		res, err = git(dir, "remote", "get-url", opts.Remote)
+	case gitFieldChangedFiles:
+		var files []string
+		files, err = gitChangedFiles(git, dir, opts.Base)
+		res = changedFilesValue(files, ctx.DefParam.Type)
+	}
+	if err != nil {
+		return v, err
+	}
+	return jsonschema.EnsureType(ctx.DefParam.Type, res)
+}
+
+// gitChangedFiles returns files changed since the common ancestor with base including uncommitted changes.
+// The paths are relative to the repository root.
+func gitChangedFiles(git gitRunner, dir, base string) ([]string, error) {
+	mb, err := git(dir, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(dir, "diff", "--name-only", mb)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out+"\n"+untracked, "\n") {
+		if f != "" && !slices.Contains(files, f) {
+			files = append(files, f)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// changedFilesValue returns the files as an array or as a space-separated string to use in shell commands.
+func changedFilesValue(files []string, t jsonschema.Type) any {
+	if t != jsonschema.Array {
+		return strings.Join(files, " ")
+	}
+	res := make([]any, len(files))
+	for i := range files {
+		res[i] = files[i]
+	}
+	return res
+}
+
+// execGit runs git binary of the host.
+func execGit(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCmdTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
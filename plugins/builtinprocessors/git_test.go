@@ -0,0 +1,142 @@
+package builtinprocessors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+const testProcGetGit = `
+runtime: plugin
+action:
+  title: test git
+  options:
+    - name: branch
+      process:
+        - processor: git.GetValue
+          options:
+            field: branch
+    - name: sha
+      process:
+        - processor: git.GetValue
+          options:
+            field: short_sha
+    - name: dirty
+      type: boolean
+      process:
+        - processor: git.GetValue
+          options:
+            field: dirty
+    - name: remote
+      process:
+        - processor: git.GetValue
+          options:
+            field: remote_url
+            remote: upstream
+    - name: files
+      type: array
+      process:
+        - processor: git.GetValue
+          options:
+            field: changed_files
+            base: main
+    - name: files_str
+      process:
+        - processor: git.GetValue
+          options:
+            field: changed_files
+`
+
+const testProcGetGitWrongField = `
+runtime: plugin
+action:
+  title: test git
+  options:
+    - name: branch
+      process:
+        - processor: git.GetValue
+          options:
+            field: unknown
+`
+
+const testProcGetGitNoRepo = `
+runtime: plugin
+action:
+  title: test git
+  options:
+    - name: branch
+      process:
+        - processor: git.GetValue
+          options:
+            field: branch
+`
+
+func testGitRunner(_ string, args ...string) (string, error) {
+	switch strings.Join(args, " ") {
+	case "rev-parse --abbrev-ref HEAD":
+		return "main", nil
+	case "rev-parse --short HEAD":
+		return "abc1234", nil
+	case "status --porcelain":
+		return "M file.go", nil
+	case "remote get-url upstream":
+		return "git@example.com:org/repo.git", nil
+	case "merge-base main HEAD", "merge-base origin/HEAD HEAD":
+		return "base1234", nil
+	case "diff --name-only base1234":
+		return "pkg/b.go\npkg/a.go", nil
+	case "ls-files --others --exclude-standard --full-name":
+		return "new.go\npkg/a.go", nil
+	default:
+		return "", fmt.Errorf("unexpected git command: %v", args)
+	}
+}
+
+func testGitRunnerNoRepo(_ string, _ ...string) (string, error) {
+	return "", errors.New("not a git repository")
+}
+
+func Test_GitProcessor(t *testing.T) {
+	am := action.NewManager()
+	am.AddValueProcessor(procGetGitValue, newGitValueProcessor(testGitRunner))
+	amNoRepo := action.NewManager()
+	amNoRepo.AddValueProcessor(procGetGitValue, newGitValueProcessor(testGitRunnerNoRepo))
+
+	expGit := action.InputParams{
+		"branch":    "main",
+		"sha":       "abc1234",
+		"dirty":     true,
+		"remote":    "git@example.com:org/repo.git",
+		"files":     []any{"new.go", "pkg/a.go", "pkg/b.go"},
+		"files_str": "new.go pkg/a.go pkg/b.go",
+	}
+	expGiven := action.InputParams{
+		"branch":    "feature",
+		"sha":       "1234567",
+		"dirty":     false,
+		"remote":    "https://example.com/repo.git",
+		"files":     []any{"pkg/c.go"},
+		"files_str": "pkg/c.go",
+	}
+	errOpts := fmt.Errorf(`option "field" of %q processor must be one of: %s`, procGetGitValue, strings.Join(gitFields, ", "))
+	errRepo := fmt.Errorf("failed to process parameter %q with %q: %w", "branch", procGetGitValue, errors.New("not a git repository"))
+	tts := []struct {
+		am action.Manager
+		action.TestCaseValueProcessor
+	}{
+		{am, action.TestCaseValueProcessor{Name: "get git value - no input given", Yaml: testProcGetGit, ExpOpts: expGit}},
+		{am, action.TestCaseValueProcessor{Name: "get git value - input given", Yaml: testProcGetGit, Opts: expGiven, ExpOpts: expGiven}},
+		{am, action.TestCaseValueProcessor{Name: "get git value - wrong field", Yaml: testProcGetGitWrongField, ErrInit: errOpts}},
+		{amNoRepo, action.TestCaseValueProcessor{Name: "get git value - not a repository", Yaml: testProcGetGitNoRepo, ErrProc: errRepo}},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+			tt.Test(t, tt.am)
+		})
+	}
+}
@@ -64,6 +64,7 @@ func addValueProcessors(m action.Manager, cfg launchr.Config) {
 	}
 	m.AddValueProcessor(procGetConfigValueDeprecated, procCfg)
 	m.AddValueProcessor(procGetConfigValue, procCfg)
+	m.AddValueProcessor(procGetGitValue, newGitValueProcessor(execGit))
 }
 
 func processorConfigGetByKey(v any, opts *ConfigGetProcessorOptions, ctx action.ValueProcessorContext, cfg launchr.Config) (any, error) {
@@ -0,0 +1,68 @@
+// Package cleanup implements a launchr plugin to remove resources left by action runs.
+package cleanup
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing a command to remove resources left by action runs.
+type Plugin struct {
+	cfg launchr.Config
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	app.GetService(&p.cfg)
+	return nil
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add the cleanup command.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	var volumes bool
+	cmd := &launchr.Command{
+		Use:   "cleanup",
+		Short: "Remove resources left by action runs",
+		Long: `Remove resources left by action runs, e.g. after a crash.
+Use --volumes to remove the volumes of container runs not used by any container.
+Cache volumes of actions are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			if !volumes {
+				return errors.New("nothing to clean up, use --volumes to remove orphaned volumes")
+			}
+			d, err := driver.NewDockerDriverWithOptions(action.LaunchrConfigRuntime(p.cfg).Docker)
+			if err != nil {
+				return err
+			}
+			defer d.Close()
+			removed, err := action.RemoveOrphanedVolumes(cmd.Context(), d)
+			for _, name := range removed {
+				launchr.Term().Printfln("Removed volume %s", name)
+			}
+			if err != nil {
+				return err
+			}
+			launchr.Term().Success().Printfln("Removed %d orphaned volumes.", len(removed))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&volumes, "volumes", false, "Remove orphaned volumes of container runs")
+	rootCmd.AddCommand(cmd)
+	return nil
+}
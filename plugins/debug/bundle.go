@@ -0,0 +1,168 @@
+package debug
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+// maskedValue replaces secrets in the bundle.
+const maskedValue = "***"
+
+// driverInfoTimeout limits the request of the container engine information.
+const driverInfoTimeout = 5 * time.Second
+
+// bundle is an archive with troubleshooting information of a run.
+type bundle struct {
+	rec      *action.RunRecord
+	config   []byte
+	versions string
+}
+
+// bundleFile is a file in the bundle archive.
+type bundleFile struct {
+	name    string
+	content []byte
+}
+
+// files returns the files of the bundle.
+func (b *bundle) files() ([]bundleFile, error) {
+	// The action definition and the output are stored in separate files to read them easily.
+	run := *b.rec
+	run.Definition = ""
+	run.Output = ""
+	runYaml, err := yaml.Marshal(run)
+	if err != nil {
+		return nil, err
+	}
+	files := []bundleFile{
+		{"run.yaml", runYaml},
+		{"action.yaml", []byte(b.rec.Definition)},
+		{"output.log", []byte(b.rec.Output)},
+		{"versions.txt", []byte(b.versions)},
+	}
+	if b.config != nil {
+		cfg, err := maskConfig(b.config)
+		if err != nil {
+			// Don't leak an unparsable configuration.
+			cfg = []byte(fmt.Sprintf("# the configuration is not included: %s\n", err))
+		}
+		files = append(files, bundleFile{"config.yaml", cfg})
+	}
+	return files, nil
+}
+
+// write writes the bundle to w as a tar.gz archive.
+func (b *bundle) write(w io.Writer) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	dir := "launchr-debug-" + b.rec.ID
+	for _, f := range files {
+		hdr := &tar.Header{
+			Name:    dir + "/" + f.name,
+			Mode:    0600,
+			Size:    int64(len(f.content)),
+			ModTime: time.Now(),
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = tw.Write(f.content); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// maskConfig replaces values of the secret keys in yaml content.
+func maskConfig(content []byte) ([]byte, error) {
+	var n yaml.Node
+	if err := yaml.Unmarshal(content, &n); err != nil {
+		return nil, err
+	}
+	maskNode(&n)
+	return yaml.Marshal(&n)
+}
+
+func maskNode(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		for _, c := range n.Content {
+			maskNode(c)
+		}
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if v.Kind == yaml.ScalarNode && action.IsSecretName(k.Value) {
+			v.Value = maskedValue
+			v.Tag = "!!str"
+			v.Style = 0
+			continue
+		}
+		maskNode(v)
+	}
+}
+
+// readConfigFile returns the content of the app configuration file or nil if it doesn't exist.
+func readConfigFile(cfg launchr.Config) []byte {
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		content, err := os.ReadFile(filepath.Join(cfg.DirPath(), name)) //nolint:gosec
+		if err == nil {
+			return content
+		}
+	}
+	return nil
+}
+
+// collectVersions describes the versions of the app and the container engine.
+func collectVersions(ctx context.Context, opts driver.DockerOptions) string {
+	var b strings.Builder
+	b.WriteString(launchr.Version().Full())
+	b.WriteString("\n")
+	ctx, cancel := context.WithTimeout(ctx, driverInfoTimeout)
+	defer cancel()
+	if ep, err := driver.ResolveDockerEndpoint(opts); err == nil && ep.Host != "" {
+		fmt.Fprintf(&b, "Docker host: %s (%s)\n", ep.Host, ep.Source)
+	}
+	d, err := driver.NewDockerDriverWithOptions(opts)
+	if err != nil {
+		fmt.Fprintf(&b, "Container engine: %s\n", err)
+		return b.String()
+	}
+	defer d.Close()
+	info, err := d.Info(ctx)
+	if err != nil {
+		fmt.Fprintf(&b, "Container engine: %s\n", err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Container engine: %s, server version %s\n", info.Name, info.ServerVersion)
+	if dv, ok := d.(driver.ContainerRunnerAPIVersion); ok {
+		if v, errV := dv.APIVersion(ctx); errV == nil {
+			fmt.Fprintf(&b, "API version: %s\n", v)
+		}
+	}
+	fmt.Fprintf(&b, "OS: %s %s (%s), kernel %s, %s\n", info.OperatingSystem, info.OSVersion, info.OSType, info.KernelVersion, info.Architecture)
+	if len(info.SecurityOptions) > 0 {
+		fmt.Fprintf(&b, "Security options: %s\n", strings.Join(info.SecurityOptions, ", "))
+	}
+	return b.String()
+}
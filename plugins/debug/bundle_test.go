@@ -0,0 +1,70 @@
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_Bundle(t *testing.T) {
+	t.Parallel()
+	b := &bundle{
+		rec: &action.RunRecord{
+			ID:         "launchr_test",
+			ActionID:   "platform:build",
+			Definition: "action:\n  title: Build\n",
+			Output:     "build failed\n",
+			ExitCode:   1,
+		},
+		config: []byte(`
+images:
+  registry:
+    password: hunter2
+    user: ci
+runtime:
+  heartbeat_interval: 1m
+`),
+		versions: "launchr version 1.0.0\n",
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, b.write(buf))
+
+	gr, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		hdr, errTar := tr.Next()
+		if errTar == io.EOF {
+			break
+		}
+		require.NoError(t, errTar)
+		content, errTar := io.ReadAll(tr)
+		require.NoError(t, errTar)
+		files[hdr.Name] = string(content)
+	}
+	dir := "launchr-debug-launchr_test/"
+	assert.Len(t, files, 5)
+	assert.Equal(t, "action:\n  title: Build\n", files[dir+"action.yaml"])
+	assert.Equal(t, "build failed\n", files[dir+"output.log"])
+	assert.Equal(t, "launchr version 1.0.0\n", files[dir+"versions.txt"])
+	assert.Contains(t, files[dir+"run.yaml"], "action_id: platform:build")
+	assert.NotContains(t, files[dir+"run.yaml"], "build failed")
+	assert.Contains(t, files[dir+"config.yaml"], `password: '***'`)
+	assert.Contains(t, files[dir+"config.yaml"], "user: ci")
+	assert.NotContains(t, files[dir+"config.yaml"], "hunter2")
+
+	// An unparsable configuration is not included.
+	b.config = []byte("password: [hunter2")
+	fs, err := b.files()
+	require.NoError(t, err)
+	assert.Equal(t, "config.yaml", fs[4].name)
+	assert.NotContains(t, string(fs[4].content), "hunter2")
+}
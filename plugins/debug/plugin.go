@@ -0,0 +1,143 @@
+// Package debug implements a launchr plugin to collect troubleshooting information for bug reports.
+package debug
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing commands to inspect recent runs and export them for bug reports.
+type Plugin struct {
+	cfg launchr.Config
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	app.GetService(&p.cfg)
+	return nil
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add the debug commands.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	cmd := &launchr.Command{
+		Use:   "debug",
+		Short: "Troubleshoot action runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(p.runsCommand(), p.bundleCommand())
+	rootCmd.AddCommand(cmd)
+	return nil
+}
+
+func (p *Plugin) runsCommand() *launchr.Command {
+	return &launchr.Command{
+		Use:   "runs",
+		Short: "List recent runs of container actions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			recs, err := action.NewRunRecorder(p.cfg).List()
+			if err != nil {
+				return err
+			}
+			data := pterm.TableData{{"Run ID", "Action", "Started", "Duration", "Exit code"}}
+			for _, rec := range recs {
+				data = append(data, []string{
+					rec.ID,
+					rec.ActionID,
+					rec.Started.Local().Format(time.DateTime),
+					rec.Duration.String(),
+					strconv.Itoa(rec.ExitCode),
+				})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+}
+
+func (p *Plugin) bundleCommand() *launchr.Command {
+	var output string
+	cmd := &launchr.Command{
+		Use:   "bundle [run_id]",
+		Short: "Export a run for a bug report",
+		Long: `Export a run of a container action to an archive to attach to a bug report.
+The archive contains the action definition, the rendered container definition, the tail of the output,
+the versions of the app and the container engine and the configuration. Secrets are masked.
+The last run is exported if the run id is not given, see "debug runs" for the recent runs.`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(_ *launchr.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			recs, _ := action.NewRunRecorder(p.cfg).List()
+			ids := make([]string, len(recs))
+			for i, rec := range recs {
+				ids[i] = rec.ID
+			}
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			rec, err := p.findRun(args)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = "launchr-debug-" + rec.ID + ".tar.gz"
+			}
+			b := &bundle{
+				rec:      rec,
+				config:   readConfigFile(p.cfg),
+				versions: collectVersions(cmd.Context(), action.LaunchrConfigRuntime(p.cfg).Docker),
+			}
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600) //nolint:gosec
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if err = b.write(f); err != nil {
+				return fmt.Errorf("failed to write the debug bundle: %w", err)
+			}
+			launchr.Term().Success().Printfln("Debug bundle of run %q is written to %s, review it before sharing.", rec.ID, output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Path of the archive, launchr-debug-<run_id>.tar.gz by default")
+	return cmd
+}
+
+// findRun returns the run given in args or the last run.
+func (p *Plugin) findRun(args []string) (*action.RunRecord, error) {
+	rr := action.NewRunRecorder(p.cfg)
+	if len(args) > 0 {
+		return rr.Load(args[0])
+	}
+	recs, err := rr.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("no runs of container actions are recorded")
+	}
+	return recs[0], nil
+}
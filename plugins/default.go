@@ -5,8 +5,16 @@ import (
 	// Default launchr plugins to include for launchr functionality.
 	_ "github.com/launchrctl/launchr/plugins/actionnaming"
 	_ "github.com/launchrctl/launchr/plugins/actionscobra"
+	_ "github.com/launchrctl/launchr/plugins/batch"
 	_ "github.com/launchrctl/launchr/plugins/builder"
 	_ "github.com/launchrctl/launchr/plugins/builtinprocessors"
+	_ "github.com/launchrctl/launchr/plugins/cleanup"
+	_ "github.com/launchrctl/launchr/plugins/debug"
+	_ "github.com/launchrctl/launchr/plugins/doctor"
+	_ "github.com/launchrctl/launchr/plugins/export"
+	_ "github.com/launchrctl/launchr/plugins/session"
+	_ "github.com/launchrctl/launchr/plugins/state"
 	_ "github.com/launchrctl/launchr/plugins/verbosity"
+	_ "github.com/launchrctl/launchr/plugins/workflow"
 	_ "github.com/launchrctl/launchr/plugins/yamldiscovery"
 )
@@ -0,0 +1,240 @@
+// Package doctor implements a launchr plugin to check the application environment.
+package doctor
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+//go:embed action.yaml
+var actionYaml []byte
+
+const sourceCore = "core"
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing a command to check the application health.
+type Plugin struct {
+	app launchr.App
+	cfg launchr.Config
+	pm  launchr.PluginManager
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{
+		Weight: math.MinInt,
+	}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	p.app = app
+	app.GetService(&p.cfg)
+	app.GetService(&p.pm)
+	return nil
+}
+
+// DiscoverActions implements [action.DiscoveryPlugin] interface.
+func (p *Plugin) DiscoverActions(_ context.Context) ([]*action.Action, error) {
+	a := action.NewFromYAML("doctor", actionYaml)
+	a.SetRuntime(action.NewFnRuntime(func(ctx context.Context, a *action.Action) error {
+		timeout, err := time.ParseDuration(a.Input().Opt("timeout").(string))
+		if err != nil {
+			return err
+		}
+		return p.run(ctx, a.Input().Streams(), timeout)
+	}))
+	return []*action.Action{a}, nil
+}
+
+type sourcedCheck struct {
+	source string
+	launchr.HealthCheck
+}
+
+func (p *Plugin) run(ctx context.Context, streams launchr.Streams, timeout time.Duration) error {
+	var checks []sourcedCheck
+	add := func(source string, hc ...launchr.HealthCheck) {
+		for _, c := range hc {
+			checks = append(checks, sourcedCheck{source, c})
+		}
+	}
+
+	add(sourceCore, p.checkConfig())
+	add(sourceCore, p.checkConfigDirWritable())
+	add(sourceCore, p.checkDiscoveryRoots()...)
+	add(sourceCore, withTimeout(ctx, timeout, p.checkDocker))
+	add(sourceCore, withTimeout(ctx, timeout, p.checkSELinux))
+	for _, pl := range launchr.GetPluginByType[launchr.HealthCheckPlugin](p.pm) {
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		add(pl.K.String(), pl.V.HealthCheck(pctx)...)
+		cancel()
+	}
+
+	data := pterm.TableData{{"Source", "Check", "Status", "Message", "Remediation"}}
+	failed := 0
+	for _, c := range checks {
+		if c.Status == launchr.HealthFail {
+			failed++
+		}
+		data = append(data, []string{c.source, c.Name, statusStyle(c.Status), c.Message, c.Remediation})
+	}
+	err := pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(streams.Out()).Render()
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func statusStyle(s launchr.HealthStatus) string {
+	switch s {
+	case launchr.HealthPass:
+		return pterm.FgGreen.Sprint(s)
+	case launchr.HealthWarn:
+		return pterm.FgYellow.Sprint(s)
+	default:
+		return pterm.FgRed.Sprint(s)
+	}
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) launchr.HealthCheck) launchr.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+func (p *Plugin) checkConfig() launchr.HealthCheck {
+	hc := launchr.HealthCheck{Name: "config readable", Status: launchr.HealthPass}
+	// Any key triggers parsing of the config file.
+	var v any
+	err := p.cfg.Get(action.ConfigImagesKey, &v)
+	if err != nil {
+		hc.Status = launchr.HealthFail
+		hc.Message = err.Error()
+		hc.Remediation = fmt.Sprintf("Fix the syntax of the config file in %q", p.cfg.DirPath())
+		return hc
+	}
+	hc.Message = "config is valid or not provided"
+	return hc
+}
+
+func (p *Plugin) checkConfigDirWritable() launchr.HealthCheck {
+	dir := p.cfg.DirPath()
+	hc := launchr.HealthCheck{Name: "config dir writable", Status: launchr.HealthPass, Message: dir}
+	stat, err := os.Stat(dir)
+	if err != nil {
+		hc.Status = launchr.HealthWarn
+		hc.Message = fmt.Sprintf("directory %q doesn't exist", dir)
+		hc.Remediation = "Create the directory to store config and image build cache"
+		return hc
+	}
+	if !stat.IsDir() {
+		hc.Status = launchr.HealthFail
+		hc.Message = fmt.Sprintf("%q is not a directory", dir)
+		hc.Remediation = "Remove the file and create a directory instead"
+		return hc
+	}
+	f, err := os.CreateTemp(dir, ".doctor")
+	if err != nil {
+		hc.Status = launchr.HealthFail
+		hc.Message = err.Error()
+		hc.Remediation = "Check permissions of the directory"
+		return hc
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return hc
+}
+
+func (p *Plugin) checkDiscoveryRoots() []launchr.HealthCheck {
+	var res []launchr.HealthCheck
+	for _, mfs := range p.app.GetRegisteredFS() {
+		path := launchr.GetFsAbsPath(mfs.FS())
+		if path == "" {
+			// In-memory file systems are always available.
+			continue
+		}
+		hc := launchr.HealthCheck{Name: "discovery root exists", Status: launchr.HealthPass, Message: path}
+		if stat, err := os.Stat(path); err != nil || !stat.IsDir() {
+			hc.Status = launchr.HealthFail
+			hc.Message = fmt.Sprintf("directory %q is not accessible", path)
+			hc.Remediation = "Check the actions path environment variable and the working directory"
+		}
+		res = append(res, hc)
+	}
+	return res
+}
+
+func (p *Plugin) dockerOptions() driver.DockerOptions {
+	return action.LaunchrConfigRuntime(p.cfg).Docker
+}
+
+func (p *Plugin) checkDocker(ctx context.Context) launchr.HealthCheck {
+	hc := launchr.HealthCheck{Name: "docker reachable", Status: launchr.HealthPass}
+	fail := func(err error) launchr.HealthCheck {
+		hc.Status = launchr.HealthFail
+		hc.Message = err.Error()
+		hc.Remediation = "Ensure the docker daemon is running, select its context with \"docker context use\" or set runtime.docker.host in the config"
+		return hc
+	}
+	opts := p.dockerOptions()
+	ep, err := driver.ResolveDockerEndpoint(opts)
+	if err != nil {
+		return fail(err)
+	}
+	d, err := driver.NewDockerDriverWithOptions(opts)
+	if err != nil {
+		return fail(err)
+	}
+	defer d.Close()
+	info, err := d.Info(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	host := ep.Host
+	if host == "" {
+		host = "default host"
+	}
+	hc.Message = fmt.Sprintf("%s, server version %s, %s from %s", info.Name, info.ServerVersion, host, ep.Source)
+	return hc
+}
+
+func (p *Plugin) checkSELinux(ctx context.Context) launchr.HealthCheck {
+	hc := launchr.HealthCheck{Name: "selinux mounts", Status: launchr.HealthPass}
+	if !launchr.IsSELinuxEnabled() {
+		hc.Message = "SELinux is not enabled, directories are mounted without relabeling"
+		return hc
+	}
+	d, err := driver.NewDockerDriverWithOptions(p.dockerOptions())
+	if err != nil {
+		hc.Status = launchr.HealthWarn
+		hc.Message = err.Error()
+		return hc
+	}
+	defer d.Close()
+	if sd, ok := d.(driver.ContainerRunnerSELinux); !ok || !sd.IsSELinuxSupported(ctx) {
+		hc.Status = launchr.HealthWarn
+		hc.Message = "SELinux is enabled on the host, but not in the docker daemon, containers bypass SELinux"
+		hc.Remediation = "Enable SELinux support in the docker daemon configuration"
+		return hc
+	}
+	hc.Message = "working and action directories are relabeled with \":z\", or \":Z\" for actions with a private selinux label"
+	hc.Remediation = "Use --mount-flags to override the flags, e.g. \"none\" to skip relabeling of large directories"
+	return hc
+}
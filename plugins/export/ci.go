@@ -0,0 +1,257 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/jsonschema"
+)
+
+const (
+	ciProviderGitHub = "github"
+	ciProviderGitLab = "gitlab"
+)
+
+type ciGenerator func(w io.Writer, bin string, actions []*action.Action) error
+
+var ciGenerators = map[string]ciGenerator{
+	ciProviderGitHub: generateGitHub,
+	ciProviderGitLab: generateGitLab,
+}
+
+func ciProviders() []string {
+	res := make([]string, 0, len(ciGenerators))
+	for k := range ciGenerators {
+		res = append(res, k)
+	}
+	slices.Sort(res)
+	return res
+}
+
+var rgxNonAlnum = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// yamlMap is a yaml mapping keeping the order of the keys.
+type yamlMap []yamlKV
+
+type yamlKV struct {
+	k string
+	v any
+}
+
+// MarshalYAML implements [yaml.Marshaler] interface.
+func (m yamlMap) MarshalYAML() (any, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	for _, kv := range m {
+		var k, v yaml.Node
+		k.SetString(kv.k)
+		if err := v.Encode(kv.v); err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, &k, &v)
+	}
+	return n, nil
+}
+
+// ciVar is an action parameter declared as a pipeline variable.
+type ciVar struct {
+	name  string
+	param *action.DefParameter
+}
+
+// ciJob holds action information to generate a pipeline job.
+type ciJob struct {
+	id    string
+	title string
+	vars  []ciVar
+	cmd   string
+}
+
+func newCIJob(bin string, a *action.Action) ciJob {
+	def := a.ActionDef()
+	job := ciJob{
+		id:    strings.Trim(rgxNonAlnum.ReplaceAllString(a.ID, "-"), "-"),
+		title: def.Title,
+	}
+	if job.title == "" {
+		job.title = a.ID
+	}
+	cmd := []string{bin, a.ID}
+	for _, p := range def.Arguments {
+		v := ciVar{name: ciVarName(a.ID, p.Name), param: p}
+		job.vars = append(job.vars, v)
+		cmd = append(cmd, fmt.Sprintf(`"$%s"`, v.name))
+	}
+	for _, p := range def.Options {
+		v := ciVar{name: ciVarName(a.ID, p.Name), param: p}
+		job.vars = append(job.vars, v)
+		// Pass optional options only when the value is set to keep the defaults of the action.
+		if p.Required {
+			cmd = append(cmd, fmt.Sprintf(`--%s="$%s"`, p.Name, v.name))
+		} else {
+			cmd = append(cmd, fmt.Sprintf(`${%s:+--%s="$%s"}`, v.name, p.Name, v.name))
+		}
+	}
+	job.cmd = strings.Join(cmd, " ")
+	return job
+}
+
+// ciVarName returns an environment variable name for an action parameter.
+func ciVarName(actionID, param string) string {
+	return strings.ToUpper(strings.Trim(rgxNonAlnum.ReplaceAllString(actionID+"_"+param, "_"), "_"))
+}
+
+func ciDescription(p *action.DefParameter) string {
+	desc := p.Title
+	if p.Description != "" {
+		if desc != "" {
+			desc += ". "
+		}
+		desc += p.Description
+	}
+	if desc == "" {
+		desc = p.Name
+	}
+	return desc
+}
+
+// ciValue returns a string representation of a parameter value as it's passed in command line.
+func ciValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		strs := make([]string, len(v))
+		for i := range v {
+			strs[i] = ciValue(v[i])
+		}
+		return strings.Join(strs, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func encodeYaml(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func generateGitHub(w io.Writer, bin string, actions []*action.Action) error {
+	inputs := yamlMap{}
+	jobs := yamlMap{}
+	for _, a := range actions {
+		job := newCIJob(bin, a)
+		env := yamlMap{}
+		for _, v := range job.vars {
+			inputID := strings.ToLower(v.name)
+			inputs = append(inputs, yamlKV{inputID, githubInput(v)})
+			env = append(env, yamlKV{v.name, fmt.Sprintf("${{ inputs.%s }}", inputID)})
+		}
+		step := yamlMap{{"name", job.title}}
+		if len(env) > 0 {
+			step = append(step, yamlKV{"env", env})
+		}
+		step = append(step, yamlKV{"run", job.cmd})
+		jobs = append(jobs, yamlKV{job.id, yamlMap{
+			{"runs-on", "ubuntu-latest"},
+			{"steps", []any{
+				yamlMap{{"uses", "actions/checkout@v4"}},
+				step,
+			}},
+		}})
+	}
+	dispatch := yamlMap{}
+	if len(inputs) > 0 {
+		dispatch = append(dispatch, yamlKV{"inputs", inputs})
+	}
+	return encodeYaml(w, yamlMap{
+		{"name", bin},
+		{"on", yamlMap{{"workflow_dispatch", dispatch}}},
+		{"jobs", jobs},
+	})
+}
+
+func githubInput(v ciVar) yamlMap {
+	p := v.param
+	in := yamlMap{
+		{"description", ciDescription(p)},
+		{"required", p.Required},
+	}
+	switch {
+	case len(p.Enum) > 0:
+		opts := make([]string, len(p.Enum))
+		for i := range p.Enum {
+			opts[i] = ciValue(p.Enum[i])
+		}
+		in = append(in, yamlKV{"type", "choice"}, yamlKV{"options", opts})
+	case p.Type == jsonschema.Boolean:
+		in = append(in, yamlKV{"type", "boolean"})
+	case p.Type == jsonschema.Integer || p.Type == jsonschema.Number:
+		in = append(in, yamlKV{"type", "number"})
+	default:
+		in = append(in, yamlKV{"type", "string"})
+	}
+	switch d := p.Default.(type) {
+	case nil:
+	case []any:
+		in = append(in, yamlKV{"default", ciValue(d)})
+	default:
+		in = append(in, yamlKV{"default", d})
+	}
+	return in
+}
+
+func generateGitLab(w io.Writer, bin string, actions []*action.Action) error {
+	vars := yamlMap{}
+	res := yamlMap{}
+	for _, a := range actions {
+		job := newCIJob(bin, a)
+		var script []string
+		for _, v := range job.vars {
+			vars = append(vars, yamlKV{v.name, gitlabVariable(v)})
+			// GitLab doesn't support required variables, check them in the script.
+			if v.param.Required {
+				script = append(script, fmt.Sprintf(`: "${%s:?variable is required}"`, v.name))
+			}
+		}
+		script = append(script, job.cmd)
+		res = append(res, yamlKV{job.id, yamlMap{{"script", script}}})
+	}
+	if len(vars) > 0 {
+		res = append(yamlMap{{"variables", vars}}, res...)
+	}
+	return encodeYaml(w, res)
+}
+
+func gitlabVariable(v ciVar) yamlMap {
+	p := v.param
+	val := ciValue(p.Default)
+	var opts []string
+	if len(p.Enum) > 0 {
+		opts = make([]string, len(p.Enum))
+		for i := range p.Enum {
+			opts[i] = ciValue(p.Enum[i])
+		}
+		// GitLab requires the value to be one of the options.
+		if !slices.Contains(opts, val) {
+			val = opts[0]
+		}
+	}
+	res := yamlMap{
+		{"value", val},
+		{"description", ciDescription(p)},
+	}
+	if len(opts) > 0 {
+		res = append(res, yamlKV{"options", opts})
+	}
+	return res
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+const testCIActionYaml = `
+runtime: plugin
+action:
+  title: Deploy app
+  arguments:
+    - name: env
+      title: Environment
+      required: true
+      enum: [dev, prod]
+  options:
+    - name: dry-run
+      type: boolean
+      default: false
+    - name: tags
+      title: Tags
+      description: Image tags
+      type: array
+      default: [a, b]
+`
+
+const expGitHubYaml = `name: launchr
+on:
+  workflow_dispatch:
+    inputs:
+      deploy_app_env:
+        description: Environment
+        required: true
+        type: choice
+        options:
+          - dev
+          - prod
+      deploy_app_dry_run:
+        description: dry-run
+        required: false
+        type: boolean
+        default: false
+      deploy_app_tags:
+        description: Tags. Image tags
+        required: false
+        type: string
+        default: a,b
+jobs:
+  deploy-app:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Deploy app
+        env:
+          DEPLOY_APP_ENV: ${{ inputs.deploy_app_env }}
+          DEPLOY_APP_DRY_RUN: ${{ inputs.deploy_app_dry_run }}
+          DEPLOY_APP_TAGS: ${{ inputs.deploy_app_tags }}
+        run: launchr deploy:app "$DEPLOY_APP_ENV" ${DEPLOY_APP_DRY_RUN:+--dry-run="$DEPLOY_APP_DRY_RUN"} ${DEPLOY_APP_TAGS:+--tags="$DEPLOY_APP_TAGS"}
+`
+
+const expGitLabYaml = `variables:
+  DEPLOY_APP_ENV:
+    value: dev
+    description: Environment
+    options:
+      - dev
+      - prod
+  DEPLOY_APP_DRY_RUN:
+    value: "false"
+    description: dry-run
+  DEPLOY_APP_TAGS:
+    value: a,b
+    description: Tags. Image tags
+deploy-app:
+  script:
+    - ': "${DEPLOY_APP_ENV:?variable is required}"'
+    - launchr deploy:app "$DEPLOY_APP_ENV" ${DEPLOY_APP_DRY_RUN:+--dry-run="$DEPLOY_APP_DRY_RUN"} ${DEPLOY_APP_TAGS:+--tags="$DEPLOY_APP_TAGS"}
+`
+
+func Test_GenerateCI(t *testing.T) {
+	t.Parallel()
+	a := action.NewFromYAML("deploy:app", []byte(testCIActionYaml))
+	_, err := a.Raw()
+	require.NoError(t, err)
+
+	tt := []struct {
+		provider string
+		exp      string
+	}{
+		{ciProviderGitHub, expGitHubYaml},
+		{ciProviderGitLab, expGitLabYaml},
+	}
+	for _, tt := range tt {
+		tt := tt
+		t.Run(tt.provider, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := ciGenerators[tt.provider](&buf, "launchr", []*action.Action{a})
+			require.NoError(t, err)
+			assert.Equal(t, tt.exp, buf.String())
+		})
+	}
+}
+
+func Test_CIVarName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "DEPLOY_APP_DRY_RUN", ciVarName("deploy:app", "dry-run"))
+	assert.Equal(t, "NS_SUB_ACTION_OPT", ciVarName("ns.sub:action", "opt"))
+}
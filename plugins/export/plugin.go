@@ -0,0 +1,92 @@
+// Package export implements a launchr plugin to export actions to other tools.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing commands to export actions.
+type Plugin struct {
+	app launchr.App
+	am  action.Manager
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	p.app = app
+	app.GetService(&p.am)
+	return nil
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add export commands.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	cmd := &launchr.Command{
+		Use:   "export",
+		Short: "Export actions to other tools",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(p.ciCommand())
+	rootCmd.AddCommand(cmd)
+	return nil
+}
+
+func (p *Plugin) ciCommand() *launchr.Command {
+	var provider string
+	cmd := &launchr.Command{
+		Use:   "ci action...",
+		Short: "Generate a CI pipeline running the actions",
+		Long: `Generate a CI pipeline running the actions.
+Arguments and options of the actions are declared as pipeline variables.
+Supported providers: ` + strings.Join(ciProviders(), ", "),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			gen, ok := ciGenerators[provider]
+			if !ok {
+				return fmt.Errorf("unsupported CI provider %q, supported: %s", provider, strings.Join(ciProviders(), ", "))
+			}
+			actions := make([]*action.Action, 0, len(args))
+			for _, id := range args {
+				a, ok := p.am.Get(p.am.GetIDFromAlias(id))
+				if !ok {
+					return fmt.Errorf("action %q is not found", id)
+				}
+				actions = append(actions, a)
+			}
+			return gen(cmd.OutOrStdout(), p.app.Name(), actions)
+		},
+		ValidArgsFunction: func(_ *launchr.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var ids []string
+			for id := range p.am.All() {
+				if strings.HasPrefix(id, toComplete) {
+					ids = append(ids, id)
+				}
+			}
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVarP(&provider, "provider", "p", "", "CI provider to generate the pipeline for")
+	_ = cmd.MarkFlagRequired("provider")
+	_ = cmd.RegisterFlagCompletionFunc("provider", func(_ *launchr.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return ciProviders(), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
@@ -0,0 +1,97 @@
+// Package session implements a launchr plugin to keep the working directory on the container engine between runs.
+package session
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+	"github.com/launchrctl/launchr/pkg/driver"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing commands to start and stop sessions of the working directory.
+type Plugin struct {
+	cfg launchr.Config
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	app.GetService(&p.cfg)
+	return nil
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add the session commands.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	cmd := &launchr.Command{
+		Use:   "session",
+		Short: "Keep the working directory on the container engine between runs",
+		Long: `Keep the working directory on the container engine between runs.
+A session copies the working directory to a named volume once, the runs with --use-volume-wd
+in the directory use the volume instead of copying the directory every time.
+The result is copied back when the session stops.`,
+	}
+	var image string
+	startCmd := &launchr.Command{
+		Use:   "start",
+		Short: "Copy the working directory to a session volume",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			m, d, err := p.sessionManager()
+			if err != nil {
+				return err
+			}
+			defer d.Close()
+			s, err := m.Start(cmd.Context(), launchr.MustAbs("."), image)
+			if err != nil {
+				return err
+			}
+			launchr.Term().Success().Printfln("Session of %s is started in volume %s.", s.WorkDir, s.Volume)
+			return nil
+		},
+	}
+	startCmd.Flags().StringVar(&image, "image", action.DefaultSessionImage, "Image of the helper containers copying the working directory")
+
+	var discard bool
+	stopCmd := &launchr.Command{
+		Use:   "stop",
+		Short: "Copy the working directory back and remove the session volume",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			m, d, err := p.sessionManager()
+			if err != nil {
+				return err
+			}
+			defer d.Close()
+			s, err := m.Stop(cmd.Context(), launchr.MustAbs("."), discard)
+			if err != nil {
+				return err
+			}
+			launchr.Term().Success().Printfln("Session of %s is stopped.", s.WorkDir)
+			return nil
+		},
+	}
+	stopCmd.Flags().BoolVar(&discard, "discard", false, "Don't copy back the changes made in the session")
+
+	cmd.AddCommand(startCmd, stopCmd)
+	rootCmd.AddCommand(cmd)
+	return nil
+}
+
+func (p *Plugin) sessionManager() (*action.SessionManager, driver.ContainerRunner, error) {
+	d, err := driver.NewDockerDriverWithOptions(action.LaunchrConfigRuntime(p.cfg).Docker)
+	if err != nil {
+		return nil, nil, err
+	}
+	return action.NewSessionManager(d), d, nil
+}
@@ -0,0 +1,186 @@
+// Package state implements a launchr plugin to inspect and clean the state kept in the config directory.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+const sourceCore = "core"
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing commands to inspect and clean the state of the app.
+type Plugin struct {
+	cfg launchr.Config
+	pm  launchr.PluginManager
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	app.GetService(&p.cfg)
+	app.GetService(&p.pm)
+	return nil
+}
+
+type sourcedEntry struct {
+	source string
+	launchr.StateEntry
+}
+
+// entries returns the state of the app and the plugins.
+func (p *Plugin) entries() []sourcedEntry {
+	var res []sourcedEntry
+	for _, e := range action.State() {
+		res = append(res, sourcedEntry{sourceCore, e})
+	}
+	for _, pl := range launchr.GetPluginByType[launchr.StatePlugin](p.pm) {
+		for _, e := range pl.V.State() {
+			res = append(res, sourcedEntry{pl.K.String(), e})
+		}
+	}
+	return res
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add the state commands.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	cmd := &launchr.Command{
+		Use:   "state",
+		Short: "Inspect and clean the state kept in the config directory",
+		Long: `Inspect and clean the state kept in the config directory, e.g. run records and the history of actions.
+The state files are versioned, the files of older versions are migrated when they are read,
+the files written by a newer version of the app are not read.`,
+	}
+	infoCmd := &launchr.Command{
+		Use:   "info",
+		Short: "Show the state kept in the config directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			launchr.Term().Printfln("State directory: %s", p.cfg.DirPath())
+			data := pterm.TableData{{"Path", "Source", "Format", "Files", "Size", "Description"}}
+			for _, e := range p.entries() {
+				info, err := entryInfo(p.cfg.Path(filepath.FromSlash(e.Path)), e.Format)
+				if err != nil {
+					return fmt.Errorf("failed to read state %q: %w", e.Path, err)
+				}
+				format := "cache"
+				if e.Format != nil {
+					format = "v" + strconv.Itoa(e.Format.Version)
+				}
+				files := strconv.Itoa(info.files)
+				if info.newer > 0 {
+					files += fmt.Sprintf(" (%d newer)", info.newer)
+				}
+				data = append(data, []string{e.Path, e.source, format, files, units.HumanSize(float64(info.size)), e.Description})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+
+	var all bool
+	cleanCmd := &launchr.Command{
+		Use:   "clean [path]...",
+		Short: "Remove the state kept in the config directory",
+		Long: `Remove the state kept in the config directory.
+Give the paths shown in "state info" or use --all to remove all state.`,
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if len(args) == 0 && !all {
+				return errors.New("nothing to clean, give the paths of the state or use --all")
+			}
+			var remove []sourcedEntry
+			for _, e := range p.entries() {
+				if all || isArg(args, e.Path) {
+					remove = append(remove, e)
+				}
+			}
+			if len(remove) < len(args) {
+				return fmt.Errorf("unknown state in %s, see \"state info\"", strings.Join(args, ", "))
+			}
+			for _, e := range remove {
+				if err := os.RemoveAll(p.cfg.Path(filepath.FromSlash(e.Path))); err != nil {
+					return fmt.Errorf("failed to remove state %q: %w", e.Path, err)
+				}
+				launchr.Term().Printfln("Removed %s", e.Path)
+			}
+			launchr.Term().Success().Printfln("Removed %d state entries.", len(remove))
+			return nil
+		},
+	}
+	cleanCmd.Flags().BoolVar(&all, "all", false, "Remove all state")
+
+	cmd.AddCommand(infoCmd, cleanCmd)
+	rootCmd.AddCommand(cmd)
+	return nil
+}
+
+func isArg(args []string, path string) bool {
+	for _, a := range args {
+		if strings.TrimSuffix(a, "/") == path {
+			return true
+		}
+	}
+	return false
+}
+
+type stateInfo struct {
+	files int
+	newer int
+	size  int64
+}
+
+// entryInfo returns a number of files and a size of the state file or directory fpath.
+// The files of a newer version of format f are counted separately.
+func entryInfo(fpath string, f *launchr.StateFormat) (stateInfo, error) {
+	var info stateInfo
+	err := filepath.WalkDir(fpath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		info.files++
+		info.size += fi.Size()
+		// Only the top files are the state, the directories may keep other data, e.g. artifacts.
+		if f != nil && strings.HasSuffix(path, ".yaml") && (path == fpath || filepath.Dir(path) == fpath) {
+			content, err := os.ReadFile(path) //nolint:gosec
+			if err != nil {
+				return err
+			}
+			var v any
+			if err = f.Decode(content, &v); errors.Is(err, launchr.ErrStateVersion) {
+				info.newer++
+			}
+		}
+		return nil
+	})
+	return info, err
+}
@@ -3,6 +3,7 @@ package verbosity
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
 	"github.com/launchrctl/launchr/internal/launchr"
@@ -22,6 +23,15 @@ func (p Plugin) PluginInfo() launchr.PluginInfo {
 	}
 }
 
+// ConfigLogKey is a field name in [launchr.Config] file for log configuration.
+const ConfigLogKey = "log"
+
+// ConfigLog is a container to parse log configuration in [launchr.Config].
+type ConfigLog struct {
+	// Drains are additional destinations of the app logs, e.g. a file, syslog or Loki.
+	Drains []launchr.LogDrainOptions `yaml:"drains"`
+}
+
 // LogFormat is a enum type for log output format.
 type LogFormat string
 
@@ -84,16 +94,18 @@ func (p Plugin) OnAppInit(app launchr.App) error {
 		panic(err)
 	}
 	pflags.ParseErrorsWhitelist.UnknownFlags = unkFlagsBkp
-	launchr.Term().EnableOutput()
+	sm := appInternal.ServiceManager()
+	term := sm.Term()
+	term.EnableOutput()
 	if quiet {
-		launchr.Term().DisableOutput()
+		term.DisableOutput()
 		app.SetStreams(launchr.NoopStreams())
 	}
 
 	streams := app.Streams()
 	out := streams.Out()
 	// Set terminal output.
-	launchr.Term().SetOutput(out)
+	term.SetOutput(out)
 	// Enable logger.
 	if verbosity > 0 {
 		var logger *launchr.Logger
@@ -105,14 +117,41 @@ func (p Plugin) OnAppInit(app launchr.App) error {
 		default:
 			logger = launchr.NewConsoleLogger(out)
 		}
-		launchr.SetLogger(logger)
+		sm.SetLogger(logger)
+	}
+	sm.Log().SetLevel(logLevelFlagInt(verbosity))
+	drains, err := logDrains(app)
+	if err != nil {
+		return err
 	}
-	launchr.Log().SetLevel(logLevelFlagInt(verbosity))
+	sm.SetLogger(sm.Log().WithDrains(drains...))
 	cmd.SetOut(out)
 	cmd.SetErr(streams.Err())
 	return nil
 }
 
+// logDrains creates the log drains defined in the config.
+func logDrains(app launchr.App) ([]*launchr.LogDrain, error) {
+	var cfg launchr.Config
+	app.GetService(&cfg)
+	var lcfg ConfigLog
+	if err := cfg.Get(ConfigLogKey, &lcfg); err != nil {
+		return nil, fmt.Errorf("failed to parse the log configuration: %w", err)
+	}
+	drains := make([]*launchr.LogDrain, 0, len(lcfg.Drains))
+	for _, opts := range lcfg.Drains {
+		d, err := launchr.NewLogDrain(opts)
+		if err != nil {
+			for _, d := range drains {
+				_ = d.Close()
+			}
+			return nil, fmt.Errorf("failed to create the %s log drain: %w", opts.Type, err)
+		}
+		drains = append(drains, d)
+	}
+	return drains, nil
+}
+
 func logLevelFlagInt(v int) launchr.LogLevel {
 	switch v {
 	case 0:
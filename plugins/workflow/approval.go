@@ -0,0 +1,218 @@
+package workflow
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// defaultApprovalTimeout limits waiting for an approval if not set in the step.
+const defaultApprovalTimeout = time.Hour
+
+// approvalPollInterval is a period of checking approvals given with "workflow approve".
+const approvalPollInterval = time.Second
+
+// approvalsDirName is a directory in the run staging area with the approvals given with "workflow approve".
+// Step ids can't start with a dot, so it doesn't clash with the staged artifacts.
+const approvalsDirName = ".approvals"
+
+// Decisions of an approval.
+const (
+	approvalApproved = "approved"
+	approvalRejected = "rejected"
+)
+
+// Ways an approval is given.
+const (
+	approvalViaTerminal = "terminal"
+	approvalViaCommand  = "command"
+	approvalViaToken    = "token"
+)
+
+// stepApproval pauses a workflow until a user approves the continuation.
+type stepApproval struct {
+	// Message is shown to the approver.
+	Message string `yaml:"message" json:"message,omitempty"`
+	// Timeout limits waiting for the approval, the step fails when it elapses.
+	Timeout time.Duration `yaml:"timeout" json:"timeout,omitempty"`
+	// SecretEnv is an environment variable with a secret to sign approval tokens.
+	// If set, an approval given with "workflow approve" must have a valid token.
+	SecretEnv string `yaml:"secret_env" json:"secret_env,omitempty"`
+}
+
+func (a *stepApproval) timeout() time.Duration {
+	if a.Timeout == 0 {
+		return defaultApprovalTimeout
+	}
+	return a.Timeout
+}
+
+// approvalRecord is an audit record of an approval decision.
+type approvalRecord struct {
+	Decision string    `yaml:"decision"`
+	By       string    `yaml:"by"`
+	Via      string    `yaml:"via"`
+	At       time.Time `yaml:"at"`
+	Token    string    `yaml:"token,omitempty"`
+}
+
+// approvalToken returns a token signing the decision on step of run with the secret.
+func approvalToken(secret, run, step, decision string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(run + "/" + step + "/" + decision))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// approvalFile returns a path of the approval of step in the run staging area.
+func approvalFile(s *runState, step string) string {
+	return filepath.Join(s.artifactsDir(), approvalsDirName, step+".yaml")
+}
+
+// writeApproval stores the decision on step of the run given with "workflow approve".
+func writeApproval(s *runState, step string, rec approvalRecord) error {
+	content, err := yaml.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	path := approvalFile(s, step)
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// readApproval reads the decision on step of the run, nil is returned if it isn't given yet.
+func readApproval(s *runState, step string) (*approvalRecord, error) {
+	content, err := os.ReadFile(approvalFile(s, step))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec := &approvalRecord{}
+	if err = yaml.Unmarshal(content, rec); err != nil {
+		return nil, fmt.Errorf("failed to parse approval of step %q: %w", step, err)
+	}
+	return rec, nil
+}
+
+// currentUser returns a name of the user giving an approval.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// runApproval waits for the decision on the approval step.
+// The decision is given in the terminal if the input is interactive or with "workflow approve".
+func (r *runner) runApproval(ctx context.Context, s *workflowStep) *stepResult {
+	ctx, cancel := context.WithTimeout(ctx, s.Approval.timeout())
+	defer cancel()
+	term := launchr.TermFromContext(ctx)
+	msg := s.Approval.Message
+	if msg == "" {
+		msg = fmt.Sprintf("Approve step %q to continue the workflow?", s.ID)
+	}
+	term.Info().Printfln("Step %q is waiting for an approval: %s", s.ID, msg)
+	if r.state != nil {
+		// The decision of the previous attempt isn't reused when the run is resumed.
+		if err := os.Remove(approvalFile(r.state, s.ID)); err != nil && !os.IsNotExist(err) {
+			return &stepResult{Status: stepStatusFailure, err: err}
+		}
+		term.Info().Printfln("Approve it with: workflow approve %s %s", r.state.ID, s.ID)
+	}
+
+	answer := make(chan approvalRecord, 1)
+	if r.streams.In().IsTerminal() {
+		go r.promptApproval(ctx, answer)
+	}
+	ticker := time.NewTicker(r.approvalPoll)
+	defer ticker.Stop()
+	var rec approvalRecord
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return &stepResult{Status: stepStatusFailure, err: fmt.Errorf("approval timed out after %s", s.Approval.timeout())}
+			}
+			return &stepResult{Status: stepStatusFailure, err: ctx.Err()}
+		case rec = <-answer:
+			break wait
+		case <-ticker.C:
+			given, err := r.givenApproval(s)
+			if err != nil {
+				return &stepResult{Status: stepStatusFailure, err: err}
+			}
+			if given != nil {
+				rec = *given
+				break wait
+			}
+		}
+	}
+	launchr.Log().Info("workflow step approval", "step", s.ID, "decision", rec.Decision, "by", rec.By, "via", rec.Via)
+	res := &stepResult{Status: stepStatusSuccess, Output: rec.Decision + " by " + rec.By, Approval: &rec}
+	if rec.Decision != approvalApproved {
+		res.Status = stepStatusFailure
+		res.err = fmt.Errorf("rejected by %s", rec.By)
+	}
+	return res
+}
+
+// promptApproval asks the user in the terminal.
+func (r *runner) promptApproval(ctx context.Context, answer chan<- approvalRecord) {
+	_, _ = fmt.Fprint(r.streams.Out(), "Approve? [y/N]: ")
+	line, err := bufio.NewReader(r.streams.In()).ReadString('\n')
+	if ctx.Err() != nil || (err != nil && line == "") {
+		return
+	}
+	decision := approvalRejected
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		decision = approvalApproved
+	}
+	answer <- approvalRecord{Decision: decision, By: currentUser(), Via: approvalViaTerminal, At: time.Now().UTC()}
+}
+
+// givenApproval returns the decision given with "workflow approve" and verifies its token.
+func (r *runner) givenApproval(s *workflowStep) (*approvalRecord, error) {
+	if r.state == nil {
+		return nil, nil
+	}
+	rec, err := readApproval(r.state, s.ID)
+	if err != nil || rec == nil {
+		return nil, err
+	}
+	if s.Approval.SecretEnv == "" {
+		rec.Via = approvalViaCommand
+		return rec, nil
+	}
+	secret := os.Getenv(s.Approval.SecretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("secret of approval tokens %s is not set", s.Approval.SecretEnv)
+	}
+	exp := approvalToken(secret, r.state.ID, s.ID, rec.Decision)
+	if !hmac.Equal([]byte(exp), []byte(rec.Token)) {
+		// Ignore the forged approval and keep waiting for a valid one.
+		launchr.Log().Warn("invalid workflow approval token", "step", s.ID, "by", rec.By)
+		_ = os.Remove(approvalFile(r.state, s.ID))
+		return nil, nil
+	}
+	rec.Via = approvalViaToken
+	return rec, nil
+}
@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+)
+
+// stageArtifacts copies the artifacts produced by step s from the working directory to the staging area.
+func (r *runner) stageArtifacts(s *workflowStep) error {
+	if len(s.Artifacts) == 0 || r.state == nil {
+		return nil
+	}
+	dir := filepath.Join(r.state.artifactsDir(), s.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for _, p := range s.Artifacts {
+		src := filepath.Join(r.workDir, p)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			return fmt.Errorf("artifact %q is not found", p)
+		}
+		if err := copyPath(src, filepath.Join(dir, p)); err != nil {
+			return fmt.Errorf("failed to stage artifact %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// handoffDirName is a directory in the working directory with the artifacts handed off to the steps.
+// The working directory is available in all runtimes: on the host, mounted or copied to a container volume.
+const handoffDirName = ".workflow-artifacts"
+
+// handoffDir returns a directory of the artifacts of step id available to the next steps.
+func (r *runner) handoffDir(id string) string {
+	return filepath.Join(r.workDir, handoffDirName, id)
+}
+
+// handoffArtifacts copies the artifacts of the steps needed by step s from the staging area
+// to the handoff directories. The files produced in the working directory are never overwritten.
+func (r *runner) handoffArtifacts(s *workflowStep) error {
+	if r.state == nil {
+		return nil
+	}
+	for _, n := range s.Needs {
+		res := r.results[n]
+		if len(res.Artifacts) == 0 {
+			continue
+		}
+		dir := r.handoffDir(n)
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		for _, p := range res.Artifacts {
+			src := filepath.Join(r.state.artifactsDir(), n, p)
+			if err := copyPath(src, filepath.Join(dir, p)); err != nil {
+				return fmt.Errorf("failed to hand off artifact %q of step %q: %w", p, n, err)
+			}
+		}
+	}
+	return nil
+}
+
+// removeHandoff removes the handed off artifacts after the run, they are kept in the staging area.
+func (r *runner) removeHandoff() {
+	if r.state == nil {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(r.workDir, handoffDirName)); err != nil {
+		launchr.Log().Warn("failed to remove workflow artifacts", "run", r.state.ID, "error", err)
+	}
+}
+
+// copyPath copies a file or a directory recursively, existing files are overwritten.
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0750)
+		}
+		if !d.Type().IsRegular() {
+			// Symlinks and special files are not copied.
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	in, err := os.Open(src) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm()) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
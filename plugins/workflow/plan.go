@@ -0,0 +1,221 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// planVersion is a version of the plan file format.
+const planVersion = 1
+
+// runPlan is a resolved workflow run stored to review it and to apply it later.
+// The plan is applied only if the actions of the steps are not changed since it was created.
+type runPlan struct {
+	Version     int               `json:"version"`
+	Workflow    string            `json:"workflow"`
+	Title       string            `json:"title,omitempty"`
+	Created     time.Time         `json:"created"`
+	Inputs      map[string]string `json:"inputs,omitempty"`
+	GracePeriod string            `json:"grace_period"`
+	Steps       []planStep        `json:"steps"`
+	OnFailure   []planStep        `json:"on_failure,omitempty"`
+	Always      []planStep        `json:"always,omitempty"`
+}
+
+// planStep is a step of the plan with the resolved action.
+type planStep struct {
+	ID        string         `json:"id"`
+	Action    string         `json:"action,omitempty"`
+	Image     string         `json:"image,omitempty"`
+	ActionSum string         `json:"action_sum,omitempty"`
+	Args      []string       `json:"args,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+	Needs     []string       `json:"needs,omitempty"`
+	If        string         `json:"if,omitempty"`
+	SkipIf    string         `json:"skip_if,omitempty"`
+	OnFailure string         `json:"on_failure"`
+	Artifacts []string       `json:"artifacts,omitempty"`
+	Approval  *stepApproval  `json:"approval,omitempty"`
+}
+
+// newRunPlan resolves the actions of workflow w and creates a plan of its run with the inputs.
+// The steps are stored in the execution order.
+func newRunPlan(am action.Manager, w *workflow, inputs map[string]string) (*runPlan, error) {
+	plan, err := w.plan()
+	if err != nil {
+		return nil, err
+	}
+	p := &runPlan{
+		Version:     planVersion,
+		Workflow:    w.Name,
+		Title:       w.Title,
+		Created:     time.Now().UTC(),
+		Inputs:      inputs,
+		GracePeriod: w.gracePeriod().String(),
+	}
+	for _, list := range []struct {
+		steps []*workflowStep
+		res   *[]planStep
+	}{{plan, &p.Steps}, {w.OnFailure, &p.OnFailure}, {w.Always, &p.Always}} {
+		for _, s := range list.steps {
+			ps, err := newPlanStep(am, s)
+			if err != nil {
+				return nil, err
+			}
+			*list.res = append(*list.res, ps)
+		}
+	}
+	return p, nil
+}
+
+func newPlanStep(am action.Manager, s *workflowStep) (planStep, error) {
+	if s.Approval != nil {
+		return planStep{ID: s.ID, Needs: s.Needs, If: s.If, SkipIf: s.SkipIf, OnFailure: s.OnFailure, Approval: s.Approval}, nil
+	}
+	a, ok := am.Get(am.GetIDFromAlias(s.Action))
+	if !ok {
+		return planStep{}, fmt.Errorf("action %q of step %q is not found", s.Action, s.ID)
+	}
+	sum, err := actionSum(a)
+	if err != nil {
+		return planStep{}, err
+	}
+	ps := planStep{
+		ID:        s.ID,
+		Action:    a.ID,
+		ActionSum: sum,
+		Args:      s.Args,
+		Options:   s.Options,
+		Needs:     s.Needs,
+		If:        s.If,
+		SkipIf:    s.SkipIf,
+		OnFailure: s.OnFailure,
+		Artifacts: s.Artifacts,
+	}
+	if def, err := a.Raw(); err == nil && def.Runtime != nil && def.Runtime.Container != nil {
+		ps.Image = def.Runtime.Container.Image
+	}
+	return ps, nil
+}
+
+// actionSum returns a checksum of the action definition.
+func actionSum(a *action.Action) (string, error) {
+	content, err := a.DefinitionEncoded()
+	if err != nil {
+		return "", fmt.Errorf("failed to read action %q: %w", a.ID, err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeRunPlan writes the plan to the file.
+func writeRunPlan(path string, p *runPlan) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0600)
+}
+
+// readRunPlan reads the plan from the file.
+func readRunPlan(path string) (*runPlan, error) {
+	content, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	p := &runPlan{}
+	if err = json.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	if p.Version != planVersion {
+		return nil, fmt.Errorf("plan file version %d is not supported, expected %d", p.Version, planVersion)
+	}
+	for _, steps := range [][]planStep{p.Steps, p.OnFailure, p.Always} {
+		for i := range steps {
+			if steps[i].Options, err = planOptions(steps[i].Options); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// planOptions restores the types of the options as in the workflow file,
+// JSON decodes all numbers as floats, and integer options don't accept them.
+func planOptions(opts map[string]any) (map[string]any, error) {
+	if len(opts) == 0 {
+		return opts, nil
+	}
+	content, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	var res map[string]any
+	// JSON is a subset of YAML.
+	if err = yaml.Unmarshal(content, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// workflow verifies the actions of the plan are not changed and returns the planned workflow.
+func (p *runPlan) workflow(am action.Manager) (*workflow, error) {
+	grace, err := time.ParseDuration(p.GracePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("grace period of the plan is not valid: %w", err)
+	}
+	w := &workflow{Name: p.Workflow, Title: p.Title, GracePeriod: grace}
+	for _, list := range []struct {
+		steps []planStep
+		res   *[]*workflowStep
+	}{{p.Steps, &w.Steps}, {p.OnFailure, &w.OnFailure}, {p.Always, &w.Always}} {
+		for _, ps := range list.steps {
+			if err = ps.verify(am); err != nil {
+				return nil, err
+			}
+			*list.res = append(*list.res, &workflowStep{
+				ID:        ps.ID,
+				Action:    ps.Action,
+				Args:      ps.Args,
+				Options:   ps.Options,
+				Needs:     ps.Needs,
+				If:        ps.If,
+				SkipIf:    ps.SkipIf,
+				OnFailure: ps.OnFailure,
+				Artifacts: ps.Artifacts,
+				Approval:  ps.Approval,
+			})
+		}
+	}
+	if err = w.validate(); err != nil {
+		return nil, fmt.Errorf("plan of workflow %q is not valid: %w", p.Workflow, err)
+	}
+	return w, nil
+}
+
+// verify checks the action of the step is the same as at the time of planning.
+func (ps planStep) verify(am action.Manager) error {
+	if ps.Approval != nil {
+		return nil
+	}
+	a, ok := am.Get(ps.Action)
+	if !ok {
+		return fmt.Errorf("action %q of step %q is not found", ps.Action, ps.ID)
+	}
+	sum, err := actionSum(a)
+	if err != nil {
+		return err
+	}
+	if sum != ps.ActionSum {
+		return fmt.Errorf("action %q of step %q is changed since the plan was created, create a new plan", ps.Action, ps.ID)
+	}
+	return nil
+}
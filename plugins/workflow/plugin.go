@@ -0,0 +1,290 @@
+// Package workflow implements a launchr plugin to run workflows of actions.
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func init() {
+	launchr.RegisterPlugin(&Plugin{})
+}
+
+// Plugin is a [launchr.Plugin] providing commands to run workflows.
+type Plugin struct {
+	app launchr.App
+	am  action.Manager
+	cfg launchr.Config
+}
+
+// PluginInfo implements [launchr.Plugin] interface.
+func (p *Plugin) PluginInfo() launchr.PluginInfo {
+	return launchr.PluginInfo{}
+}
+
+// OnAppInit implements [launchr.OnAppInitPlugin] interface.
+func (p *Plugin) OnAppInit(app launchr.App) error {
+	p.app = app
+	app.GetService(&p.am)
+	app.GetService(&p.cfg)
+	return nil
+}
+
+// State implements [launchr.StatePlugin] interface.
+func (p *Plugin) State() []launchr.StateEntry {
+	return []launchr.StateEntry{
+		{Path: runsDir, Description: "States and staged artifacts of workflow runs", Format: runStateFormat},
+	}
+}
+
+// CobraAddCommands implements [launchr.CobraPlugin] interface to add workflow commands.
+func (p *Plugin) CobraAddCommands(rootCmd *launchr.Command) error {
+	var file string
+	cmd := &launchr.Command{
+		Use:   "workflow",
+		Short: "Run workflows of actions",
+		Long: `Run workflows of actions declared in ` + workflowFileName + `.
+A workflow is a graph of steps running existing actions with the given input.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&file, "file", "f", workflowFileName, "Workflow file")
+	cmd.AddCommand(p.runCommand(&file))
+	cmd.AddCommand(p.applyCommand())
+	cmd.AddCommand(p.approveCommand())
+	cmd.AddCommand(p.listCommand(&file))
+	rootCmd.AddCommand(cmd)
+	return nil
+}
+
+func (p *Plugin) runCommand(file *string) *launchr.Command {
+	var graph bool
+	var resume, planFile string
+	var inputs map[string]string
+	cmd := &launchr.Command{
+		Use:   "run name",
+		Short: "Run a workflow",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			w, err := findWorkflow(*file, args[0])
+			if err != nil {
+				return err
+			}
+			if graph {
+				return printPlan(cmd, w)
+			}
+			if planFile != "" {
+				return p.writePlan(cmd, w, inputs, planFile)
+			}
+			r := p.newRunner()
+			if resume != "" {
+				r.state, err = loadRunState(p.cfg.Path(runsDir), resume, w)
+				if err != nil {
+					return err
+				}
+			} else {
+				r.state = p.newRunState(w)
+			}
+			// Inputs of the resumed run are kept unless new ones are given.
+			if len(inputs) > 0 || resume == "" {
+				r.state.Inputs = inputs
+			}
+			r.inputs = r.state.Inputs
+			err = r.runInterruptible(cmd.Context(), w)
+			printResults(cmd, w, r)
+			if err != nil {
+				launchr.Term().Info().Printfln("Resume the workflow with --resume %s", r.state.ID)
+			}
+			return err
+		},
+		ValidArgsFunction: func(_ *launchr.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			wfs, err := readWorkflowFile(*file)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return sortedWorkflowNames(wfs), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVar(&graph, "graph", false, "Print the execution plan without running it")
+	cmd.Flags().StringVar(&planFile, "plan", "", "Write the resolved run to a plan file without running it, apply it with \"workflow apply\"")
+	cmd.Flags().StringToStringVar(&inputs, "input", nil, "Input values available in templates as .inputs.NAME")
+	cmd.Flags().StringVar(&resume, "resume", "", "Resume a failed run by id, successful steps are not run again")
+	return cmd
+}
+
+func (p *Plugin) applyCommand() *launchr.Command {
+	return &launchr.Command{
+		Use:   "apply plan.json",
+		Short: "Run a workflow exactly as planned with \"workflow run --plan\"",
+		Long: `Run a workflow exactly as planned with "workflow run --plan".
+The workflow file is not read, the steps and the inputs are taken from the plan.
+The run fails if an action of the plan is changed since the plan was created.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			plan, err := readRunPlan(args[0])
+			if err != nil {
+				return err
+			}
+			w, err := plan.workflow(p.am)
+			if err != nil {
+				return err
+			}
+			r := p.newRunner()
+			r.state = p.newRunState(w)
+			r.state.Inputs = plan.Inputs
+			r.inputs = plan.Inputs
+			err = r.runInterruptible(cmd.Context(), w)
+			printResults(cmd, w, r)
+			return err
+		},
+	}
+}
+
+func (p *Plugin) approveCommand() *launchr.Command {
+	var reject bool
+	var token, by string
+	cmd := &launchr.Command{
+		Use:   "approve run_id step",
+		Short: "Approve a step of a running workflow waiting for an approval",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *launchr.Command, args []string) error {
+			cmd.SilenceUsage = true
+			runID, step := args[0], args[1]
+			// The id of a run is a timestamp and the workflow name.
+			_, name, _ := strings.Cut(runID, "-")
+			state, err := loadRunState(p.cfg.Path(runsDir), runID, &workflow{Name: name})
+			if err != nil {
+				return err
+			}
+			rec := approvalRecord{Decision: approvalApproved, By: by, Via: approvalViaCommand, At: time.Now().UTC(), Token: token}
+			if reject {
+				rec.Decision = approvalRejected
+			}
+			if rec.By == "" {
+				rec.By = currentUser()
+			}
+			if err = writeApproval(state, step, rec); err != nil {
+				return err
+			}
+			launchr.Term().Success().Printfln("Step %q of run %s is %s", step, runID, rec.Decision)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&reject, "reject", false, "Reject the step, the workflow fails")
+	cmd.Flags().StringVar(&token, "token", "", "Token signing the decision if the step requires it")
+	cmd.Flags().StringVar(&by, "by", "", "Name of the approver, the current user by default")
+	return cmd
+}
+
+// newRunner creates a runner of workflows configured with the app config.
+func (p *Plugin) newRunner() *runner {
+	rcfg := action.LaunchrConfigRuntime(p.cfg)
+	r := newRunner(p.am, p.app.Streams())
+	r.outputLimit = rcfg.OutputLimit
+	r.recordSensitive = rcfg.RecordSensitive
+	return r
+}
+
+// newRunState creates a state of a new run of the workflow, the states of old runs are pruned.
+func (p *Plugin) newRunState(w *workflow) *runState {
+	if err := pruneRunStates(p.cfg.Path(runsDir), runsRetention-1); err != nil {
+		launchr.Log().Warn("failed to prune workflow runs", "error", err)
+	}
+	return newRunState(p.cfg.Path(runsDir), w)
+}
+
+// writePlan resolves the run of the workflow and writes it to the plan file.
+func (p *Plugin) writePlan(cmd *launchr.Command, w *workflow, inputs map[string]string, path string) error {
+	plan, err := newRunPlan(p.am, w, inputs)
+	if err != nil {
+		return err
+	}
+	if err = writeRunPlan(path, plan); err != nil {
+		return err
+	}
+	if err = printPlan(cmd, w); err != nil {
+		return err
+	}
+	launchr.Term().Success().Printfln("Plan is written to %s, run it with: workflow apply %s", path, path)
+	return nil
+}
+
+func (p *Plugin) listCommand(file *string) *launchr.Command {
+	return &launchr.Command{
+		Use:   "list",
+		Short: "List available workflows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *launchr.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			wfs, err := readWorkflowFile(*file)
+			if err != nil {
+				return err
+			}
+			data := pterm.TableData{{"Name", "Title", "Steps"}}
+			for _, name := range sortedWorkflowNames(wfs) {
+				w := wfs[name]
+				data = append(data, []string{name, w.Title, strconv.Itoa(len(w.Steps))})
+			}
+			return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+		},
+	}
+}
+
+func findWorkflow(file, name string) (*workflow, error) {
+	wfs, err := readWorkflowFile(file)
+	if err != nil {
+		return nil, err
+	}
+	w, ok := wfs[name]
+	if !ok {
+		return nil, fmt.Errorf("workflow %q is not found in %q", name, file)
+	}
+	return w, nil
+}
+
+// printPlan prints the steps in the execution order with the depth in the dependency graph.
+func printPlan(cmd *launchr.Command, w *workflow) error {
+	plan, err := w.plan()
+	if err != nil {
+		return err
+	}
+	levels := w.levels(plan)
+	data := pterm.TableData{{"Step", "Action", "Needs", "Condition", "Skip if", "On failure"}}
+	for _, s := range plan {
+		step := strings.Repeat("  ", levels[s.ID]) + s.ID
+		data = append(data, []string{step, s.actionName(), strings.Join(s.Needs, ", "), s.If, s.SkipIf, s.OnFailure})
+	}
+	// Cleanup steps run after the steps, the stage is shown as the needs.
+	for _, s := range w.OnFailure {
+		data = append(data, []string{s.ID, s.Action, "workflow failure", s.If, s.SkipIf, onFailureContinue})
+	}
+	for _, s := range w.Always {
+		data = append(data, []string{s.ID, s.Action, "always", s.If, s.SkipIf, onFailureContinue})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+}
+
+func printResults(cmd *launchr.Command, w *workflow, r *runner) {
+	data := pterm.TableData{{"Step", "Action", "Status"}}
+	for _, s := range slices.Concat(w.Steps, w.cleanupSteps()) {
+		status := stepStatusSkipped
+		if res, ok := r.results[s.ID]; ok {
+			status = res.Status
+		}
+		data = append(data, []string{s.ID, s.actionName(), status})
+	}
+	_ = pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(cmd.OutOrStdout()).Render()
+}
@@ -0,0 +1,330 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// Statuses of a workflow step.
+const (
+	stepStatusSuccess = "success"
+	stepStatusFailure = "failure"
+	stepStatusSkipped = "skipped"
+)
+
+// stepResult is a result of a step available to conditions of the next steps.
+type stepResult struct {
+	Status    string   `yaml:"status"`
+	Output    string   `yaml:"output"`
+	Artifacts []string `yaml:"artifacts,omitempty"`
+	// Approval is an audit record of the decision on an approval step.
+	Approval *approvalRecord `yaml:"approval,omitempty"`
+	err      error
+}
+
+// runner executes workflow steps with the actions of the manager.
+type runner struct {
+	am      action.Manager
+	streams launchr.Streams
+	workDir string
+	results map[string]*stepResult
+	// inputs are values of the run available in templates.
+	inputs map[string]string
+	// state persists the results to resume the run, successful steps of the state are not run again.
+	state *runState
+	// outputLimit limits the output of steps kept in results and in the state.
+	outputLimit action.ConfigOutputLimit
+	// sensitive are values of sensitive parameters of the run steps, they are masked in the state.
+	sensitive []string
+	// recordSensitive keeps the sensitive values in the state.
+	recordSensitive bool
+	// approvalPoll is a period of checking approvals of approval steps.
+	approvalPoll time.Duration
+}
+
+func newRunner(am action.Manager, streams launchr.Streams) *runner {
+	return &runner{
+		am:           am,
+		streams:      streams,
+		workDir:      ".",
+		results:      make(map[string]*stepResult),
+		outputLimit:  action.DefaultConfigRuntime().OutputLimit,
+		approvalPoll: approvalPollInterval,
+	}
+}
+
+// tplData returns template data of conditions and step input.
+func (r *runner) tplData() map[string]any {
+	steps := make(map[string]any, len(r.results))
+	for id, res := range r.results {
+		step := map[string]any{"status": res.Status, "output": res.Output, "artifacts": res.Artifacts}
+		if len(res.Artifacts) > 0 {
+			// The path is relative to the working directory, it's the same in all runtimes.
+			step["artifacts_dir"] = filepath.ToSlash(filepath.Join(handoffDirName, id))
+		}
+		steps[id] = step
+	}
+	inputs := make(map[string]any, len(r.inputs))
+	for k, v := range r.inputs {
+		inputs[k] = v
+	}
+	return map[string]any{"steps": steps, "inputs": inputs}
+}
+
+// run executes the workflow, the steps failed or skipped after abort are reported in the results.
+func (r *runner) run(ctx context.Context, w *workflow) error {
+	plan, err := w.plan()
+	if err != nil {
+		return err
+	}
+	defer r.removeHandoff()
+	var prev map[string]*stepResult
+	if r.state != nil {
+		prev = r.state.Steps
+		r.state.Steps = r.results
+	}
+	aborted := false
+	var failed []string
+	for _, s := range plan {
+		if aborted || ctx.Err() != nil {
+			r.results[s.ID] = &stepResult{Status: stepStatusSkipped}
+			continue
+		}
+		if res, ok := prev[s.ID]; ok && res.Status == stepStatusSuccess {
+			launchr.TermFromContext(ctx).Info().Printfln("Step %q succeeded in the previous run, reusing the result", s.ID)
+			r.results[s.ID] = res
+			continue
+		}
+		res := r.runStep(ctx, s)
+		r.results[s.ID] = res
+		r.saveState()
+		if res.Status != stepStatusFailure {
+			continue
+		}
+		failed = append(failed, s.ID)
+		launchr.TermFromContext(ctx).Error().Printfln("Step %q failed: %v", s.ID, res.err)
+		if s.OnFailure != onFailureContinue {
+			aborted = true
+		}
+	}
+	failed = append(failed, r.runCleanup(ctx, w, len(failed) > 0 || ctx.Err() != nil)...)
+	r.saveState()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("workflow %q failed, failed steps: %s", w.Name, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runSignals are signals cancelling the run, the cleanup steps still run after them.
+var runSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// runInterruptible executes the workflow until it's cancelled with one of runSignals.
+func (r *runner) runInterruptible(ctx context.Context, w *workflow) error {
+	ctx, stop := signal.NotifyContext(ctx, runSignals...)
+	defer stop()
+	return r.run(ctx, w)
+}
+
+// runCleanup runs the cleanup steps in the declaration order and returns the failed ones.
+// The steps run even if the workflow is cancelled, but they are limited by the grace period.
+func (r *runner) runCleanup(ctx context.Context, w *workflow, failed bool) []string {
+	steps := w.Always
+	for _, s := range w.OnFailure {
+		if !failed {
+			r.results[s.ID] = &stepResult{Status: stepStatusSkipped}
+		}
+	}
+	if failed {
+		steps = w.cleanupSteps()
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.gracePeriod())
+	defer cancel()
+	var res []string
+	for _, s := range steps {
+		if ctx.Err() != nil {
+			launchr.TermFromContext(ctx).Warning().Printfln("Step %q is skipped, the grace period %s is exceeded", s.ID, w.gracePeriod())
+			r.results[s.ID] = &stepResult{Status: stepStatusSkipped}
+			continue
+		}
+		stepRes := r.runStep(ctx, s)
+		r.results[s.ID] = stepRes
+		r.saveState()
+		if stepRes.Status == stepStatusFailure {
+			res = append(res, s.ID)
+			launchr.TermFromContext(ctx).Error().Printfln("Step %q failed: %v", s.ID, stepRes.err)
+		}
+	}
+	return res
+}
+
+// saveState persists the results, a failure doesn't stop the workflow.
+func (r *runner) saveState() {
+	if r.state == nil {
+		return
+	}
+	var mask *action.SensitiveMask
+	if !r.recordSensitive {
+		mask = action.NewSensitiveMask(r.sensitive...)
+	}
+	if err := r.state.save(mask); err != nil {
+		launchr.Log().Warn("failed to save workflow run state", "run", r.state.ID, "error", err)
+	}
+}
+
+func (r *runner) runStep(ctx context.Context, s *workflowStep) *stepResult {
+	ok, err := r.shouldRun(s)
+	if err != nil {
+		return &stepResult{Status: stepStatusFailure, err: err}
+	}
+	if !ok {
+		launchr.TermFromContext(ctx).Info().Printfln("Step %q is skipped", s.ID)
+		return &stepResult{Status: stepStatusSkipped}
+	}
+	skip, err := r.shouldSkip(s)
+	if err != nil {
+		return &stepResult{Status: stepStatusFailure, err: err}
+	}
+	if skip {
+		launchr.TermFromContext(ctx).Info().Printfln("Step %q is skipped by the skip condition", s.ID)
+		return &stepResult{Status: stepStatusSkipped}
+	}
+	if s.Approval != nil {
+		return r.runApproval(ctx, s)
+	}
+	if err = r.handoffArtifacts(s); err != nil {
+		return &stepResult{Status: stepStatusFailure, err: err}
+	}
+	launchr.TermFromContext(ctx).Info().Printfln("Step %q: running action %q", s.ID, s.Action)
+	out := r.outputLimit.NewBuffer()
+	err = r.runAction(ctx, s, outputStreams{
+		Streams: r.streams,
+		out:     launchr.NewOut(io.MultiWriter(r.streams.Out(), out)),
+	})
+	if err == nil {
+		err = r.stageArtifacts(s)
+	}
+	res := &stepResult{Status: stepStatusSuccess, Output: strings.TrimSpace(out.String()), Artifacts: s.Artifacts, err: err}
+	if err != nil {
+		res.Status = stepStatusFailure
+		res.Artifacts = nil
+	}
+	return res
+}
+
+// shouldRun checks the step condition. Without a condition, the step runs if all needed steps succeeded.
+func (r *runner) shouldRun(s *workflowStep) (bool, error) {
+	if s.cond == nil {
+		for _, n := range s.Needs {
+			if r.results[n].Status != stepStatusSuccess {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	res, err := r.render(s.cond)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition: %w", err)
+	}
+	return strings.TrimSpace(res) == "true", nil
+}
+
+// shouldSkip checks the skip condition of the step.
+func (r *runner) shouldSkip(s *workflowStep) (bool, error) {
+	if s.skip == nil {
+		return false, nil
+	}
+	res, err := r.render(s.skip)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate skip condition: %w", err)
+	}
+	return strings.TrimSpace(res) == "true", nil
+}
+
+func (r *runner) render(tpl *template.Template) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, r.tplData()); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderValue renders templates in string values of the step input.
+func (r *runner) renderValue(s *workflowStep, v string) (string, error) {
+	if !strings.Contains(v, "{{") {
+		return v, nil
+	}
+	tpl, err := template.New(s.ID).Option("missingkey=zero").Parse(v)
+	if err != nil {
+		return "", err
+	}
+	return r.render(tpl)
+}
+
+func (r *runner) runAction(ctx context.Context, s *workflowStep, streams launchr.Streams) error {
+	a, ok := r.am.Get(r.am.GetIDFromAlias(s.Action))
+	if !ok {
+		return fmt.Errorf("action %q is not found", s.Action)
+	}
+	args := make([]string, len(s.Args))
+	for i, v := range s.Args {
+		var err error
+		if args[i], err = r.renderValue(s, v); err != nil {
+			return err
+		}
+	}
+	opts := make(action.InputParams, len(s.Options))
+	for k, v := range s.Options {
+		if str, isStr := v.(string); isStr {
+			var err error
+			if v, err = r.renderValue(s, str); err != nil {
+				return err
+			}
+		}
+		opts[k] = v
+	}
+	argsNamed, err := action.ArgsPosToNamed(a, args)
+	if err != nil {
+		return err
+	}
+	input := action.NewInput(a, argsNamed, opts, streams)
+	if rt, ok := a.Runtime().(action.RuntimeFlags); ok {
+		if err = rt.UseFlags(action.InputParams{}); err != nil {
+			return err
+		}
+		if err = rt.ValidateInput(a, input); err != nil {
+			return err
+		}
+	}
+	if err = a.SetInput(input); err != nil {
+		return err
+	}
+	r.sensitive = append(r.sensitive, a.SensitiveMask().Values()...)
+	_, err = r.am.Run(ctx, a)
+	return err
+}
+
+// outputStreams copies the action output to capture it as the step output.
+type outputStreams struct {
+	launchr.Streams
+	out *launchr.Out
+}
+
+func (s outputStreams) Out() *launchr.Out { return s.out }
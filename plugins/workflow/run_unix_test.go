@@ -0,0 +1,60 @@
+//go:build unix
+
+package workflow
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+func Test_RunWorkflowSignal(t *testing.T) {
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    steps:
+      - id: wait
+        action: wait
+      - id: build
+        action: build
+        args: [app]
+    always:
+      - id: unlock
+        action: test
+`))
+	require.NoError(t, err)
+	am, log := testManager(t, "")
+	started := make(chan struct{})
+	a := action.NewFromYAML("wait", []byte("runtime: plugin\naction:\n  title: Wait\n"))
+	a.SetRuntime(action.NewFnRuntime(func(ctx context.Context, _ *action.Action) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	require.NoError(t, am.Add(a))
+
+	go func() {
+		<-started
+		assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	}()
+	r := newRunner(am, launchr.NoopStreams())
+	done := make(chan error)
+	go func() { done <- r.runInterruptible(context.Background(), wfs["w"]) }()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the workflow is not cancelled by the signal")
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, stepStatusFailure, r.results["wait"].Status)
+	assert.Equal(t, stepStatusSkipped, r.results["build"].Status)
+	assert.Equal(t, stepStatusSuccess, r.results["unlock"].Status)
+	assert.Equal(t, "test map[] map[]\n", log.String())
+}
@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+// runsDir is a directory in the config directory with the states of workflow runs.
+const runsDir = "workflows"
+
+// runsRetention is a number of the latest runs kept in the runs directory, older runs are pruned.
+const runsRetention = 20
+
+// runStateFormat is a format of the states of workflow runs.
+var runStateFormat = &launchr.StateFormat{Version: 1}
+
+// runState is a persisted state of a workflow run used to resume it.
+type runState struct {
+	ID       string                 `yaml:"id"`
+	Workflow string                 `yaml:"workflow"`
+	Inputs   map[string]string      `yaml:"inputs,omitempty"`
+	Steps    map[string]*stepResult `yaml:"steps"`
+
+	dir string
+}
+
+// newRunState creates a state of a new run of workflow w stored in dir.
+func newRunState(dir string, w *workflow) *runState {
+	return &runState{
+		ID:       strconv.FormatInt(time.Now().Unix(), 10) + "-" + w.Name,
+		Workflow: w.Name,
+		Steps:    make(map[string]*stepResult),
+		dir:      dir,
+	}
+}
+
+// loadRunState reads a state of the run id of workflow w from dir.
+func loadRunState(dir, id string, w *workflow) (*runState, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("workflow run id %q is not valid", id)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, id+".yaml")) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("workflow run %q is not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &runState{}
+	if err = runStateFormat.Decode(content, s); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow run %q: %w", id, err)
+	}
+	if s.Workflow != w.Name {
+		return nil, fmt.Errorf("workflow run %q belongs to workflow %q", id, s.Workflow)
+	}
+	if s.Steps == nil {
+		s.Steps = make(map[string]*stepResult)
+	}
+	s.ID = id
+	s.dir = dir
+	return s, nil
+}
+
+// artifactsDir returns a staging directory of the run artifacts.
+func (s *runState) artifactsDir() string {
+	return filepath.Join(s.dir, s.ID)
+}
+
+// save writes the state to the runs directory.
+// The values of mask are replaced in the inputs and in the output of the steps.
+func (s *runState) save(mask *action.SensitiveMask) error {
+	st := *s
+	if mask != nil {
+		st.Inputs = make(map[string]string, len(s.Inputs))
+		for k, v := range s.Inputs {
+			st.Inputs[k] = mask.Mask(v)
+		}
+		st.Steps = make(map[string]*stepResult, len(s.Steps))
+		for id, res := range s.Steps {
+			masked := *res
+			masked.Output = mask.Mask(res.Output)
+			st.Steps[id] = &masked
+		}
+	}
+	content, err := runStateFormat.Encode(&st)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.dir, 0750); err != nil {
+		return err
+	}
+	return launchr.WriteFileAtomic(filepath.Join(s.dir, s.ID+".yaml"), content, 0600)
+}
+
+// pruneRunStates removes the states and the staged artifacts of the runs in dir except the keep latest ones.
+func pruneRunStates(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	type run struct {
+		id  string
+		mod time.Time
+	}
+	var runs []run
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, errInfo := e.Info()
+		if errInfo != nil {
+			return errInfo
+		}
+		runs = append(runs, run{id: id, mod: info.ModTime()})
+	}
+	if len(runs) <= keep {
+		return nil
+	}
+	slices.SortFunc(runs, func(a, b run) int {
+		return b.mod.Compare(a.mod)
+	})
+	for _, r := range runs[keep:] {
+		if err = os.RemoveAll(filepath.Join(dir, r.id)); err != nil {
+			return err
+		}
+		if err = os.Remove(filepath.Join(dir, r.id+".yaml")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
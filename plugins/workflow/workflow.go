@@ -0,0 +1,257 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workflowFileName is a default file with workflows in the working directory.
+const workflowFileName = "launchr.workflow.yaml"
+
+// Failure strategies of a step.
+const (
+	onFailureAbort    = "abort"
+	onFailureContinue = "continue"
+)
+
+var rgxStepID = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// workflowFile is a file declaring workflows by name.
+type workflowFile struct {
+	Workflows map[string]*workflow `yaml:"workflows"`
+}
+
+// defaultGracePeriod limits the duration of cleanup steps if not set in the workflow.
+const defaultGracePeriod = time.Minute
+
+// workflow is a graph of action runs.
+type workflow struct {
+	Name  string          `yaml:"-"`
+	Title string          `yaml:"title"`
+	Steps []*workflowStep `yaml:"steps"`
+	// OnFailure steps run after the steps if the run fails or is cancelled.
+	OnFailure []*workflowStep `yaml:"on_failure"`
+	// Always steps run after the steps regardless of the result.
+	Always []*workflowStep `yaml:"always"`
+	// GracePeriod limits the duration of cleanup steps.
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+// workflowStep is a run of an action in a workflow.
+type workflowStep struct {
+	ID        string         `yaml:"id"`
+	Action    string         `yaml:"action"`
+	Args      []string       `yaml:"args"`
+	Options   map[string]any `yaml:"options"`
+	Needs     []string       `yaml:"needs"`
+	If        string         `yaml:"if"`
+	SkipIf    string         `yaml:"skip_if"`
+	OnFailure string         `yaml:"on_failure"`
+	Artifacts []string       `yaml:"artifacts"`
+	// Approval makes the step wait for a decision of a user instead of running an action.
+	Approval *stepApproval `yaml:"approval"`
+
+	cond *template.Template
+	skip *template.Template
+}
+
+// readWorkflowFile reads and validates workflows from the file.
+func readWorkflowFile(path string) (map[string]*workflow, error) {
+	content, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow file: %w", err)
+	}
+	return parseWorkflows(content)
+}
+
+func parseWorkflows(content []byte) (map[string]*workflow, error) {
+	var f workflowFile
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow file: %w", err)
+	}
+	for name, w := range f.Workflows {
+		if w == nil {
+			return nil, fmt.Errorf("workflow %q has no steps", name)
+		}
+		w.Name = name
+		if err := w.validate(); err != nil {
+			return nil, fmt.Errorf("workflow %q is not valid: %w", name, err)
+		}
+	}
+	return f.Workflows, nil
+}
+
+// sortedWorkflowNames returns workflow names in alphabetical order.
+func sortedWorkflowNames(wfs map[string]*workflow) []string {
+	names := make([]string, 0, len(wfs))
+	for name := range wfs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (w *workflow) validate() error {
+	if len(w.Steps) == 0 {
+		return errors.New("steps are not defined")
+	}
+	if w.GracePeriod < 0 {
+		return errors.New("grace_period must not be negative")
+	}
+	var ids []string
+	for _, steps := range [][]*workflowStep{w.Steps, w.OnFailure, w.Always} {
+		for i, s := range steps {
+			if s == nil {
+				return fmt.Errorf("step %d is empty", i+1)
+			}
+			if slices.Contains(ids, s.ID) {
+				return fmt.Errorf("step id %q is not unique", s.ID)
+			}
+			if err := s.validate(); err != nil {
+				return err
+			}
+			ids = append(ids, s.ID)
+		}
+	}
+	for _, s := range w.Steps {
+		for _, n := range s.Needs {
+			if !slices.Contains(ids[:len(w.Steps)], n) {
+				return fmt.Errorf("step %q needs unknown step %q", s.ID, n)
+			}
+		}
+	}
+	for _, s := range w.cleanupSteps() {
+		if len(s.Needs) > 0 {
+			return fmt.Errorf("cleanup step %q must not have needs, cleanup steps run in the declaration order", s.ID)
+		}
+		if s.Approval != nil {
+			return fmt.Errorf("cleanup step %q must not be an approval", s.ID)
+		}
+	}
+	_, err := w.plan()
+	return err
+}
+
+func (s *workflowStep) validate() error {
+	if !rgxStepID.MatchString(s.ID) {
+		return fmt.Errorf("step id %q is not valid, use letters, digits and underscores", s.ID)
+	}
+	if s.Approval != nil {
+		if s.Action != "" || len(s.Args) > 0 || len(s.Options) > 0 || len(s.Artifacts) > 0 {
+			return fmt.Errorf("approval step %q must not have action, args, options and artifacts", s.ID)
+		}
+		if s.Approval.Timeout < 0 {
+			return fmt.Errorf("approval timeout of step %q must not be negative", s.ID)
+		}
+	} else if s.Action == "" {
+		return fmt.Errorf("action of step %q is not defined", s.ID)
+	}
+	switch s.OnFailure {
+	case "":
+		s.OnFailure = onFailureAbort
+	case onFailureAbort, onFailureContinue:
+	default:
+		return fmt.Errorf("on_failure of step %q must be %q or %q", s.ID, onFailureAbort, onFailureContinue)
+	}
+	if s.If != "" {
+		var err error
+		s.cond, err = template.New(s.ID).Option("missingkey=zero").Parse(s.If)
+		if err != nil {
+			return fmt.Errorf("condition of step %q is not valid: %w", s.ID, err)
+		}
+	}
+	for _, p := range s.Artifacts {
+		if !filepath.IsLocal(p) {
+			return fmt.Errorf("artifact %q of step %q must be a relative path inside the working directory", p, s.ID)
+		}
+	}
+	if s.SkipIf != "" {
+		var err error
+		s.skip, err = template.New(s.ID).Option("missingkey=zero").Parse(s.SkipIf)
+		if err != nil {
+			return fmt.Errorf("skip condition of step %q is not valid: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// actionName returns the action of the step or a type of the step not running an action.
+func (s *workflowStep) actionName() string {
+	if s.Approval != nil {
+		return "approval"
+	}
+	return s.Action
+}
+
+// cleanupSteps returns the steps running after the workflow steps.
+func (w *workflow) cleanupSteps() []*workflowStep {
+	return slices.Concat(w.OnFailure, w.Always)
+}
+
+// gracePeriod returns the time limit of cleanup steps.
+func (w *workflow) gracePeriod() time.Duration {
+	if w.GracePeriod == 0 {
+		return defaultGracePeriod
+	}
+	return w.GracePeriod
+}
+
+// plan returns the steps in the execution order.
+// A step runs after the steps it needs, otherwise the declaration order is kept.
+func (w *workflow) plan() ([]*workflowStep, error) {
+	res := make([]*workflowStep, 0, len(w.Steps))
+	done := make(map[string]bool, len(w.Steps))
+	for len(res) < len(w.Steps) {
+		added := false
+		for _, s := range w.Steps {
+			if done[s.ID] || !allDone(s.Needs, done) {
+				continue
+			}
+			res = append(res, s)
+			done[s.ID] = true
+			added = true
+			break
+		}
+		if !added {
+			var cycle []string
+			for _, s := range w.Steps {
+				if !done[s.ID] {
+					cycle = append(cycle, s.ID)
+				}
+			}
+			return nil, fmt.Errorf("steps have circular dependencies: %v", cycle)
+		}
+	}
+	return res, nil
+}
+
+// levels returns a depth of every step in the dependency graph.
+func (w *workflow) levels(plan []*workflowStep) map[string]int {
+	res := make(map[string]int, len(plan))
+	for _, s := range plan {
+		lvl := 0
+		for _, n := range s.Needs {
+			lvl = max(lvl, res[n]+1)
+		}
+		res[s.ID] = lvl
+	}
+	return res
+}
+
+func allDone(ids []string, done map[string]bool) bool {
+	for _, id := range ids {
+		if !done[id] {
+			return false
+		}
+	}
+	return true
+}
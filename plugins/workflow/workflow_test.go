@@ -0,0 +1,694 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/launchrctl/launchr/internal/launchr"
+	"github.com/launchrctl/launchr/pkg/action"
+)
+
+const testWorkflows = `
+workflows:
+  release:
+    title: Release
+    steps:
+      - id: test
+        action: test
+        needs: [build]
+      - id: build
+        action: build
+        args: [app]
+      - id: publish
+        action: echo
+        needs: [build, test]
+        options:
+          msg: "published {{ .steps.build.output }}"
+      - id: notify
+        action: echo
+        needs: [publish]
+        if: '{{ ne .steps.publish.status "success" }}'
+        options:
+          msg: failed
+`
+
+func Test_ParseWorkflows(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(testWorkflows))
+	require.NoError(t, err)
+	w := wfs["release"]
+	require.NotNil(t, w)
+	plan, err := w.plan()
+	require.NoError(t, err)
+	ids := make([]string, len(plan))
+	for i, s := range plan {
+		ids[i] = s.ID
+	}
+	assert.Equal(t, []string{"build", "test", "publish", "notify"}, ids)
+	assert.Equal(t, map[string]int{"build": 0, "test": 1, "publish": 2, "notify": 3}, w.levels(plan))
+	assert.Equal(t, onFailureAbort, plan[0].OnFailure)
+
+	tts := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"no steps", "workflows:\n  w:\n    steps: []", `workflow "w" is not valid: steps are not defined`},
+		{"invalid id", "workflows:\n  w:\n    steps:\n      - id: my-step\n        action: a", `step id "my-step" is not valid`},
+		{"duplicate id", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n      - id: a\n        action: a", `step id "a" is not unique`},
+		{"no action", "workflows:\n  w:\n    steps:\n      - id: a", `action of step "a" is not defined`},
+		{"unknown need", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        needs: [b]", `step "a" needs unknown step "b"`},
+		{"cycle", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        needs: [b]\n      - id: b\n        action: a\n        needs: [a]", `circular dependencies: [a b]`},
+		{"on failure", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        on_failure: retry", `on_failure of step "a" must be`},
+		{"condition", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        if: '{{ .steps'", `condition of step "a" is not valid`},
+		{"artifact outside", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        artifacts: [../out]", `artifact "../out" of step "a" must be a relative path`},
+		{"cleanup needs", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n    always:\n      - id: b\n        action: a\n        needs: [a]", `cleanup step "b" must not have needs`},
+		{"cleanup duplicate", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n    on_failure:\n      - id: a\n        action: a", `step id "a" is not unique`},
+		{"grace period", "workflows:\n  w:\n    grace_period: -1s\n    steps:\n      - id: a\n        action: a", `grace_period must not be negative`},
+		{"approval with action", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        approval: {}", `approval step "a" must not have action`},
+		{"cleanup approval", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n    always:\n      - id: b\n        approval: {}", `cleanup step "b" must not be an approval`},
+		{"skip condition", "workflows:\n  w:\n    steps:\n      - id: a\n        action: a\n        skip_if: '{{ .steps'", `skip condition of step "a" is not valid`},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseWorkflows([]byte(tt.yaml))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func testManager(t *testing.T, failing string) (action.Manager, *bytes.Buffer) {
+	am := action.NewManager()
+	log := &bytes.Buffer{}
+	add := func(id, yaml string, fn action.FnRuntime) {
+		a := action.NewFromYAML(id, []byte(yaml))
+		a.SetRuntime(action.NewFnRuntime(fn))
+		require.NoError(t, am.Add(a))
+	}
+	run := func(_ context.Context, a *action.Action) error {
+		input := a.Input()
+		fmt.Fprintln(log, a.ID, input.Args(), input.Opts())
+		if a.ID == failing {
+			return errors.New("action failed")
+		}
+		_, err := fmt.Fprintln(input.Streams().Out(), a.ID+"-output")
+		return err
+	}
+	add("build", "runtime: plugin\naction:\n  title: Build\n  arguments:\n    - name: name\n", run)
+	add("test", "runtime: plugin\naction:\n  title: Test\n", run)
+	add("echo", "runtime: plugin\naction:\n  title: Echo\n  options:\n    - name: msg\n      default: \"\"\n", run)
+	return am, log
+}
+
+func Test_RunWorkflow(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(testWorkflows))
+	require.NoError(t, err)
+	w := wfs["release"]
+
+	type testCase struct {
+		name     string
+		failing  string
+		expLog   string
+		expErr   string
+		expState map[string]string
+	}
+	tts := []testCase{
+		{
+			name:   "success",
+			expLog: "build map[name:app] map[]\ntest map[] map[]\necho map[] map[msg:published build-output]\n",
+			expState: map[string]string{
+				"build": stepStatusSuccess, "test": stepStatusSuccess,
+				"publish": stepStatusSuccess, "notify": stepStatusSkipped,
+			},
+		},
+		{
+			name:    "abort on failure",
+			failing: "test",
+			expLog:  "build map[name:app] map[]\ntest map[] map[]\n",
+			expErr:  `workflow "release" failed, failed steps: test`,
+			expState: map[string]string{
+				"build": stepStatusSuccess, "test": stepStatusFailure,
+				"publish": stepStatusSkipped, "notify": stepStatusSkipped,
+			},
+		},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			am, log := testManager(t, tt.failing)
+			r := newRunner(am, launchr.NoopStreams())
+			err := r.run(context.Background(), w)
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expLog, log.String())
+			state := make(map[string]string, len(r.results))
+			for id, res := range r.results {
+				state[id] = res.Status
+			}
+			assert.Equal(t, tt.expState, state)
+		})
+	}
+}
+
+func Test_RunWorkflowContinue(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    steps:
+      - id: build
+        action: build
+        args: [app]
+        on_failure: continue
+      - id: after
+        action: test
+      - id: dependent
+        action: test
+        needs: [build]
+      - id: cleanup
+        action: echo
+        needs: [build]
+        if: '{{ eq .steps.build.status "failure" }}'
+`))
+	require.NoError(t, err)
+	am, log := testManager(t, "build")
+	r := newRunner(am, launchr.NoopStreams())
+	err = r.run(context.Background(), wfs["w"])
+	assert.EqualError(t, err, `workflow "w" failed, failed steps: build`)
+	assert.Equal(t, "build map[name:app] map[]\ntest map[] map[]\necho map[] map[msg:]\n", log.String())
+	assert.Equal(t, stepStatusSkipped, r.results["dependent"].Status)
+	assert.Equal(t, stepStatusSuccess, r.results["cleanup"].Status)
+}
+
+func Test_RunWorkflowResume(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(testWorkflows))
+	require.NoError(t, err)
+	w := wfs["release"]
+	dir := t.TempDir()
+
+	am, log := testManager(t, "test")
+	r := newRunner(am, launchr.NoopStreams())
+	r.state = newRunState(dir, w)
+	require.Error(t, r.run(context.Background(), w))
+	assert.Equal(t, "build map[name:app] map[]\ntest map[] map[]\n", log.String())
+
+	_, err = loadRunState(dir, "unknown", w)
+	assert.EqualError(t, err, `workflow run "unknown" is not found`)
+	_, err = loadRunState(dir, "../"+r.state.ID, w)
+	assert.Error(t, err)
+	_, err = loadRunState(dir, r.state.ID, &workflow{Name: "other"})
+	assert.EqualError(t, err, fmt.Sprintf("workflow run %q belongs to workflow %q", r.state.ID, "release"))
+
+	state, err := loadRunState(dir, r.state.ID, w)
+	require.NoError(t, err)
+	assert.Equal(t, stepStatusSuccess, state.Steps["build"].Status)
+	assert.Equal(t, stepStatusFailure, state.Steps["test"].Status)
+	assert.Equal(t, stepStatusSkipped, state.Steps["publish"].Status)
+
+	am, log = testManager(t, "")
+	r = newRunner(am, launchr.NoopStreams())
+	r.state = state
+	require.NoError(t, r.run(context.Background(), w))
+	assert.Equal(t, "test map[] map[]\necho map[] map[msg:published build-output]\n", log.String())
+
+	state, err = loadRunState(dir, r.state.ID, w)
+	require.NoError(t, err)
+	assert.Equal(t, stepStatusSuccess, state.Steps["test"].Status)
+	assert.Equal(t, "test-output", state.Steps["test"].Output)
+}
+
+func Test_RunWorkflowOutputLimit(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(testWorkflows))
+	require.NoError(t, err)
+	am, log := testManager(t, "")
+	r := newRunner(am, launchr.NoopStreams())
+	r.outputLimit = action.ConfigOutputLimit{Head: 2, Tail: 7}
+	require.NoError(t, r.run(context.Background(), wfs["release"]))
+	assert.Equal(t, "bu\n[... 4 bytes of output truncated ...]\noutput", r.results["build"].Output)
+	assert.Contains(t, log.String(), "[msg:published bu\n[... 4 bytes of output truncated ...]\noutput]")
+}
+
+func Test_RunWorkflowStateSensitive(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    steps:
+      - id: login
+        action: login
+        options:
+          token: "{{ .inputs.token }}"
+`))
+	require.NoError(t, err)
+	am := action.NewManager()
+	a := action.NewFromYAML("login", []byte("runtime: plugin\naction:\n  title: Login\n  options:\n    - name: token\n      sensitive: true\n"))
+	a.SetRuntime(action.NewFnRuntime(func(_ context.Context, a *action.Action) error {
+		_, err := fmt.Fprintln(a.Input().Streams().Out(), "logged in with", a.Input().Opt("token"))
+		return err
+	}))
+	require.NoError(t, am.Add(a))
+
+	for _, record := range []bool{false, true} {
+		dir := t.TempDir()
+		r := newRunner(am, launchr.NoopStreams())
+		r.recordSensitive = record
+		r.state = newRunState(dir, wfs["w"])
+		r.inputs = map[string]string{"token": "s3cr3t-token"}
+		r.state.Inputs = r.inputs
+		require.NoError(t, r.run(context.Background(), wfs["w"]))
+		// The values are available during the run.
+		assert.Equal(t, "logged in with s3cr3t-token", r.results["login"].Output)
+
+		state, err := loadRunState(dir, r.state.ID, wfs["w"])
+		require.NoError(t, err)
+		if record {
+			assert.Equal(t, "s3cr3t-token", state.Inputs["token"])
+			assert.Equal(t, "logged in with s3cr3t-token", state.Steps["login"].Output)
+		} else {
+			assert.Equal(t, "***", state.Inputs["token"])
+			assert.Equal(t, "logged in with ***", state.Steps["login"].Output)
+		}
+	}
+}
+
+func Test_RunWorkflowSkip(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    steps:
+      - id: compare
+        action: build
+        args: ["{{ .inputs.name }}"]
+      - id: propagate
+        action: echo
+        needs: [compare]
+        skip_if: '{{ eq .steps.compare.output "build-output" }}'
+      - id: notify
+        action: echo
+        needs: [propagate]
+      - id: report
+        action: test
+        skip_if: '{{ eq .inputs.report "false" }}'
+`))
+	require.NoError(t, err)
+	tts := []struct {
+		name   string
+		inputs map[string]string
+		expLog string
+		expRep string
+	}{
+		{"skip report", map[string]string{"name": "app", "report": "false"}, "build map[name:app] map[]\n", stepStatusSkipped},
+		{"run report", map[string]string{"name": "lib"}, "build map[name:lib] map[]\ntest map[] map[]\n", stepStatusSuccess},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			am, log := testManager(t, "")
+			r := newRunner(am, launchr.NoopStreams())
+			r.inputs = tt.inputs
+			require.NoError(t, r.run(context.Background(), wfs["w"]))
+			assert.Equal(t, tt.expLog, log.String())
+			assert.Equal(t, stepStatusSkipped, r.results["propagate"].Status)
+			assert.Equal(t, stepStatusSkipped, r.results["notify"].Status)
+			assert.Equal(t, tt.expRep, r.results["report"].Status)
+		})
+	}
+}
+
+func Test_RunWorkflowArtifacts(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    steps:
+      - id: build
+        action: build
+        artifacts: [out]
+      - id: test
+        action: test
+        needs: [build]
+`))
+	require.NoError(t, err)
+	w := wfs["w"]
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+
+	newManager := func(failing bool) action.Manager {
+		am := action.NewManager()
+		build := action.NewFromYAML("build", []byte("runtime: plugin\naction:\n  title: Build\n"))
+		build.SetRuntime(action.NewFnRuntime(func(_ context.Context, _ *action.Action) error {
+			require.NoError(t, os.MkdirAll(filepath.Join(workDir, "out", "bin"), 0750))
+			return os.WriteFile(filepath.Join(workDir, "out", "bin", "app"), []byte("binary"), 0600)
+		}))
+		test := action.NewFromYAML("test", []byte("runtime: plugin\naction:\n  title: Test\n"))
+		test.SetRuntime(action.NewFnRuntime(func(_ context.Context, a *action.Action) error {
+			if failing {
+				return errors.New("action failed")
+			}
+			content, err := os.ReadFile(filepath.Join(workDir, handoffDirName, "build", "out", "bin", "app"))
+			if err != nil {
+				return err
+			}
+			_, err = a.Input().Streams().Out().Write(content)
+			return err
+		}))
+		require.NoError(t, am.Add(build))
+		require.NoError(t, am.Add(test))
+		return am
+	}
+
+	r := newRunner(newManager(true), launchr.NoopStreams())
+	r.workDir = workDir
+	r.state = newRunState(stateDir, w)
+	require.Error(t, r.run(context.Background(), w))
+	assert.Equal(t, []string{"out"}, r.results["build"].Artifacts)
+	assert.FileExists(t, filepath.Join(stateDir, r.state.ID, "build", "out", "bin", "app"))
+
+	assert.NoDirExists(t, filepath.Join(workDir, handoffDirName))
+
+	// The artifacts are handed off from the staging area when the working directory is changed.
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "out", "bin", "app"), []byte("edited"), 0600))
+	state, err := loadRunState(stateDir, r.state.ID, w)
+	require.NoError(t, err)
+	r = newRunner(newManager(false), launchr.NoopStreams())
+	r.workDir = workDir
+	r.state = state
+	require.NoError(t, r.run(context.Background(), w))
+	assert.Equal(t, "binary", r.results["test"].Output)
+	// The artifacts aren't copied over the files of the working directory.
+	content, err := os.ReadFile(filepath.Join(workDir, "out", "bin", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "edited", string(content))
+	assert.NoDirExists(t, filepath.Join(workDir, handoffDirName))
+	assert.Equal(t, ".workflow-artifacts/build", r.tplData()["steps"].(map[string]any)["build"].(map[string]any)["artifacts_dir"])
+}
+
+func Test_RunWorkflowPlan(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  deploy:
+    steps:
+      - id: build
+        action: build
+        args: ["{{ .inputs.name }}"]
+      - id: publish
+        action: echo
+        needs: [build]
+        options:
+          msg: "{{ .steps.build.output }}"
+    always:
+      - id: notify
+        action: echo
+`))
+	require.NoError(t, err)
+	am, log := testManager(t, "")
+	plan, err := newRunPlan(am, wfs["deploy"], map[string]string{"name": "app"})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, writeRunPlan(path, plan))
+
+	plan, err = readRunPlan(path)
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", plan.Workflow)
+	assert.Equal(t, map[string]string{"name": "app"}, plan.Inputs)
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "build", plan.Steps[0].Action)
+	assert.NotEmpty(t, plan.Steps[0].ActionSum)
+	require.Len(t, plan.Always, 1)
+
+	w, err := plan.workflow(am)
+	require.NoError(t, err)
+	r := newRunner(am, launchr.NoopStreams())
+	r.inputs = plan.Inputs
+	require.NoError(t, r.run(context.Background(), w))
+	assert.Equal(t, "build map[name:app] map[]\necho map[] map[msg:build-output]\necho map[] map[msg:]\n", log.String())
+
+	// The plan isn't applied if an action is changed.
+	changed := action.NewManager()
+	for _, a := range am.All() {
+		if a.ID == "echo" {
+			a = action.NewFromYAML("echo", []byte("runtime: plugin\naction:\n  title: Changed\n"))
+		}
+		require.NoError(t, changed.Add(a))
+	}
+	_, err = plan.workflow(changed)
+	assert.ErrorContains(t, err, `action "echo" of step "publish" is changed since the plan was created`)
+}
+
+func Test_RunWorkflowApproval(t *testing.T) {
+	t.Setenv("TEST_APPROVAL_SECRET", "secret")
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  deploy:
+    steps:
+      - id: approve
+        approval:
+          message: Deploy?
+          timeout: 300ms
+      - id: signed
+        approval:
+          timeout: 300ms
+          secret_env: TEST_APPROVAL_SECRET
+        skip_if: '{{ eq .inputs.signed "" }}'
+      - id: deploy
+        action: echo
+        needs: [approve]
+`))
+	require.NoError(t, err)
+	w := wfs["deploy"]
+
+	tts := []struct {
+		name     string
+		signed   bool
+		decision string
+		token    string
+		expErr   string
+		expLog   string
+		expVia   string
+	}{
+		{"approved", false, approvalApproved, "", "", "echo map[] map[msg:]\n", approvalViaCommand},
+		{"rejected", false, approvalRejected, "", "failed steps: approve", "", approvalViaCommand},
+		{"valid token", true, approvalApproved, "valid", "", "echo map[] map[msg:]\n", approvalViaToken},
+		{"invalid token", true, approvalApproved, "invalid", "failed steps: signed", "", ""},
+	}
+	for _, tt := range tts {
+		t.Run(tt.name, func(t *testing.T) {
+			am, log := testManager(t, "")
+			r := newRunner(am, launchr.NoopStreams())
+			r.approvalPoll = 10 * time.Millisecond
+			r.state = newRunState(t.TempDir(), w)
+			r.inputs = map[string]string{"signed": ""}
+			if tt.signed {
+				r.inputs["signed"] = "true"
+			}
+			// Give the decisions until the run is finished, the decisions of previous attempts are removed.
+			done := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					case <-time.After(5 * time.Millisecond):
+					}
+					for _, step := range []string{"approve", "signed"} {
+						decision := tt.decision
+						token := tt.token
+						if step == "approve" && tt.signed {
+							decision, token = approvalApproved, ""
+						}
+						if token == "valid" {
+							token = approvalToken("secret", r.state.ID, step, decision)
+						}
+						if rec, _ := readApproval(r.state, step); rec == nil {
+							_ = writeApproval(r.state, step, approvalRecord{Decision: decision, By: "alice", Token: token})
+						}
+					}
+				}
+			}()
+			err := r.run(context.Background(), w)
+			close(done)
+			if tt.expErr != "" {
+				assert.ErrorContains(t, err, tt.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expLog, log.String())
+			step := "approve"
+			if tt.signed {
+				step = "signed"
+			}
+			res := r.results[step]
+			if tt.expVia == "" {
+				assert.Nil(t, res.Approval)
+				return
+			}
+			require.NotNil(t, res.Approval)
+			assert.Equal(t, tt.decision, res.Approval.Decision)
+			assert.Equal(t, "alice", res.Approval.By)
+			assert.Equal(t, tt.expVia, res.Approval.Via)
+		})
+	}
+}
+
+func Test_PruneRunStates(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("%d-w", i)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, id, "step"), 0750))
+		p := filepath.Join(dir, id+".yaml")
+		require.NoError(t, os.WriteFile(p, []byte("id: "+id), 0600))
+		mod := now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(p, mod, mod))
+	}
+	require.NoError(t, pruneRunStates(dir, 2))
+	for i, exists := range []bool{false, false, true, true} {
+		id := fmt.Sprintf("%d-w", i)
+		assert.Equal(t, exists, fileExists(filepath.Join(dir, id+".yaml")), id)
+		assert.Equal(t, exists, fileExists(filepath.Join(dir, id)), id)
+	}
+	require.NoError(t, pruneRunStates(filepath.Join(dir, "missing"), 2))
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func Test_RunWorkflowArtifactMissing(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte("workflows:\n  w:\n    steps:\n      - id: a\n        action: test\n        artifacts: [out]\n"))
+	require.NoError(t, err)
+	am, _ := testManager(t, "")
+	r := newRunner(am, launchr.NoopStreams())
+	r.workDir = t.TempDir()
+	r.state = newRunState(t.TempDir(), wfs["w"])
+	require.Error(t, r.run(context.Background(), wfs["w"]))
+	assert.EqualError(t, r.results["a"].err, `artifact "out" is not found`)
+}
+
+func Test_RunWorkflowCleanup(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    grace_period: 100ms
+    steps:
+      - id: build
+        action: build
+        args: [app]
+    on_failure:
+      - id: notify
+        action: echo
+        options:
+          msg: "{{ .steps.build.status }}"
+    always:
+      - id: unlock
+        action: test
+`))
+	require.NoError(t, err)
+	w := wfs["w"]
+	assert.Equal(t, 100*time.Millisecond, w.gracePeriod())
+
+	type testCase struct {
+		name     string
+		failing  string
+		cancel   bool
+		expLog   string
+		expState map[string]string
+	}
+	tts := []testCase{
+		{
+			name:     "success",
+			expLog:   "build map[name:app] map[]\ntest map[] map[]\n",
+			expState: map[string]string{"build": stepStatusSuccess, "notify": stepStatusSkipped, "unlock": stepStatusSuccess},
+		},
+		{
+			name:     "failure",
+			failing:  "build",
+			expLog:   "build map[name:app] map[]\necho map[] map[msg:failure]\ntest map[] map[]\n",
+			expState: map[string]string{"build": stepStatusFailure, "notify": stepStatusSuccess, "unlock": stepStatusSuccess},
+		},
+		{
+			name:     "cancelled",
+			cancel:   true,
+			expLog:   "echo map[] map[msg:skipped]\ntest map[] map[]\n",
+			expState: map[string]string{"build": stepStatusSkipped, "notify": stepStatusSuccess, "unlock": stepStatusSuccess},
+		},
+	}
+	for _, tt := range tts {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			am, log := testManager(t, tt.failing)
+			r := newRunner(am, launchr.NoopStreams())
+			err := r.run(ctx, w)
+			assert.Equal(t, tt.failing != "" || tt.cancel, err != nil)
+			assert.Equal(t, tt.expLog, log.String())
+			state := make(map[string]string, len(r.results))
+			for id, res := range r.results {
+				state[id] = res.Status
+			}
+			assert.Equal(t, tt.expState, state)
+		})
+	}
+}
+
+func Test_RunWorkflowCleanupGracePeriod(t *testing.T) {
+	t.Parallel()
+	wfs, err := parseWorkflows([]byte(`
+workflows:
+  w:
+    grace_period: 50ms
+    steps:
+      - id: build
+        action: build
+        args: [app]
+    always:
+      - id: wait
+        action: wait
+      - id: unlock
+        action: test
+`))
+	require.NoError(t, err)
+	am, _ := testManager(t, "")
+	a := action.NewFromYAML("wait", []byte("runtime: plugin\naction:\n  title: Wait\n"))
+	a.SetRuntime(action.NewFnRuntime(func(ctx context.Context, _ *action.Action) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	require.NoError(t, am.Add(a))
+	r := newRunner(am, launchr.NoopStreams())
+	err = r.run(context.Background(), wfs["w"])
+	assert.EqualError(t, err, `workflow "w" failed, failed steps: wait`)
+	assert.Equal(t, stepStatusFailure, r.results["wait"].Status)
+	assert.Equal(t, stepStatusSkipped, r.results["unlock"].Status)
+}
@@ -17,6 +17,7 @@ func init() {
 // Plugin is a [launchr.Plugin] to discover actions defined in yaml.
 type Plugin struct {
 	am  action.Manager
+	cfg launchr.Config
 	app launchr.App
 }
 
@@ -30,6 +31,7 @@ func (p *Plugin) PluginInfo() launchr.PluginInfo {
 // OnAppInit implements [launchr.Plugin] interface to provide discovered actions.
 func (p *Plugin) OnAppInit(app launchr.App) error {
 	app.GetService(&p.am)
+	app.GetService(&p.cfg)
 	p.app = app
 	return nil
 }
@@ -49,6 +51,8 @@ func (p *Plugin) DiscoverActions(ctx context.Context) ([]*action.Action, error)
 			res = append(res, discovered...)
 		}
 	}
+	// Add actions defined inline in the config.
+	res = append(res, action.DiscoverConfigActions(p.cfg, p.app.GetWD())...)
 
 	return res, nil
 }
@@ -2,6 +2,7 @@
 package launchr
 
 import (
+	"context"
 	"io"
 	"io/fs"
 
@@ -18,6 +19,20 @@ const (
 	LogLevelInfo     = launchr.LogLevelInfo     // LogLevelInfo is the log level for info.
 	LogLevelWarn     = launchr.LogLevelWarn     // LogLevelWarn is the log level for warnings.
 	LogLevelError    = launchr.LogLevelError    // LogLevelError is the log level for errors.
+
+	// PluginAPIVersion is the current version of the plugin API.
+	PluginAPIVersion = launchr.PluginAPIVersion
+
+	PluginCapabilityAppInit         = launchr.PluginCapabilityAppInit         // PluginCapabilityAppInit - app initialisation hook.
+	PluginCapabilityCobra           = launchr.PluginCapabilityCobra           // PluginCapabilityCobra - cobra commands hook.
+	PluginCapabilityGenerate        = launchr.PluginCapabilityGenerate        // PluginCapabilityGenerate - code generation hook.
+	PluginCapabilityActionDiscovery = launchr.PluginCapabilityActionDiscovery // PluginCapabilityActionDiscovery - action discovery hook.
+	PluginCapabilityActionsAlter    = launchr.PluginCapabilityActionsAlter    // PluginCapabilityActionsAlter - altering of discovered actions.
+	PluginCapabilityValueProcessors = launchr.PluginCapabilityValueProcessors // PluginCapabilityValueProcessors - action value processors.
+
+	HealthPass = launchr.HealthPass // HealthPass - the check passed.
+	HealthWarn = launchr.HealthWarn // HealthWarn - the check passed with issues.
+	HealthFail = launchr.HealthFail // HealthFail - the check failed.
 )
 
 // Variables for version provided by ldflags.
@@ -51,6 +66,10 @@ type (
 	Terminal = launchr.Terminal
 	// TextPrinter contains methods to print formatted text to the console or return it as a string.
 	TextPrinter = launchr.TextPrinter
+	// Progress reports the progress of a transfer of bytes, e.g. a copy of files.
+	Progress = launchr.Progress
+	// ProgressStats is a summary of a finished transfer.
+	ProgressStats = launchr.ProgressStats
 	// Streams is an interface which exposes the standard input and output streams.
 	Streams = launchr.Streams
 	// In is an input stream used by the app to read user input.
@@ -60,6 +79,8 @@ type (
 
 	// PluginInfo provides information about the plugin and is used as a unique data to indentify a plugin.
 	PluginInfo = launchr.PluginInfo
+	// PluginCapability is a flag of a plugin API feature that a plugin requires.
+	PluginCapability = launchr.PluginCapability
 	// Plugin is a common interface for launchr plugins.
 	Plugin = launchr.Plugin
 	// OnAppInitPlugin is an interface to implement a plugin for app initialisation.
@@ -68,10 +89,24 @@ type (
 	ActionDiscoveryPlugin = action.DiscoveryPlugin
 	// ActionsAlterPlugin is in interface to implement a plugin to alter registered actions.
 	ActionsAlterPlugin = action.AlterActionsPlugin
+	// ImageBuildResolverPlugin is an interface to implement a plugin providing image build resolvers.
+	ImageBuildResolverPlugin = action.ImageBuildResolverPlugin
 	// CobraPlugin is an interface to implement a plugin for cobra.
 	CobraPlugin = launchr.CobraPlugin
 	// GeneratePlugin is an interface to generate supporting files before build.
 	GeneratePlugin = launchr.GeneratePlugin
+	// HealthCheckPlugin is an interface to implement a plugin reporting its health.
+	HealthCheckPlugin = launchr.HealthCheckPlugin
+	// HealthCheck is a result of a health check.
+	HealthCheck = launchr.HealthCheck
+	// HealthStatus is a status of a health check.
+	HealthStatus = launchr.HealthStatus
+	// StatePlugin is an interface to implement a plugin keeping state in the config directory.
+	StatePlugin = launchr.StatePlugin
+	// StateEntry is a file or a directory of the state kept in the config directory.
+	StateEntry = launchr.StateEntry
+	// StateFormat is a versioned format of yaml state files in the config directory.
+	StateFormat = launchr.StateFormat
 	// GenerateConfig defines generation config.
 	GenerateConfig = launchr.GenerateConfig
 	// PluginManager handles plugins.
@@ -80,6 +115,8 @@ type (
 	ServiceInfo = launchr.ServiceInfo
 	// Service is a common interface for a service to register.
 	Service = launchr.Service
+	// ServiceManager holds the services, the logger and the terminal of an app instance.
+	ServiceManager = launchr.ServiceManager
 	// Config handles application configuration.
 	Config = launchr.Config
 	// ConfigAware provides an interface for structs to support launchr configuration setting.
@@ -124,6 +161,12 @@ func Log() *Logger { return launchr.Log() }
 // SetLogger sets the default logger.
 func SetLogger(l *Logger) { launchr.SetLogger(l) }
 
+// LogFromContext returns the logger of the app running with ctx.
+func LogFromContext(ctx context.Context) *Logger { return launchr.LogFromContext(ctx) }
+
+// TermFromContext returns the terminal of the app running with ctx.
+func TermFromContext(ctx context.Context) *Terminal { return launchr.TermFromContext(ctx) }
+
 // NewConsoleLogger creates a default console logger.
 func NewConsoleLogger(w io.Writer) *Logger { return launchr.NewConsoleLogger(w) }
 